@@ -0,0 +1,64 @@
+package backends
+
+import "time"
+
+// ACL is one access control entry. Used as a filter (list-acls, delete-acl),
+// empty fields match any value.
+type ACL struct {
+	ResourceType string // topic, group, cluster, transactional-id, delegation-token
+	ResourceName string
+	PatternType  string // literal, prefixed; filters also take match and any
+	Principal    string // e.g. User:alice
+	Host         string // "*" for every host
+	Operation    string // read, write, create, delete, alter, describe, all, ...
+	Permission   string // allow or deny
+}
+
+// ClusterDescription is the broker membership of a cluster.
+type ClusterDescription struct {
+	ClusterID  string
+	Controller int // ID of the controller broker, -1 when unknown
+	Brokers    []BrokerInfo
+}
+
+// BrokerInfo is one broker of a cluster.
+type BrokerInfo struct {
+	ID   int
+	Host string
+	Port int
+	Rack string // "" when the broker has no broker.rack
+}
+
+// BrokerStats is the status a broker publishes about itself (MQTT $SYS
+// topics). Counters the broker does not report are -1.
+type BrokerStats struct {
+	Version          string
+	Uptime           string
+	ClientsConnected int64
+	MessagesReceived int64 // since the broker started
+	MessagesSent     int64
+	Subscriptions    int64
+	RetainedMessages int64
+}
+
+// ConfigEntry is one configuration setting of a broker or topic.
+type ConfigEntry struct {
+	Name      string
+	Value     string // "" for sensitive values, which brokers never return
+	Source    string // where the value comes from: default, static-broker, dynamic-broker, dynamic-topic, ...
+	Default   bool
+	ReadOnly  bool
+	Sensitive bool
+}
+
+// NamespacePolicies are the retention, TTL, backlog quota and deduplication
+// policies of a namespace (Pulsar). Nil fields are not set on the namespace,
+// so the broker defaults apply; in an update they are left unchanged.
+type NamespacePolicies struct {
+	RetentionTime      *time.Duration // -1 keeps messages forever
+	RetentionSizeMB    *int64         // -1 for no size limit
+	MessageTTL         *time.Duration
+	BacklogQuotaLimit  *int64 // bytes, -1 for no limit
+	BacklogQuotaPolicy string // producer_request_hold, producer_exception or consumer_backlog_eviction
+	Deduplication      *bool
+}
@@ -8,3 +8,18 @@ var ErrNoMessageAvailable = errors.New("no message available")
 // does not support stateful browsing. Callers should fall back to the plain
 // Receive loop when they see this error.
 var ErrBrowseUnsupported = errors.New("browse not supported by this backend")
+
+// ErrMultiTopicUnsupported is returned by MultiTopicBackend.SubscribeTopics
+// when the wrapped backend cannot subscribe to several topics at once.
+// Callers should fall back to one subscription per topic.
+var ErrMultiTopicUnsupported = errors.New("multi-topic subscribe not supported by this backend")
+
+// ErrReceiptUnsupported is returned by ReceiptBackend.PublishWithReceipt when
+// the wrapped backend does not report where messages were stored. Callers
+// should fall back to Publish.
+var ErrReceiptUnsupported = errors.New("publish receipts not supported by this backend")
+
+// ErrBatchUnsupported is returned by BatchPublisher.PublishAsync when the
+// backend, or the producer settings in the options, don't batch publishes.
+// Callers should fall back to a synchronous publish.
+var ErrBatchUnsupported = errors.New("batched publish not supported by this backend")
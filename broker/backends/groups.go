@@ -0,0 +1,93 @@
+package backends
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GroupDescription describes a consumer group: its members and how far it
+// has read every partition it holds a committed offset on.
+type GroupDescription struct {
+	Name    string
+	State   string // broker-reported state: Stable, Empty, PreparingRebalance, Dead, ...
+	Members []GroupMember
+	Offsets []PartitionOffset
+}
+
+// GroupMember is one consumer currently joined to a group.
+type GroupMember struct {
+	ID       string
+	ClientID string
+	Host     string
+	Assigned int // number of partitions assigned to the member
+}
+
+// PartitionOffset is a group's position on one partition.
+type PartitionOffset struct {
+	Topic     string
+	Partition int
+	Committed int64  // -1 when the group has not committed on the partition
+	End       int64  // log-end offset (high watermark)
+	Lag       int64  // End - Committed; End - earliest offset when nothing is committed
+	Member    string // ID of the member the partition is assigned to, "" when unassigned
+}
+
+// OffsetTargetKind selects how OffsetTarget computes a partition's new offset.
+type OffsetTargetKind int
+
+const (
+	OffsetEarliest  OffsetTargetKind = iota // earliest retained offset
+	OffsetLatest                            // log-end offset: skip everything
+	OffsetAbsolute                          // Offset
+	OffsetTimestamp                         // first offset at or after Time
+	OffsetShift                             // committed offset moved by Offset (negative: back)
+)
+
+// OffsetTarget is where a consumer group offset reset moves a partition to.
+// Results beyond the retained range are clamped to it.
+type OffsetTarget struct {
+	Kind   OffsetTargetKind
+	Offset int64
+	Time   time.Time
+}
+
+// OffsetChange is one partition's planned or applied offset reset.
+type OffsetChange struct {
+	Topic     string
+	Partition int
+	Old       int64 // -1 when nothing was committed
+	New       int64
+}
+
+// offsetTimeLayouts are the timestamp forms ParseOffsetTarget accepts besides
+// RFC 3339; they carry no zone and are read in the caller's location.
+var offsetTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// ParseOffsetTarget parses a log position: earliest, latest, a non-negative
+// offset, or a timestamp (RFC 3339, or a zoneless date/time in loc). Plain
+// numbers are always offsets.
+func ParseOffsetTarget(s string, loc *time.Location) (OffsetTarget, error) {
+	switch strings.ToLower(s) {
+	case "earliest":
+		return OffsetTarget{Kind: OffsetEarliest}, nil
+	case "latest":
+		return OffsetTarget{Kind: OffsetLatest}, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n < 0 {
+			return OffsetTarget{}, fmt.Errorf("offset %d must be >= 0", n)
+		}
+		return OffsetTarget{Kind: OffsetAbsolute, Offset: n}, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return OffsetTarget{Kind: OffsetTimestamp, Time: t}, nil
+	}
+	for _, layout := range offsetTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return OffsetTarget{Kind: OffsetTimestamp, Time: t}, nil
+		}
+	}
+	return OffsetTarget{}, fmt.Errorf("%q is not earliest, latest, an offset or a timestamp", s)
+}
@@ -2,6 +2,7 @@ package backends
 
 import (
 	"os"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -184,3 +185,27 @@ func TestIsOKStatusCustom(t *testing.T) {
 		t.Error("404 should not be OK")
 	}
 }
+
+func TestGlobRegexp(t *testing.T) {
+	cases := []struct {
+		glob, topic string
+		want        bool
+	}{
+		{"orders.*", "orders.eu", true},
+		{"orders.*", "ordersXeu", false},
+		{"orders-?", "orders-1", true},
+		{"orders-?", "orders-12", false},
+		{"orders-[12]", "orders-2", true},
+		{"orders-[!12]", "orders-2", false},
+		{"a[b", "a[b", true},
+	}
+	for _, c := range cases {
+		re := regexp.MustCompile(GlobRegexp(c.glob))
+		if got := re.MatchString(c.topic); got != c.want {
+			t.Errorf("GlobRegexp(%q) on %q = %v, want %v", c.glob, c.topic, got, c.want)
+		}
+	}
+	if HasGlob("orders") || !HasGlob("orders.*") {
+		t.Error("HasGlob misclassified a topic name")
+	}
+}
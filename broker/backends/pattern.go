@@ -0,0 +1,45 @@
+package backends
+
+import (
+	"regexp"
+	"strings"
+)
+
+// HasGlob reports whether a topic name contains shell-style wildcards
+// (*, ?, [...]), i.e. names a set of topics rather than a single one.
+func HasGlob(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// GlobRegexp translates a shell-style wildcard pattern into an anchored
+// regular expression for brokers whose native topic patterns are regexes
+// (Kafka, Pulsar): * matches any run of characters, ? a single character and
+// [...] a character class; everything else matches literally.
+func GlobRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteByte('^')
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteByte('.')
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteByte('$')
+	return b.String()
+}
@@ -34,6 +34,9 @@ type Message struct {
 	Priority      int
 	Persistent    bool
 	Key           string // Partition/ordering key (Kafka, Pulsar, Google, AWS FIFO); empty for other brokers
+	Topic         string // Source topic of a subscribed message (set by multi-topic and wildcard subscriptions)
+	Tombstone     bool   // Null value, as opposed to an empty payload: a Kafka delete marker for Key on compacted topics
+	Retained      bool   // Delivered as the topic's retained message rather than live (MQTT)
 
 	// Internal metadata (for display purposes)
 	InternalMetadata map[string]any
@@ -15,6 +15,7 @@ type PublishOptions struct {
 	Priority      int
 	Persistent    bool
 	TTL           int64             // Time-to-live in milliseconds (0 = no expiry)
+	Tombstone     bool              // Publish a null value instead of Message (Kafka: deletes Key from a compacted topic); cmd rejects it for other brokers
 	Extra         map[string]string // Broker-specific flags (e.g. qos, retain, routing-type)
 }
 
@@ -49,3 +50,77 @@ type TopicInfo struct {
 	PartitionCount int // Kafka-specific
 	ConsumerGroups int
 }
+
+// TopicStats is the traffic, storage and backlog of one topic (Pulsar).
+type TopicStats struct {
+	Name          string
+	MsgRateIn     float64 // messages per second
+	MsgRateOut    float64
+	ThroughputIn  float64 // bytes per second
+	ThroughputOut float64
+	StorageSize   int64 // bytes
+	Publishers    int
+	Subscriptions []SubscriptionStats
+}
+
+// SubscriptionStats is the backlog and consumers of one subscription.
+type SubscriptionStats struct {
+	Name       string
+	Type       string // Exclusive, Shared, Failover, Key_Shared
+	Backlog    int64  // messages not yet acknowledged
+	Consumers  int
+	MsgRateOut float64
+}
+
+// TopicBrowseBackend is an optional interface implemented by topic backends
+// whose topics retain their messages (Kafka). BrowseTopic returns a cursor
+// over the messages stored at the time of the call, read from the beginning
+// without joining a consumer group or committing offsets. grep uses it to
+// search topics non-destructively.
+type TopicBrowseBackend interface {
+	BrowseTopic(ctx context.Context, opts SubscribeOptions) (Browser, error)
+}
+
+// MultiTopicBackend is an optional interface implemented by topic backends
+// that can consume several topics, or a wildcard pattern, through one native
+// subscription: MQTT filters, NATS subject wildcards, RabbitMQ topic
+// bindings, Kafka consumer groups over several topics, Pulsar topic lists and
+// patterns, Redis stream key scans. Each returned message carries its source
+// topic in Message.Topic. Backends without it are fanned out by the caller
+// over one subscription per topic.
+type MultiTopicBackend interface {
+	SubscribeTopics(ctx context.Context, topics []string, opts SubscribeOptions) (*Message, error)
+}
+
+// PublishReceipt reports where the broker stored a published message.
+type PublishReceipt struct {
+	Topic     string
+	Partition int
+	Offset    int64
+}
+
+// ReceiptBackend is an optional interface implemented by topic backends that
+// learn where each published message was stored (Kafka partition and offset).
+// A nil receipt with a nil error means the broker did not report it, e.g. a
+// Kafka publish with acks=none.
+type ReceiptBackend interface {
+	PublishWithReceipt(ctx context.Context, opts PublishOptions) (*PublishReceipt, error)
+}
+
+// BatchPublisher is an optional interface implemented by topic backends whose
+// producer can batch several publishes into one request (Kafka with
+// --batch-size or --linger). PublishAsync queues a message and returns before
+// it is written; Flush waits until every queued message is written and
+// returns their receipts in publish order.
+type BatchPublisher interface {
+	PublishAsync(ctx context.Context, opts PublishOptions) error
+	Flush() ([]PublishReceipt, error)
+}
+
+// TopicSchema is the schema registered for a topic (Pulsar).
+type TopicSchema struct {
+	Type       string // AVRO, JSON, PROTOBUF, STRING, ...
+	Version    int64
+	Definition string // schema definition, e.g. an Avro record as JSON
+	Properties map[string]string
+}
@@ -3,6 +3,7 @@
 package broker
 
 import (
+	"context"
 	"os"
 
 	"github.com/makibytes/xmc/broker/backends"
@@ -14,6 +15,9 @@ import (
 
 func GetRootCommand() *cobra.Command {
 	var connArgs ibmmq.ConnArguments
+	var queueConfig ibmmq.QueueConfig
+	var purgeOnDelete bool
+	var resetStats bool
 
 	defaultServer := os.Getenv("IMC_SERVER")
 	if defaultServer == "" {
@@ -25,17 +29,92 @@ func GetRootCommand() *cobra.Command {
 		Short:     "IBM MQ Messaging Client",
 		Long:      "Command-line interface for IBM MQ messaging",
 		AIContext: AIDoc("ibmmq"),
+		ProduceFlags: func(c *cobra.Command) {
+			c.Flags().String("topic-object", "", "Administrative topic object whose topic string prefixes the topic (publish)")
+			c.Flags().String("group", "", "Send the messages as one message group with this group ID, up to 24 bytes (send)")
+			c.Flags().Int("segment-size", 0, "Split each message into segments of at most this many bytes (send)")
+			c.Flags().String("report", "", "Request reports: comma-separated coa, cod, exception, expiration (send)")
+			c.Flags().String("report-queue", "", "Queue for the requested reports (send; sets the reply-to queue)")
+		},
+		ProduceExtra: func(c *cobra.Command) map[string]string {
+			extra := make(map[string]string)
+			for _, name := range []string{"topic-object", "group", "segment-size", "report", "report-queue"} {
+				if f := c.Flags().Lookup(name); f != nil && f.Changed {
+					extra[name] = f.Value.String()
+				}
+			}
+			return extra
+		},
+		ConsumeFlags: func(c *cobra.Command) {
+			c.Flags().String("topic-object", "", "Administrative topic object whose topic string prefixes the topic (subscribe)")
+			c.Flags().String("subscription-name", "", "Subscription name, e.g. of an administratively defined subscription (subscribe; default for -D: <group>:<topic>, or xmc-durable-<topic> without -g)")
+			c.Flags().Bool("complete-group", false, "Wait for a complete message group and return its messages joined into one (receive, peek)")
+			c.Flags().String("backout-queue", "", "Requeue messages backed out at least --backout-threshold times to this queue instead of returning them (receive)")
+			c.Flags().Int32("backout-threshold", 0, "Backout count at which messages go to --backout-queue (default: the queue's BOTHRESH)")
+		},
+		ConsumeExtra: func(c *cobra.Command) map[string]string {
+			extra := make(map[string]string)
+			for _, name := range []string{"topic-object", "subscription-name", "complete-group", "backout-queue", "backout-threshold"} {
+				if f := c.Flags().Lookup(name); f != nil && f.Changed {
+					extra[name] = f.Value.String()
+				}
+			}
+			return extra
+		},
 		RegisterFlags: func(c *cobra.Command) {
-			c.PersistentFlags().StringVarP(&connArgs.Server, "server", "s", defaultServer, "Server URL")
+			connArgs.Server, connArgs.ServerSet = defaultServer, os.Getenv("IMC_SERVER") != ""
+			c.PersistentFlags().VarP(serverFlag{&connArgs}, "server", "s", "Server URL")
 			c.PersistentFlags().StringVarP(&connArgs.User, "user", "u", os.Getenv("IMC_USER"), "Username for authentication")
 			c.PersistentFlags().StringVarP(&connArgs.Password, "password", "p", os.Getenv("IMC_PASSWORD"), "Password for authentication")
 			c.PersistentFlags().StringVarP(&connArgs.QueueManager, "qmgr", "m", os.Getenv("IMC_QUEUE_MANAGER"), "Queue manager name (overrides URL)")
 			c.PersistentFlags().StringVarP(&connArgs.Channel, "channel", "c", os.Getenv("IMC_CHANNEL"), "Channel name (overrides URL)")
 			c.PersistentFlags().StringVar(&connArgs.ModelQueue, "model-queue", os.Getenv("IMC_MODEL_QUEUE"), "Model queue for temporary reply queues (default SYSTEM.DEFAULT.MODEL.QUEUE)")
 			c.PersistentFlags().StringVar(&connArgs.DynamicQueue, "dynamic-queue", os.Getenv("IMC_DYNAMIC_QUEUE"), "Dynamic queue name pattern for temporary reply queues (default XMC.REPLY.*)")
+			c.PersistentFlags().StringVar(&connArgs.CipherSpec, "cipher-spec", os.Getenv("IMC_CIPHER_SPEC"), "TLS CipherSpec matching the channel's SSLCIPH (e.g. ANY_TLS13_OR_HIGHER); enables TLS")
+			c.PersistentFlags().StringVar(&connArgs.KeyRepository, "key-repository", os.Getenv("IMC_KEY_REPOSITORY"), "TLS key repository: key.kdb (with its .sth stash) or a .p12 file (default $MQSSLKEYR)")
+			c.PersistentFlags().StringVar(&connArgs.KeyRepoPassword, "key-repository-password", os.Getenv("IMC_KEY_REPOSITORY_PASSWORD"), "Password of a .p12 key repository")
+			c.PersistentFlags().StringVar(&connArgs.CertLabel, "cert-label", os.Getenv("IMC_CERT_LABEL"), "Label of the client certificate in the key repository")
+			c.PersistentFlags().StringVar(&connArgs.PeerName, "peer-name", os.Getenv("IMC_PEER_NAME"), "Only accept a queue manager certificate with this distinguished name (e.g. \"CN=QM1,O=ACME\")")
+			c.PersistentFlags().StringVar(&connArgs.CCDT, "ccdt", "", "Client channel definition table (JSON or binary; file path or URL) to look up the queue manager's channel (default $MQCCDTURL unless a server, channel or TLS channel setting is given)")
 		},
 		Queue: func() (backends.QueueBackend, error) { return ibmmq.NewQueueAdapter(connArgs) },
+		Topic: func() (backends.TopicBackend, error) { return ibmmq.NewTopicAdapter(connArgs) },
 		Ping:  func() (cmd.Closeable, error) { return ibmmq.NewQueueAdapter(connArgs) },
+		ManageSpec: &cmd.ManageSpec{
+			Objects: []cmd.ObjectType{
+				{
+					Label: "Queues",
+					List:  func() ([]backends.ObjectNode, error) { return ibmmq.ListQueues(connArgs) },
+				},
+				{
+					Label:        "Channels",
+					Hierarchical: true,
+					List:         func() ([]backends.ObjectNode, error) { return ibmmq.ListChannels(connArgs) },
+				},
+			},
+			Purge: func(queue string) (int64, error) { return ibmmq.PurgeQueue(connArgs, queue) },
+			Stats: func(queue string) (*backends.QueueStats, error) {
+				return ibmmq.GetQueueStats(connArgs, queue, resetStats)
+			},
+			StatsFlags: func(c *cobra.Command) {
+				c.Flags().BoolVar(&resetStats, "reset", false, "Also report enqueue/dequeue counts via RESET QSTATS, which resets them for every monitoring tool")
+			},
+			CreateQueue: &cmd.ManageAction{
+				SetupFlags: func(c *cobra.Command) {
+					c.Flags().Int32Var(&queueConfig.MaxDepth, "max-depth", 0, "Maximum number of messages on the queue (MAXDEPTH)")
+					c.Flags().StringVar(&queueConfig.BackoutQueue, "backout-queue", "", "Queue for messages backed out too often (BOQNAME)")
+					c.Flags().Int32Var(&queueConfig.BackoutThreshold, "backout-threshold", 0, "Backouts before a message moves to the backout queue (BOTHRESH)")
+					c.Flags().StringVar(&queueConfig.Description, "description", "", "Queue description (DESCR)")
+				},
+				Run: func(queue string) error { return ibmmq.CreateQueue(connArgs, queue, queueConfig) },
+			},
+			DeleteQueue: &cmd.ManageAction{
+				SetupFlags: func(c *cobra.Command) {
+					c.Flags().BoolVar(&purgeOnDelete, "purge", false, "Also delete the queue's messages (the queue manager refuses to delete a non-empty queue otherwise)")
+				},
+				Run: func(queue string) error { return ibmmq.DeleteQueue(connArgs, queue, purgeOnDelete) },
+			},
+		},
 		Extra: []*cobra.Command{
 			mcp.NewCommand(mcp.Deps{
 				ServerName:    "xmc-ibmmq",
@@ -44,7 +123,35 @@ func GetRootCommand() *cobra.Command {
 				NewQueue: func() (backends.QueueBackend, error) {
 					return ibmmq.NewQueueAdapter(connArgs)
 				},
+				NewTopic: func() (backends.TopicBackend, error) {
+					return ibmmq.NewTopicAdapter(connArgs)
+				},
+				PurgeQueue: func(_ context.Context, queue string) (int64, error) {
+					return ibmmq.PurgeQueue(connArgs, queue)
+				},
+				QueueStats: func(_ context.Context, queue string) (*mcp.QueueStats, error) {
+					s, err := ibmmq.GetQueueStats(connArgs, queue, false)
+					if err != nil {
+						return nil, err
+					}
+					return &mcp.QueueStats{
+						Name: s.Name, MessageCount: s.MessageCount, ConsumerCount: int64(s.ConsumerCount),
+						EnqueueCount: s.EnqueueCount, DequeueCount: s.DequeueCount,
+					}, nil
+				},
 			}),
 		},
 	})
 }
+
+// serverFlag is the --server value. It records that a server was given, so a
+// $MQCCDTURL table only applies to connections nobody configured explicitly.
+type serverFlag struct{ args *ibmmq.ConnArguments }
+
+func (f serverFlag) String() string { return f.args.Server }
+func (f serverFlag) Type() string   { return "string" }
+
+func (f serverFlag) Set(v string) error {
+	f.args.Server, f.args.ServerSet = v, true
+	return nil
+}
@@ -13,6 +13,11 @@ type SendArguments struct {
 	Persistence   int
 	ReplyTo       string
 	TTL           int64 // Time-to-live in milliseconds (converted to MQMD.Expiry tenths of a second)
+	Report        int32 // MQRO_* report options; reports go to ReplyTo
+	GroupID       string
+	MsgSeqNumber  int32 // position in the group, from 1
+	LastInGroup   bool
+	SegmentSize   int // split the message into segments of at most this many bytes (0 = don't)
 }
 
 type ReceiveArguments struct {
@@ -21,4 +26,8 @@ type ReceiveArguments struct {
 	Wait        bool
 	Acknowledge bool // get = true, peek = false
 	Selector    string
+
+	CompleteGroup    bool   // get a whole message group, joined into one message
+	BackoutQueue     string // requeue messages backed out BackoutThreshold times here
+	BackoutThreshold int32  // 0 = the queue's BOTHRESH
 }
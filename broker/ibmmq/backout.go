@@ -0,0 +1,72 @@
+//go:build ibmmq
+
+package ibmmq
+
+import (
+	"fmt"
+
+	"github.com/ibm-messaging/mq-golang/v5/ibmmq"
+	"github.com/makibytes/xmc/log"
+)
+
+// getWithBackout gets messages in units of work, requeueing those that were
+// backed out at least the backout threshold times (poison messages) to
+// args.BackoutQueue, until it gets one to return. This is what MQ's JMS and
+// XMS clients do with BOTHRESH and BOQNAME. The returned message handle
+// follows the ReceiveMessage contract.
+func getWithBackout(qMgr ibmmq.MQQueueManager, qObject ibmmq.MQObject, gmo *ibmmq.MQGMO, args ReceiveArguments) (*ibmmq.MQMD, []byte, ibmmq.MQMessageHandle, error) {
+	threshold := args.BackoutThreshold
+	if threshold == 0 {
+		attrs, err := qObject.Inq([]int32{ibmmq.MQIA_BACKOUT_THRESHOLD})
+		if err != nil {
+			return nil, nil, ibmmq.MQMessageHandle{}, fmt.Errorf("failed to inquire backout threshold: %w", err)
+		}
+		threshold, _ = attrs[ibmmq.MQIA_BACKOUT_THRESHOLD].(int32)
+		if threshold <= 0 {
+			return nil, nil, ibmmq.MQMessageHandle{}, fmt.Errorf("queue %s has no backout threshold (BOTHRESH); set --backout-threshold", args.Queue)
+		}
+	}
+
+	gmo.Options = gmo.Options&^ibmmq.MQGMO_NO_SYNCPOINT | ibmmq.MQGMO_SYNCPOINT
+	for {
+		md, data, msgHandle, err := getMessage(qMgr, qObject, gmo)
+		if err != nil {
+			qMgr.Back() //nolint:errcheck
+			return nil, nil, msgHandle, err
+		}
+		if md.BackoutCount < threshold {
+			if err := qMgr.Cmit(); err != nil {
+				msgHandle.DltMH(ibmmq.NewMQDMHO()) //nolint:errcheck
+				return nil, nil, msgHandle, fmt.Errorf("failed to commit get: %w", err)
+			}
+			return md, data, msgHandle, nil
+		}
+
+		log.Verbose("♻️  requeueing message %s (backout count %d) to %s...", mqIDToString(md.MsgId), md.BackoutCount, args.BackoutQueue)
+		err = requeue(qMgr, args.BackoutQueue, md, data, msgHandle)
+		msgHandle.DltMH(ibmmq.NewMQDMHO()) //nolint:errcheck
+		if err != nil {
+			qMgr.Back() //nolint:errcheck
+			return nil, nil, msgHandle, err
+		}
+		if err := qMgr.Cmit(); err != nil {
+			return nil, nil, msgHandle, fmt.Errorf("failed to commit requeue: %w", err)
+		}
+	}
+}
+
+// requeue puts a message that was got in the current unit of work on queue,
+// keeping its descriptor (IDs, format, group fields) and properties.
+func requeue(qMgr ibmmq.MQQueueManager, queue string, md *ibmmq.MQMD, data []byte, msgHandle ibmmq.MQMessageHandle) error {
+	mqod := ibmmq.NewMQOD()
+	mqod.ObjectType = ibmmq.MQOT_Q
+	mqod.ObjectName = queue
+
+	pmo := ibmmq.NewMQPMO()
+	pmo.Options = ibmmq.MQPMO_SYNCPOINT | ibmmq.MQPMO_FAIL_IF_QUIESCING
+	pmo.OriginalMsgHandle = msgHandle
+	if err := qMgr.Put1(mqod, md, pmo, data); err != nil {
+		return fmt.Errorf("failed to requeue message to %s: %w", queue, err)
+	}
+	return nil
+}
@@ -44,7 +44,7 @@ type queueBrowser struct {
 
 func (b *queueBrowser) Next(_ context.Context) (*backends.Message, error) {
 	gmo := ibmmq.NewMQGMO()
-	gmo.Options = ibmmq.MQGMO_NO_SYNCPOINT | ibmmq.MQGMO_FAIL_IF_QUIESCING | ibmmq.MQGMO_WAIT
+	gmo.Options = ibmmq.MQGMO_NO_SYNCPOINT | ibmmq.MQGMO_FAIL_IF_QUIESCING | ibmmq.MQGMO_WAIT | ibmmq.MQGMO_COMPLETE_MSG
 	if b.first {
 		gmo.Options |= ibmmq.MQGMO_BROWSE_FIRST
 	} else {
@@ -60,7 +60,7 @@ func (b *queueBrowser) Next(_ context.Context) (*backends.Message, error) {
 	defer msgHandle.DltMH(ibmmq.NewMQDMHO())
 	gmo.MsgHandle = msgHandle
 
-	md := ibmmq.NewMQMD()
+	md := newGetMD()
 	buffer := make([]byte, 0)
 
 	datalen, err := b.obj.Get(md, gmo, buffer)
@@ -74,7 +74,7 @@ func (b *queueBrowser) Next(_ context.Context) (*backends.Message, error) {
 			// retry with the same BROWSE_FIRST/NEXT options and a buffer of
 			// the reported size.
 			buffer = make([]byte, datalen)
-			md = ibmmq.NewMQMD()
+			md = newGetMD()
 			datalen, err = b.obj.Get(md, gmo, buffer)
 			if err != nil {
 				return nil, fmt.Errorf("failed to browse message: %w", err)
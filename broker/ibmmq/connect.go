@@ -5,6 +5,7 @@ package ibmmq
 import (
 	"fmt"
 	"net/url"
+	"os"
 	"strings"
 
 	"github.com/ibm-messaging/mq-golang/v5/ibmmq"
@@ -18,6 +19,16 @@ type ConnArguments struct {
 	Channel      string
 	ModelQueue   string // model queue for temporary reply queues (request command)
 	DynamicQueue string // dynamic queue name pattern for temporary reply queues, e.g. "XMC.REPLY.*"
+
+	// TLS (the channel's SSLCIPH must match CipherSpec)
+	CipherSpec      string // MQCD.SSLCipherSpec, e.g. "ANY_TLS13_OR_HIGHER"; enables TLS
+	KeyRepository   string // key.kdb (with key.sth) or a PKCS#12 file
+	KeyRepoPassword string // password of a PKCS#12 key repository
+	CertLabel       string // client certificate label (default ibmwebspheremq<user>)
+	PeerName        string // required distinguished name of the queue manager's certificate, e.g. "CN=QM1,O=ACME"
+
+	CCDT      string // client channel definition table (file path or URL); replaces the server URL's channel and host
+	ServerSet bool   // the server was given (--server or IMC_SERVER), so $MQCCDTURL does not apply
 }
 
 // Connect establishes a connection to IBM MQ
@@ -47,14 +58,39 @@ func Connect(args ConnArguments) (ibmmq.MQQueueManager, error) {
 		csp.Password = args.Password
 	}
 	cno.SecurityParms = csp
+	cno.Options = ibmmq.MQCNO_CLIENT_BINDING
 
-	// Set client connection options
-	cd := ibmmq.NewMQCD()
-	cd.ChannelName = channel
-	cd.ConnectionName = fmt.Sprintf("%s(%s)", host, port)
+	if args.KeyRepository != "" || args.CertLabel != "" {
+		sco := ibmmq.NewMQSCO()
+		sco.KeyRepository = keyRepositoryPath(args.KeyRepository)
+		sco.KeyRepoPassword = args.KeyRepoPassword
+		sco.CertificateLabel = args.CertLabel
+		cno.SSLConfig = sco
+	}
 
-	cno.ClientConn = cd
-	cno.Options = ibmmq.MQCNO_CLIENT_BINDING
+	explicitConn := args.ServerSet || args.Channel != "" || args.CipherSpec != "" || args.PeerName != ""
+	if table := channelTable(args.CCDT, os.Getenv("MQCCDTURL"), explicitConn); table != "" {
+		// The channel definition, including its TLS settings, comes from the
+		// table entry for the queue manager.
+		if args.Channel != "" || args.CipherSpec != "" || args.PeerName != "" {
+			return ibmmq.MQQueueManager{}, fmt.Errorf("--channel, --cipher-spec and --peer-name come from the CCDT; remove them or --ccdt")
+		}
+		ccdt, err := ccdtURL(table)
+		if err != nil {
+			return ibmmq.MQQueueManager{}, err
+		}
+		cno.CCDTUrl = ccdt
+	} else {
+		if args.PeerName != "" && args.CipherSpec == "" {
+			return ibmmq.MQQueueManager{}, fmt.Errorf("--peer-name requires --cipher-spec")
+		}
+		cd := ibmmq.NewMQCD()
+		cd.ChannelName = channel
+		cd.ConnectionName = fmt.Sprintf("%s(%s)", host, port)
+		cd.SSLCipherSpec = args.CipherSpec
+		cd.SSLPeerName = args.PeerName
+		cno.ClientConn = cd
+	}
 
 	// Connect to queue manager
 	qMgr, err := ibmmq.Connx(qmName, cno)
@@ -0,0 +1,98 @@
+//go:build ibmmq
+
+package ibmmq
+
+import (
+	"fmt"
+
+	"github.com/ibm-messaging/mq-golang/v5/ibmmq"
+	"github.com/makibytes/xmc/log"
+)
+
+// sendInGroup sends args as the next message of its group. Only the message
+// after it tells whether it is the last one, so each message is held back
+// until the next Send and put then; flushGroup puts the last one when the
+// group ends (another destination or group, or Close).
+func (a *QueueAdapter) sendInGroup(args SendArguments) error {
+	args.MsgSeqNumber = 1
+	if p := a.pending; p != nil {
+		args.MsgSeqNumber = p.MsgSeqNumber + 1
+		a.pending = nil
+		if err := SendMessage(a.qMgr, *p); err != nil {
+			return err
+		}
+	}
+	a.pending = &args
+	return nil
+}
+
+// flushGroup puts the held-back message as the last of its group.
+func (a *QueueAdapter) flushGroup() error {
+	p := a.pending
+	if p == nil {
+		return nil
+	}
+	a.pending = nil
+	p.LastInGroup = true
+	if err := SendMessage(a.qMgr, *p); err != nil {
+		return fmt.Errorf("failed to send the last message of group %s: %w", p.GroupID, err)
+	}
+	return nil
+}
+
+// getGroup gets the next complete message group in logical order (once all of
+// its messages have arrived) and joins their bodies into one message. The
+// first message's descriptor and properties describe the result, with the
+// last message's sequence number so it tells the group's size. A message
+// outside a group is returned on its own. Destructive gets run in a unit of
+// work, so a failure leaves the whole group on the queue.
+func getGroup(qMgr ibmmq.MQQueueManager, qObject ibmmq.MQObject, gmo *ibmmq.MQGMO, destructive bool) (*ibmmq.MQMD, []byte, ibmmq.MQMessageHandle, error) {
+	gmo.Options |= ibmmq.MQGMO_ALL_MSGS_AVAILABLE | ibmmq.MQGMO_LOGICAL_ORDER
+	if destructive {
+		gmo.Options = gmo.Options&^ibmmq.MQGMO_NO_SYNCPOINT | ibmmq.MQGMO_SYNCPOINT
+	}
+	backout := func() {
+		if destructive {
+			qMgr.Back() //nolint:errcheck
+		}
+	}
+
+	first, data, msgHandle, err := getMessage(qMgr, qObject, gmo)
+	if err != nil {
+		backout()
+		return nil, nil, msgHandle, err
+	}
+
+	md := first
+	for md.MsgFlags&ibmmq.MQMF_MSG_IN_GROUP != 0 && md.MsgFlags&ibmmq.MQMF_LAST_MSG_IN_GROUP == 0 {
+		// The rest of the group is already there: no need to wait.
+		gmo.Options &^= ibmmq.MQGMO_WAIT
+		if !destructive {
+			gmo.Options = gmo.Options&^ibmmq.MQGMO_BROWSE_FIRST | ibmmq.MQGMO_BROWSE_NEXT
+		}
+		next := md.MsgSeqNumber + 1
+		var part []byte
+		var partHandle ibmmq.MQMessageHandle
+		md, part, partHandle, err = getMessage(qMgr, qObject, gmo)
+		if err != nil {
+			msgHandle.DltMH(ibmmq.NewMQDMHO()) //nolint:errcheck
+			backout()
+			return nil, nil, msgHandle, fmt.Errorf("failed to get message %d of group %s: %w", next, mqIDToString(first.GroupId), err)
+		}
+		partHandle.DltMH(ibmmq.NewMQDMHO()) //nolint:errcheck
+		data = append(data, part...)
+	}
+
+	if destructive {
+		if err := qMgr.Cmit(); err != nil {
+			msgHandle.DltMH(ibmmq.NewMQDMHO()) //nolint:errcheck
+			return nil, nil, msgHandle, fmt.Errorf("failed to commit group: %w", err)
+		}
+	}
+	if md != first {
+		log.Verbose("📚 joined %d messages of group %s...", md.MsgSeqNumber, mqIDToString(first.GroupId))
+		first.MsgSeqNumber = md.MsgSeqNumber
+		first.MsgFlags |= ibmmq.MQMF_LAST_MSG_IN_GROUP
+	}
+	return first, data, msgHandle, nil
+}
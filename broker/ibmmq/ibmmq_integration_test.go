@@ -209,3 +209,239 @@ func TestIBMMQ_QueueProperties(t *testing.T) {
 		t.Errorf("expected color=%q, got %v", "red", msg.Properties["color"])
 	}
 }
+
+func TestIBMMQ_Manage(t *testing.T) {
+	t.Parallel()
+	queue := "XMC.MANAGE." + randomSuffix()
+	// PCF commands need the admin channel's authority.
+	connArgs := makeConnArgs()
+	connArgs.Channel = "DEV.ADMIN.SVRCONN"
+
+	if err := CreateQueue(connArgs, queue, QueueConfig{MaxDepth: 100, BackoutQueue: "DEV.DEAD.LETTER.QUEUE", BackoutThreshold: 3}); err != nil {
+		t.Fatalf("CreateQueue: %v", err)
+	}
+	defer DeleteQueue(connArgs, queue, true) //nolint:errcheck
+
+	sender, err := NewQueueAdapter(connArgs)
+	if err != nil {
+		t.Fatalf("NewQueueAdapter: %v", err)
+	}
+	defer sender.Close()
+	for range 2 {
+		if err := sender.Send(context.Background(), backends.SendOptions{Queue: queue, Message: []byte("m")}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	queues, err := ListQueues(connArgs)
+	if err != nil {
+		t.Fatalf("ListQueues: %v", err)
+	}
+	found := false
+	for _, q := range queues {
+		if q.Name == queue {
+			found = true
+			if q.Kind != "local" || len(q.Metrics) == 0 || q.Metrics[0].Value != 2 {
+				t.Errorf("listed %s as %s %v, want local with msgs=2", queue, q.Kind, q.Metrics)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("ListQueues did not return %s", queue)
+	}
+
+	stats, err := GetQueueStats(connArgs, queue, false)
+	if err != nil {
+		t.Fatalf("GetQueueStats: %v", err)
+	}
+	if stats.MessageCount != 2 {
+		t.Errorf("stats.MessageCount = %d, want 2", stats.MessageCount)
+	}
+
+	if err := DeleteQueue(connArgs, queue, false); err == nil {
+		t.Error("DeleteQueue of a non-empty queue without purge: expected an error")
+	}
+	if n, err := PurgeQueue(connArgs, queue); err != nil || n != 2 {
+		t.Errorf("PurgeQueue = %d, %v; want 2", n, err)
+	}
+	if err := DeleteQueue(connArgs, queue, false); err != nil {
+		t.Errorf("DeleteQueue of the cleared queue: %v", err)
+	}
+
+	channels, err := ListChannels(connArgs)
+	if err != nil {
+		t.Fatalf("ListChannels: %v", err)
+	}
+	for _, c := range channels {
+		if c.Name == "DEV.ADMIN.SVRCONN" && len(c.Children) > 0 {
+			return
+		}
+	}
+	t.Errorf("ListChannels did not show DEV.ADMIN.SVRCONN running: %v", channels)
+}
+
+func TestIBMMQ_PublishSubscribe(t *testing.T) {
+	t.Parallel()
+	// The developer image lets the app user publish and subscribe below dev/.
+	topic := "dev/xmc/" + randomSuffix()
+	connArgs := makeConnArgs()
+
+	subscriber, err := NewTopicAdapter(connArgs)
+	if err != nil {
+		t.Fatalf("NewTopicAdapter (subscriber): %v", err)
+	}
+	defer subscriber.Close()
+	opts := backends.SubscribeOptions{Topic: topic, GroupID: "xmc-test", Timeout: 0.2}
+	// Only publications after the subscription exists are delivered.
+	if _, err := subscriber.Subscribe(context.Background(), opts); err != backends.ErrNoMessageAvailable {
+		t.Fatalf("Subscribe before publish: %v, want ErrNoMessageAvailable", err)
+	}
+
+	publisher, err := NewTopicAdapter(connArgs)
+	if err != nil {
+		t.Fatalf("NewTopicAdapter (publisher): %v", err)
+	}
+	defer publisher.Close()
+	if err := publisher.Publish(context.Background(), backends.PublishOptions{Topic: topic, Message: []byte("hello")}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	opts.Timeout = 5
+	msg, err := subscriber.Subscribe(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if string(msg.Data) != "hello" || msg.Topic != topic {
+		t.Errorf("got %q on %q, want %q on %q", msg.Data, msg.Topic, "hello", topic)
+	}
+}
+
+func TestIBMMQ_DurableSubscription(t *testing.T) {
+	t.Parallel()
+	topic := "dev/xmc/durable/" + randomSuffix()
+	connArgs := makeConnArgs()
+	opts := backends.SubscribeOptions{Topic: topic, GroupID: "xmc-test", Durable: true, Timeout: 0.2}
+
+	subscriber, err := NewTopicAdapter(connArgs)
+	if err != nil {
+		t.Fatalf("NewTopicAdapter: %v", err)
+	}
+	if _, err := subscriber.Subscribe(context.Background(), opts); err != backends.ErrNoMessageAvailable {
+		t.Fatalf("Subscribe: %v, want ErrNoMessageAvailable", err)
+	}
+	subscriber.Close()
+
+	// Publications are kept for the durable subscription while nobody is
+	// connected.
+	publisher, err := NewTopicAdapter(connArgs)
+	if err != nil {
+		t.Fatalf("NewTopicAdapter (publisher): %v", err)
+	}
+	defer publisher.Close()
+	if err := publisher.Publish(context.Background(), backends.PublishOptions{Topic: topic, Message: []byte("kept")}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	subscriber, err = NewTopicAdapter(connArgs)
+	if err != nil {
+		t.Fatalf("NewTopicAdapter: %v", err)
+	}
+	defer subscriber.Close()
+	opts.Timeout = 5
+	msg, err := subscriber.Subscribe(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Subscribe after reconnect: %v", err)
+	}
+	if string(msg.Data) != "kept" {
+		t.Errorf("got %q, want %q", msg.Data, "kept")
+	}
+}
+
+func TestIBMMQ_MessageGroup(t *testing.T) {
+	t.Parallel()
+	queue := "XMC.GROUP." + randomSuffix()
+	connArgs := makeConnArgs()
+	connArgs.Channel = "DEV.ADMIN.SVRCONN"
+	if err := CreateQueue(connArgs, queue, QueueConfig{}); err != nil {
+		t.Fatalf("CreateQueue: %v", err)
+	}
+	defer DeleteQueue(connArgs, queue, true) //nolint:errcheck
+
+	sender, err := NewQueueAdapter(connArgs)
+	if err != nil {
+		t.Fatalf("NewQueueAdapter (sender): %v", err)
+	}
+	extra := map[string]string{"group": "ORDER-4711", "segment-size": "3"}
+	for _, part := range []string{"header;", "line-1;", "trailer"} {
+		if err := sender.Send(context.Background(), backends.SendOptions{Queue: queue, Message: []byte(part), Extra: extra}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	// Close puts the held-back last message of the group.
+	if err := sender.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	receiver, err := NewQueueAdapter(connArgs)
+	if err != nil {
+		t.Fatalf("NewQueueAdapter (receiver): %v", err)
+	}
+	defer receiver.Close()
+
+	msg, err := receiver.Receive(context.Background(), backends.ReceiveOptions{
+		Queue:       queue,
+		Acknowledge: true,
+		Timeout:     5,
+		Extra:       map[string]string{"complete-group": "true"},
+	})
+	if err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	if string(msg.Data) != "header;line-1;trailer" {
+		t.Errorf("expected the joined group, got %q", msg.Data)
+	}
+	if msg.InternalMetadata["GroupId"] != "ORDER-4711" || msg.InternalMetadata["MsgSeqNumber"] != int32(3) {
+		t.Errorf("unexpected group metadata: %v", msg.InternalMetadata)
+	}
+	if _, err := receiver.Receive(context.Background(), backends.ReceiveOptions{Queue: queue, Acknowledge: true, Timeout: 1}); err != backends.ErrNoMessageAvailable {
+		t.Errorf("queue not empty after getting the group: %v", err)
+	}
+}
+
+func TestIBMMQ_ReportCOA(t *testing.T) {
+	t.Parallel()
+	queue := "XMC.REPORT." + randomSuffix()
+	reportQueue := queue + ".REPLY"
+	connArgs := makeConnArgs()
+	connArgs.Channel = "DEV.ADMIN.SVRCONN"
+	for _, q := range []string{queue, reportQueue} {
+		if err := CreateQueue(connArgs, q, QueueConfig{}); err != nil {
+			t.Fatalf("CreateQueue: %v", err)
+		}
+		defer DeleteQueue(connArgs, q, true) //nolint:errcheck
+	}
+
+	adapter, err := NewQueueAdapter(connArgs)
+	if err != nil {
+		t.Fatalf("NewQueueAdapter: %v", err)
+	}
+	defer adapter.Close()
+
+	err = adapter.Send(context.Background(), backends.SendOptions{
+		Queue:     queue,
+		Message:   []byte("report me"),
+		MessageID: "REPORT-1",
+		Extra:     map[string]string{"report": "coa", "report-queue": reportQueue},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	report, err := adapter.Receive(context.Background(), backends.ReceiveOptions{Queue: reportQueue, Acknowledge: true, Timeout: 5})
+	if err != nil {
+		t.Fatalf("Receive report: %v", err)
+	}
+	if report.InternalMetadata["Feedback"] != "coa" || report.CorrelationID != "REPORT-1" {
+		t.Errorf("expected a COA report correlated to REPORT-1, got %v (correlation ID %q)", report.InternalMetadata, report.CorrelationID)
+	}
+}
@@ -0,0 +1,251 @@
+//go:build ibmmq
+
+package ibmmq
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ibm-messaging/mq-golang/v5/ibmmq"
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// Management goes through the queue manager's command server: each call
+// connects, sends one or more PCF commands to SYSTEM.ADMIN.COMMAND.QUEUE and
+// disconnects again. The user needs command server authority (e.g. the
+// developer image's admin user on DEV.ADMIN.SVRCONN).
+
+// QueueConfig holds the attributes create-queue sets on a new local queue.
+// Zero values keep the queue manager's defaults (SYSTEM.DEFAULT.LOCAL.QUEUE).
+type QueueConfig struct {
+	MaxDepth         int32  // MAXDEPTH
+	BackoutQueue     string // BOQNAME
+	BackoutThreshold int32  // BOTHRESH
+	Description      string // DESCR
+}
+
+// isSystemObject reports whether name is a queue manager object (SYSTEM.*)
+// or a temporary dynamic queue (AMQ.*). They are listed only while in use.
+func isSystemObject(name string) bool {
+	return strings.HasPrefix(name, "SYSTEM.") || strings.HasPrefix(name, "AMQ.")
+}
+
+// ListQueues lists the local, alias and remote queues with their depth and
+// open handle counts. SYSTEM.* and dynamic queues are left out unless they
+// hold messages (e.g. the dead-letter queue).
+func ListQueues(connArgs ConnArguments) ([]backends.ObjectNode, error) {
+	return withQueueManager(connArgs, func(qMgr ibmmq.MQQueueManager) ([]backends.ObjectNode, error) {
+		responses, err := runPCF(qMgr, connArgs, ibmmq.MQCMD_INQUIRE_Q,
+			pcfString(ibmmq.MQCA_Q_NAME, "*"),
+			pcfInt(ibmmq.MQIA_Q_TYPE, ibmmq.MQQT_ALL),
+			pcfIntList(ibmmq.MQIACF_Q_ATTRS, ibmmq.MQCA_Q_NAME, ibmmq.MQIA_Q_TYPE, ibmmq.MQIA_CURRENT_Q_DEPTH,
+				ibmmq.MQIA_OPEN_INPUT_COUNT, ibmmq.MQIA_OPEN_OUTPUT_COUNT))
+		if err != nil {
+			return nil, err
+		}
+		var nodes []backends.ObjectNode
+		for _, r := range responses {
+			qType := int32(r.num(ibmmq.MQIA_Q_TYPE))
+			if qType != ibmmq.MQQT_LOCAL && qType != ibmmq.MQQT_ALIAS && qType != ibmmq.MQQT_REMOTE {
+				continue
+			}
+			name, depth := r.str(ibmmq.MQCA_Q_NAME), r.num(ibmmq.MQIA_CURRENT_Q_DEPTH)
+			if isSystemObject(name) && depth <= 0 {
+				continue
+			}
+			node := backends.ObjectNode{Name: name, Kind: mqiName("QT", int64(qType))}
+			if qType == ibmmq.MQQT_LOCAL {
+				node.Metrics = []backends.Metric{
+					{Label: "msgs", Value: depth},
+					{Label: "input", Value: r.num(ibmmq.MQIA_OPEN_INPUT_COUNT)},
+					{Label: "output", Value: r.num(ibmmq.MQIA_OPEN_OUTPUT_COUNT)},
+				}
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes, nil
+	})
+}
+
+// GetQueueStats returns a local queue's depth and open input handles
+// (consumers) from its attributes, which leaves the queue manager's counters
+// alone. With reset it also runs RESET QSTATS for the enqueue and dequeue
+// counts: they cover the time since the previous reset and restart counting
+// for everyone monitoring the queue, and stay 0 when the queue manager has
+// performance events disabled. For an alias queue the target queue's
+// statistics are returned.
+func GetQueueStats(connArgs ConnArguments, queue string, reset bool) (*backends.QueueStats, error) {
+	return withQueueManager(connArgs, func(qMgr ibmmq.MQQueueManager) (*backends.QueueStats, error) {
+		inquire := func(name string) (pcfResponse, error) {
+			responses, err := runPCF(qMgr, connArgs, ibmmq.MQCMD_INQUIRE_Q,
+				pcfString(ibmmq.MQCA_Q_NAME, name),
+				pcfIntList(ibmmq.MQIACF_Q_ATTRS, ibmmq.MQIA_Q_TYPE, ibmmq.MQIA_CURRENT_Q_DEPTH,
+					ibmmq.MQIA_OPEN_INPUT_COUNT, ibmmq.MQCA_BASE_OBJECT_NAME))
+			if err != nil {
+				return nil, err
+			}
+			if len(responses) == 0 {
+				return nil, fmt.Errorf("queue %s not found", name)
+			}
+			return responses[0], nil
+		}
+
+		name := queue
+		r, err := inquire(name)
+		if err != nil {
+			return nil, err
+		}
+		if r.num(ibmmq.MQIA_Q_TYPE) == int64(ibmmq.MQQT_ALIAS) {
+			if name = r.str(ibmmq.MQCA_BASE_OBJECT_NAME); name == "" {
+				return nil, fmt.Errorf("alias queue %s has no target queue", queue)
+			}
+			if r, err = inquire(name); err != nil {
+				return nil, err
+			}
+		}
+		if r.num(ibmmq.MQIA_Q_TYPE) != int64(ibmmq.MQQT_LOCAL) {
+			return nil, fmt.Errorf("%s is a %s queue; stats needs a local queue", name, mqiName("QT", r.num(ibmmq.MQIA_Q_TYPE)))
+		}
+
+		stats := &backends.QueueStats{
+			Name:          name,
+			MessageCount:  r.num(ibmmq.MQIA_CURRENT_Q_DEPTH),
+			ConsumerCount: int(r.num(ibmmq.MQIA_OPEN_INPUT_COUNT)),
+		}
+		if !reset {
+			return stats, nil
+		}
+		counts, err := runPCF(qMgr, connArgs, ibmmq.MQCMD_RESET_Q_STATS, pcfString(ibmmq.MQCA_Q_NAME, name))
+		switch {
+		case hasReason(err, ibmmq.MQRCCF_EVENTS_DISABLED):
+		case err != nil:
+			return nil, err
+		case len(counts) > 0:
+			stats.EnqueueCount = counts[0].num(ibmmq.MQIA_MSG_ENQ_COUNT)
+			stats.DequeueCount = counts[0].num(ibmmq.MQIA_MSG_DEQ_COUNT)
+		}
+		return stats, nil
+	})
+}
+
+// PurgeQueue clears a local queue and returns its depth just before. The
+// queue manager refuses while an application has the queue open or it holds
+// uncommitted messages.
+func PurgeQueue(connArgs ConnArguments, queue string) (int64, error) {
+	return withQueueManager(connArgs, func(qMgr ibmmq.MQQueueManager) (int64, error) {
+		responses, err := runPCF(qMgr, connArgs, ibmmq.MQCMD_INQUIRE_Q,
+			pcfString(ibmmq.MQCA_Q_NAME, queue),
+			pcfInt(ibmmq.MQIA_Q_TYPE, ibmmq.MQQT_LOCAL),
+			pcfIntList(ibmmq.MQIACF_Q_ATTRS, ibmmq.MQIA_CURRENT_Q_DEPTH))
+		if err != nil {
+			return 0, err
+		}
+		var depth int64
+		if len(responses) > 0 {
+			depth = max(responses[0].num(ibmmq.MQIA_CURRENT_Q_DEPTH), 0)
+		}
+		if _, err := runPCF(qMgr, connArgs, ibmmq.MQCMD_CLEAR_Q, pcfString(ibmmq.MQCA_Q_NAME, queue)); err != nil {
+			if hasReason(err, ibmmq.MQRC_OBJECT_IN_USE, ibmmq.MQRCCF_OBJECT_OPEN) {
+				return 0, fmt.Errorf("%w (the queue is open; stop its applications or drain it with receive -n 0)", err)
+			}
+			return 0, err
+		}
+		return depth, nil
+	})
+}
+
+// CreateQueue defines a local queue. An existing queue is left unchanged and
+// reported as an error.
+func CreateQueue(connArgs ConnArguments, queue string, cfg QueueConfig) error {
+	params := []*ibmmq.PCFParameter{
+		pcfString(ibmmq.MQCA_Q_NAME, queue),
+		pcfInt(ibmmq.MQIA_Q_TYPE, ibmmq.MQQT_LOCAL),
+	}
+	if cfg.MaxDepth > 0 {
+		params = append(params, pcfInt(ibmmq.MQIA_MAX_Q_DEPTH, cfg.MaxDepth))
+	}
+	if cfg.BackoutQueue != "" {
+		params = append(params, pcfString(ibmmq.MQCA_BACKOUT_REQ_Q_NAME, cfg.BackoutQueue))
+	}
+	if cfg.BackoutThreshold > 0 {
+		params = append(params, pcfInt(ibmmq.MQIA_BACKOUT_THRESHOLD, cfg.BackoutThreshold))
+	}
+	if cfg.Description != "" {
+		params = append(params, pcfString(ibmmq.MQCA_Q_DESC, cfg.Description))
+	}
+	_, err := withQueueManager(connArgs, func(qMgr ibmmq.MQQueueManager) ([]pcfResponse, error) {
+		return runPCF(qMgr, connArgs, ibmmq.MQCMD_CREATE_Q, params...)
+	})
+	return err
+}
+
+// DeleteQueue deletes a local queue. Without purge the queue manager refuses
+// while the queue holds messages.
+func DeleteQueue(connArgs ConnArguments, queue string, purge bool) error {
+	params := []*ibmmq.PCFParameter{
+		pcfString(ibmmq.MQCA_Q_NAME, queue),
+		pcfInt(ibmmq.MQIA_Q_TYPE, ibmmq.MQQT_LOCAL),
+	}
+	if purge {
+		params = append(params, pcfInt(ibmmq.MQIACF_PURGE, ibmmq.MQPO_YES))
+	}
+	_, err := withQueueManager(connArgs, func(qMgr ibmmq.MQQueueManager) ([]pcfResponse, error) {
+		return runPCF(qMgr, connArgs, ibmmq.MQCMD_DELETE_Q, params...)
+	})
+	if hasReason(err, ibmmq.MQRC_Q_NOT_EMPTY) {
+		return fmt.Errorf("%w (use --purge to delete its messages too)", err)
+	}
+	return err
+}
+
+// ListChannels lists the channels with their type and status, and their
+// running instances (connection name, status, messages transferred) as
+// children. SYSTEM.* channels are left out unless they have instances.
+func ListChannels(connArgs ConnArguments) ([]backends.ObjectNode, error) {
+	return withQueueManager(connArgs, func(qMgr ibmmq.MQQueueManager) ([]backends.ObjectNode, error) {
+		channels, err := runPCF(qMgr, connArgs, ibmmq.MQCMD_INQUIRE_CHANNEL,
+			pcfString(ibmmq.MQCACH_CHANNEL_NAME, "*"),
+			pcfIntList(ibmmq.MQIACF_CHANNEL_ATTRS, ibmmq.MQCACH_CHANNEL_NAME, ibmmq.MQIACH_CHANNEL_TYPE))
+		if err != nil {
+			return nil, err
+		}
+		statuses, err := runPCF(qMgr, connArgs, ibmmq.MQCMD_INQUIRE_CHANNEL_STATUS,
+			pcfString(ibmmq.MQCACH_CHANNEL_NAME, "*"),
+			pcfIntList(ibmmq.MQIACH_CHANNEL_INSTANCE_ATTRS, ibmmq.MQCACH_CHANNEL_NAME, ibmmq.MQCACH_CONNECTION_NAME,
+				ibmmq.MQIACH_CHANNEL_STATUS, ibmmq.MQIACH_MSGS))
+		if err != nil && !hasReason(err, ibmmq.MQRCCF_CHL_STATUS_NOT_FOUND) {
+			return nil, err
+		}
+		instances := make(map[string][]pcfResponse)
+		for _, s := range statuses {
+			name := s.str(ibmmq.MQCACH_CHANNEL_NAME)
+			instances[name] = append(instances[name], s)
+		}
+
+		var nodes []backends.ObjectNode
+		for _, c := range channels {
+			name := c.str(ibmmq.MQCACH_CHANNEL_NAME)
+			running := instances[name]
+			if isSystemObject(name) && len(running) == 0 {
+				continue
+			}
+			status := "inactive"
+			node := backends.ObjectNode{Name: name}
+			for _, s := range running {
+				instStatus := mqiName("CHS", s.num(ibmmq.MQIACH_CHANNEL_STATUS))
+				if status == "inactive" || instStatus == "running" {
+					status = instStatus
+				}
+				child := backends.ObjectNode{Name: s.str(ibmmq.MQCACH_CONNECTION_NAME), Kind: instStatus}
+				if msgs := s.num(ibmmq.MQIACH_MSGS); msgs >= 0 {
+					child.Metrics = []backends.Metric{{Label: "msgs", Value: msgs}}
+				}
+				node.Children = append(node.Children, child)
+			}
+			node.Kind = mqiName("CHT", c.num(ibmmq.MQIACH_CHANNEL_TYPE)) + " " + status
+			node.Metrics = []backends.Metric{{Label: "instances", Value: int64(len(running))}}
+			nodes = append(nodes, node)
+		}
+		return nodes, nil
+	})
+}
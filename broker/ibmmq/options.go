@@ -0,0 +1,90 @@
+//go:build ibmmq
+
+package ibmmq
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ibm-messaging/mq-golang/v5/ibmmq"
+)
+
+// reportOptions maps --report names to MQMD report options.
+var reportOptions = map[string]int32{
+	"coa":        ibmmq.MQRO_COA,
+	"cod":        ibmmq.MQRO_COD,
+	"exception":  ibmmq.MQRO_EXCEPTION,
+	"expiration": ibmmq.MQRO_EXPIRATION,
+}
+
+// applySendExtra applies the imc send flags carried in extra to args.
+func applySendExtra(args *SendArguments, extra map[string]string) error {
+	args.GroupID = extra["group"]
+
+	if v := extra["segment-size"]; v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid --segment-size %q: must be a positive number of bytes", v)
+		}
+		args.SegmentSize = n
+	}
+
+	if v := extra["report"]; v != "" {
+		for _, name := range strings.Split(v, ",") {
+			option, ok := reportOptions[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return fmt.Errorf("invalid --report %q: use coa, cod, exception or expiration", name)
+			}
+			args.Report |= option
+		}
+	}
+
+	// Reports are sent to the message's reply-to queue, so --report-queue
+	// and --reply-to name the same MQMD field.
+	reportQueue := extra["report-queue"]
+	switch {
+	case reportQueue != "" && args.Report == 0:
+		return fmt.Errorf("--report-queue requires --report")
+	case reportQueue != "" && args.ReplyTo != "" && args.ReplyTo != reportQueue:
+		return fmt.Errorf("--report-queue and --reply-to both set the MQMD ReplyToQ; use one of them")
+	case reportQueue != "":
+		args.ReplyTo = reportQueue
+	case args.Report != 0 && args.ReplyTo == "":
+		return fmt.Errorf("--report requires --report-queue (or --reply-to)")
+	}
+	return nil
+}
+
+// checkPublishExtra rejects the send-only flags on publish: message groups,
+// segmentation and report options are not applied to publications.
+func checkPublishExtra(extra map[string]string) error {
+	for _, name := range []string{"group", "segment-size", "report", "report-queue"} {
+		if _, ok := extra[name]; ok {
+			return fmt.Errorf("--%s applies to send only; publish does not support it", name)
+		}
+	}
+	return nil
+}
+
+// applyReceiveExtra applies the imc receive flags carried in extra to args.
+func applyReceiveExtra(args *ReceiveArguments, extra map[string]string) error {
+	args.CompleteGroup = extra["complete-group"] == "true"
+	args.BackoutQueue = extra["backout-queue"]
+
+	if v := extra["backout-threshold"]; v != "" {
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid --backout-threshold %q: must be a positive number", v)
+		}
+		if args.BackoutQueue == "" {
+			return fmt.Errorf("--backout-threshold requires --backout-queue")
+		}
+		args.BackoutThreshold = int32(n)
+	}
+
+	if args.CompleteGroup && args.BackoutQueue != "" {
+		return fmt.Errorf("--complete-group cannot be combined with --backout-queue")
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+//go:build ibmmq
+
+package ibmmq
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ibm-messaging/mq-golang/v5/ibmmq"
+	"github.com/makibytes/xmc/log"
+)
+
+const (
+	// commandQueue is the command server's input queue on distributed
+	// queue managers.
+	commandQueue = "SYSTEM.ADMIN.COMMAND.QUEUE"
+	// pcfTimeout is how long to wait for each command server response, in
+	// milliseconds.
+	pcfTimeout = 30 * 1000
+)
+
+// pcfError is a PCF command the command server answered with a failure.
+type pcfError struct {
+	Command int32
+	Reason  int32
+}
+
+func (e *pcfError) Error() string {
+	return fmt.Sprintf("%s failed: %s [%d]", ibmmq.MQItoString("CMD", int(e.Command)), ibmmq.MQItoString("RC", int(e.Reason)), e.Reason)
+}
+
+// hasReason reports whether err is a PCF failure with one of reasons.
+func hasReason(err error, reasons ...int32) bool {
+	pcfErr, ok := err.(*pcfError)
+	if !ok {
+		return false
+	}
+	for _, r := range reasons {
+		if pcfErr.Reason == r {
+			return true
+		}
+	}
+	return false
+}
+
+// pcfResponse holds the parameters of one command server response, keyed by
+// parameter ID.
+type pcfResponse map[int32]*ibmmq.PCFParameter
+
+// str returns a string parameter, trimmed of MQ's blank padding.
+func (r pcfResponse) str(id int32) string {
+	if p, ok := r[id]; ok && len(p.String) > 0 {
+		return strings.TrimSpace(p.String[0])
+	}
+	return ""
+}
+
+// num returns an integer parameter, or -1 when it is absent.
+func (r pcfResponse) num(id int32) int64 {
+	if p, ok := r[id]; ok && len(p.Int64Value) > 0 {
+		return p.Int64Value[0]
+	}
+	return -1
+}
+
+// mqiName renders an MQI constant of class (e.g. "CHS") in lower case without
+// its prefix: MQCHS_RUNNING becomes "running". Unknown values are shown as
+// numbers.
+func mqiName(class string, value int64) string {
+	s := ibmmq.MQItoString(class, int(value))
+	if s == "" {
+		return fmt.Sprint(value)
+	}
+	return strings.ToLower(strings.TrimPrefix(s, "MQ"+class+"_"))
+}
+
+func pcfString(id int32, value string) *ibmmq.PCFParameter {
+	return &ibmmq.PCFParameter{Type: ibmmq.MQCFT_STRING, Parameter: id, String: []string{value}}
+}
+
+func pcfInt(id int32, value int32) *ibmmq.PCFParameter {
+	return &ibmmq.PCFParameter{Type: ibmmq.MQCFT_INTEGER, Parameter: id, Int64Value: []int64{int64(value)}}
+}
+
+func pcfIntList(id int32, values ...int32) *ibmmq.PCFParameter {
+	p := &ibmmq.PCFParameter{Type: ibmmq.MQCFT_INTEGER_LIST, Parameter: id}
+	for _, v := range values {
+		p.Int64Value = append(p.Int64Value, int64(v))
+	}
+	return p
+}
+
+// runPCF sends a PCF command to the command server and collects its
+// responses. Replies arrive on a temporary dynamic queue created from the
+// same model queue as request replies (see --model-queue). The first failed
+// response is returned as a *pcfError.
+func runPCF(qMgr ibmmq.MQQueueManager, connArgs ConnArguments, command int32, params ...*ibmmq.PCFParameter) ([]pcfResponse, error) {
+	cmdOD := ibmmq.NewMQOD()
+	cmdOD.ObjectType = ibmmq.MQOT_Q
+	cmdOD.ObjectName = commandQueue
+	cmdObj, err := qMgr.Open(cmdOD, ibmmq.MQOO_OUTPUT|ibmmq.MQOO_FAIL_IF_QUIESCING)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open command queue: %w", err)
+	}
+	defer cmdObj.Close(0)
+
+	replyOD := ibmmq.NewMQOD()
+	replyOD.ObjectType = ibmmq.MQOT_Q
+	replyOD.ObjectName = defaultReplyModelQueue
+	if connArgs.ModelQueue != "" {
+		replyOD.ObjectName = connArgs.ModelQueue
+	}
+	replyOD.DynamicQName = defaultReplyDynamicQueue
+	if connArgs.DynamicQueue != "" {
+		replyOD.DynamicQName = connArgs.DynamicQueue
+	}
+	replyObj, err := qMgr.Open(replyOD, ibmmq.MQOO_INPUT_EXCLUSIVE|ibmmq.MQOO_FAIL_IF_QUIESCING)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open reply queue: %w", err)
+	}
+	defer replyObj.Close(ibmmq.MQCO_DELETE_PURGE)
+
+	cfh := ibmmq.NewMQCFH()
+	cfh.Command = command
+	cfh.ParameterCount = int32(len(params))
+	buf := cfh.Bytes()
+	for _, p := range params {
+		buf = append(buf, p.Bytes()...)
+	}
+
+	md := ibmmq.NewMQMD()
+	md.Format = ibmmq.MQFMT_ADMIN
+	md.MsgType = ibmmq.MQMT_REQUEST
+	md.ReplyToQ = strings.TrimSpace(replyObj.Name)
+	md.Expiry = pcfTimeout / 100 // tenths of a second: drop the command if nobody answers in time
+	md.Report = ibmmq.MQRO_PASS_DISCARD_AND_EXPIRY | ibmmq.MQRO_DISCARD_MSG
+	pmo := ibmmq.NewMQPMO()
+	pmo.Options = ibmmq.MQPMO_NO_SYNCPOINT | ibmmq.MQPMO_NEW_MSG_ID | ibmmq.MQPMO_FAIL_IF_QUIESCING
+
+	log.Verbose("sending %s to %s...", ibmmq.MQItoString("CMD", int(command)), commandQueue)
+	if err := cmdObj.Put(md, pmo, buf); err != nil {
+		return nil, fmt.Errorf("failed to send command: %w", err)
+	}
+
+	gmo := ibmmq.NewMQGMO()
+	gmo.Options = ibmmq.MQGMO_NO_SYNCPOINT | ibmmq.MQGMO_FAIL_IF_QUIESCING | ibmmq.MQGMO_WAIT | ibmmq.MQGMO_CONVERT
+	gmo.WaitInterval = pcfTimeout
+	gmo.MatchOptions = ibmmq.MQMO_MATCH_CORREL_ID
+
+	var responses []pcfResponse
+	var firstErr error
+	buffer := make([]byte, 64*1024)
+	for {
+		replyMD := ibmmq.NewMQMD()
+		copy(replyMD.CorrelId, md.MsgId)
+		datalen, err := replyObj.Get(replyMD, gmo, buffer)
+		if err != nil {
+			mqret, ok := err.(*ibmmq.MQReturn)
+			switch {
+			case ok && mqret.MQRC == ibmmq.MQRC_NO_MSG_AVAILABLE:
+				return nil, fmt.Errorf("no response from the command server (is it running?)")
+			case ok && mqret.MQRC == ibmmq.MQRC_TRUNCATED_MSG_FAILED:
+				buffer = make([]byte, datalen)
+				continue
+			default:
+				return nil, fmt.Errorf("failed to get command response: %w", err)
+			}
+		}
+
+		data := buffer[:datalen]
+		header, offset := ibmmq.ReadPCFHeader(data)
+		if header == nil {
+			return nil, fmt.Errorf("malformed command response")
+		}
+		response := make(pcfResponse, header.ParameterCount)
+		for i := int32(0); i < header.ParameterCount && offset < len(data); i++ {
+			p, n := ibmmq.ReadPCFParameter(data[offset:])
+			offset += n
+			response[p.Parameter] = p
+		}
+		if header.CompCode != ibmmq.MQCC_OK {
+			if firstErr == nil {
+				firstErr = &pcfError{Command: command, Reason: header.Reason}
+			}
+		} else {
+			responses = append(responses, response)
+		}
+		if header.Control == ibmmq.MQCFC_LAST {
+			break
+		}
+	}
+	return responses, firstErr
+}
+
+// withQueueManager runs fn on a fresh connection.
+func withQueueManager[T any](connArgs ConnArguments, fn func(ibmmq.MQQueueManager) (T, error)) (T, error) {
+	qMgr, err := Connect(connArgs)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer qMgr.Disc() //nolint:errcheck
+	return fn(qMgr)
+}
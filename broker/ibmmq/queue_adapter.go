@@ -8,12 +8,14 @@ import (
 
 	"github.com/ibm-messaging/mq-golang/v5/ibmmq"
 	"github.com/makibytes/xmc/broker/backends"
+	"github.com/makibytes/xmc/log"
 )
 
 // QueueAdapter adapts IBM MQ to the QueueBackend interface
 type QueueAdapter struct {
 	connArgs ConnArguments
 	qMgr     ibmmq.MQQueueManager
+	pending  *SendArguments // held-back message of a --group (see sendInGroup)
 }
 
 // NewQueueAdapter creates a new IBM MQ queue adapter
@@ -48,6 +50,17 @@ func (a *QueueAdapter) Send(ctx context.Context, opts backends.SendOptions) erro
 		Persistence:   persistence,
 		TTL:           opts.TTL,
 	}
+	if err := applySendExtra(&args, opts.Extra); err != nil {
+		return err
+	}
+	if p := a.pending; p != nil && (p.Queue != args.Queue || p.GroupID != args.GroupID) {
+		if err := a.flushGroup(); err != nil {
+			return err
+		}
+	}
+	if args.GroupID != "" {
+		return a.sendInGroup(args)
+	}
 
 	return SendMessage(a.qMgr, args)
 }
@@ -61,6 +74,9 @@ func (a *QueueAdapter) Receive(ctx context.Context, opts backends.ReceiveOptions
 		Acknowledge: opts.Acknowledge,
 		Selector:    opts.Selector,
 	}
+	if err := applyReceiveExtra(&args, opts.Extra); err != nil {
+		return nil, err
+	}
 
 	md, data, msgHandle, err := ReceiveMessage(a.qMgr, args)
 	if err != nil {
@@ -81,6 +97,11 @@ func (a *QueueAdapter) Receive(ctx context.Context, opts backends.ReceiveOptions
 
 // Close implements backends.QueueBackend
 func (a *QueueAdapter) Close() error {
+	if err := a.flushGroup(); err != nil {
+		log.Error("%s\n", err)
+		a.qMgr.Disc() //nolint:errcheck
+		return err
+	}
 	return a.qMgr.Disc()
 }
 
@@ -108,6 +129,19 @@ func convertMQMDToBackendMessage(md *ibmmq.MQMD, data []byte, msgHandle ibmmq.MQ
 	result.Priority = int(md.Priority)
 	result.Persistent = md.Persistence == int32(ibmmq.MQPER_PERSISTENT)
 
+	// Delivery details that matter for redelivery, groups and reports.
+	if md.BackoutCount > 0 {
+		result.InternalMetadata["BackoutCount"] = md.BackoutCount
+	}
+	if md.MsgFlags&ibmmq.MQMF_MSG_IN_GROUP != 0 {
+		result.InternalMetadata["GroupId"] = mqIDToString(md.GroupId)
+		result.InternalMetadata["MsgSeqNumber"] = md.MsgSeqNumber
+		result.InternalMetadata["LastInGroup"] = md.MsgFlags&ibmmq.MQMF_LAST_MSG_IN_GROUP != 0
+	}
+	if md.MsgType == ibmmq.MQMT_REPORT {
+		result.InternalMetadata["Feedback"] = mqiName("FB", int64(md.Feedback))
+	}
+
 	// Extract properties from message handle
 	impo := ibmmq.NewMQIMPO()
 	impo.Options = ibmmq.MQIMPO_INQ_FIRST
@@ -39,6 +39,11 @@ func ReceiveMessage(qMgr ibmmq.MQQueueManager, args ReceiveArguments) (*ibmmq.MQ
 		mqod.SelectionString = args.Selector
 	}
 
+	// Without --backout-threshold the threshold is the queue's BOTHRESH.
+	if args.BackoutQueue != "" && args.BackoutThreshold == 0 {
+		openOptions |= ibmmq.MQOO_INQUIRE
+	}
+
 	qObject, err := qMgr.Open(mqod, openOptions)
 	if err != nil {
 		return nil, nil, ibmmq.MQMessageHandle{}, fmt.Errorf("failed to open queue: %w", err)
@@ -59,8 +64,23 @@ func ReceiveMessage(qMgr ibmmq.MQQueueManager, args ReceiveArguments) (*ibmmq.MQ
 		gmo.WaitInterval = int32(args.Timeout * 1000) // seconds → milliseconds
 	}
 
-	md := ibmmq.NewMQMD()
+	log.Verbose("📩 receiving message from queue %s...", args.Queue)
+	switch {
+	case args.CompleteGroup:
+		return getGroup(qMgr, qObject, gmo, args.Acknowledge)
+	case args.BackoutQueue != "" && args.Acknowledge:
+		return getWithBackout(qMgr, qObject, gmo, args)
+	}
+	return getMessage(qMgr, qObject, gmo)
+}
+
+// getMessage gets one message from an open queue with gmo, growing the
+// buffer when the message does not fit. Segmented messages are reassembled.
+// The returned message handle follows the ReceiveMessage contract.
+func getMessage(qMgr ibmmq.MQQueueManager, qObject ibmmq.MQObject, gmo *ibmmq.MQGMO) (*ibmmq.MQMD, []byte, ibmmq.MQMessageHandle, error) {
+	md := newGetMD()
 	buffer := make([]byte, 0)
+	gmo.Options |= ibmmq.MQGMO_COMPLETE_MSG
 
 	// Create message handle to retrieve properties
 	cmho := ibmmq.NewMQCMHO()
@@ -73,7 +93,6 @@ func ReceiveMessage(qMgr ibmmq.MQQueueManager, args ReceiveArguments) (*ibmmq.MQ
 	gmo.MsgHandle = msgHandle
 
 	// Get message
-	log.Verbose("📩 receiving message from queue %s...", args.Queue)
 	datalen, err := qObject.Get(md, gmo, buffer)
 	if err != nil {
 		mqret := err.(*ibmmq.MQReturn)
@@ -87,7 +106,7 @@ func ReceiveMessage(qMgr ibmmq.MQQueueManager, args ReceiveArguments) (*ibmmq.MQ
 			// message in place (and does not advance the browse cursor), so
 			// retry with the same options and a buffer of the reported size.
 			buffer = make([]byte, datalen)
-			md = ibmmq.NewMQMD()
+			md = newGetMD()
 			datalen, err = qObject.Get(md, gmo, buffer)
 			if err != nil {
 				discardHandle()
@@ -101,3 +120,11 @@ func ReceiveMessage(qMgr ibmmq.MQQueueManager, args ReceiveArguments) (*ibmmq.MQ
 
 	return md, buffer[:datalen], msgHandle, nil
 }
+
+// newGetMD returns a message descriptor that receives the group and segment
+// fields (MQMD version 2).
+func newGetMD() *ibmmq.MQMD {
+	md := ibmmq.NewMQMD()
+	md.Version = ibmmq.MQMD_VERSION_2
+	return md
+}
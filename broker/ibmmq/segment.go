@@ -0,0 +1,18 @@
+package ibmmq
+
+// This file carries no ibmmq build tag on purpose (see ids.go): splitting a
+// message into segments has no cgo dependency.
+
+// splitSegments splits data into segments of at most size bytes. A message
+// that fits (or is empty) stays a single segment.
+func splitSegments(data []byte, size int) [][]byte {
+	if size <= 0 || len(data) <= size {
+		return [][]byte{data}
+	}
+	segments := make([][]byte, 0, (len(data)+size-1)/size)
+	for len(data) > size {
+		segments = append(segments, data[:size])
+		data = data[size:]
+	}
+	return append(segments, data)
+}
@@ -0,0 +1,39 @@
+package ibmmq
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitSegments(t *testing.T) {
+	data := []byte("0123456789")
+	for _, tc := range []struct {
+		size int
+		want []string
+	}{
+		{0, []string{"0123456789"}},
+		{10, []string{"0123456789"}},
+		{20, []string{"0123456789"}},
+		{4, []string{"0123", "4567", "89"}},
+		{5, []string{"01234", "56789"}},
+		{1, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}},
+	} {
+		got := splitSegments(data, tc.size)
+		if len(got) != len(tc.want) {
+			t.Errorf("splitSegments(size %d) = %d segments, want %d", tc.size, len(got), len(tc.want))
+			continue
+		}
+		for i := range got {
+			if string(got[i]) != tc.want[i] {
+				t.Errorf("splitSegments(size %d)[%d] = %q, want %q", tc.size, i, got[i], tc.want[i])
+			}
+		}
+		if joined := bytes.Join(got, nil); !bytes.Equal(joined, data) {
+			t.Errorf("splitSegments(size %d) joined = %q", tc.size, joined)
+		}
+	}
+
+	if got := splitSegments(nil, 4); len(got) != 1 || len(got[0]) != 0 {
+		t.Errorf("splitSegments(empty) = %q, want one empty segment", got)
+	}
+}
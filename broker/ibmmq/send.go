@@ -3,6 +3,7 @@
 package ibmmq
 
 import (
+	"crypto/rand"
 	"fmt"
 
 	"github.com/ibm-messaging/mq-golang/v5/ibmmq"
@@ -26,6 +27,11 @@ func SendMessage(qMgr ibmmq.MQQueueManager, args SendArguments) error {
 	}
 	defer qObject.Close(0)
 
+	return putMessage(qMgr, qObject, args)
+}
+
+// putMessage puts a message built from args on an open queue or topic.
+func putMessage(qMgr ibmmq.MQQueueManager, qObject ibmmq.MQObject, args SendArguments) error {
 	// Create message descriptor
 	pmo := ibmmq.NewMQPMO()
 	pmo.Options = ibmmq.MQPMO_NO_SYNCPOINT
@@ -49,6 +55,7 @@ func SendMessage(qMgr ibmmq.MQQueueManager, args SendArguments) error {
 	if args.ReplyTo != "" {
 		md.ReplyToQ = args.ReplyTo
 	}
+	md.Report = args.Report
 
 	// Set priority (0-9, default 4)
 	if args.Priority >= 0 && args.Priority <= 9 {
@@ -111,11 +118,55 @@ func SendMessage(qMgr ibmmq.MQQueueManager, args SendArguments) error {
 		pmo.OriginalMsgHandle = msgHandle
 	}
 
-	// Put message
-	log.Verbose("💌 sending message to queue %s...", args.Queue)
-	err = qObject.Put(md, pmo, buffer)
-	if err != nil {
-		return fmt.Errorf("failed to put message: %w", err)
+	segments := splitSegments(buffer, args.SegmentSize)
+	if args.GroupID == "" && len(segments) == 1 {
+		log.Verbose("💌 sending message to %s...", args.Queue)
+		if err := qObject.Put(md, pmo, buffer); err != nil {
+			return fmt.Errorf("failed to put message: %w", err)
+		}
+		return nil
+	}
+
+	// Groups and segments need the version 2 MQMD fields. They are set
+	// explicitly rather than with MQPMO_LOGICAL_ORDER so that --group keeps
+	// the given ID; segments of an ungrouped message get a random one.
+	md.Version = ibmmq.MQMD_VERSION_2
+	md.MsgSeqNumber = 1
+	if args.GroupID != "" {
+		if len(args.GroupID) > int(ibmmq.MQ_GROUP_ID_LENGTH) {
+			return fmt.Errorf("group ID must be at most %d bytes, got %d", ibmmq.MQ_GROUP_ID_LENGTH, len(args.GroupID))
+		}
+		copy(md.GroupId, args.GroupID)
+		md.MsgFlags |= ibmmq.MQMF_MSG_IN_GROUP
+		if args.LastInGroup {
+			md.MsgFlags |= ibmmq.MQMF_LAST_MSG_IN_GROUP
+		}
+		if args.MsgSeqNumber > 0 {
+			md.MsgSeqNumber = args.MsgSeqNumber
+		}
+	} else if _, err := rand.Read(md.GroupId); err != nil {
+		return fmt.Errorf("failed to generate group ID: %w", err)
+	}
+
+	var offset int
+	for i, segment := range segments {
+		if len(segments) > 1 {
+			md.Offset = int32(offset)
+			md.MsgFlags |= ibmmq.MQMF_SEGMENT
+			if i == len(segments)-1 {
+				md.MsgFlags |= ibmmq.MQMF_LAST_SEGMENT
+			}
+			// Properties travel with the first segment.
+			if i == 1 {
+				pmo = ibmmq.NewMQPMO()
+				pmo.Options = ibmmq.MQPMO_NO_SYNCPOINT
+			}
+		}
+		log.Verbose("💌 sending message %d (offset %d) of group %s to %s...", md.MsgSeqNumber, offset, mqIDToString(md.GroupId), args.Queue)
+		if err := qObject.Put(md, pmo, segment); err != nil {
+			return fmt.Errorf("failed to put message: %w", err)
+		}
+		offset += len(segment)
 	}
 
 	return nil
@@ -0,0 +1,48 @@
+package ibmmq
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// This file carries no ibmmq build tag on purpose (see ids.go): the TLS and
+// CCDT option handling has no cgo dependency.
+
+// keyRepositoryPath returns the MQSCO KeyRepository value for path. A CMS key
+// database is named by its stem (key.kdb → key, next to key.sth); PKCS#12
+// files keep their full name.
+func keyRepositoryPath(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".kdb") {
+		return strings.TrimSuffix(path, filepath.Ext(path))
+	}
+	return path
+}
+
+// channelTable picks the CCDT to connect with: --ccdt when given, otherwise
+// envCCDT ($MQCCDTURL), but only if the connection is not configured
+// explicitly. A server, channel or TLS channel setting names a channel of its
+// own and wins over the environment's table.
+func channelTable(ccdt, envCCDT string, explicitConn bool) string {
+	if ccdt != "" || explicitConn {
+		return ccdt
+	}
+	return envCCDT
+}
+
+// ccdtURL returns the MQCNO CCDTUrl for a --ccdt value: file, http(s) and ftp
+// URLs are used as-is, anything else is a local file path.
+func ccdtURL(ccdt string) (string, error) {
+	if u, err := url.Parse(ccdt); err == nil {
+		switch strings.ToLower(u.Scheme) {
+		case "file", "http", "https", "ftp":
+			return ccdt, nil
+		}
+	}
+	abs, err := filepath.Abs(ccdt)
+	if err != nil {
+		return "", fmt.Errorf("invalid --ccdt %q: %w", ccdt, err)
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(), nil
+}
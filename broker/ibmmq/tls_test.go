@@ -0,0 +1,53 @@
+package ibmmq
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyRepositoryPath(t *testing.T) {
+	for in, want := range map[string]string{
+		"/var/mqm/ssl/key.kdb": "/var/mqm/ssl/key",
+		"/var/mqm/ssl/KEY.KDB": "/var/mqm/ssl/KEY",
+		"/var/mqm/ssl/key":     "/var/mqm/ssl/key",
+		"/var/mqm/ssl/app.p12": "/var/mqm/ssl/app.p12",
+	} {
+		if got := keyRepositoryPath(in); got != want {
+			t.Errorf("keyRepositoryPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestChannelTable(t *testing.T) {
+	for _, tc := range []struct {
+		ccdt, env string
+		explicit  bool
+		want      string
+	}{
+		{"", "", false, ""},
+		{"flag.json", "env.json", false, "flag.json"},
+		{"flag.json", "env.json", true, "flag.json"},
+		{"", "env.json", false, "env.json"},
+		{"", "env.json", true, ""}, // --server, --channel, --cipher-spec or --peer-name win
+	} {
+		if got := channelTable(tc.ccdt, tc.env, tc.explicit); got != tc.want {
+			t.Errorf("channelTable(%q, %q, %v) = %q, want %q", tc.ccdt, tc.env, tc.explicit, got, tc.want)
+		}
+	}
+}
+
+func TestCCDTURL(t *testing.T) {
+	for _, u := range []string{"file:///etc/mq/ccdt.json", "https://mq.example.com/ccdt.json", "ftp://host/AMQCLCHL.TAB"} {
+		if got, err := ccdtURL(u); err != nil || got != u {
+			t.Errorf("ccdtURL(%q) = %q, %v; want it unchanged", u, got, err)
+		}
+	}
+	got, err := ccdtURL("/etc/mq/ccdt.json")
+	if err != nil || got != "file:///etc/mq/ccdt.json" {
+		t.Errorf("ccdtURL(absolute path) = %q, %v", got, err)
+	}
+	got, err = ccdtURL("ccdt.json")
+	if err != nil || !strings.HasPrefix(got, "file:///") || !strings.HasSuffix(got, "/ccdt.json") {
+		t.Errorf("ccdtURL(relative path) = %q, %v", got, err)
+	}
+}
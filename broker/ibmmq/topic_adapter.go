@@ -0,0 +1,194 @@
+//go:build ibmmq
+
+package ibmmq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ibm-messaging/mq-golang/v5/ibmmq"
+	"github.com/makibytes/xmc/broker/backends"
+	"github.com/makibytes/xmc/log"
+)
+
+// TopicAdapter adapts IBM MQ publish/subscribe to the TopicBackend interface.
+// Topics are topic strings (e.g. "prices/fx/EURUSD"), optionally below an
+// administrative topic object (--topic-object) whose TOPICSTR prefixes them.
+type TopicAdapter struct {
+	connArgs ConnArguments
+	qMgr     ibmmq.MQQueueManager
+	sub      *subscription
+}
+
+// subscription is the open MQSUB of the current subscribe command: one or
+// more subscriptions delivering to a single managed queue.
+type subscription struct {
+	key   string
+	queue ibmmq.MQObject
+	subs  []ibmmq.MQObject
+}
+
+// NewTopicAdapter creates a new IBM MQ topic adapter
+func NewTopicAdapter(connArgs ConnArguments) (*TopicAdapter, error) {
+	qMgr, err := Connect(connArgs)
+	if err != nil {
+		return nil, err
+	}
+	return &TopicAdapter{connArgs: connArgs, qMgr: qMgr}, nil
+}
+
+// Publish implements backends.TopicBackend
+func (a *TopicAdapter) Publish(ctx context.Context, opts backends.PublishOptions) error {
+	if err := checkPublishExtra(opts.Extra); err != nil {
+		return err
+	}
+
+	mqod := ibmmq.NewMQOD()
+	mqod.ObjectType = ibmmq.MQOT_TOPIC
+	mqod.ObjectName = opts.Extra["topic-object"]
+	mqod.ObjectString = opts.Topic
+
+	log.Verbose("📤 opening topic %s for publishing...", opts.Topic)
+	topic, err := a.qMgr.Open(mqod, ibmmq.MQOO_OUTPUT|ibmmq.MQOO_FAIL_IF_QUIESCING)
+	if err != nil {
+		return fmt.Errorf("failed to open topic: %w", err)
+	}
+	defer topic.Close(0)
+
+	var persistence int
+	if opts.Persistent {
+		persistence = 1
+	}
+	return putMessage(a.qMgr, topic, SendArguments{
+		Queue:         opts.Topic,
+		Message:       opts.Message,
+		Properties:    backends.StringifyProps(opts.Properties),
+		MessageID:     opts.MessageID,
+		CorrelationID: opts.CorrelationID,
+		ReplyTo:       opts.ReplyTo,
+		ContentType:   opts.ContentType,
+		Priority:      opts.Priority,
+		Persistence:   persistence,
+		TTL:           opts.TTL,
+	})
+}
+
+// Subscribe implements backends.TopicBackend
+func (a *TopicAdapter) Subscribe(ctx context.Context, opts backends.SubscribeOptions) (*backends.Message, error) {
+	return a.receive([]string{opts.Topic}, opts)
+}
+
+// SubscribeTopics implements backends.MultiTopicBackend: every topic gets its
+// own MQSUB, all delivering to the same managed queue. Topic strings may use
+// MQ's topic wildcards (+ for one level, # for any number of levels).
+func (a *TopicAdapter) SubscribeTopics(ctx context.Context, topics []string, opts backends.SubscribeOptions) (*backends.Message, error) {
+	return a.receive(topics, opts)
+}
+
+func (a *TopicAdapter) receive(topics []string, opts backends.SubscribeOptions) (*backends.Message, error) {
+	if err := a.subscribe(topics, opts); err != nil {
+		return nil, err
+	}
+
+	gmo := ibmmq.NewMQGMO()
+	gmo.Options = ibmmq.MQGMO_NO_SYNCPOINT | ibmmq.MQGMO_FAIL_IF_QUIESCING | ibmmq.MQGMO_WAIT
+	if opts.Wait {
+		gmo.WaitInterval = ibmmq.MQWI_UNLIMITED
+	} else {
+		gmo.WaitInterval = int32(opts.Timeout * 1000) // seconds → milliseconds
+	}
+
+	md, data, msgHandle, err := getMessage(a.qMgr, a.sub.queue, gmo)
+	if err != nil {
+		if strings.Contains(err.Error(), "timeout") {
+			return nil, backends.ErrNoMessageAvailable
+		}
+		return nil, err
+	}
+	defer msgHandle.DltMH(ibmmq.NewMQDMHO()) //nolint:errcheck
+
+	msg := convertMQMDToBackendMessage(md, data, msgHandle, opts.Verbosity >= backends.VerbosityVerbose)
+	// The queue manager labels every publication with its topic string.
+	impo := ibmmq.NewMQIMPO()
+	if _, topic, err := msgHandle.InqMP(impo, ibmmq.NewMQPD(), "MQTopicString"); err == nil {
+		if s, ok := topic.(string); ok {
+			msg.Topic = s
+		}
+	}
+	return msg, nil
+}
+
+// subscribe opens the subscriptions for topics unless they are already open
+// with the same settings. A durable subscription is named --subscription-name,
+// or <group>:<topic> so the same group on different topics doesn't share one,
+// or xmc-durable-<topic> without a group; it is resumed when it exists and
+// kept on close.
+func (a *TopicAdapter) subscribe(topics []string, opts backends.SubscribeOptions) error {
+	topicObject := opts.Extra["topic-object"]
+	name := opts.Extra["subscription-name"]
+	if name != "" && len(topics) > 1 {
+		return fmt.Errorf("--subscription-name names a single subscription; subscribe to one topic")
+	}
+	key := strings.Join([]string{strings.Join(topics, "\n"), topicObject, name, opts.GroupID, opts.Selector, fmt.Sprint(opts.Durable)}, "\x00")
+	if a.sub != nil && a.sub.key == key {
+		return nil
+	}
+	a.closeSubscription()
+
+	sub := &subscription{key: key}
+	for i, topic := range topics {
+		mqsd := ibmmq.NewMQSD()
+		mqsd.Options = ibmmq.MQSO_CREATE | ibmmq.MQSO_FAIL_IF_QUIESCING | ibmmq.MQSO_WILDCARD_TOPIC
+		if i == 0 {
+			mqsd.Options |= ibmmq.MQSO_MANAGED
+		}
+		mqsd.ObjectName = topicObject
+		mqsd.ObjectString = topic
+		mqsd.SelectionString = opts.Selector
+		mqsd.SubName = name
+		if opts.Durable {
+			mqsd.Options |= ibmmq.MQSO_DURABLE | ibmmq.MQSO_RESUME
+			if mqsd.SubName == "" {
+				scoped := opts
+				scoped.Topic = topic
+				mqsd.SubName, _ = backends.ScopedSubscriptionName(scoped, ":")
+			}
+		} else {
+			mqsd.Options |= ibmmq.MQSO_NON_DURABLE
+		}
+
+		log.Verbose("📥 subscribing to topic %s...", topic)
+		// The first subscription creates the managed queue; the others
+		// deliver to it as well.
+		subObj, err := a.qMgr.Sub(mqsd, &sub.queue)
+		if err != nil {
+			a.sub = sub
+			a.closeSubscription()
+			return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+		sub.subs = append(sub.subs, subObj)
+	}
+	a.sub = sub
+	return nil
+}
+
+// closeSubscription closes the open subscriptions. Non-durable ones (and
+// their managed queue) are removed by the queue manager; durable ones keep
+// collecting publications until the next subscribe.
+func (a *TopicAdapter) closeSubscription() {
+	if a.sub == nil {
+		return
+	}
+	for _, s := range a.sub.subs {
+		s.Close(0) //nolint:errcheck
+	}
+	a.sub.queue.Close(0) //nolint:errcheck
+	a.sub = nil
+}
+
+// Close implements backends.TopicBackend
+func (a *TopicAdapter) Close() error {
+	a.closeSubscription()
+	return a.qMgr.Disc()
+}
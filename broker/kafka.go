@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/makibytes/xmc/broker/backends"
@@ -20,6 +21,7 @@ func GetRootCommand() *cobra.Command {
 	var partitions int
 	var replicationFactor int
 	var configEntries []string
+	var compacted bool
 	var updateTopicCmd *cobra.Command
 	var updateConfigEntries []string
 
@@ -38,17 +40,47 @@ func GetRootCommand() *cobra.Command {
 		Long:             "Command-line interface for Apache Kafka messaging",
 		AIContext:        AIDoc("kafka"),
 		UnsupportedFlags: []string{"priority", "persistent", "selector"},
+		ProduceFlags: func(c *cobra.Command) {
+			c.Flags().String("acks", "all", "Required acknowledgements: all, one or none")
+			c.Flags().String("compression", "none", "Compression codec: none, gzip, snappy, lz4 or zstd")
+			c.Flags().Int("batch-size", 0, "Batch up to N messages per request; publishes are queued and reported at the end (default: kafka-go's 100)")
+			c.Flags().Duration("linger", 0, "How long a batch waits to fill before it is sent; publishes are queued and reported at the end (default: kafka-go's 1s)")
+			c.Flags().Int("partition", -1, "Publish to this partition instead of the key/least-bytes balancer")
+			c.Flags().Bool("idempotent", false, "Idempotent producer settings: acks=all, persistent retries, one in-flight batch per partition (no broker-side deduplication)")
+			c.Flags().BoolP("json", "J", false, "Report each message's partition and offset as JSON")
+			c.Flags().Bool("tombstone", false, "Publish a null value for -K instead of a message (deletes the key from a compacted topic)")
+		},
+		ProduceExtra: func(c *cobra.Command) map[string]string {
+			extra := make(map[string]string)
+			for _, name := range []string{"acks", "compression", "batch-size", "linger", "partition"} {
+				if f := c.Flags().Lookup(name); f != nil && f.Changed {
+					extra[name] = f.Value.String()
+				}
+			}
+			if idem, _ := c.Flags().GetBool("idempotent"); idem {
+				extra["idempotent"] = "true"
+			}
+			return extra
+		},
 		ConsumeFlags: func(c *cobra.Command) {
-			c.Flags().Int("partition", -1, "Read from a specific partition (disables consumer group)")
+			c.Flags().IntSlice("partition", nil, "Read from specific partitions (disables consumer group; several only with --from/--until)")
 			c.Flags().String("offset", "", "Start offset: earliest, latest, or a number (requires --partition)")
+			c.Flags().String("from", "", "Read partitions directly from: earliest, latest, <offset> or <timestamp> (no consumer group)")
+			c.Flags().String("until", "", "Stop each partition at: end (log end at start), <offset> or <timestamp> (no consumer group)")
 		},
 		ConsumeExtra: func(c *cobra.Command) map[string]string {
 			extra := make(map[string]string)
-			if p, _ := c.Flags().GetInt("partition"); p >= 0 {
-				extra["partition"] = fmt.Sprintf("%d", p)
+			if ps, _ := c.Flags().GetIntSlice("partition"); len(ps) > 0 {
+				parts := make([]string, len(ps))
+				for i, p := range ps {
+					parts[i] = strconv.Itoa(p)
+				}
+				extra["partition"] = strings.Join(parts, ",")
 			}
-			if o, _ := c.Flags().GetString("offset"); o != "" {
-				extra["offset"] = o
+			for _, name := range []string{"offset", "from", "until"} {
+				if v, _ := c.Flags().GetString(name); v != "" {
+					extra[name] = v
+				}
 			}
 			return extra
 		},
@@ -56,6 +88,15 @@ func GetRootCommand() *cobra.Command {
 			c.PersistentFlags().StringVarP(&connArgs.Server, "server", "s", defaultServer, "Server URL (kafka://broker1:9092 or kafka://broker1:9092,broker2:9092)")
 			c.PersistentFlags().StringVarP(&connArgs.User, "user", "u", os.Getenv("KMC_USER"), "Username for SASL authentication")
 			c.PersistentFlags().StringVarP(&connArgs.Password, "password", "p", os.Getenv("KMC_PASSWORD"), "Password for SASL authentication")
+			saslMechanism := os.Getenv("KMC_SASL_MECHANISM")
+			if saslMechanism == "" {
+				saslMechanism = "plain"
+			}
+			c.PersistentFlags().StringVar(&connArgs.SASLMechanism, "sasl-mechanism", saslMechanism, "SASL mechanism: plain, scram-sha-256, scram-sha-512 or oauthbearer")
+			c.PersistentFlags().StringVar(&connArgs.Token, "token", os.Getenv("KMC_TOKEN"), "OAUTHBEARER access token")
+			c.PersistentFlags().StringVar(&connArgs.TokenFile, "token-file", os.Getenv("KMC_TOKEN_FILE"), "OAUTHBEARER: file holding the access token, re-read on every connection")
+			c.PersistentFlags().StringVar(&connArgs.TokenURL, "token-url", os.Getenv("KMC_TOKEN_URL"), "OAUTHBEARER: OAuth 2 token endpoint for the client-credentials grant (--user/--password are client ID and secret)")
+			c.PersistentFlags().StringSliceVar(&connArgs.TokenScopes, "token-scope", nil, "OAUTHBEARER: scope requested from --token-url (repeatable)")
 			backends.RegisterTLSFlags(c, &connArgs.TLS)
 		},
 		Topic: topicFactory,
@@ -91,9 +132,13 @@ func GetRootCommand() *cobra.Command {
 					c.Flags().IntVar(&partitions, "partitions", 1, "Number of partitions")
 					c.Flags().IntVar(&replicationFactor, "replication-factor", 1, "Replication factor")
 					c.Flags().StringArrayVar(&configEntries, "config", nil, "Topic config entries (key=value, repeatable)")
+					c.Flags().BoolVar(&compacted, "compacted", false, "Create a compacted topic (cleanup.policy=compact)")
 				},
 				Run: func(topic string) error {
 					configs := make(map[string]string)
+					if compacted {
+						configs["cleanup.policy"] = "compact"
+					}
 					for _, entry := range configEntries {
 						k, v, ok := strings.Cut(entry, "=")
 						if !ok {
@@ -140,6 +185,28 @@ func GetRootCommand() *cobra.Command {
 			DeleteConsumerGroup: &cmd.ManageAction{
 				Run: func(group string) error { return kafka.DeleteConsumerGroup(connArgs, group) },
 			},
+			DescribeGroup: func(group string) (*backends.GroupDescription, error) {
+				return kafka.DescribeConsumerGroup(connArgs, group)
+			},
+			ResetOffsets: func(group, topic string, partitions []int, to backends.OffsetTarget, dryRun bool) ([]backends.OffsetChange, error) {
+				return kafka.ResetConsumerGroupOffsets(connArgs, group, topic, partitions, to, dryRun)
+			},
+			ListACLs: func(filter backends.ACL) ([]backends.ACL, error) {
+				return kafka.ListACLs(connArgs, filter)
+			},
+			CreateACL: func(acl backends.ACL) error { return kafka.CreateACL(connArgs, acl) },
+			DeleteACLs: func(filter backends.ACL) ([]backends.ACL, error) {
+				return kafka.DeleteACLs(connArgs, filter)
+			},
+			DescribeCluster: func() (*backends.ClusterDescription, error) {
+				return kafka.DescribeCluster(connArgs)
+			},
+			DescribeConfigs: func(resourceType, name string) ([]backends.ConfigEntry, error) {
+				return kafka.DescribeConfigs(connArgs, resourceType, name)
+			},
+			AlterConfigs: func(resourceType, name string, set map[string]string, remove []string) error {
+				return kafka.AlterConfigs(connArgs, resourceType, name, set, remove)
+			},
 		},
 		Extra: []*cobra.Command{
 			mcp.NewCommand(mcp.Deps{
@@ -9,6 +9,7 @@ const OffsetUnset int64 = -3
 
 type ReceiveArguments struct {
 	Topic     string
+	Topics    []string // several topics read by one consumer group (replaces Topic)
 	Timeout   float32
 	Wait      bool
 	GroupID   string
@@ -0,0 +1,125 @@
+//go:build kafka
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// getSASLMechanism returns the SASL mechanism selected by --sasl-mechanism,
+// or nil when the connection is unauthenticated (PLAIN without credentials).
+func getSASLMechanism(args ConnArguments) (sasl.Mechanism, error) {
+	switch strings.ToLower(args.SASLMechanism) {
+	case "", "plain":
+		if args.User != "" && args.Password != "" {
+			return &plain.Mechanism{Username: args.User, Password: args.Password}, nil
+		}
+		return nil, nil
+	case "scram-sha-256":
+		return scramMechanism(scram.SHA256, args)
+	case "scram-sha-512":
+		return scramMechanism(scram.SHA512, args)
+	case "oauthbearer":
+		source, err := oauthTokenSource(args)
+		if err != nil {
+			return nil, err
+		}
+		return &oauthBearer{source: source}, nil
+	}
+	return nil, fmt.Errorf("unknown --sasl-mechanism %q (use plain, scram-sha-256, scram-sha-512 or oauthbearer)", args.SASLMechanism)
+}
+
+func scramMechanism(algo scram.Algorithm, args ConnArguments) (sasl.Mechanism, error) {
+	if args.User == "" || args.Password == "" {
+		return nil, fmt.Errorf("SASL %s requires --user and --password", strings.ToUpper(args.SASLMechanism))
+	}
+	m, err := scram.Mechanism(algo, args.User, args.Password)
+	if err != nil {
+		return nil, fmt.Errorf("SASL %s: %w", strings.ToUpper(args.SASLMechanism), err)
+	}
+	return m, nil
+}
+
+// oauthTokenSource picks the OAUTHBEARER token source: a fixed --token, a
+// --token-file re-read on every authentication (for tokens rotated by a
+// sidecar), or the OAuth 2 client-credentials grant against --token-url with
+// --user/--password as client ID and secret.
+func oauthTokenSource(args ConnArguments) (oauth2.TokenSource, error) {
+	set := 0
+	for _, v := range []string{args.Token, args.TokenFile, args.TokenURL} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("SASL OAUTHBEARER requires exactly one of --token, --token-file or --token-url")
+	}
+
+	switch {
+	case args.Token != "":
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: args.Token}), nil
+	case args.TokenFile != "":
+		return tokenFile(args.TokenFile), nil
+	}
+	if args.User == "" || args.Password == "" {
+		return nil, fmt.Errorf("--token-url requires --user and --password as OAuth client ID and secret")
+	}
+	cfg := clientcredentials.Config{
+		ClientID:     args.User,
+		ClientSecret: args.Password,
+		TokenURL:     args.TokenURL,
+		Scopes:       args.TokenScopes,
+	}
+	return cfg.TokenSource(context.Background()), nil
+}
+
+// tokenFile is a token source reading the whole file as the access token.
+type tokenFile string
+
+func (f tokenFile) Token() (*oauth2.Token, error) {
+	data, err := os.ReadFile(string(f))
+	if err != nil {
+		return nil, fmt.Errorf("reading token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return nil, fmt.Errorf("token file %s is empty", f)
+	}
+	return &oauth2.Token{AccessToken: token}, nil
+}
+
+// oauthBearer implements SASL/OAUTHBEARER (RFC 7628). Every authentication
+// asks the token source, so reconnects pick up refreshed tokens.
+type oauthBearer struct {
+	source oauth2.TokenSource
+}
+
+func (m *oauthBearer) Name() string { return "OAUTHBEARER" }
+
+func (m *oauthBearer) Start(ctx context.Context) (sasl.StateMachine, []byte, error) {
+	token, err := m.source.Token()
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching OAUTHBEARER token: %w", err)
+	}
+	return oauthBearerSession{}, []byte("n,,\x01auth=Bearer " + token.AccessToken + "\x01\x01"), nil
+}
+
+type oauthBearerSession struct{}
+
+// Next accepts the server's empty success response. Anything else is the
+// RFC 7628 error status (JSON), reported as the failure reason.
+func (oauthBearerSession) Next(ctx context.Context, challenge []byte) (bool, []byte, error) {
+	if len(challenge) == 0 {
+		return true, nil, nil
+	}
+	return false, nil, fmt.Errorf("SASL OAUTHBEARER authentication failed: %s", challenge)
+}
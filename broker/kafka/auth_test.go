@@ -0,0 +1,153 @@
+//go:build kafka
+
+package kafka
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+func saslArgs(mechanism, user, password string) ConnArguments {
+	return ConnArguments{
+		CommonConnArgs: backends.CommonConnArgs{User: user, Password: password},
+		SASLMechanism:  mechanism,
+	}
+}
+
+// initialResponse starts an authentication and returns the client's first message.
+func initialResponse(t *testing.T, args ConnArguments) string {
+	t.Helper()
+	m, err := getSASLMechanism(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name() != "OAUTHBEARER" {
+		t.Fatalf("mechanism = %s, want OAUTHBEARER", m.Name())
+	}
+	_, ir, err := m.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(ir)
+}
+
+func TestGetSASLMechanism(t *testing.T) {
+	t.Run("plain without credentials is unauthenticated", func(t *testing.T) {
+		m, err := getSASLMechanism(saslArgs("", "", ""))
+		if err != nil || m != nil {
+			t.Fatalf("got %v, %v; want nil, nil", m, err)
+		}
+	})
+
+	for mechanism, name := range map[string]string{
+		"plain":         "PLAIN",
+		"scram-sha-256": "SCRAM-SHA-256",
+		"SCRAM-SHA-512": "SCRAM-SHA-512",
+	} {
+		t.Run(mechanism, func(t *testing.T) {
+			m, err := getSASLMechanism(saslArgs(mechanism, "alice", "secret"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Name() != name {
+				t.Errorf("Name() = %s, want %s", m.Name(), name)
+			}
+		})
+	}
+
+	t.Run("scram requires credentials", func(t *testing.T) {
+		_, err := getSASLMechanism(saslArgs("scram-sha-512", "alice", ""))
+		if err == nil || !strings.Contains(err.Error(), "--user and --password") {
+			t.Errorf("err = %v, want missing credentials error", err)
+		}
+	})
+
+	t.Run("unknown mechanism", func(t *testing.T) {
+		_, err := getSASLMechanism(saslArgs("gssapi", "", ""))
+		if err == nil || !strings.Contains(err.Error(), "unknown --sasl-mechanism") {
+			t.Errorf("err = %v, want unknown mechanism error", err)
+		}
+	})
+}
+
+func TestOAuthBearer(t *testing.T) {
+	t.Run("static token", func(t *testing.T) {
+		args := saslArgs("oauthbearer", "", "")
+		args.Token = "tok123"
+		if got, want := initialResponse(t, args), "n,,\x01auth=Bearer tok123\x01\x01"; got != want {
+			t.Errorf("initial response = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("token file is re-read", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "token")
+		if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		args := saslArgs("oauthbearer", "", "")
+		args.TokenFile = path
+		if got := initialResponse(t, args); !strings.Contains(got, "Bearer first\x01") {
+			t.Errorf("initial response = %q, want token from file", got)
+		}
+		if err := os.WriteFile(path, []byte("second"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if got := initialResponse(t, args); !strings.Contains(got, "Bearer second\x01") {
+			t.Errorf("initial response = %q, want rotated token", got)
+		}
+	})
+
+	t.Run("client credentials", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("ParseForm: %v", err)
+			}
+			id, secret, _ := r.BasicAuth()
+			if id == "" {
+				id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+			}
+			if r.PostForm.Get("grant_type") != "client_credentials" || id != "kmc" || secret != "s3cret" {
+				http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+				return
+			}
+			if scope := r.PostForm.Get("scope"); scope != "kafka" {
+				t.Errorf("scope = %q, want kafka", scope)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"issued-token","token_type":"Bearer","expires_in":3600}`)) //nolint:errcheck
+		}))
+		defer srv.Close()
+
+		args := saslArgs("oauthbearer", "kmc", "s3cret")
+		args.TokenURL = srv.URL
+		args.TokenScopes = []string{"kafka"}
+		if got := initialResponse(t, args); !strings.Contains(got, "Bearer issued-token\x01") {
+			t.Errorf("initial response = %q, want token from endpoint", got)
+		}
+	})
+
+	t.Run("exactly one token source", func(t *testing.T) {
+		args := saslArgs("oauthbearer", "", "")
+		if _, err := getSASLMechanism(args); err == nil {
+			t.Error("no token source: expected error")
+		}
+		args.Token, args.TokenFile = "tok", "/tmp/token"
+		if _, err := getSASLMechanism(args); err == nil {
+			t.Error("two token sources: expected error")
+		}
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		done, _, err := oauthBearerSession{}.Next(context.Background(), []byte(`{"status":"invalid_token"}`))
+		if done || err == nil || !strings.Contains(err.Error(), "invalid_token") {
+			t.Errorf("Next = %v, %v; want failure reporting the server status", done, err)
+		}
+	})
+}
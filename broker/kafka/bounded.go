@@ -0,0 +1,380 @@
+//go:build kafka
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+	"github.com/makibytes/xmc/log"
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// readBounds is a bounded read selected by --from and --until.
+type readBounds struct {
+	from  backends.OffsetTarget
+	until *backends.OffsetTarget // nil: no end, keep following the partitions
+}
+
+// parseReadBounds reads --from (earliest, latest, offset, timestamp; default
+// earliest) and --until (end, offset, timestamp) from the subscribe extras.
+// ok is false when neither is set.
+func parseReadBounds(extra map[string]string) (b readBounds, ok bool, err error) {
+	from, hasFrom := extra["from"]
+	until, hasUntil := extra["until"]
+	if !hasFrom && !hasUntil {
+		return b, false, nil
+	}
+	if hasFrom {
+		if b.from, err = backends.ParseOffsetTarget(from, time.Local); err != nil {
+			return b, false, fmt.Errorf("invalid --from: %w", err)
+		}
+	}
+	if hasUntil {
+		var to backends.OffsetTarget
+		switch strings.ToLower(until) {
+		case "end", "latest":
+			to = backends.OffsetTarget{Kind: backends.OffsetLatest}
+		case "earliest":
+			return b, false, fmt.Errorf("invalid --until %q: use end, an offset or a timestamp", until)
+		default:
+			if to, err = backends.ParseOffsetTarget(until, time.Local); err != nil {
+				return b, false, fmt.Errorf("invalid --until: %w", err)
+			}
+		}
+		b.until = &to
+	}
+	return b, true, nil
+}
+
+// partitionSpan is the part of one partition a bounded read covers: from
+// start up to, not including, stop. A --until timestamp without a message
+// that recent yet ends the partition at the first message at or after
+// stopTime instead.
+type partitionSpan struct {
+	partition int
+	start     int64
+	stop      int64 // math.MaxInt64: no offset bound
+	stopTime  time.Time
+}
+
+// bounded reports whether the span has an offset bound.
+func (s partitionSpan) bounded() bool { return s.stop != math.MaxInt64 }
+
+// spanOf computes a partition's span. b is its retained range when the read
+// starts, atFrom and atUntil the offsets the --from/--until timestamp lookups
+// returned (-1: no message that recent).
+func spanOf(partition int, bounds readBounds, b offsetBounds, atFrom, atUntil int64) partitionSpan {
+	s := partitionSpan{
+		partition: partition,
+		start:     targetOffset(bounds.from, -1, b, atFrom),
+		stop:      math.MaxInt64,
+	}
+	if bounds.until == nil {
+		return s
+	}
+	switch bounds.until.Kind {
+	case backends.OffsetLatest:
+		s.stop = b.last
+	case backends.OffsetAbsolute:
+		s.stop = bounds.until.Offset
+	case backends.OffsetTimestamp:
+		if atUntil >= 0 {
+			s.stop = atUntil
+		} else {
+			s.stopTime = bounds.until.Time
+		}
+	}
+	return s
+}
+
+// boundedReader reads a fixed set of partitions directly (no consumer group,
+// nothing committed), one goroutine each, until every partition reached its
+// stop offset. msgs is closed once all partitions are done.
+type boundedReader struct {
+	key       string
+	msgs      chan kafka.Message
+	errs      chan error
+	cancel    context.CancelFunc
+	exhausted bool // the end was reported once already
+}
+
+// boundedKey identifies the bounded read a boundedReader was built for.
+func boundedKey(topic, partitions string, extra map[string]string) string {
+	return fmt.Sprintf("topic=%s|partitions=%s|from=%s|until=%s", topic, partitions, extra["from"], extra["until"])
+}
+
+// subscribeBounded implements Subscribe for --from/--until. The partitions'
+// offsets are looked up when the read starts; --until end therefore stops at
+// the log end of that moment and makes subscribe -n 0 a complete, finite export.
+func (a *TopicAdapter) subscribeBounded(ctx context.Context, opts backends.SubscribeOptions, bounds readBounds) (*backends.Message, error) {
+	key := boundedKey(opts.Topic, opts.Extra["partition"], opts.Extra)
+
+	a.readerMu.Lock()
+	r := a.bounded
+	if r == nil || r.key != key {
+		if r != nil {
+			r.cancel()
+		}
+		var err error
+		if r, err = a.startBounded(opts, bounds, key); err != nil {
+			a.readerMu.Unlock()
+			return nil, err
+		}
+		a.bounded = r
+	}
+	a.readerMu.Unlock()
+
+	timeout := backends.TimeoutDuration(opts.Timeout, opts.Wait)
+	if r.exhausted {
+		// Streaming loops (--for, --stats) keep polling after the end;
+		// don't let them spin.
+		timeout = min(timeout, time.Second)
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case msg, ok := <-r.msgs:
+		if !ok {
+			select {
+			case err := <-r.errs:
+				return nil, err
+			default:
+			}
+			if r.exhausted {
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}
+			r.exhausted = true
+			return nil, backends.ErrNoMessageAvailable
+		}
+		return a.receivedMessage(ctx, &msg, opts.Verbosity >= backends.VerbosityVerbose), nil
+	case err := <-r.errs:
+		return nil, err
+	case <-timer.C:
+		return nil, backends.ErrNoMessageAvailable
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// startBounded looks up the spans of the selected partitions (all when
+// --partition is not given) and starts reading them.
+func (a *TopicAdapter) startBounded(opts backends.SubscribeOptions, bounds readBounds, key string) (*boundedReader, error) {
+	var only []int
+	if v := opts.Extra["partition"]; v != "" {
+		var err error
+		if only, err = parsePartitions(v); err != nil {
+			return nil, err
+		}
+	}
+
+	client, brokers, err := newAdminClient(a.connArgs)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	tps, err := topicPartitions(ctx, client, brokers, opts.Topic, only)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := partitionBounds(ctx, client, brokers, tps)
+	if err != nil {
+		return nil, err
+	}
+	atFrom, atUntil := map[topicPartition]int64{}, map[topicPartition]int64{}
+	if bounds.from.Kind == backends.OffsetTimestamp {
+		if atFrom, err = timeOffsets(ctx, client, brokers, tps, bounds.from); err != nil {
+			return nil, err
+		}
+	}
+	if bounds.until != nil && bounds.until.Kind == backends.OffsetTimestamp {
+		if atUntil, err = timeOffsets(ctx, client, brokers, tps, *bounds.until); err != nil {
+			return nil, err
+		}
+	}
+
+	_, tlsConfig, err := parseKafkaURL(a.connArgs.Server, a.connArgs.TLS)
+	if err != nil {
+		return nil, err
+	}
+	dialer, err := buildDialer(a.connArgs, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	readCtx, cancel := context.WithCancel(context.Background())
+	r := &boundedReader{
+		key:    key,
+		msgs:   make(chan kafka.Message),
+		errs:   make(chan error, len(tps)),
+		cancel: cancel,
+	}
+	var wg sync.WaitGroup
+	for _, tp := range tps {
+		at := func(m map[topicPartition]int64) int64 {
+			if v, ok := m[tp]; ok {
+				return v
+			}
+			return -1
+		}
+		span := spanOf(tp.partition, bounds, offsets[tp], at(atFrom), at(atUntil))
+		if span.start >= span.stop {
+			log.Verbose("📖 %s partition %d: nothing between offset %d and %d", opts.Topic, span.partition, span.start, span.stop)
+			continue
+		}
+		log.Verbose("📖 reading %s partition %d from offset %d%s...", opts.Topic, span.partition, span.start, describeStop(span))
+		reader := kafka.NewReader(kafka.ReaderConfig{
+			Brokers:   a.brokers,
+			Topic:     opts.Topic,
+			Partition: span.partition,
+			MinBytes:  1,
+			MaxBytes:  10e6,
+			MaxWait:   time.Second,
+			Dialer:    dialer,
+		})
+		if err := reader.SetOffset(span.start); err != nil {
+			reader.Close()
+			cancel()
+			return nil, fmt.Errorf("setting offset %d on partition %d: %w", span.start, span.partition, err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer reader.Close()
+			r.readPartition(readCtx, reader, client, opts.Topic, span, a.brokers)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(r.msgs)
+	}()
+	return r, nil
+}
+
+// describeStop renders a span's end for verbose output.
+func describeStop(s partitionSpan) string {
+	switch {
+	case s.bounded():
+		return fmt.Sprintf(" up to offset %d", s.stop-1)
+	case !s.stopTime.IsZero():
+		return " up to " + s.stopTime.Format(time.RFC3339)
+	}
+	return ""
+}
+
+// readPartition delivers the span's messages in order. It ends the partition
+// only once its position reached the stop offset: a fetch that gets nothing
+// for browseFetchTimeout is no proof that nothing is left (the broker may be
+// slow or the reader reconnecting), so it then asks the broker directly which
+// offset comes next. Only when that is past the stop offset, because the
+// records left below it were compacted away, is the partition done.
+func (r *boundedReader) readPartition(ctx context.Context, reader *kafka.Reader, client *kafka.Client, topic string, s partitionSpan, brokers []string) {
+	pos := s.start
+	for {
+		fetchCtx, cancel := context.WithTimeout(ctx, browseFetchTimeout)
+		msg, err := reader.FetchMessage(fetchCtx)
+		cancel()
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err == nil:
+			if msg.Offset >= s.stop || (!s.stopTime.IsZero() && !msg.Time.Before(s.stopTime)) {
+				return
+			}
+			select {
+			case r.msgs <- msg:
+			case <-ctx.Done():
+				return
+			}
+			pos = msg.Offset + 1
+			if pos >= s.stop {
+				return
+			}
+		case errors.Is(err, context.DeadlineExceeded):
+			if !s.bounded() {
+				continue
+			}
+			next, err := nextOffset(ctx, client, topic, s.partition, pos)
+			switch {
+			case err != nil:
+				log.Verbose("📖 partition %d: still waiting for offset %d (%s)", s.partition, pos, err)
+			case next >= s.stop:
+				log.Verbose("📖 partition %d: no records left between offset %d and %d", s.partition, pos, s.stop)
+				return
+			}
+		default:
+			r.errs <- hintAdvertisedListeners(fmt.Errorf("failed to fetch message from partition %d: %w", s.partition, err), brokers)
+			return
+		}
+	}
+}
+
+// nextOffset fetches a partition directly from pos and returns the offset of
+// the first record at or after it, i.e. the one the reader delivers next. A
+// fetch that returns no record reports the high watermark instead: nothing
+// is stored between pos and it.
+func nextOffset(ctx context.Context, client *kafka.Client, topic string, partition int, pos int64) (int64, error) {
+	fetchCtx, cancel := context.WithTimeout(ctx, browseFetchTimeout)
+	defer cancel()
+	resp, err := client.Fetch(fetchCtx, &kafka.FetchRequest{
+		Topic:     topic,
+		Partition: partition,
+		Offset:    pos,
+		MinBytes:  1,
+		MaxBytes:  1e6,
+		MaxWait:   500 * time.Millisecond,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if resp.Error != nil {
+		return 0, resp.Error
+	}
+	for {
+		rec, err := resp.Records.ReadRecord()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return resp.HighWatermark, nil
+			}
+			return 0, err
+		}
+		if rec.Key != nil {
+			rec.Key.Close()
+		}
+		if rec.Value != nil {
+			rec.Value.Close()
+		}
+		// A batch can start before the requested offset.
+		if rec.Offset >= pos {
+			return rec.Offset, nil
+		}
+	}
+}
+
+// parsePartitions parses a --partition list ("0,2,5").
+func parsePartitions(v string) ([]int, error) {
+	var out []int
+	for _, p := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid --partition value %q", p)
+		}
+		if !slices.Contains(out, n) {
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,87 @@
+//go:build kafka
+
+package kafka
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+func TestParseReadBounds(t *testing.T) {
+	if _, ok, err := parseReadBounds(map[string]string{"partition": "1"}); ok || err != nil {
+		t.Errorf("no --from/--until: ok = %v, err = %v", ok, err)
+	}
+
+	b, ok, err := parseReadBounds(map[string]string{"until": "end"})
+	if err != nil || !ok {
+		t.Fatalf("--until end: ok = %v, err = %v", ok, err)
+	}
+	if b.from.Kind != backends.OffsetEarliest || b.until == nil || b.until.Kind != backends.OffsetLatest {
+		t.Errorf("--until end = %+v, want earliest to latest", b)
+	}
+
+	b, _, err = parseReadBounds(map[string]string{"from": "100", "until": "2026-03-01T10:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.from.Kind != backends.OffsetAbsolute || b.from.Offset != 100 {
+		t.Errorf("--from 100 = %+v", b.from)
+	}
+	if b.until.Kind != backends.OffsetTimestamp || !b.until.Time.Equal(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("--until timestamp = %+v", b.until)
+	}
+
+	b, _, err = parseReadBounds(map[string]string{"from": "latest"})
+	if err != nil || b.until != nil || b.from.Kind != backends.OffsetLatest {
+		t.Errorf("--from latest = %+v, %v; want no end", b, err)
+	}
+
+	for _, bad := range []map[string]string{{"until": "earliest"}, {"until": "soon"}, {"from": "-1"}} {
+		if _, _, err := parseReadBounds(bad); err == nil {
+			t.Errorf("parseReadBounds(%v): expected an error", bad)
+		}
+	}
+}
+
+func TestSpanOf(t *testing.T) {
+	b := offsetBounds{first: 10, last: 50}
+	end := backends.OffsetTarget{Kind: backends.OffsetLatest}
+	at := func(n int64) *backends.OffsetTarget {
+		return &backends.OffsetTarget{Kind: backends.OffsetAbsolute, Offset: n}
+	}
+	cutoff := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		bounds          readBounds
+		atFrom, atUntil int64
+		want            partitionSpan
+	}{
+		{"earliest to end", readBounds{until: &end}, -1, -1, partitionSpan{start: 10, stop: 50}},
+		{"offset clamped to retained range", readBounds{from: backends.OffsetTarget{Kind: backends.OffsetAbsolute, Offset: 3}, until: at(20)}, -1, -1, partitionSpan{start: 10, stop: 20}},
+		{"until past the end waits", readBounds{until: at(80)}, -1, -1, partitionSpan{start: 10, stop: 80}},
+		{"from timestamp, no end", readBounds{from: backends.OffsetTarget{Kind: backends.OffsetTimestamp}}, 30, -1, partitionSpan{start: 30, stop: math.MaxInt64}},
+		{"until timestamp found", readBounds{until: &backends.OffsetTarget{Kind: backends.OffsetTimestamp, Time: cutoff}}, -1, 40, partitionSpan{start: 10, stop: 40}},
+		{"until timestamp in the future", readBounds{until: &backends.OffsetTarget{Kind: backends.OffsetTimestamp, Time: cutoff}}, -1, -1, partitionSpan{start: 10, stop: math.MaxInt64, stopTime: cutoff}},
+	}
+	for _, tt := range tests {
+		got := spanOf(0, tt.bounds, b, tt.atFrom, tt.atUntil)
+		if got != tt.want {
+			t.Errorf("%s: spanOf = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParsePartitions(t *testing.T) {
+	got, err := parsePartitions("2, 0,2")
+	if err != nil || !slices.Equal(got, []int{2, 0}) {
+		t.Errorf("parsePartitions = %v, %v; want [2 0]", got, err)
+	}
+	if _, err := parsePartitions("1,x"); err == nil {
+		t.Error("parsePartitions(\"1,x\"): expected an error")
+	}
+}
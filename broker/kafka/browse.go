@@ -0,0 +1,151 @@
+//go:build kafka
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+	"github.com/makibytes/xmc/log"
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// browseFetchTimeout bounds a single fetch while browsing. A partition whose
+// high-water mark is never reached by a readable record (compacted tail,
+// transaction markers) is abandoned after this long instead of blocking.
+const browseFetchTimeout = 3 * time.Second
+
+// BrowseTopic implements backends.TopicBrowseBackend. Each partition is read
+// through a partition reader (no consumer group, nothing committed) from its
+// first retained offset up to the high-water mark seen when BrowseTopic was
+// called; records produced afterwards are not picked up.
+func (a *TopicAdapter) BrowseTopic(ctx context.Context, opts backends.SubscribeOptions) (backends.Browser, error) {
+	brokers, tlsConfig, err := parseKafkaURL(a.connArgs.Server, a.connArgs.TLS)
+	if err != nil {
+		return nil, err
+	}
+	dialer, err := buildDialer(a.connArgs, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	if dialer == nil {
+		dialer = &kafka.Dialer{}
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", brokers[0])
+	if err != nil {
+		return nil, hintAdvertisedListeners(fmt.Errorf("failed to connect to Kafka: %w", err), brokers)
+	}
+	defer conn.Close()
+
+	parts, err := conn.ReadPartitions(opts.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read partitions for %s: %w", opts.Topic, err)
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("topic %s not found", opts.Topic)
+	}
+	slices.SortFunc(parts, func(x, y kafka.Partition) int { return x.ID - y.ID })
+
+	var ranges []partitionRange
+	for _, p := range parts {
+		pconn, err := dialer.DialLeader(ctx, "tcp", brokers[0], opts.Topic, p.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial leader for %s partition %d: %w", opts.Topic, p.ID, err)
+		}
+		first, last, err := pconn.ReadOffsets()
+		pconn.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read offsets for %s partition %d: %w", opts.Topic, p.ID, err)
+		}
+		if last > first {
+			ranges = append(ranges, partitionRange{partition: p.ID, first: first, last: last})
+		}
+	}
+
+	return &topicBrowser{
+		adapter:      a,
+		dialer:       dialer,
+		brokers:      brokers,
+		topic:        opts.Topic,
+		ranges:       ranges,
+		withMetadata: opts.Verbosity >= backends.VerbosityVerbose,
+	}, nil
+}
+
+// partitionRange is the [first, last) offset window of one partition.
+type partitionRange struct {
+	partition   int
+	first, last int64
+}
+
+// topicBrowser walks the partitions of a topic one after another.
+type topicBrowser struct {
+	adapter      *TopicAdapter
+	dialer       *kafka.Dialer
+	brokers      []string
+	topic        string
+	ranges       []partitionRange // partitions still to read; ranges[0] is current
+	reader       *kafka.Reader
+	withMetadata bool
+}
+
+func (b *topicBrowser) Next(ctx context.Context) (*backends.Message, error) {
+	for len(b.ranges) > 0 {
+		r := b.ranges[0]
+		if b.reader == nil {
+			log.Verbose("📖 browsing %s partition %d (offsets %d-%d)...", b.topic, r.partition, r.first, r.last-1)
+			b.reader = kafka.NewReader(kafka.ReaderConfig{
+				Brokers:   b.brokers,
+				Topic:     b.topic,
+				Partition: r.partition,
+				MinBytes:  1,
+				MaxBytes:  10e6,
+				MaxWait:   time.Second,
+				Dialer:    b.dialer,
+			})
+			if err := b.reader.SetOffset(r.first); err != nil {
+				return nil, fmt.Errorf("setting offset %d on partition %d: %w", r.first, r.partition, err)
+			}
+		}
+
+		fetchCtx, cancel := context.WithTimeout(ctx, browseFetchTimeout)
+		msg, err := b.reader.FetchMessage(fetchCtx)
+		cancel()
+		switch {
+		case err == nil && msg.Offset < r.last:
+			if msg.Offset == r.last-1 {
+				b.nextPartition()
+			}
+			return b.adapter.receivedMessage(ctx, &msg, b.withMetadata), nil
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case err != nil && !errors.Is(err, context.DeadlineExceeded):
+			return nil, hintAdvertisedListeners(fmt.Errorf("failed to fetch message: %w", err), b.brokers)
+		}
+		// Past the snapshot, or nothing readable left below it.
+		b.nextPartition()
+	}
+	return nil, backends.ErrNoMessageAvailable
+}
+
+func (b *topicBrowser) nextPartition() {
+	if b.reader != nil {
+		b.reader.Close()
+		b.reader = nil
+	}
+	b.ranges = b.ranges[1:]
+}
+
+func (b *topicBrowser) Close() error {
+	if b.reader != nil {
+		err := b.reader.Close()
+		b.reader = nil
+		return err
+	}
+	return nil
+}
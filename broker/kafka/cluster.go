@@ -0,0 +1,443 @@
+//go:build kafka
+
+package kafka
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/makibytes/xmc/broker/backends"
+	"github.com/makibytes/xmc/log"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// clusterResourceName is the only resource name Kafka accepts for cluster ACLs.
+const clusterResourceName = "kafka-cluster"
+
+// aclEntry is a backends.ACL with its fields parsed into kafka-go's types.
+type aclEntry struct {
+	resourceType kafkago.ResourceType
+	resourceName string
+	patternType  kafkago.PatternType
+	principal    string
+	host         string
+	operation    kafkago.ACLOperationType
+	permission   kafkago.ACLPermissionType
+}
+
+// parseACLFilter parses an ACL filter: empty fields match anything.
+func parseACLFilter(acl backends.ACL) (aclEntry, error) {
+	e := aclEntry{
+		resourceType: kafkago.ResourceTypeAny,
+		resourceName: acl.ResourceName,
+		patternType:  kafkago.PatternTypeAny,
+		principal:    acl.Principal,
+		host:         acl.Host,
+		operation:    kafkago.ACLOperationTypeAny,
+		permission:   kafkago.ACLPermissionTypeAny,
+	}
+	if err := parseACLFields(acl, &e); err != nil {
+		return e, err
+	}
+	if e.resourceType == kafkago.ResourceTypeCluster && e.resourceName == "" {
+		e.resourceName = clusterResourceName
+	}
+	return e, nil
+}
+
+// parseACL parses an ACL to create. Pattern type, host and permission default
+// to literal, "*" and allow; "any" and "match" are only valid in filters.
+func parseACL(acl backends.ACL) (aclEntry, error) {
+	e := aclEntry{
+		resourceName: acl.ResourceName,
+		patternType:  kafkago.PatternTypeLiteral,
+		principal:    acl.Principal,
+		host:         acl.Host,
+		permission:   kafkago.ACLPermissionTypeAllow,
+	}
+	if e.host == "" {
+		e.host = "*"
+	}
+	switch {
+	case acl.ResourceType == "":
+		return e, fmt.Errorf("--resource-type is required")
+	case acl.Operation == "":
+		return e, fmt.Errorf("--operation is required")
+	case acl.Principal == "":
+		return e, fmt.Errorf("--principal is required (e.g. User:alice)")
+	}
+	if err := parseACLFields(acl, &e); err != nil {
+		return e, err
+	}
+	if e.resourceType == kafkago.ResourceTypeCluster && e.resourceName == "" {
+		e.resourceName = clusterResourceName
+	}
+	switch {
+	case e.resourceName == "":
+		return e, fmt.Errorf("--resource-name is required for %s ACLs", resourceTypeName(e.resourceType))
+	case e.resourceType == kafkago.ResourceTypeAny, e.patternType == kafkago.PatternTypeAny,
+		e.patternType == kafkago.PatternTypeMatch, e.operation == kafkago.ACLOperationTypeAny,
+		e.permission == kafkago.ACLPermissionTypeAny:
+		return e, fmt.Errorf("\"any\" and \"match\" only select ACLs in list-acls and delete-acl")
+	}
+	return e, nil
+}
+
+// parseACLFields parses the set enum fields of acl into e.
+func parseACLFields(acl backends.ACL, e *aclEntry) error {
+	for _, f := range []struct {
+		flag, value string
+		into        encoding.TextUnmarshaler
+	}{
+		{"resource-type", acl.ResourceType, &e.resourceType},
+		{"pattern-type", acl.PatternType, &e.patternType},
+		{"operation", acl.Operation, &e.operation},
+		{"permission", acl.Permission, &e.permission},
+	} {
+		if f.value == "" {
+			continue
+		}
+		// kafka-go spells the values without separators: transactionalid, describeconfigs.
+		v := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(f.value))
+		if v == "unknown" || f.into.UnmarshalText([]byte(v)) != nil {
+			return fmt.Errorf("invalid --%s %q", f.flag, f.value)
+		}
+	}
+	return nil
+}
+
+// resourceTypeName renders a resource type the way --resource-type takes it.
+func resourceTypeName(t kafkago.ResourceType) string {
+	switch t {
+	case kafkago.ResourceTypeTransactionalID:
+		return "transactional-id"
+	case kafkago.ResourceTypeDelegationToken:
+		return "delegation-token"
+	}
+	return strings.ToLower(t.String())
+}
+
+// kebab turns kafka-go's CamelCase enum names into flag spelling:
+// DescribeConfigs → describe-configs.
+func kebab(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+func toBackendACL(resourceType kafkago.ResourceType, name string, pattern kafkago.PatternType, principal, host string, op kafkago.ACLOperationType, perm kafkago.ACLPermissionType) backends.ACL {
+	return backends.ACL{
+		ResourceType: resourceTypeName(resourceType),
+		ResourceName: name,
+		PatternType:  kebab(pattern.String()),
+		Principal:    principal,
+		Host:         host,
+		Operation:    kebab(op.String()),
+		Permission:   kebab(perm.String()),
+	}
+}
+
+// ListACLs returns the ACLs matching filter, sorted by resource then principal.
+func ListACLs(connArgs ConnArguments, filter backends.ACL) ([]backends.ACL, error) {
+	f, err := parseACLFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	client, brokers, err := newAdminClient(connArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Verbose("listing ACLs on %s...", brokers[0])
+	resp, err := client.DescribeACLs(context.Background(), &kafkago.DescribeACLsRequest{
+		Addr: kafkago.TCP(brokers[0]),
+		Filter: kafkago.ACLFilter{
+			ResourceTypeFilter:        f.resourceType,
+			ResourceNameFilter:        f.resourceName,
+			ResourcePatternTypeFilter: f.patternType,
+			PrincipalFilter:           f.principal,
+			HostFilter:                f.host,
+			Operation:                 f.operation,
+			PermissionType:            f.permission,
+		},
+	})
+	if err != nil {
+		return nil, hintAdvertisedListeners(fmt.Errorf("failed to list ACLs: %w", err), brokers)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("list ACLs: %w", resp.Error)
+	}
+
+	var out []backends.ACL
+	for _, r := range resp.Resources {
+		for _, a := range r.ACLs {
+			out = append(out, toBackendACL(r.ResourceType, r.ResourceName, r.PatternType, a.Principal, a.Host, a.Operation, a.PermissionType))
+		}
+	}
+	slices.SortFunc(out, compareACLs)
+	return out, nil
+}
+
+// CreateACL adds one ACL.
+func CreateACL(connArgs ConnArguments, acl backends.ACL) error {
+	e, err := parseACL(acl)
+	if err != nil {
+		return err
+	}
+	client, brokers, err := newAdminClient(connArgs)
+	if err != nil {
+		return err
+	}
+
+	log.Verbose("creating ACL on %s...", brokers[0])
+	resp, err := client.CreateACLs(context.Background(), &kafkago.CreateACLsRequest{
+		Addr: kafkago.TCP(brokers[0]),
+		ACLs: []kafkago.ACLEntry{{
+			ResourceType:        e.resourceType,
+			ResourceName:        e.resourceName,
+			ResourcePatternType: e.patternType,
+			Principal:           e.principal,
+			Host:                e.host,
+			Operation:           e.operation,
+			PermissionType:      e.permission,
+		}},
+	})
+	if err != nil {
+		return hintAdvertisedListeners(fmt.Errorf("failed to create ACL: %w", err), brokers)
+	}
+	for _, aclErr := range resp.Errors {
+		if aclErr != nil {
+			return fmt.Errorf("create ACL: %w", aclErr)
+		}
+	}
+	return nil
+}
+
+// DeleteACLs removes every ACL matching filter and returns the removed ones.
+func DeleteACLs(connArgs ConnArguments, filter backends.ACL) ([]backends.ACL, error) {
+	f, err := parseACLFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	client, brokers, err := newAdminClient(connArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Verbose("deleting ACLs on %s...", brokers[0])
+	resp, err := client.DeleteACLs(context.Background(), &kafkago.DeleteACLsRequest{
+		Addr: kafkago.TCP(brokers[0]),
+		Filters: []kafkago.DeleteACLsFilter{{
+			ResourceTypeFilter:        f.resourceType,
+			ResourceNameFilter:        f.resourceName,
+			ResourcePatternTypeFilter: f.patternType,
+			PrincipalFilter:           f.principal,
+			HostFilter:                f.host,
+			Operation:                 f.operation,
+			PermissionType:            f.permission,
+		}},
+	})
+	if err != nil {
+		return nil, hintAdvertisedListeners(fmt.Errorf("failed to delete ACLs: %w", err), brokers)
+	}
+
+	var out []backends.ACL
+	for _, r := range resp.Results {
+		if r.Error != nil {
+			return nil, fmt.Errorf("delete ACLs: %w", r.Error)
+		}
+		for _, m := range r.MatchingACLs {
+			if m.Error != nil {
+				return nil, fmt.Errorf("delete ACL for %s on %s %s: %w", m.Principal, resourceTypeName(m.ResourceType), m.ResourceName, m.Error)
+			}
+			out = append(out, toBackendACL(m.ResourceType, m.ResourceName, m.ResourcePatternType, m.Principal, m.Host, m.Operation, m.PermissionType))
+		}
+	}
+	slices.SortFunc(out, compareACLs)
+	return out, nil
+}
+
+func compareACLs(a, b backends.ACL) int {
+	for _, c := range [][2]string{
+		{a.ResourceType, b.ResourceType},
+		{a.ResourceName, b.ResourceName},
+		{a.PatternType, b.PatternType},
+		{a.Principal, b.Principal},
+		{a.Host, b.Host},
+		{a.Operation, b.Operation},
+		{a.Permission, b.Permission},
+	} {
+		if n := strings.Compare(c[0], c[1]); n != 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// DescribeCluster reports the cluster ID, the controller and every broker.
+func DescribeCluster(connArgs ConnArguments) (*backends.ClusterDescription, error) {
+	client, brokers, err := newAdminClient(connArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := clusterMetadata(context.Background(), client, brokers)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := &backends.ClusterDescription{ClusterID: meta.ClusterID, Controller: -1}
+	if meta.Controller.Host != "" {
+		desc.Controller = meta.Controller.ID
+	}
+	for _, b := range meta.Brokers {
+		desc.Brokers = append(desc.Brokers, backends.BrokerInfo{ID: b.ID, Host: b.Host, Port: b.Port, Rack: b.Rack})
+	}
+	slices.SortFunc(desc.Brokers, func(a, b backends.BrokerInfo) int { return a.ID - b.ID })
+	return desc, nil
+}
+
+// clusterMetadata fetches broker metadata without any topics.
+func clusterMetadata(ctx context.Context, client *kafkago.Client, brokers []string) (*kafkago.MetadataResponse, error) {
+	log.Verbose("reading cluster metadata from %s...", brokers[0])
+	meta, err := client.Metadata(ctx, &kafkago.MetadataRequest{
+		Addr:   kafkago.TCP(brokers[0]),
+		Topics: []string{}, // non-nil: no topics, nil would fetch all of them
+	})
+	if err != nil {
+		return nil, hintAdvertisedListeners(fmt.Errorf("failed to read cluster metadata: %w", err), brokers)
+	}
+	return meta, nil
+}
+
+// configResource maps describe-configs/alter-configs arguments to the Kafka
+// resource and the broker to send the request to. Broker configs go to that
+// broker itself, as only it knows its static settings.
+func configResource(ctx context.Context, client *kafkago.Client, brokers []string, resourceType, name string) (kafkago.ResourceType, string, error) {
+	switch resourceType {
+	case "topic":
+		return kafkago.ResourceTypeTopic, brokers[0], nil
+	case "broker":
+		id, err := strconv.Atoi(name)
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid broker %q: expected a broker ID", name)
+		}
+		meta, err := clusterMetadata(ctx, client, brokers)
+		if err != nil {
+			return 0, "", err
+		}
+		for _, b := range meta.Brokers {
+			if b.ID == id {
+				return kafkago.ResourceTypeBroker, fmt.Sprintf("%s:%d", b.Host, b.Port), nil
+			}
+		}
+		return 0, "", fmt.Errorf("broker %d not found", id)
+	}
+	return 0, "", fmt.Errorf("invalid resource type %q (use broker or topic)", resourceType)
+}
+
+// configSources names DescribeConfigs' ConfigSource values, after Kafka's
+// ConfigEntry.ConfigSource enum.
+var configSources = map[int8]string{
+	1: "dynamic-topic",
+	2: "dynamic-broker",
+	3: "dynamic-default-broker",
+	4: "static-broker",
+	5: "default",
+	6: "dynamic-broker-logger",
+}
+
+// DescribeConfigs returns every config entry of a broker (name: broker ID) or
+// topic, sorted by name.
+func DescribeConfigs(connArgs ConnArguments, resourceType, name string) ([]backends.ConfigEntry, error) {
+	client, brokers, err := newAdminClient(connArgs)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	rt, addr, err := configResource(ctx, client, brokers, resourceType, name)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Verbose("describing %s %s config on %s...", resourceType, name, addr)
+	resp, err := client.DescribeConfigs(ctx, &kafkago.DescribeConfigsRequest{
+		Addr:      kafkago.TCP(addr),
+		Resources: []kafkago.DescribeConfigRequestResource{{ResourceType: rt, ResourceName: name}},
+	})
+	if err != nil {
+		return nil, hintAdvertisedListeners(fmt.Errorf("failed to describe config of %s %s: %w", resourceType, name, err), brokers)
+	}
+
+	var out []backends.ConfigEntry
+	for _, r := range resp.Resources {
+		if r.Error != nil {
+			return nil, fmt.Errorf("describe config of %s %s: %w", resourceType, name, r.Error)
+		}
+		for _, e := range r.ConfigEntries {
+			source, ok := configSources[e.ConfigSource]
+			if !ok {
+				source = "unknown"
+			}
+			out = append(out, backends.ConfigEntry{
+				Name:      e.ConfigName,
+				Value:     e.ConfigValue,
+				Source:    source,
+				Default:   e.IsDefault || e.ConfigSource == 5,
+				ReadOnly:  e.ReadOnly,
+				Sensitive: e.IsSensitive,
+			})
+		}
+	}
+	slices.SortFunc(out, func(a, b backends.ConfigEntry) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+// AlterConfigs sets and removes (reverts to the default) config entries of a
+// broker or topic. Only the named entries change.
+func AlterConfigs(connArgs ConnArguments, resourceType, name string, set map[string]string, remove []string) error {
+	client, brokers, err := newAdminClient(connArgs)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	rt, addr, err := configResource(ctx, client, brokers, resourceType, name)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]kafkago.IncrementalAlterConfigsRequestConfig, 0, len(set)+len(remove))
+	for k, v := range set {
+		entries = append(entries, kafkago.IncrementalAlterConfigsRequestConfig{Name: k, Value: v, ConfigOperation: kafkago.ConfigOperationSet})
+	}
+	for _, k := range remove {
+		entries = append(entries, kafkago.IncrementalAlterConfigsRequestConfig{Name: k, ConfigOperation: kafkago.ConfigOperationDelete})
+	}
+
+	log.Verbose("altering %s %s config on %s...", resourceType, name, addr)
+	resp, err := client.IncrementalAlterConfigs(ctx, &kafkago.IncrementalAlterConfigsRequest{
+		Addr:      kafkago.TCP(addr),
+		Resources: []kafkago.IncrementalAlterConfigsRequestResource{{ResourceType: rt, ResourceName: name, Configs: entries}},
+	})
+	if err != nil {
+		return hintAdvertisedListeners(fmt.Errorf("failed to alter config of %s %s: %w", resourceType, name, err), brokers)
+	}
+	for _, r := range resp.Resources {
+		if r.Error != nil {
+			return fmt.Errorf("alter config of %s %s: %w", resourceType, name, r.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+//go:build kafka
+
+package kafka
+
+import (
+	"testing"
+
+	"github.com/makibytes/xmc/broker/backends"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+func TestParseACL(t *testing.T) {
+	e, err := parseACL(backends.ACL{ResourceType: "Transactional-ID", ResourceName: "tx-", PatternType: "prefixed", Principal: "User:alice", Operation: "describe-configs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := aclEntry{
+		resourceType: kafkago.ResourceTypeTransactionalID,
+		resourceName: "tx-",
+		patternType:  kafkago.PatternTypePrefixed,
+		principal:    "User:alice",
+		host:         "*",
+		operation:    kafkago.ACLOperationTypeDescribeConfigs,
+		permission:   kafkago.ACLPermissionTypeAllow,
+	}
+	if e != want {
+		t.Errorf("parseACL = %+v, want %+v", e, want)
+	}
+
+	e, err = parseACL(backends.ACL{ResourceType: "cluster", Principal: "User:admin", Operation: "alter", Permission: "deny"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.resourceName != clusterResourceName || e.permission != kafkago.ACLPermissionTypeDeny {
+		t.Errorf("cluster ACL = %+v", e)
+	}
+
+	for _, bad := range []backends.ACL{
+		{ResourceType: "topic", Principal: "User:alice", Operation: "read"},                                          // no name
+		{ResourceType: "topic", ResourceName: "t", Principal: "User:alice", Operation: "any"},                        // filter-only value
+		{ResourceType: "topic", ResourceName: "t", PatternType: "match", Principal: "User:alice", Operation: "read"}, // filter-only value
+		{ResourceType: "queue", ResourceName: "t", Principal: "User:alice", Operation: "read"},                       // unknown type
+		{ResourceType: "topic", ResourceName: "t", Principal: "User:alice", Operation: "unknown"},                    // unknown operation
+		{ResourceType: "topic", ResourceName: "t", Operation: "read"},                                                // no principal
+	} {
+		if _, err := parseACL(bad); err == nil {
+			t.Errorf("parseACL(%+v): expected an error", bad)
+		}
+	}
+}
+
+func TestParseACLFilter(t *testing.T) {
+	e, err := parseACLFilter(backends.ACL{Principal: "User:alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := aclEntry{
+		resourceType: kafkago.ResourceTypeAny,
+		patternType:  kafkago.PatternTypeAny,
+		principal:    "User:alice",
+		operation:    kafkago.ACLOperationTypeAny,
+		permission:   kafkago.ACLPermissionTypeAny,
+	}
+	if e != want {
+		t.Errorf("parseACLFilter = %+v, want %+v", e, want)
+	}
+
+	if e, err := parseACLFilter(backends.ACL{PatternType: "match", ResourceName: "orders"}); err != nil || e.patternType != kafkago.PatternTypeMatch {
+		t.Errorf("match filter = %+v, %v", e, err)
+	}
+	if _, err := parseACLFilter(backends.ACL{Permission: "maybe"}); err == nil {
+		t.Error("invalid permission: expected an error")
+	}
+}
+
+func TestToBackendACL(t *testing.T) {
+	got := toBackendACL(kafkago.ResourceTypeTransactionalID, "tx", kafkago.PatternTypeLiteral, "User:bob", "*",
+		kafkago.ACLOperationTypeIdempotentWrite, kafkago.ACLPermissionTypeAllow)
+	want := backends.ACL{ResourceType: "transactional-id", ResourceName: "tx", PatternType: "literal", Principal: "User:bob", Host: "*", Operation: "idempotent-write", Permission: "allow"}
+	if got != want {
+		t.Errorf("toBackendACL = %+v, want %+v", got, want)
+	}
+	// What list-acls prints must be accepted by create-acl.
+	if _, err := parseACL(got); err != nil {
+		t.Errorf("parseACL(toBackendACL(...)): %v", err)
+	}
+}
@@ -13,11 +13,17 @@ import (
 	"github.com/makibytes/xmc/broker/backends"
 	"github.com/makibytes/xmc/broker/tlsutil"
 	"github.com/segmentio/kafka-go"
-	"github.com/segmentio/kafka-go/sasl"
-	"github.com/segmentio/kafka-go/sasl/plain"
 )
 
-type ConnArguments = backends.CommonConnArgs
+// ConnArguments holds Kafka connection parameters. Token is the OAUTHBEARER
+// access token (--token).
+type ConnArguments struct {
+	backends.CommonConnArgs
+	SASLMechanism string   // plain (default), scram-sha-256, scram-sha-512 or oauthbearer
+	TokenFile     string   // OAUTHBEARER: file holding the access token
+	TokenURL      string   // OAUTHBEARER: OAuth 2 token endpoint (client-credentials grant)
+	TokenScopes   []string // OAUTHBEARER: scopes requested from TokenURL
+}
 
 // parseKafkaURL parses the server URL and returns brokers and TLS config
 func parseKafkaURL(serverURL string, tlsCfg tlsutil.TLSConfig) ([]string, *tls.Config, error) {
@@ -47,25 +53,17 @@ func parseKafkaURL(serverURL string, tlsCfg tlsutil.TLSConfig) ([]string, *tls.C
 	return brokers, tlsConfig, nil
 }
 
-// getSASLMechanism returns SASL mechanism if credentials are provided
-func getSASLMechanism(user, password string) sasl.Mechanism {
-	if user != "" && password != "" {
-		return &plain.Mechanism{
-			Username: user,
-			Password: password,
-		}
-	}
-	return nil
-}
-
 // buildDialer returns a *kafka.Dialer configured for tlsConfig/SASL, or nil when
 // neither applies. Writers and readers only need a dialer when one of them is set.
-func buildDialer(connArgs ConnArguments, tlsConfig *tls.Config) *kafka.Dialer {
-	sasl := getSASLMechanism(connArgs.User, connArgs.Password)
-	if tlsConfig == nil && sasl == nil {
-		return nil
+func buildDialer(connArgs ConnArguments, tlsConfig *tls.Config) (*kafka.Dialer, error) {
+	mechanism, err := getSASLMechanism(connArgs)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil && mechanism == nil {
+		return nil, nil
 	}
-	return &kafka.Dialer{TLS: tlsConfig, SASLMechanism: sasl}
+	return &kafka.Dialer{TLS: tlsConfig, SASLMechanism: mechanism}, nil
 }
 
 // dnsNoHostRe matches Go's "lookup <host>: no such host" DNS resolution error,
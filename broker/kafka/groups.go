@@ -0,0 +1,361 @@
+//go:build kafka
+
+package kafka
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/makibytes/xmc/broker/backends"
+	"github.com/makibytes/xmc/log"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// topicPartition identifies one partition of one topic.
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+// offsetBounds is a partition's retained range: the earliest offset still in
+// the log and the log-end offset (the offset the next message will get).
+type offsetBounds struct {
+	first, last int64
+}
+
+// DescribeConsumerGroup reports a group's state and members, and for every
+// partition the group has committed on or is assigned, the committed offset,
+// log-end offset and lag. Kafka answers an unknown group with state "Dead"
+// and nothing committed, which is reported as not found.
+func DescribeConsumerGroup(connArgs ConnArguments, group string) (*backends.GroupDescription, error) {
+	client, brokers, err := newAdminClient(connArgs)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	log.Verbose("describing consumer group %s on %s...", group, brokers[0])
+	g, err := describeGroup(ctx, client, brokers, group)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := &backends.GroupDescription{Name: group, State: g.GroupState}
+	owners := make(map[topicPartition]string)
+	for _, m := range g.Members {
+		assigned := 0
+		for _, t := range m.MemberAssignments.Topics {
+			for _, p := range t.Partitions {
+				owners[topicPartition{t.Topic, p}] = m.MemberID
+				assigned++
+			}
+		}
+		desc.Members = append(desc.Members, backends.GroupMember{
+			ID:       m.MemberID,
+			ClientID: m.ClientID,
+			Host:     m.ClientHost,
+			Assigned: assigned,
+		})
+	}
+
+	committed, err := fetchCommitted(ctx, client, brokers, group, nil)
+	if err != nil {
+		return nil, err
+	}
+	for tp := range owners {
+		if _, ok := committed[tp]; !ok {
+			committed[tp] = -1
+		}
+	}
+	if len(committed) == 0 && g.GroupState == "Dead" {
+		return nil, fmt.Errorf("consumer group %s not found", group)
+	}
+
+	tps := sortedPartitions(committed)
+	bounds, err := partitionBounds(ctx, client, brokers, tps)
+	if err != nil {
+		return nil, err
+	}
+	for _, tp := range tps {
+		b := bounds[tp]
+		offset := committed[tp]
+		lag := b.last - offset
+		if offset < 0 {
+			lag = b.last - b.first // a new consumer starts at the earliest offset
+		}
+		desc.Offsets = append(desc.Offsets, backends.PartitionOffset{
+			Topic:     tp.topic,
+			Partition: tp.partition,
+			Committed: offset,
+			End:       b.last,
+			Lag:       max(lag, 0),
+			Member:    owners[tp],
+		})
+	}
+	return desc, nil
+}
+
+// ResetConsumerGroupOffsets moves group's committed offsets on topic to the
+// position to selects, on the listed partitions or all of them, and returns
+// the old and new offset per partition. A dry run stops before committing.
+// Kafka only accepts a commit from outside the group while it has no active
+// members, so a group with members is rejected up front with a clear error.
+func ResetConsumerGroupOffsets(connArgs ConnArguments, group, topic string, partitions []int, to backends.OffsetTarget, dryRun bool) ([]backends.OffsetChange, error) {
+	client, brokers, err := newAdminClient(connArgs)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	g, err := describeGroup(ctx, client, brokers, group)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun && len(g.Members) > 0 {
+		return nil, fmt.Errorf("consumer group %s has %d active member(s) (state %s); stop its consumers before resetting offsets", group, len(g.Members), g.GroupState)
+	}
+
+	tps, err := topicPartitions(ctx, client, brokers, topic, partitions)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(tps))
+	for i, tp := range tps {
+		ids[i] = tp.partition
+	}
+	committed, err := fetchCommitted(ctx, client, brokers, group, map[string][]int{topic: ids})
+	if err != nil {
+		return nil, err
+	}
+	bounds, err := partitionBounds(ctx, client, brokers, tps)
+	if err != nil {
+		return nil, err
+	}
+	var atTime map[topicPartition]int64
+	if to.Kind == backends.OffsetTimestamp {
+		if atTime, err = timeOffsets(ctx, client, brokers, tps, to); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := make([]backends.OffsetChange, 0, len(tps))
+	commits := make([]kafkago.OffsetCommit, 0, len(tps))
+	for _, tp := range tps {
+		old, ok := committed[tp]
+		if !ok {
+			old = -1
+		}
+		next := targetOffset(to, old, bounds[tp], atTime[tp])
+		changes = append(changes, backends.OffsetChange{Topic: topic, Partition: tp.partition, Old: old, New: next})
+		commits = append(commits, kafkago.OffsetCommit{Partition: tp.partition, Offset: next})
+	}
+	if dryRun {
+		return changes, nil
+	}
+
+	log.Verbose("committing %d offset(s) for group %s on %s...", len(commits), group, topic)
+	resp, err := client.OffsetCommit(ctx, &kafkago.OffsetCommitRequest{
+		Addr:         kafkago.TCP(brokers[0]),
+		GroupID:      group,
+		GenerationID: -1,
+		Topics:       map[string][]kafkago.OffsetCommit{topic: commits},
+	})
+	if err != nil {
+		return nil, hintAdvertisedListeners(fmt.Errorf("failed to commit offsets: %w", err), brokers)
+	}
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("commit offset for %s partition %d: %w", topic, p.Partition, p.Error)
+		}
+	}
+	return changes, nil
+}
+
+// targetOffset computes a partition's new offset, clamped to its retained
+// range. A shift of a partition without a committed offset starts from the
+// earliest offset, where a new consumer of the group would start. at is the
+// offset a timestamp lookup returned (-1: no message that recent).
+func targetOffset(to backends.OffsetTarget, committed int64, b offsetBounds, at int64) int64 {
+	var next int64
+	switch to.Kind {
+	case backends.OffsetEarliest:
+		next = b.first
+	case backends.OffsetLatest:
+		next = b.last
+	case backends.OffsetAbsolute:
+		next = to.Offset
+	case backends.OffsetTimestamp:
+		next = at
+		if at < 0 {
+			next = b.last
+		}
+	case backends.OffsetShift:
+		base := committed
+		if base < 0 {
+			base = b.first
+		}
+		next = base + to.Offset
+	}
+	return min(max(next, b.first), b.last)
+}
+
+// describeGroup returns Kafka's description of a single group.
+func describeGroup(ctx context.Context, client *kafkago.Client, brokers []string, group string) (*kafkago.DescribeGroupsResponseGroup, error) {
+	resp, err := client.DescribeGroups(ctx, &kafkago.DescribeGroupsRequest{
+		Addr:     kafkago.TCP(brokers[0]),
+		GroupIDs: []string{group},
+	})
+	if err != nil {
+		return nil, hintAdvertisedListeners(fmt.Errorf("failed to describe consumer group: %w", err), brokers)
+	}
+	if len(resp.Groups) == 0 {
+		return nil, fmt.Errorf("consumer group %s not found", group)
+	}
+	g := &resp.Groups[0]
+	if g.Error != nil {
+		return nil, fmt.Errorf("describe consumer group %s: %w", group, g.Error)
+	}
+	return g, nil
+}
+
+// fetchCommitted returns the group's committed offsets on the given
+// partitions, or on every partition it has committed on when topics is nil.
+func fetchCommitted(ctx context.Context, client *kafkago.Client, brokers []string, group string, topics map[string][]int) (map[topicPartition]int64, error) {
+	resp, err := client.OffsetFetch(ctx, &kafkago.OffsetFetchRequest{
+		Addr:    kafkago.TCP(brokers[0]),
+		GroupID: group,
+		Topics:  topics,
+	})
+	if err != nil {
+		return nil, hintAdvertisedListeners(fmt.Errorf("failed to fetch committed offsets: %w", err), brokers)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("fetch committed offsets of %s: %w", group, resp.Error)
+	}
+
+	committed := make(map[topicPartition]int64)
+	for topic, parts := range resp.Topics {
+		for _, p := range parts {
+			if p.Error != nil {
+				return nil, fmt.Errorf("fetch committed offset for %s partition %d: %w", topic, p.Partition, p.Error)
+			}
+			committed[topicPartition{topic, p.Partition}] = p.CommittedOffset
+		}
+	}
+	return committed, nil
+}
+
+// topicPartitions returns topic's partitions, or the listed ones after
+// checking that they exist.
+func topicPartitions(ctx context.Context, client *kafkago.Client, brokers []string, topic string, only []int) ([]topicPartition, error) {
+	resp, err := client.Metadata(ctx, &kafkago.MetadataRequest{
+		Addr:   kafkago.TCP(brokers[0]),
+		Topics: []string{topic},
+	})
+	if err != nil {
+		return nil, hintAdvertisedListeners(fmt.Errorf("failed to read metadata for %s: %w", topic, err), brokers)
+	}
+	if len(resp.Topics) > 0 && resp.Topics[0].Error != nil {
+		return nil, fmt.Errorf("read metadata for %s: %w", topic, resp.Topics[0].Error)
+	}
+	if len(resp.Topics) == 0 || len(resp.Topics[0].Partitions) == 0 {
+		return nil, fmt.Errorf("topic %s not found", topic)
+	}
+
+	existing := make([]int, 0, len(resp.Topics[0].Partitions))
+	for _, p := range resp.Topics[0].Partitions {
+		existing = append(existing, p.ID)
+	}
+	if len(only) == 0 {
+		only = existing
+	}
+	tps := make([]topicPartition, 0, len(only))
+	for _, id := range only {
+		if !slices.Contains(existing, id) {
+			return nil, fmt.Errorf("topic %s has no partition %d", topic, id)
+		}
+		if tp := (topicPartition{topic, id}); !slices.Contains(tps, tp) {
+			tps = append(tps, tp)
+		}
+	}
+	slices.SortFunc(tps, compareTopicPartitions)
+	return tps, nil
+}
+
+// partitionBounds looks up the earliest and log-end offset of every partition.
+func partitionBounds(ctx context.Context, client *kafkago.Client, brokers []string, tps []topicPartition) (map[topicPartition]offsetBounds, error) {
+	requests := make(map[string][]kafkago.OffsetRequest)
+	for _, tp := range tps {
+		requests[tp.topic] = append(requests[tp.topic], kafkago.FirstOffsetOf(tp.partition), kafkago.LastOffsetOf(tp.partition))
+	}
+	resp, err := listOffsets(ctx, client, brokers, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := make(map[topicPartition]offsetBounds, len(tps))
+	for topic, parts := range resp.Topics {
+		for _, p := range parts {
+			bounds[topicPartition{topic, p.Partition}] = offsetBounds{first: p.FirstOffset, last: p.LastOffset}
+		}
+	}
+	return bounds, nil
+}
+
+// timeOffsets looks up, per partition, the first offset whose timestamp is at
+// or after to.Time; -1 when the partition has no message that recent.
+func timeOffsets(ctx context.Context, client *kafkago.Client, brokers []string, tps []topicPartition, to backends.OffsetTarget) (map[topicPartition]int64, error) {
+	requests := make(map[string][]kafkago.OffsetRequest)
+	for _, tp := range tps {
+		requests[tp.topic] = append(requests[tp.topic], kafkago.TimeOffsetOf(tp.partition, to.Time))
+	}
+	resp, err := listOffsets(ctx, client, brokers, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[topicPartition]int64, len(tps))
+	for topic, parts := range resp.Topics {
+		for _, p := range parts {
+			offset := int64(-1)
+			for o := range p.Offsets {
+				offset = o
+			}
+			offsets[topicPartition{topic, p.Partition}] = offset
+		}
+	}
+	return offsets, nil
+}
+
+func listOffsets(ctx context.Context, client *kafkago.Client, brokers []string, requests map[string][]kafkago.OffsetRequest) (*kafkago.ListOffsetsResponse, error) {
+	resp, err := client.ListOffsets(ctx, &kafkago.ListOffsetsRequest{
+		Addr:   kafkago.TCP(brokers[0]),
+		Topics: requests,
+	})
+	if err != nil {
+		return nil, hintAdvertisedListeners(fmt.Errorf("failed to list offsets: %w", err), brokers)
+	}
+	for topic, parts := range resp.Topics {
+		for _, p := range parts {
+			if p.Error != nil {
+				return nil, fmt.Errorf("list offsets for %s partition %d: %w", topic, p.Partition, p.Error)
+			}
+		}
+	}
+	return resp, nil
+}
+
+func sortedPartitions(m map[topicPartition]int64) []topicPartition {
+	tps := make([]topicPartition, 0, len(m))
+	for tp := range m {
+		tps = append(tps, tp)
+	}
+	slices.SortFunc(tps, compareTopicPartitions)
+	return tps
+}
+
+func compareTopicPartitions(a, b topicPartition) int {
+	return cmp.Or(cmp.Compare(a.topic, b.topic), cmp.Compare(a.partition, b.partition))
+}
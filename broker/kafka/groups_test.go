@@ -0,0 +1,38 @@
+//go:build kafka
+
+package kafka
+
+import (
+	"testing"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+func TestTargetOffset(t *testing.T) {
+	b := offsetBounds{first: 100, last: 500}
+	tests := []struct {
+		name      string
+		to        backends.OffsetTarget
+		committed int64
+		at        int64
+		want      int64
+	}{
+		{"earliest", backends.OffsetTarget{Kind: backends.OffsetEarliest}, 300, 0, 100},
+		{"latest", backends.OffsetTarget{Kind: backends.OffsetLatest}, 300, 0, 500},
+		{"absolute", backends.OffsetTarget{Kind: backends.OffsetAbsolute, Offset: 250}, 300, 0, 250},
+		{"absolute below retention clamps", backends.OffsetTarget{Kind: backends.OffsetAbsolute, Offset: 5}, 300, 0, 100},
+		{"absolute past end clamps", backends.OffsetTarget{Kind: backends.OffsetAbsolute, Offset: 900}, 300, 0, 500},
+		{"timestamp", backends.OffsetTarget{Kind: backends.OffsetTimestamp}, 300, 420, 420},
+		{"timestamp after last message", backends.OffsetTarget{Kind: backends.OffsetTimestamp}, 300, -1, 500},
+		{"shift back", backends.OffsetTarget{Kind: backends.OffsetShift, Offset: -50}, 300, 0, 250},
+		{"shift forward clamps", backends.OffsetTarget{Kind: backends.OffsetShift, Offset: 1000}, 300, 0, 500},
+		{"shift uncommitted from earliest", backends.OffsetTarget{Kind: backends.OffsetShift, Offset: 10}, -1, 0, 110},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := targetOffset(tt.to, tt.committed, b, tt.at); got != tt.want {
+				t.Errorf("targetOffset() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,9 +4,12 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -32,7 +35,7 @@ func TestMain(m *testing.M) {
 }
 
 func makeConnArgs() ConnArguments {
-	return ConnArguments{Server: testBroker}
+	return ConnArguments{CommonConnArgs: backends.CommonConnArgs{Server: testBroker}}
 }
 
 // TestKafka_TopicPublishSubscribe verifies that a published message is received
@@ -462,6 +465,60 @@ func TestKafka_UpdateTopic_Config(t *testing.T) {
 	}
 }
 
+// TestKafka_AlterDescribeConfigs verifies that alter-configs sets and reverts
+// topic config entries and describe-configs reports their source.
+func TestKafka_AlterDescribeConfigs(t *testing.T) {
+	t.Parallel()
+	topic := "test-alter-describe-configs"
+
+	if err := CreateTopic(makeConnArgs(), topic, 1, 1, map[string]string{"cleanup.policy": "compact"}); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	if err := AlterConfigs(makeConnArgs(), "topic", topic, map[string]string{"retention.ms": "3600000"}, []string{"cleanup.policy"}); err != nil {
+		t.Fatalf("AlterConfigs: %v", err)
+	}
+
+	entries, err := DescribeConfigs(makeConnArgs(), "topic", topic)
+	if err != nil {
+		t.Fatalf("DescribeConfigs: %v", err)
+	}
+	got := make(map[string]backends.ConfigEntry)
+	for _, e := range entries {
+		got[e.Name] = e
+	}
+	if e := got["retention.ms"]; e.Value != "3600000" || e.Source != "dynamic-topic" || e.Default {
+		t.Errorf("retention.ms = %+v, want 3600000 from dynamic-topic", e)
+	}
+	if e := got["cleanup.policy"]; e.Value != "delete" || e.Source == "dynamic-topic" {
+		t.Errorf("cleanup.policy = %+v, want reverted to the default", e)
+	}
+}
+
+// TestKafka_DescribeCluster verifies that the single test broker is reported
+// as the controller, and that its configs can be described by ID.
+func TestKafka_DescribeCluster(t *testing.T) {
+	t.Parallel()
+
+	desc, err := DescribeCluster(makeConnArgs())
+	if err != nil {
+		t.Fatalf("DescribeCluster: %v", err)
+	}
+	if desc.ClusterID == "" || len(desc.Brokers) != 1 {
+		t.Fatalf("DescribeCluster = %+v, want one broker and a cluster ID", desc)
+	}
+	if desc.Controller != desc.Brokers[0].ID {
+		t.Errorf("controller = %d, want the only broker %d", desc.Controller, desc.Brokers[0].ID)
+	}
+
+	entries, err := DescribeConfigs(makeConnArgs(), "broker", strconv.Itoa(desc.Brokers[0].ID))
+	if err != nil {
+		t.Fatalf("DescribeConfigs broker: %v", err)
+	}
+	if !slices.ContainsFunc(entries, func(e backends.ConfigEntry) bool { return e.Name == "log.retention.hours" }) {
+		t.Errorf("broker config misses log.retention.hours: %d entries", len(entries))
+	}
+}
+
 // TestKafka_TopicStats verifies that TopicStats reports the correct message
 // count after publishing a known number of messages.
 func TestKafka_TopicStats(t *testing.T) {
@@ -568,3 +625,170 @@ func TestKafka_PartitionOffsetRead(t *testing.T) {
 		t.Error("expected error for --offset without --partition")
 	}
 }
+
+// TestKafka_BatchedPublish verifies that --batch-size queues publishes on the
+// asynchronous Writer and that Flush reports them in publish order.
+func TestKafka_BatchedPublish(t *testing.T) {
+	t.Parallel()
+	topic := "test-batched-publish"
+	const messageCount = 5
+
+	adapter, err := NewTopicAdapter(makeConnArgs())
+	if err != nil {
+		t.Fatalf("NewTopicAdapter: %v", err)
+	}
+	defer adapter.Close()
+
+	ctx := context.Background()
+	extra := map[string]string{"batch-size": "10", "linger": "50ms", "idempotent": "true"}
+	for i := 0; i < messageCount; i++ {
+		if err := adapter.PublishAsync(ctx, backends.PublishOptions{
+			Topic:   topic,
+			Message: []byte(fmt.Sprintf("batched %d", i)),
+			Extra:   extra,
+		}); err != nil {
+			t.Fatalf("PublishAsync %d: %v", i, err)
+		}
+	}
+	receipts, err := adapter.Flush()
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if len(receipts) != messageCount {
+		t.Fatalf("Flush returned %d receipts, want %d", len(receipts), messageCount)
+	}
+	for i, r := range receipts {
+		if r.Topic != topic || r.Offset != int64(i) {
+			t.Errorf("receipt %d = %+v, want offset %d", i, r, i)
+		}
+	}
+
+	if err := adapter.PublishAsync(ctx, backends.PublishOptions{Topic: topic, Message: []byte("sync")}); !errors.Is(err, backends.ErrBatchUnsupported) {
+		t.Errorf("PublishAsync without batch flags = %v, want ErrBatchUnsupported", err)
+	}
+}
+
+// TestKafka_BoundedRead verifies that --until end reads every partition up to
+// the log end seen at start, ignores later messages and then reports the end.
+func TestKafka_BoundedRead(t *testing.T) {
+	t.Parallel()
+	topic := "test-bounded-read"
+	const messageCount = 6
+
+	if err := CreateTopic(makeConnArgs(), topic, 3, 1, nil); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	publisher, err := NewTopicAdapter(makeConnArgs())
+	if err != nil {
+		t.Fatalf("NewTopicAdapter: %v", err)
+	}
+	defer publisher.Close()
+
+	ctx := context.Background()
+	for i := 0; i < messageCount; i++ {
+		if err := publisher.Publish(ctx, backends.PublishOptions{
+			Topic:   topic,
+			Key:     fmt.Sprintf("key-%d", i),
+			Message: []byte(fmt.Sprintf("bounded message %d", i)),
+		}); err != nil {
+			t.Fatalf("Publish %d: %v", i, err)
+		}
+	}
+
+	reader, err := NewTopicAdapter(makeConnArgs())
+	if err != nil {
+		t.Fatalf("NewTopicAdapter (reader): %v", err)
+	}
+	defer reader.Close()
+	opts := backends.SubscribeOptions{Topic: topic, Timeout: 15, Extra: map[string]string{"until": "end"}}
+
+	seen := make(map[string]bool)
+	for len(seen) < messageCount {
+		msg, err := reader.Subscribe(ctx, opts)
+		if err != nil {
+			t.Fatalf("Subscribe after %d messages: %v", len(seen), err)
+		}
+		if len(seen) == 0 {
+			// Published after the snapshot: must not be read.
+			if err := publisher.Publish(ctx, backends.PublishOptions{Topic: topic, Message: []byte("late")}); err != nil {
+				t.Fatalf("Publish late: %v", err)
+			}
+		}
+		seen[string(msg.Data)] = true
+	}
+	if seen["late"] {
+		t.Error("message published after the snapshot was read")
+	}
+	if _, err := reader.Subscribe(ctx, opts); !errors.Is(err, backends.ErrNoMessageAvailable) {
+		t.Errorf("Subscribe after the end: err = %v, want ErrNoMessageAvailable", err)
+	}
+
+	// --from with a selected partition.
+	from, err := NewTopicAdapter(makeConnArgs())
+	if err != nil {
+		t.Fatalf("NewTopicAdapter (from): %v", err)
+	}
+	defer from.Close()
+	msg, err := from.Subscribe(ctx, backends.SubscribeOptions{
+		Topic:   topic,
+		Timeout: 15,
+		Extra:   map[string]string{"partition": "0,1,2", "from": "earliest", "until": "end"},
+	})
+	if err != nil {
+		t.Fatalf("Subscribe (--from earliest): %v", err)
+	}
+	if !strings.HasPrefix(string(msg.Data), "bounded message") && string(msg.Data) != "late" {
+		t.Errorf("unexpected message %q", msg.Data)
+	}
+}
+
+// TestKafka_Tombstone verifies that a tombstone is written as a null value
+// and read back as one, distinct from the keyed records around it and from
+// an empty value.
+func TestKafka_Tombstone(t *testing.T) {
+	t.Parallel()
+	topic := "test-tombstone"
+
+	if err := CreateTopic(makeConnArgs(), topic, 1, 1, map[string]string{"cleanup.policy": "compact"}); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	adapter, err := NewTopicAdapter(makeConnArgs())
+	if err != nil {
+		t.Fatalf("NewTopicAdapter: %v", err)
+	}
+	defer adapter.Close()
+
+	ctx := context.Background()
+	if err := adapter.Publish(ctx, backends.PublishOptions{Topic: topic, Key: "cfg", Message: []byte("v1")}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := adapter.Publish(ctx, backends.PublishOptions{Topic: topic, Key: "cfg", Tombstone: true}); err != nil {
+		t.Fatalf("Publish tombstone: %v", err)
+	}
+	if err := adapter.Publish(ctx, backends.PublishOptions{Topic: topic, Key: "cfg"}); err != nil {
+		t.Fatalf("Publish empty value: %v", err)
+	}
+
+	opts := backends.SubscribeOptions{Topic: topic, Timeout: 15, Extra: map[string]string{"until": "end"}}
+	first, err := adapter.Subscribe(ctx, opts)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if first.Tombstone || string(first.Data) != "v1" {
+		t.Errorf("first record = %+v, want v1", first)
+	}
+	second, err := adapter.Subscribe(ctx, opts)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if !second.Tombstone || second.Key != "cfg" {
+		t.Errorf("second record = %+v, want a tombstone for cfg", second)
+	}
+	third, err := adapter.Subscribe(ctx, opts)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if third.Tombstone || third.Key != "cfg" || len(third.Data) != 0 {
+		t.Errorf("third record = %+v, want an empty value for cfg", third)
+	}
+}
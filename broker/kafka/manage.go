@@ -26,7 +26,10 @@ func CreateTopic(connArgs ConnArguments, topic string, partitions, replicationFa
 		return err
 	}
 
-	dialer := buildDialer(connArgs, tlsConfig)
+	dialer, err := buildDialer(connArgs, tlsConfig)
+	if err != nil {
+		return err
+	}
 	if dialer == nil {
 		dialer = &kafkago.Dialer{}
 	}
@@ -60,7 +63,10 @@ func DeleteTopic(connArgs ConnArguments, topic string) error {
 		return err
 	}
 
-	dialer := buildDialer(connArgs, tlsConfig)
+	dialer, err := buildDialer(connArgs, tlsConfig)
+	if err != nil {
+		return err
+	}
 	if dialer == nil {
 		dialer = &kafkago.Dialer{}
 	}
@@ -84,10 +90,11 @@ func newAdminClient(connArgs ConnArguments) (*kafkago.Client, []string, error) {
 		return nil, nil, err
 	}
 
-	transport := &kafkago.Transport{TLS: tlsConfig}
-	if sasl := getSASLMechanism(connArgs.User, connArgs.Password); sasl != nil {
-		transport.SASL = sasl
+	mechanism, err := getSASLMechanism(connArgs)
+	if err != nil {
+		return nil, nil, err
 	}
+	transport := &kafkago.Transport{TLS: tlsConfig, SASL: mechanism}
 
 	client := &kafkago.Client{
 		Addr:      kafkago.TCP(brokers...),
@@ -186,7 +193,10 @@ func TopicStats(connArgs ConnArguments, topic string) (*backends.QueueStats, err
 		return nil, err
 	}
 
-	dialer := buildDialer(connArgs, tlsConfig)
+	dialer, err := buildDialer(connArgs, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
 	if dialer == nil {
 		dialer = &kafkago.Dialer{}
 	}
@@ -263,7 +273,10 @@ func ListTopics(connArgs ConnArguments) ([]TopicInfo, error) {
 		return nil, err
 	}
 
-	dialer := buildDialer(connArgs, tlsConfig)
+	dialer, err := buildDialer(connArgs, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
 	if dialer == nil {
 		dialer = &kafkago.Dialer{}
 	}
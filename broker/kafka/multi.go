@@ -0,0 +1,112 @@
+//go:build kafka
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// SubscribeTopics implements backends.MultiTopicBackend.
+// All topics are read by one consumer group (kafka-go GroupTopics), so the
+// group balances and commits them together. Topics with shell wildcards
+// (*, ?, [...]) are matched as regexes against the cluster's topic list, the
+// way Kafka's own pattern subscriptions work; the match is taken once per
+// adapter, so topics created later join on the next run. Each message is
+// labelled with its topic.
+func (a *TopicAdapter) SubscribeTopics(ctx context.Context, topics []string, opts backends.SubscribeOptions) (*backends.Message, error) {
+	if opts.Extra["partition"] != "" || opts.Extra["offset"] != "" || opts.Extra["from"] != "" || opts.Extra["until"] != "" {
+		return nil, fmt.Errorf("--partition/--offset/--from/--until read a single topic and cannot be combined with several topics or a pattern")
+	}
+	if opts.GroupID == "" {
+		return nil, fmt.Errorf("subscribing to several topics requires a consumer group (-g)")
+	}
+
+	resolved, err := a.expandTopics(topics)
+	if err != nil {
+		return nil, err
+	}
+
+	args := ReceiveArguments{
+		Topic:     strings.Join(resolved, ", "),
+		Topics:    resolved,
+		GroupID:   opts.GroupID,
+		Timeout:   opts.Timeout,
+		Wait:      opts.Wait,
+		Partition: -1,
+		Offset:    OffsetUnset,
+	}
+	reader, err := a.getReader(args)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := fetchMessage(ctx, reader, args, a.brokers)
+	if err != nil {
+		return nil, err
+	}
+	if message == nil {
+		return nil, backends.ErrNoMessageAvailable
+	}
+
+	msg := a.receivedMessage(ctx, message, opts.Verbosity >= backends.VerbosityVerbose)
+	msg.Topic = message.Topic
+	return msg, nil
+}
+
+// expandTopics replaces every wildcard pattern with the existing topics it
+// matches. Internal topics (__consumer_offsets, ...) only match patterns that
+// start with "__" themselves.
+func (a *TopicAdapter) expandTopics(topics []string) ([]string, error) {
+	key := strings.Join(topics, ",")
+	if resolved, ok := a.expanded[key]; ok {
+		return resolved, nil
+	}
+
+	var existing []TopicInfo
+	var resolved []string
+	for _, t := range topics {
+		if !backends.HasGlob(t) {
+			if !slices.Contains(resolved, t) {
+				resolved = append(resolved, t)
+			}
+			continue
+		}
+		if existing == nil {
+			var err error
+			if existing, err = ListTopics(a.connArgs); err != nil {
+				return nil, err
+			}
+		}
+		re, err := regexp.Compile(backends.GlobRegexp(t))
+		if err != nil {
+			return nil, fmt.Errorf("invalid topic pattern %q: %w", t, err)
+		}
+		matched := false
+		for _, info := range existing {
+			if strings.HasPrefix(info.Name, "__") && !strings.HasPrefix(t, "__") {
+				continue
+			}
+			if re.MatchString(info.Name) {
+				matched = true
+				if !slices.Contains(resolved, info.Name) {
+					resolved = append(resolved, info.Name)
+				}
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no topics match %q", t)
+		}
+	}
+
+	if a.expanded == nil {
+		a.expanded = make(map[string][]string)
+	}
+	a.expanded[key] = resolved
+	return resolved, nil
+}
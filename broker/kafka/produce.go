@@ -0,0 +1,319 @@
+//go:build kafka
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+	"github.com/makibytes/xmc/log"
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// producerConfig is the Writer configuration selected by the publish flags
+// (--acks, --compression, --batch-size, --linger, --partition, --idempotent).
+// It is comparable, so the adapter can tell whether its cached Writer fits.
+type producerConfig struct {
+	acks        kafka.RequiredAcks
+	compression kafka.Compression
+	batchSize   int           // 0: kafka-go default (100 messages)
+	linger      time.Duration // 0: kafka-go default (1s)
+	partition   int           // -1: chosen by the balancer
+	idempotent  bool
+	batched     bool // --batch-size or --linger given: write asynchronously
+}
+
+// idempotentMaxAttempts is how often an idempotent producer tries a batch.
+// The Java client retries until delivery.timeout.ms (2 minutes); with
+// kafka-go's backoff of at most 1s this takes about as long.
+const idempotentMaxAttempts = 100
+
+// defaultProducerConfig matches the Writer kmc has always used: acks=all,
+// no compression, kafka-go batching, key-aware balancing.
+var defaultProducerConfig = producerConfig{acks: kafka.RequireAll, partition: -1}
+
+var compressionCodecs = map[string]kafka.Compression{
+	"none":   0,
+	"gzip":   kafka.Gzip,
+	"snappy": kafka.Snappy,
+	"lz4":    kafka.Lz4,
+	"zstd":   kafka.Zstd,
+}
+
+// parseProducerConfig reads the publish flags from PublishOptions.Extra.
+func parseProducerConfig(extra map[string]string) (producerConfig, error) {
+	cfg := defaultProducerConfig
+	if v, ok := extra["acks"]; ok {
+		if err := cfg.acks.UnmarshalText([]byte(strings.ToLower(v))); err != nil {
+			return cfg, fmt.Errorf("invalid --acks %q (use all, one, none, -1, 1 or 0)", v)
+		}
+	}
+	if v, ok := extra["compression"]; ok {
+		codec, ok := compressionCodecs[strings.ToLower(v)]
+		if !ok {
+			return cfg, fmt.Errorf("invalid --compression %q (use none, gzip, snappy, lz4 or zstd)", v)
+		}
+		cfg.compression = codec
+	}
+	if v, ok := extra["batch-size"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return cfg, fmt.Errorf("invalid --batch-size %q: must be a positive number of messages", v)
+		}
+		cfg.batchSize = n
+		cfg.batched = true
+	}
+	if v, ok := extra["linger"]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid --linger %q: must be a positive duration", v)
+		}
+		cfg.linger = d
+		cfg.batched = true
+	}
+	if v, ok := extra["partition"]; ok {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 0 {
+			return cfg, fmt.Errorf("invalid --partition %q: must be >= 0", v)
+		}
+		cfg.partition = p
+	}
+	if extra["idempotent"] == "true" {
+		if _, ok := extra["acks"]; ok && cfg.acks != kafka.RequireAll {
+			return cfg, fmt.Errorf("--idempotent requires --acks all")
+		}
+		cfg.idempotent = true
+	}
+	return cfg, nil
+}
+
+// fixedPartitionBalancer sends every message to one partition (--partition).
+type fixedPartitionBalancer int
+
+func (b fixedPartitionBalancer) Balance(kafka.Message, ...int) int { return int(b) }
+
+// writtenMessages collects the messages a Writer's Completion callback
+// reports, which carry the partition and offset Kafka assigned, and the
+// first write error (asynchronous writes report errors only there).
+type writtenMessages struct {
+	mu     sync.Mutex
+	msgs   []kafka.Message
+	err    error
+	failed int
+}
+
+func (w *writtenMessages) add(msgs []kafka.Message, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		if w.err == nil {
+			w.err = err
+		}
+		w.failed += len(msgs)
+		return
+	}
+	w.msgs = append(w.msgs, msgs...)
+}
+
+// take returns and forgets the collected messages and error.
+func (w *writtenMessages) take() ([]kafka.Message, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	msgs, err := w.msgs, w.err
+	if err != nil {
+		err = fmt.Errorf("%d messages were not written: %w", w.failed, err)
+	}
+	w.msgs, w.err, w.failed = nil, nil, 0
+	return msgs, err
+}
+
+// getWriter returns a Writer for cfg, replacing the cached one when the
+// publish flags changed. Must be called with a.writerMu held.
+func (a *TopicAdapter) getWriter(cfg producerConfig) (*kafka.Writer, error) {
+	if a.writer != nil && a.writerCfg == cfg {
+		return a.writer, nil
+	}
+	if a.writer != nil {
+		a.writer.Close()
+		a.writer = nil
+	}
+
+	brokers, tlsConfig, err := parseKafkaURL(a.connArgs.Server, a.connArgs.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer, err := buildDialer(a.connArgs, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var balancer kafka.Balancer = &keyAwareBalancer{}
+	if cfg.partition >= 0 {
+		balancer = fixedPartitionBalancer(cfg.partition)
+	}
+	writer := kafka.NewWriter(kafka.WriterConfig{
+		Brokers:  brokers,
+		Balancer: balancer,
+		Dialer:   dialer,
+	})
+	writer.AllowAutoTopicCreation = true
+	writer.RequiredAcks = cfg.acks
+	writer.Compression = cfg.compression
+	writer.BatchSize = cfg.batchSize
+	writer.BatchTimeout = cfg.linger
+	writer.Completion = a.written.add
+	if cfg.batched {
+		writer.Async = true
+		writer.Completion = a.batched.add
+	}
+	if cfg.idempotent {
+		// kafka-go has no producer IDs or sequence numbers, so the broker
+		// cannot deduplicate. What it keeps is one in-flight batch per
+		// partition, so retries don't reorder; add acks=all (the default)
+		// and the Java client's persistent retries.
+		writer.MaxAttempts = idempotentMaxAttempts
+		log.Verbose("idempotent mode: acks=all, %d attempts, one in-flight batch per partition (no broker-side deduplication)", idempotentMaxAttempts)
+	}
+
+	a.writer = writer
+	a.writerCfg = cfg
+	return writer, nil
+}
+
+// checkPartition makes sure an explicit --partition exists before publishing,
+// since kafka-go would otherwise keep retrying the unknown partition.
+func (a *TopicAdapter) checkPartition(ctx context.Context, topic string, partition int) error {
+	key := fmt.Sprintf("%s/%d", topic, partition)
+	if a.checked[key] {
+		return nil
+	}
+	client, brokers, err := newAdminClient(a.connArgs)
+	if err != nil {
+		return err
+	}
+	if _, err := topicPartitions(ctx, client, brokers, topic, []int{partition}); err != nil {
+		return err
+	}
+	if a.checked == nil {
+		a.checked = make(map[string]bool)
+	}
+	a.checked[key] = true
+	return nil
+}
+
+// PublishWithReceipt implements backends.ReceiptBackend. The receipt is nil
+// with acks=none, where Kafka does not answer the produce request.
+func (a *TopicAdapter) PublishWithReceipt(ctx context.Context, opts backends.PublishOptions) (*backends.PublishReceipt, error) {
+	if opts.Tombstone && opts.Key == "" {
+		return nil, fmt.Errorf("a tombstone needs a key (-K)")
+	}
+	cfg, err := parseProducerConfig(opts.Extra)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single synchronous write; PublishAsync is what batches.
+	cfg.batched = false
+
+	a.writerMu.Lock()
+	defer a.writerMu.Unlock()
+
+	writer, err := a.prepareWriter(ctx, opts.Topic, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Verbose("💌 publishing message to topic %s...", opts.Topic)
+	a.written.take()
+	if err := writer.WriteMessages(ctx, buildKafkaMessage(opts)); err != nil {
+		return nil, hintAdvertisedListeners(fmt.Errorf("failed to publish message: %w", err), a.brokers)
+	}
+
+	written, _ := a.written.take()
+	if cfg.acks == kafka.RequireNone || len(written) == 0 {
+		return nil, nil
+	}
+	m := written[len(written)-1]
+	return &backends.PublishReceipt{Topic: m.Topic, Partition: m.Partition, Offset: m.Offset}, nil
+}
+
+// prepareWriter checks an explicit --partition and returns the Writer for
+// cfg. Must be called with a.writerMu held.
+func (a *TopicAdapter) prepareWriter(ctx context.Context, topic string, cfg producerConfig) (*kafka.Writer, error) {
+	if cfg.partition >= 0 {
+		if err := a.checkPartition(ctx, topic, cfg.partition); err != nil {
+			return nil, err
+		}
+	}
+	return a.getWriter(cfg)
+}
+
+// PublishAsync implements backends.BatchPublisher. With --batch-size or
+// --linger the message is queued on an asynchronous Writer, which sends a
+// batch once it is full or lingered long enough; Flush reports the outcome.
+// Without them it returns backends.ErrBatchUnsupported.
+func (a *TopicAdapter) PublishAsync(ctx context.Context, opts backends.PublishOptions) error {
+	if opts.Tombstone && opts.Key == "" {
+		return fmt.Errorf("a tombstone needs a key (-K)")
+	}
+	cfg, err := parseProducerConfig(opts.Extra)
+	if err != nil {
+		return err
+	}
+	if !cfg.batched {
+		return backends.ErrBatchUnsupported
+	}
+
+	a.writerMu.Lock()
+	defer a.writerMu.Unlock()
+
+	writer, err := a.prepareWriter(ctx, opts.Topic, cfg)
+	if err != nil {
+		return err
+	}
+	msg := buildKafkaMessage(opts)
+	msg.WriterData = a.queued // publish order, for Flush
+	a.queued++
+	log.Verbose("💌 queueing message for topic %s...", opts.Topic)
+	return writer.WriteMessages(ctx, msg)
+}
+
+// Flush implements backends.BatchPublisher: it closes the asynchronous
+// Writer, which sends what is still queued, and returns the receipts in
+// publish order. With acks=none Kafka reports no offsets, so there are none.
+func (a *TopicAdapter) Flush() ([]backends.PublishReceipt, error) {
+	a.writerMu.Lock()
+	defer a.writerMu.Unlock()
+
+	acks := a.writerCfg.acks
+	if a.writer != nil && a.writerCfg.batched {
+		if err := a.writer.Close(); err != nil {
+			return nil, err
+		}
+		a.writer = nil
+	}
+	a.queued = 0
+	written, err := a.batched.take()
+	if err != nil {
+		err = hintAdvertisedListeners(fmt.Errorf("failed to publish: %w", err), a.brokers)
+	}
+	if acks == kafka.RequireNone {
+		return nil, err
+	}
+
+	slices.SortFunc(written, func(x, y kafka.Message) int {
+		return x.WriterData.(int) - y.WriterData.(int)
+	})
+	receipts := make([]backends.PublishReceipt, len(written))
+	for i, m := range written {
+		receipts[i] = backends.PublishReceipt{Topic: m.Topic, Partition: m.Partition, Offset: m.Offset}
+	}
+	return receipts, err
+}
@@ -0,0 +1,81 @@
+//go:build kafka
+
+package kafka
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+func TestParseProducerConfig(t *testing.T) {
+	cfg, err := parseProducerConfig(nil)
+	if err != nil || cfg != defaultProducerConfig {
+		t.Fatalf("no flags: cfg = %+v, err = %v; want defaults", cfg, err)
+	}
+
+	cfg, err = parseProducerConfig(map[string]string{
+		"acks":        "one",
+		"compression": "zstd",
+		"batch-size":  "1",
+		"linger":      "5ms",
+		"partition":   "3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := producerConfig{acks: kafkago.RequireOne, compression: kafkago.Zstd, batchSize: 1, linger: 5 * time.Millisecond, partition: 3, batched: true}
+	if cfg != want {
+		t.Errorf("cfg = %+v, want %+v", cfg, want)
+	}
+
+	cfg, err = parseProducerConfig(map[string]string{"acks": "0"})
+	if err != nil || cfg.acks != kafkago.RequireNone {
+		t.Errorf("acks=0: cfg = %+v, err = %v", cfg, err)
+	}
+
+	cfg, err = parseProducerConfig(map[string]string{"idempotent": "true"})
+	if err != nil || !cfg.idempotent || cfg.acks != kafkago.RequireAll || cfg.batched {
+		t.Errorf("idempotent: cfg = %+v, err = %v", cfg, err)
+	}
+
+	for _, bad := range []map[string]string{
+		{"acks": "2"},
+		{"compression": "brotli"},
+		{"batch-size": "0"},
+		{"linger": "soon"},
+		{"partition": "-1"},
+		{"idempotent": "true", "acks": "one"},
+	} {
+		if _, err := parseProducerConfig(bad); err == nil {
+			t.Errorf("parseProducerConfig(%v): expected an error", bad)
+		}
+	}
+}
+
+func TestWrittenMessages(t *testing.T) {
+	var w writtenMessages
+	w.add([]kafkago.Message{{Offset: 1}, {Offset: 2}}, nil)
+	w.add([]kafkago.Message{{Offset: 3}}, errors.New("leader not available"))
+	msgs, err := w.take()
+	if len(msgs) != 2 || err == nil || !strings.Contains(err.Error(), "1 messages were not written") {
+		t.Errorf("take = %d messages, %v", len(msgs), err)
+	}
+	if msgs, err := w.take(); len(msgs) != 0 || err != nil {
+		t.Errorf("second take = %d messages, %v; want nothing", len(msgs), err)
+	}
+}
+
+func TestTombstoneRecords(t *testing.T) {
+	m := buildKafkaMessage(backends.PublishOptions{Topic: "t", Key: "k1", Tombstone: true})
+	if m.Value != nil {
+		t.Errorf("tombstone value = %q, want nil", m.Value)
+	}
+	if m := buildKafkaMessage(backends.PublishOptions{Topic: "t", Key: "k1"}); m.Value == nil {
+		t.Error("empty payload was sent as a null value")
+	}
+}
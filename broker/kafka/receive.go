@@ -4,7 +4,9 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/makibytes/xmc/log"
@@ -40,3 +42,45 @@ func fetchMessage(ctx context.Context, reader *kafka.Reader, args ReceiveArgumen
 
 	return &message, nil
 }
+
+// nullValue reports whether the record at offset holds a null value. The
+// Reader decodes a null and an empty value alike, so an empty record is
+// fetched again at the protocol level, where a null value has no Value at all.
+func nullValue(ctx context.Context, client *kafka.Client, topic string, partition int, offset int64) (bool, error) {
+	fetchCtx, cancel := context.WithTimeout(ctx, browseFetchTimeout)
+	defer cancel()
+	resp, err := client.Fetch(fetchCtx, &kafka.FetchRequest{
+		Topic:     topic,
+		Partition: partition,
+		Offset:    offset,
+		MinBytes:  1,
+		MaxBytes:  1e6,
+		MaxWait:   500 * time.Millisecond,
+	})
+	if err != nil {
+		return false, err
+	}
+	if resp.Error != nil {
+		return false, resp.Error
+	}
+	for {
+		rec, err := resp.Records.ReadRecord()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return false, fmt.Errorf("offset %d not found on partition %d", offset, partition)
+			}
+			return false, err
+		}
+		if rec.Key != nil {
+			rec.Key.Close()
+		}
+		null := rec.Value == nil
+		if !null {
+			rec.Value.Close()
+		}
+		// A batch can start before the requested offset.
+		if rec.Offset >= offset {
+			return null && rec.Offset == offset, nil
+		}
+	}
+}
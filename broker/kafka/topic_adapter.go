@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -34,32 +35,46 @@ func (b *keyAwareBalancer) Balance(msg kafka.Message, partitions ...int) int {
 type TopicAdapter struct {
 	connArgs ConnArguments
 	brokers  []string
-	writer   *kafka.Writer
+
+	writerMu  sync.Mutex
+	writer    *kafka.Writer
+	writerCfg producerConfig
+	written   writtenMessages // filled by the Writer's Completion callback
+	batched   writtenMessages // the same for the asynchronous Writer (--batch-size, --linger)
+	queued    int             // messages queued on the asynchronous Writer since the last Flush
+	checked   map[string]bool // "topic/partition" already validated for --partition
 
 	readerMu  sync.Mutex
 	reader    *kafka.Reader
 	readerKey string
+	bounded   *boundedReader // --from/--until read, see subscribeBounded
+
+	expanded map[string][]string // topic patterns → matching topics, see expandTopics
+
+	clientMu sync.Mutex
+	client   *kafka.Client // protocol-level fetches that tell null values from empty ones
 }
 
-// NewTopicAdapter creates a new Kafka topic adapter
+// NewTopicAdapter creates a new Kafka topic adapter. The Writer is built on
+// the first publish, from that publish's producer settings.
 func NewTopicAdapter(connArgs ConnArguments) (*TopicAdapter, error) {
-	brokers, tlsConfig, err := parseKafkaURL(connArgs.Server, connArgs.TLS)
+	brokers, _, err := parseKafkaURL(connArgs.Server, connArgs.TLS)
 	if err != nil {
 		return nil, err
 	}
-
-	writer := kafka.NewWriter(kafka.WriterConfig{
-		Brokers:  brokers,
-		Balancer: &keyAwareBalancer{},
-		Dialer:   buildDialer(connArgs, tlsConfig),
-	})
-	writer.AllowAutoTopicCreation = true
-
-	return &TopicAdapter{connArgs: connArgs, brokers: brokers, writer: writer}, nil
+	return &TopicAdapter{connArgs: connArgs, brokers: brokers}, nil
 }
 
 // Publish implements backends.TopicBackend
 func (a *TopicAdapter) Publish(ctx context.Context, opts backends.PublishOptions) error {
+	_, err := a.PublishWithReceipt(ctx, opts)
+	return err
+}
+
+// buildKafkaMessage converts publish options to a Kafka record, carrying the
+// message metadata as headers. kafka-go writes a nil Value as null, so only a
+// tombstone leaves it nil; an empty payload is sent as an empty value.
+func buildKafkaMessage(opts backends.PublishOptions) kafka.Message {
 	var headers []kafka.Header
 	addHeader := func(key, value string) {
 		if value != "" {
@@ -77,18 +92,18 @@ func (a *TopicAdapter) Publish(ctx context.Context, opts backends.PublishOptions
 		addHeader(k, v)
 	}
 
-	message := kafka.Message{
+	value := opts.Message
+	if opts.Tombstone {
+		value = nil
+	} else if value == nil {
+		value = []byte{}
+	}
+	return kafka.Message{
 		Topic:   opts.Topic,
 		Key:     []byte(opts.Key),
-		Value:   opts.Message,
+		Value:   value,
 		Headers: headers,
 	}
-
-	log.Verbose("💌 publishing message to topic %s...", opts.Topic)
-	if err := a.writer.WriteMessages(ctx, message); err != nil {
-		return hintAdvertisedListeners(fmt.Errorf("failed to publish message: %w", err), a.brokers)
-	}
-	return nil
 }
 
 // Subscribe implements backends.TopicBackend
@@ -102,8 +117,22 @@ func (a *TopicAdapter) Subscribe(ctx context.Context, opts backends.SubscribeOpt
 		Offset:    OffsetUnset,
 	}
 
+	bounds, isBounded, err := parseReadBounds(opts.Extra)
+	if err != nil {
+		return nil, err
+	}
+	if isBounded {
+		if _, ok := opts.Extra["offset"]; ok {
+			return nil, fmt.Errorf("--offset cannot be combined with --from/--until; use --from <offset>")
+		}
+		return a.subscribeBounded(ctx, opts, bounds)
+	}
+
 	if opts.Extra != nil {
 		if v, ok := opts.Extra["partition"]; ok {
+			if strings.Contains(v, ",") {
+				return nil, fmt.Errorf("several --partition values need --from or --until")
+			}
 			p, err := strconv.Atoi(v)
 			if err != nil {
 				return nil, fmt.Errorf("invalid --partition value %q: %w", v, err)
@@ -129,6 +158,13 @@ func (a *TopicAdapter) Subscribe(ctx context.Context, opts backends.SubscribeOpt
 		}
 	}
 
+	// Without a group or partition there is nothing to join: follow every
+	// partition from its log end, like a non-durable subscription elsewhere,
+	// so probes (ping, bench, stats-stream) leave no group behind.
+	if args.Partition < 0 && args.GroupID == "" {
+		return a.subscribeBounded(ctx, opts, readBounds{from: backends.OffsetTarget{Kind: backends.OffsetLatest}})
+	}
+
 	reader, err := a.getReader(args)
 	if err != nil {
 		return nil, err
@@ -142,7 +178,7 @@ func (a *TopicAdapter) Subscribe(ctx context.Context, opts backends.SubscribeOpt
 		return nil, backends.ErrNoMessageAvailable
 	}
 
-	return convertKafkaToBackendMessage(message, opts.Verbosity >= backends.VerbosityVerbose), nil
+	return a.receivedMessage(ctx, message, opts.Verbosity >= backends.VerbosityVerbose), nil
 }
 
 // receiveArgsKey identifies which (topic, group, partition, offset) a Reader was
@@ -152,6 +188,9 @@ func receiveArgsKey(args ReceiveArguments) string {
 	if args.Partition >= 0 {
 		return fmt.Sprintf("topic=%s|partition=%d|offset=%d", args.Topic, args.Partition, args.Offset)
 	}
+	if len(args.Topics) > 0 {
+		return fmt.Sprintf("topics=%s|group=%s", strings.Join(args.Topics, ","), args.GroupID)
+	}
 	return fmt.Sprintf("topic=%s|group=%s", args.Topic, args.GroupID)
 }
 
@@ -179,6 +218,11 @@ func (a *TopicAdapter) getReader(args ReceiveArguments) (*kafka.Reader, error) {
 		return nil, err
 	}
 
+	dialer, err := buildDialer(a.connArgs, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Verbose("📥 creating Kafka reader...")
 
 	readerConfig := kafka.ReaderConfig{
@@ -191,7 +235,11 @@ func (a *TopicAdapter) getReader(args ReceiveArguments) (*kafka.Reader, error) {
 		MinBytes: 1,
 		MaxBytes: 10e6,        // 10MB
 		MaxWait:  time.Second, // cap the broker long-poll so an empty partition keeps --timeout responsive
-		Dialer:   buildDialer(a.connArgs, tlsConfig),
+		Dialer:   dialer,
+	}
+	if len(args.Topics) > 0 {
+		readerConfig.Topic = ""
+		readerConfig.GroupTopics = args.Topics
 	}
 
 	if args.Partition >= 0 {
@@ -222,20 +270,64 @@ func (a *TopicAdapter) Close() error {
 	a.readerMu.Lock()
 	reader := a.reader
 	a.reader = nil
+	if a.bounded != nil {
+		a.bounded.cancel()
+		a.bounded = nil
+	}
 	a.readerMu.Unlock()
 
 	var err error
 	if reader != nil {
 		err = reader.Close()
 	}
+	a.writerMu.Lock()
+	defer a.writerMu.Unlock()
 	if a.writer != nil {
 		if werr := a.writer.Close(); werr != nil && err == nil {
 			err = werr
 		}
+		a.writer = nil
 	}
 	return err
 }
 
+// receivedMessage converts a record the Reader fetched and marks it as a
+// tombstone when its value is null. The Reader decodes a null and an empty
+// value alike, so only empty records are looked up again (see nullValue); if
+// that lookup fails the record is reported as an empty value, never guessed
+// to be a delete.
+func (a *TopicAdapter) receivedMessage(ctx context.Context, msg *kafka.Message, withMetadata bool) *backends.Message {
+	result := convertKafkaToBackendMessage(msg, withMetadata)
+	if len(msg.Value) > 0 {
+		return result
+	}
+	client, err := a.fetchClient()
+	if err == nil {
+		result.Tombstone, err = nullValue(ctx, client, msg.Topic, msg.Partition, msg.Offset)
+	}
+	if err != nil {
+		log.Verbose("⚠️  %s partition %d offset %d: cannot tell a null from an empty value: %v", msg.Topic, msg.Partition, msg.Offset, err)
+	}
+	return result
+}
+
+// fetchClient returns the adapter's protocol-level client, creating it on
+// first use.
+func (a *TopicAdapter) fetchClient() (*kafka.Client, error) {
+	a.clientMu.Lock()
+	defer a.clientMu.Unlock()
+	if a.client == nil {
+		client, _, err := newAdminClient(a.connArgs)
+		if err != nil {
+			return nil, err
+		}
+		a.client = client
+	}
+	return a.client, nil
+}
+
+// convertKafkaToBackendMessage converts a fetched record. Tombstone is left to
+// receivedMessage, which can tell a null value from an empty one.
 func convertKafkaToBackendMessage(msg *kafka.Message, withMetadata bool) *backends.Message {
 	result := &backends.Message{
 		Data:       msg.Value,
@@ -3,8 +3,10 @@
 package broker
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/makibytes/xmc/broker/backends"
 	"github.com/makibytes/xmc/broker/mqtt"
@@ -23,6 +25,8 @@ func GetRootCommand() *cobra.Command {
 
 	var group string
 	var mqttVersion int
+	var manageOpts mqtt.ManageOptions
+	var retainedOpts mqtt.ManageOptions
 
 	defaultVersion := 5
 	if v := os.Getenv("MMC_MQTT_VERSION"); v == "3" || v == "4" {
@@ -50,6 +54,17 @@ func GetRootCommand() *cobra.Command {
 		}
 	}
 
+	versionedOpts := func(opts mqtt.ManageOptions) (mqtt.ManageOptions, error) {
+		switch mqttVersion {
+		case 5:
+		case 3, 4:
+			opts.V3 = true
+		default:
+			return opts, fmt.Errorf("unsupported --mqtt-version %d (use 5 or 3)", mqttVersion)
+		}
+		return opts, nil
+	}
+
 	return cmd.NewRootCommand(cmd.BrokerSpec{
 		Use:       "mmc",
 		Short:     "MQTT Messaging Client",
@@ -73,6 +88,9 @@ func GetRootCommand() *cobra.Command {
 		},
 		ConsumeFlags: func(c *cobra.Command) {
 			c.Flags().Int("qos", 1, "QoS level for subscription (0, 1, or 2)")
+			c.Flags().Bool("no-local", false, "Don't receive messages this client publishes (MQTT 5)")
+			c.Flags().Bool("retain-as-published", false, "Keep the retain flag of forwarded messages as published (MQTT 5)")
+			c.Flags().Int("retain-handling", 0, "Retained messages on subscribe: 0 send, 1 only for a new subscription, 2 never (MQTT 5)")
 		},
 		ConsumeExtra: func(c *cobra.Command) map[string]string {
 			extra := make(map[string]string)
@@ -81,10 +99,19 @@ func GetRootCommand() *cobra.Command {
 			if group != "" {
 				extra["group"] = group
 			}
+			for _, name := range []string{"no-local", "retain-as-published"} {
+				if v, _ := c.Flags().GetBool(name); v {
+					extra[name] = "true"
+				}
+			}
+			if c.Flags().Changed("retain-handling") {
+				rh, _ := c.Flags().GetInt("retain-handling")
+				extra["retain-handling"] = fmt.Sprintf("%d", rh)
+			}
 			return extra
 		},
 		RegisterFlags: func(c *cobra.Command) {
-			c.PersistentFlags().StringVarP(&connArgs.Server, "server", "s", defaultServer, "MQTT broker URL")
+			c.PersistentFlags().StringVarP(&connArgs.Server, "server", "s", defaultServer, "MQTT broker URL (tcp://, ssl://, ws:// or wss://)")
 			c.PersistentFlags().StringVarP(&connArgs.User, "user", "u", os.Getenv("MMC_USER"), "Username")
 			c.PersistentFlags().StringVarP(&connArgs.Password, "password", "p", os.Getenv("MMC_PASSWORD"), "Password")
 			c.PersistentFlags().StringVar(&connArgs.ClientID, "client-id", "", "MQTT client ID (auto-generated if empty)")
@@ -93,18 +120,84 @@ func GetRootCommand() *cobra.Command {
 			// bridge, which has a different meaning and default.
 			c.PersistentFlags().StringVar(&group, "queue-group", "xmc", "Queue shared subscription group name")
 			c.PersistentFlags().IntVar(&mqttVersion, "mqtt-version", defaultVersion, "MQTT protocol version: 5 (default) or 3 (legacy 3.1.1, no metadata support)")
+			c.PersistentFlags().StringVar(&connArgs.WSPath, "ws-path", "", "WebSocket path for ws:// and wss:// servers (default: the URL's path, or /mqtt)")
+			c.PersistentFlags().StringArrayVar(&connArgs.WSHeaders, "ws-header", nil, "WebSocket upgrade request header, Name=value (repeatable)")
+			c.PersistentFlags().DurationVar(&connArgs.SessionExpiry, "session-expiry", 0, "Keep the session this long after disconnecting and resume it on reconnect, e.g. 1h (requires --client-id; 0: clean session)")
+			c.PersistentFlags().StringVar(&connArgs.Will.Topic, "will-topic", "", "Last-will topic, published by the broker if the connection is lost")
+			c.PersistentFlags().StringVar(&connArgs.Will.Message, "will-message", "", "Last-will message")
+			c.PersistentFlags().IntVar(&connArgs.Will.QoS, "will-qos", 0, "Last-will QoS level (0, 1, or 2)")
+			c.PersistentFlags().BoolVar(&connArgs.Will.Retain, "will-retain", false, "Retain the last-will message")
+			c.PersistentFlags().DurationVar(&connArgs.Will.Delay, "will-delay", 0, "Delay the last will, and drop it if the session resumes in time (MQTT 5)")
+			c.PersistentFlags().IntVar(&connArgs.TopicAliasMax, "topic-alias-max", 0, "Topic aliases to use on publish and accept on receive (MQTT 5; 0: none)")
 			backends.RegisterTLSFlags(c, &connArgs.TLS)
 		},
 		Queue: newQueue,
 		Topic: newTopic,
 		Ping:  func() (cmd.Closeable, error) { return newQueue() },
+		ManageSpec: &cmd.ManageSpec{
+			SetupFlags: func(c *cobra.Command) {
+				c.PersistentFlags().DurationVar(&manageOpts.Window, "window", 3*time.Second, "How long to listen for topics (list) or $SYS statistics (stats)")
+				c.PersistentFlags().BoolVar(&manageOpts.Sys, "sys", false, "Also list the broker's $SYS topics")
+			},
+			Objects: []cmd.ObjectType{
+				{
+					Label:        "Topics",
+					Hierarchical: true,
+					List: func() ([]backends.ObjectNode, error) {
+						opts, err := versionedOpts(manageOpts)
+						if err != nil {
+							return nil, err
+						}
+						return mqtt.ListTopics(connArgs, opts)
+					},
+				},
+			},
+			BrokerStats: func() (*backends.BrokerStats, error) {
+				opts, err := versionedOpts(manageOpts)
+				if err != nil {
+					return nil, err
+				}
+				return mqtt.GetBrokerStats(connArgs, opts)
+			},
+		},
+		Retained: &cmd.RetainedSpec{
+			SetupFlags: func(c *cobra.Command) {
+				c.PersistentFlags().DurationVar(&retainedOpts.Window, "window", 5*time.Second, "Longest time to wait for the broker to send the retained messages")
+			},
+			Collect: func(ctx context.Context, filter string) ([]*backends.Message, error) {
+				opts, err := versionedOpts(retainedOpts)
+				if err != nil {
+					return nil, err
+				}
+				return mqtt.CollectRetained(ctx, connArgs, filter, opts)
+			},
+			Clear: func(topics []string) error {
+				t, err := newTopic()
+				if err != nil {
+					return err
+				}
+				defer t.Close()
+				// An empty retained message removes the stored one.
+				for _, topic := range topics {
+					err := t.Publish(context.Background(), backends.PublishOptions{
+						Topic: topic,
+						Extra: map[string]string{"qos": "1", "retain": "true"},
+					})
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			Wildcards: "+#",
+		},
 		Extra: []*cobra.Command{
 			mcp.NewCommand(mcp.Deps{
 				ServerName:    "xmc-mqtt",
 				ServerVersion: cmd.Version(),
 				Target:        connArgs.Server,
-				NewQueue: newQueue,
-				NewTopic: newTopic,
+				NewQueue:      newQueue,
+				NewTopic:      newTopic,
 			}),
 		},
 	})
@@ -10,26 +10,56 @@ import (
 
 	pahomqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/makibytes/xmc/broker/backends"
-	"github.com/makibytes/xmc/broker/tlsutil"
 )
 
 // ConnArguments holds MQTT connection parameters.
 type ConnArguments struct {
 	backends.CommonConnArgs
-	ClientID string // auto-generated if empty
+	ClientID  string   // auto-generated if empty
+	WSPath    string   // WebSocket path for ws:// and wss:// (default: the URL's, or /mqtt)
+	WSHeaders []string // extra WebSocket upgrade request headers, "Name=value"
+
+	SessionExpiry time.Duration // keep the session this long after disconnecting and resume it (0: clean session)
+	Will          WillOptions   // last will, published if the connection is lost
+	TopicAliasMax int           // MQTT 5: topic aliases used on publish and accepted from the broker
 }
 
 // ConnectV3 creates and connects a legacy MQTT 3.1.1 client (--mqtt-version 3).
 func ConnectV3(args ConnArguments) (pahomqtt.Client, error) {
+	return connectV3(args, nil)
+}
+
+// connectV3 is ConnectV3 with a handler for the messages no subscription
+// routes, such as those a resumed session delivers before the subscriptions
+// are renewed.
+func connectV3(args ConnArguments, unrouted pahomqtt.MessageHandler) (pahomqtt.Client, error) {
+	if err := validateSession(args, true); err != nil {
+		return nil, err
+	}
 	clientID := args.ClientID
 	if clientID == "" {
 		clientID = fmt.Sprintf("xmc-%d-%d", os.Getpid(), rand.Int31()) //nolint:gosec
 	}
 
+	u, err := brokerURL(args)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg, err := brokerTLS(args, u)
+	if err != nil {
+		return nil, err
+	}
+	header, err := wsHeader(args.WSHeaders)
+	if err != nil {
+		return nil, err
+	}
+
 	opts := pahomqtt.NewClientOptions().
-		AddBroker(args.Server).
+		AddBroker(u.String()).
 		SetClientID(clientID).
-		SetCleanSession(true).
+		// 3.1.1 has no session expiry: the broker keeps a non-clean
+		// session until its own limit.
+		SetCleanSession(args.SessionExpiry == 0).
 		SetAutoReconnect(true).
 		SetConnectRetry(true).
 		SetMaxReconnectInterval(30 * time.Second)
@@ -39,13 +69,18 @@ func ConnectV3(args ConnArguments) (pahomqtt.Client, error) {
 		opts.SetPassword(args.Password)
 	}
 
-	if args.TLS.Enabled {
-		tlsCfg, err := tlsutil.BuildTLSConfig(args.TLS)
-		if err != nil {
-			return nil, fmt.Errorf("TLS configuration error: %w", err)
-		}
+	if tlsCfg != nil {
 		opts.SetTLSConfig(tlsCfg)
 	}
+	if header != nil {
+		opts.SetHTTPHeaders(header)
+	}
+	if w := args.Will; w.Topic != "" {
+		opts.SetWill(w.Topic, w.Message, byte(w.QoS), w.Retain)
+	}
+	if unrouted != nil {
+		opts.SetDefaultPublishHandler(unrouted)
+	}
 
 	client := pahomqtt.NewClient(opts)
 	token := client.Connect()
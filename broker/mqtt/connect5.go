@@ -6,6 +6,7 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"sync"
@@ -14,32 +15,37 @@ import (
 	"github.com/eclipse/paho.golang/paho"
 
 	"github.com/makibytes/xmc/broker/backends"
-	"github.com/makibytes/xmc/broker/tlsutil"
 )
 
 // Connect5 creates and connects an MQTT 5 client (the default). The connection
 // manager reconnects automatically; call Disconnect to release it.
 func Connect5(args ConnArguments) (*autopaho.ConnectionManager, error) {
+	return connect5(args, nil)
+}
+
+// connect5 is Connect5 with onPublish as the connection's publish handler.
+// Unlike handlers added with AddOnPublishReceived, it survives reconnects
+// and sees the messages a resumed session delivers right after connecting.
+func connect5(args ConnArguments, onPublish func(paho.PublishReceived) (bool, error)) (*autopaho.ConnectionManager, error) {
+	if err := validateSession(args, false); err != nil {
+		return nil, err
+	}
 	clientID := args.ClientID
 	if clientID == "" {
 		clientID = fmt.Sprintf("xmc-%d-%s", os.Getpid(), backends.RandomSuffix())
 	}
 
-	u, err := url.Parse(args.Server)
+	u, err := brokerURL(args)
 	if err != nil {
-		return nil, fmt.Errorf("invalid MQTT server URL %q: %w", args.Server, err)
+		return nil, err
 	}
-
-	var tlsCfg *tls.Config
-	if args.TLS.Enabled {
-		tlsCfg, err = tlsutil.BuildTLSConfig(args.TLS)
-		if err != nil {
-			return nil, fmt.Errorf("TLS configuration error: %w", err)
-		}
-		// --tls with a plain scheme: upgrade so the dialer actually uses TLS.
-		if u.Scheme == "tcp" || u.Scheme == "mqtt" || u.Scheme == "" {
-			u.Scheme = "ssl"
-		}
+	tlsCfg, err := brokerTLS(args, u)
+	if err != nil {
+		return nil, err
+	}
+	header, err := wsHeader(args.WSHeaders)
+	if err != nil {
+		return nil, err
 	}
 
 	// Surface the real reason when the initial connection can't be made;
@@ -51,7 +57,8 @@ func Connect5(args ConnArguments) (*autopaho.ConnectionManager, error) {
 		ServerUrls:                    []*url.URL{u},
 		TlsCfg:                        tlsCfg,
 		KeepAlive:                     30,
-		CleanStartOnInitialConnection: true,
+		CleanStartOnInitialConnection: args.SessionExpiry == 0,
+		SessionExpiryInterval:         expirySeconds(args.SessionExpiry),
 		ConnectUsername:               args.User,
 		OnConnectError: func(err error) {
 			lastErrMu.Lock()
@@ -65,6 +72,43 @@ func Connect5(args ConnArguments) (*autopaho.ConnectionManager, error) {
 	if args.User != "" {
 		cfg.ConnectPassword = []byte(args.Password)
 	}
+	if header != nil {
+		cfg.WebSocketCfg = &autopaho.WebSocketConfig{
+			Header: func(*url.URL, *tls.Config) http.Header { return header },
+		}
+	}
+	if w := args.Will; w.Topic != "" {
+		cfg.WillMessage = &paho.WillMessage{Topic: w.Topic, Payload: []byte(w.Message), QoS: byte(w.QoS), Retain: w.Retain}
+		if w.Delay > 0 {
+			cfg.WillProperties = &paho.WillProperties{WillDelayInterval: paho.Uint32(expirySeconds(w.Delay))}
+		}
+	}
+	if args.TopicAliasMax > 0 {
+		aliasMax := uint16(args.TopicAliasMax)
+		var out topicAliases
+		in := inboundAliases{topics: make(map[uint16]string)}
+		cfg.ConnectPacketBuilder = func(cp *paho.Connect, _ *url.URL) (*paho.Connect, error) {
+			if cp.Properties == nil {
+				cp.Properties = &paho.ConnectProperties{}
+			}
+			cp.Properties.TopicAliasMaximum = &aliasMax
+			return cp, nil
+		}
+		cfg.OnConnectionUp = func(_ *autopaho.ConnectionManager, ca *paho.Connack) {
+			// The broker's limit for ours; its own start over with the
+			// connection too, but each is sent with its topic first.
+			var brokerMax uint16
+			if ca.Properties != nil && ca.Properties.TopicAliasMaximum != nil {
+				brokerMax = *ca.Properties.TopicAliasMaximum
+			}
+			out.reset(min(aliasMax, brokerMax))
+		}
+		cfg.PublishHook = out.publishHook
+		cfg.OnPublishReceived = append(cfg.OnPublishReceived, in.resolve)
+	}
+	if onPublish != nil {
+		cfg.OnPublishReceived = append(cfg.OnPublishReceived, onPublish)
+	}
 
 	// The context passed to NewConnection bounds the manager's lifetime, not
 	// one command, so adapters own it until Close → Disconnect.
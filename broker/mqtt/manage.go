@@ -0,0 +1,296 @@
+//go:build mqtt
+
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// MQTT brokers have no management API: topics exist only while messages flow
+// through them. manage therefore listens for a while — to "#" for topic
+// discovery and to "$SYS/#" for the statistics most brokers publish there.
+
+// ManageOptions configures the listening manage commands.
+type ManageOptions struct {
+	Window time.Duration // how long to listen
+	Sys    bool          // list also discovers $SYS topics ("#" does not match them)
+	V3     bool          // connect with MQTT 3.1.1 (--mqtt-version 3)
+}
+
+// topicActivity is what list saw of one topic during the window.
+type topicActivity struct {
+	messages int64     // live messages, not counting a retained one
+	lastSeen time.Time // of the last live message
+	retained bool      // the broker holds a retained message for it
+}
+
+// ListTopics discovers the topics that carry messages during the window (or
+// hold a retained one) and returns them as a tree: one node per first topic
+// level, with the topics below it as children.
+func ListTopics(args ConnArguments, opts ManageOptions) ([]backends.ObjectNode, error) {
+	filters := []string{"#"}
+	if opts.Sys {
+		filters = append(filters, "$SYS/#")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Window)
+	defer cancel()
+	topics := make(map[string]*topicActivity)
+	err := watch(ctx, args, opts.V3, filters, nil, func(msg *backends.Message) bool {
+		a := topics[msg.Topic]
+		if a == nil {
+			a = &topicActivity{}
+			topics[msg.Topic] = a
+		}
+		// Retained messages arrive right after subscribing, flagged as such;
+		// live ones are not.
+		if msg.Retained {
+			a.retained = true
+		} else {
+			a.messages++
+			a.lastSeen = time.Now()
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return topicTree(topics, time.Now()), nil
+}
+
+// topicTree groups topics by their first level. Roots report the topics and
+// messages below them; topics report their messages, and how many seconds
+// before now they last had one (last-seen, omitted for topics that only hold
+// a retained message).
+func topicTree(topics map[string]*topicActivity, now time.Time) []backends.ObjectNode {
+	byRoot := make(map[string][]string)
+	for topic := range topics {
+		root, _, _ := strings.Cut(topic, "/")
+		byRoot[root] = append(byRoot[root], topic)
+	}
+
+	nodes := make([]backends.ObjectNode, 0, len(byRoot))
+	for root, names := range byRoot {
+		slices.Sort(names)
+		var total int64
+		children := make([]backends.ObjectNode, 0, len(names))
+		for _, name := range names {
+			a := topics[name]
+			total += a.messages
+			child := backends.ObjectNode{Name: name, Metrics: []backends.Metric{{Label: "msgs", Value: a.messages}}}
+			if a.retained {
+				child.Kind = "retained"
+			}
+			if a.messages > 0 {
+				child.Metrics = append(child.Metrics, backends.Metric{Label: "last-seen", Value: int64(now.Sub(a.lastSeen).Seconds())})
+			}
+			children = append(children, child)
+		}
+		nodes = append(nodes, backends.ObjectNode{
+			Name: root,
+			Metrics: []backends.Metric{
+				{Label: "topics", Value: int64(len(names))},
+				{Label: "msgs", Value: total},
+			},
+			Children: children,
+		})
+	}
+	slices.SortFunc(nodes, func(a, b backends.ObjectNode) int { return strings.Compare(a.Name, b.Name) })
+	return nodes
+}
+
+// GetBrokerStats reads the broker statistics from $SYS. Brokers publish them
+// retained (Mosquitto) or periodically (EMQX, every minute by default), so it
+// listens until every counter has arrived or the window ends.
+func GetBrokerStats(args ConnArguments, opts ManageOptions) (*backends.BrokerStats, error) {
+	stats := &backends.BrokerStats{ClientsConnected: -1, MessagesReceived: -1, MessagesSent: -1, Subscriptions: -1, RetainedMessages: -1}
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Window)
+	defer cancel()
+	var seen int
+	err := watch(ctx, args, opts.V3, []string{"$SYS/#"}, nil, func(msg *backends.Message) bool {
+		if applySysStat(stats, msg.Topic, string(msg.Data)) {
+			seen++
+		}
+		return brokerStatsComplete(stats)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if seen == 0 {
+		return nil, fmt.Errorf("no broker statistics on $SYS within %s (the broker may not publish them, or may not allow this user to read them; try a longer --window)", opts.Window)
+	}
+	return stats, nil
+}
+
+// applySysStat stores a $SYS value in stats and reports whether it was one
+// of the known statistics. Topics are matched below "$SYS/broker/"
+// (Mosquitto and most others) or "$SYS/brokers/<node>/" (EMQX).
+func applySysStat(stats *backends.BrokerStats, topic, value string) bool {
+	var key string
+	switch {
+	case strings.HasPrefix(topic, "$SYS/broker/"):
+		key = strings.TrimPrefix(topic, "$SYS/broker/")
+	case strings.HasPrefix(topic, "$SYS/brokers/"):
+		_, key, _ = strings.Cut(strings.TrimPrefix(topic, "$SYS/brokers/"), "/")
+	default:
+		return false
+	}
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case "version":
+		stats.Version = value
+		return true
+	case "uptime":
+		// Mosquitto says "3600 seconds", EMQX publishes plain seconds.
+		if n, err := strconv.ParseInt(strings.TrimSuffix(value, " seconds"), 10, 64); err == nil {
+			value = (time.Duration(n) * time.Second).String()
+		}
+		stats.Uptime = value
+		return true
+	}
+
+	var counter *int64
+	switch key {
+	case "clients/connected", "stats/connections/count":
+		counter = &stats.ClientsConnected
+	case "messages/received", "metrics/messages/received":
+		counter = &stats.MessagesReceived
+	case "messages/sent", "metrics/messages/sent":
+		counter = &stats.MessagesSent
+	case "subscriptions/count", "stats/subscriptions/count":
+		counter = &stats.Subscriptions
+	case "retained messages/count", "stats/retained/count":
+		counter = &stats.RetainedMessages
+	default:
+		return false
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false
+	}
+	*counter = n
+	return true
+}
+
+func brokerStatsComplete(s *backends.BrokerStats) bool {
+	return s.Version != "" && s.Uptime != "" && s.ClientsConnected >= 0 && s.MessagesReceived >= 0 &&
+		s.MessagesSent >= 0 && s.Subscriptions >= 0 && s.RetainedMessages >= 0
+}
+
+// watch subscribes to filters with QoS 0 and calls fn for every message,
+// labelled with its topic, until ctx ends or fn returns true. subscribed, if
+// set, is called once the broker has acknowledged the subscription. Calls to
+// subscribed and fn are serialized and none happen after watch returns. The
+// connection is ephemeral: it neither resumes the configured session nor
+// carries its will.
+func watch(ctx context.Context, args ConnArguments, v3 bool, filters []string, subscribed func(), fn func(*backends.Message) bool) error {
+	args = ephemeral(args)
+	var mu sync.Mutex
+	done := make(chan struct{})
+	var once sync.Once
+	finish := func() { once.Do(func() { close(done) }) }
+	handle := func(msg *backends.Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if fn(msg) {
+			finish()
+		}
+	}
+
+	var disconnect func()
+	var err error
+	if v3 {
+		disconnect, err = watchV3(args, filters, handle)
+	} else {
+		disconnect, err = watch5(args, filters, handle)
+	}
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+	if subscribed != nil {
+		mu.Lock()
+		subscribed()
+		mu.Unlock()
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-done:
+	}
+	mu.Lock()
+	finish()
+	mu.Unlock()
+	return nil
+}
+
+func watch5(args ConnArguments, filters []string, handle func(*backends.Message)) (func(), error) {
+	cm, err := Connect5(args)
+	if err != nil {
+		return nil, err
+	}
+	disconnect := func() { _ = cm.Disconnect(context.Background()) }
+
+	cm.AddOnPublishReceived(func(pr autopaho.PublishReceived) (bool, error) {
+		msg := convertPublish(pr.Packet, false)
+		msg.Topic = pr.Packet.Topic
+		handle(msg)
+		return true, nil
+	})
+	subs := make([]paho.SubscribeOptions, len(filters))
+	for i, f := range filters {
+		subs[i] = paho.SubscribeOptions{Topic: f}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tokenTimeout)
+	defer cancel()
+	if _, err := cm.Subscribe(ctx, &paho.Subscribe{Subscriptions: subs}); err != nil {
+		disconnect()
+		return nil, fmt.Errorf("MQTT subscribe to %s: %w", strings.Join(filters, ", "), err)
+	}
+	return disconnect, nil
+}
+
+func watchV3(args ConnArguments, filters []string, handle func(*backends.Message)) (func(), error) {
+	client, err := ConnectV3(args)
+	if err != nil {
+		return nil, err
+	}
+	disconnect := func() { client.Disconnect(250) }
+
+	subs := make(map[string]byte, len(filters))
+	for _, f := range filters {
+		subs[f] = 0
+	}
+	token := client.SubscribeMultiple(subs, func(_ pahomqtt.Client, msg pahomqtt.Message) {
+		m := convertMessageV3(msg)
+		m.Topic = msg.Topic()
+		handle(m)
+	})
+	if !token.WaitTimeout(tokenTimeout) {
+		disconnect()
+		return nil, fmt.Errorf("MQTT subscribe to %s timed out", strings.Join(filters, ", "))
+	}
+	if err := token.Error(); err != nil {
+		disconnect()
+		return nil, fmt.Errorf("MQTT subscribe to %s: %w", strings.Join(filters, ", "), err)
+	}
+	return disconnect, nil
+}
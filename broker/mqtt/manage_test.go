@@ -0,0 +1,76 @@
+//go:build mqtt
+
+package mqtt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+func TestTopicTree(t *testing.T) {
+	now := time.Now()
+	nodes := topicTree(map[string]*topicActivity{
+		"sensors/kitchen/temp": {messages: 3, lastSeen: now.Add(-2 * time.Second)},
+		"sensors/hall/temp":    {retained: true},
+		"alerts":               {messages: 1, lastSeen: now, retained: true},
+	}, now)
+
+	if len(nodes) != 2 || nodes[0].Name != "alerts" || nodes[1].Name != "sensors" {
+		t.Fatalf("roots = %v, want alerts and sensors", nodes)
+	}
+	sensors := nodes[1]
+	if got := sensors.Metrics; len(got) != 2 || got[0] != (backends.Metric{Label: "topics", Value: 2}) || got[1] != (backends.Metric{Label: "msgs", Value: 3}) {
+		t.Errorf("sensors metrics = %v", got)
+	}
+	if len(sensors.Children) != 2 {
+		t.Fatalf("sensors children = %v", sensors.Children)
+	}
+	hall, kitchen := sensors.Children[0], sensors.Children[1]
+	if hall.Name != "sensors/hall/temp" || hall.Kind != "retained" || len(hall.Metrics) != 1 || hall.Metrics[0].Value != 0 {
+		t.Errorf("retained-only topic = %+v", hall)
+	}
+	if kitchen.Kind != "" || len(kitchen.Metrics) != 2 || kitchen.Metrics[0].Value != 3 || kitchen.Metrics[1] != (backends.Metric{Label: "last-seen", Value: 2}) {
+		t.Errorf("live topic = %+v", kitchen)
+	}
+	if alerts := nodes[0]; len(alerts.Children) != 1 || alerts.Children[0].Kind != "retained" || alerts.Children[0].Metrics[0].Value != 1 {
+		t.Errorf("alerts = %+v", alerts)
+	}
+}
+
+func TestApplySysStat(t *testing.T) {
+	stats := &backends.BrokerStats{ClientsConnected: -1, MessagesReceived: -1, MessagesSent: -1, Subscriptions: -1, RetainedMessages: -1}
+	for topic, value := range map[string]string{
+		"$SYS/broker/version":                               "mosquitto version 2.0.18",
+		"$SYS/broker/uptime":                                "3725 seconds",
+		"$SYS/broker/clients/connected":                     "4",
+		"$SYS/broker/messages/received":                     "1200",
+		"$SYS/broker/retained messages/count":               "12",
+		"$SYS/brokers/emqx@node1/metrics/messages/sent":     "900",
+		"$SYS/brokers/emqx@node1/stats/subscriptions/count": "8",
+	} {
+		if !applySysStat(stats, topic, value) {
+			t.Errorf("applySysStat(%q) not recognized", topic)
+		}
+	}
+	want := backends.BrokerStats{
+		Version: "mosquitto version 2.0.18", Uptime: "1h2m5s",
+		ClientsConnected: 4, MessagesReceived: 1200, MessagesSent: 900, Subscriptions: 8, RetainedMessages: 12,
+	}
+	if *stats != want {
+		t.Errorf("stats = %+v, want %+v", *stats, want)
+	}
+	if !brokerStatsComplete(stats) {
+		t.Error("brokerStatsComplete = false for a complete set")
+	}
+
+	for _, topic := range []string{"$SYS/broker/load/bytes/received", "sensors/temp"} {
+		if applySysStat(stats, topic, "1") {
+			t.Errorf("applySysStat(%q) recognized an unknown topic", topic)
+		}
+	}
+	if applySysStat(stats, "$SYS/broker/clients/connected", "n/a") {
+		t.Error("applySysStat accepted a non-numeric counter")
+	}
+}
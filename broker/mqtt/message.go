@@ -51,6 +51,7 @@ func convertPublish(msg *paho.Publish, stripQueuePrefix bool) *backends.Message
 	result := &backends.Message{
 		Data:       data,
 		Properties: make(map[string]any),
+		Retained:   msg.Retain,
 	}
 
 	p := msg.Properties
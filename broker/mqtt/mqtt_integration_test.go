@@ -15,7 +15,7 @@ import (
 	"github.com/makibytes/xmc/test/integration"
 )
 
-var testServer string
+var testServer, testWSServer string
 
 func TestMain(m *testing.M) {
 	ctx := context.Background()
@@ -26,6 +26,7 @@ func TestMain(m *testing.M) {
 	}
 	defer broker.Terminate(ctx)
 	testServer = broker.URL
+	testWSServer = broker.WebSocketURL
 	os.Exit(m.Run())
 }
 
@@ -442,3 +443,240 @@ func TestMQTT_V3_RejectsMetadata(t *testing.T) {
 		t.Errorf("Send: got %v, want metadata-requires-MQTT-5 error", err)
 	}
 }
+
+// TestMQTT_ManageListAndStats verifies topic discovery (a retained topic and
+// one with live traffic during the window) and the $SYS statistics Mosquitto
+// publishes.
+func TestMQTT_ManageListAndStats(t *testing.T) {
+	t.Parallel()
+	root := "manage" + randomSuffix()
+	retainedTopic := root + "/config"
+	liveTopic := root + "/events"
+
+	pub, err := NewTopicAdapter(makeConnArgs())
+	if err != nil {
+		t.Fatalf("NewTopicAdapter: %v", err)
+	}
+	defer pub.Close()
+	if err := pub.Publish(context.Background(), backends.PublishOptions{Topic: retainedTopic, Message: []byte("v1"), Extra: map[string]string{"retain": "true"}}); err != nil {
+		t.Fatalf("Publish retained: %v", err)
+	}
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		for range 3 {
+			_ = pub.Publish(context.Background(), backends.PublishOptions{Topic: liveTopic, Message: []byte("e")})
+		}
+	}()
+
+	nodes, err := ListTopics(makeConnArgs(), ManageOptions{Window: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("ListTopics: %v", err)
+	}
+	var found *backends.ObjectNode
+	for i := range nodes {
+		if nodes[i].Name == root {
+			found = &nodes[i]
+		}
+	}
+	if found == nil || len(found.Children) != 2 {
+		t.Fatalf("ListTopics did not return %s with two topics: %v", root, nodes)
+	}
+	config, events := found.Children[0], found.Children[1]
+	if config.Name != retainedTopic || config.Kind != "retained" {
+		t.Errorf("retained topic = %+v", config)
+	}
+	if events.Name != liveTopic || events.Metrics[0].Value != 3 {
+		t.Errorf("live topic = %+v, want msgs=3", events)
+	}
+	// Clear the retained message.
+	_ = pub.Publish(context.Background(), backends.PublishOptions{Topic: retainedTopic, Extra: map[string]string{"retain": "true"}})
+
+	stats, err := GetBrokerStats(makeConnArgs(), ManageOptions{Window: 15 * time.Second})
+	if err != nil {
+		t.Fatalf("GetBrokerStats: %v", err)
+	}
+	if !strings.HasPrefix(stats.Version, "mosquitto") || stats.ClientsConnected < 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+// TestMQTT_RetainedCollectAndClear verifies that CollectRetained returns only
+// retained messages, flagged as such, and that an empty retained publish
+// clears them.
+func TestMQTT_RetainedCollectAndClear(t *testing.T) {
+	t.Parallel()
+	root := "retained" + randomSuffix()
+	opts := ManageOptions{Window: 5 * time.Second}
+
+	pub, err := NewTopicAdapter(makeConnArgs())
+	if err != nil {
+		t.Fatalf("NewTopicAdapter: %v", err)
+	}
+	defer pub.Close()
+	retain := map[string]string{"retain": "true"}
+	for _, topic := range []string{root + "/a", root + "/b"} {
+		if err := pub.Publish(context.Background(), backends.PublishOptions{Topic: topic, Message: []byte("state"), ContentType: "text/plain", Extra: retain}); err != nil {
+			t.Fatalf("Publish %s: %v", topic, err)
+		}
+	}
+	if err := pub.Publish(context.Background(), backends.PublishOptions{Topic: root + "/live", Message: []byte("event")}); err != nil {
+		t.Fatalf("Publish live: %v", err)
+	}
+
+	msgs, err := CollectRetained(context.Background(), makeConnArgs(), root+"/#", opts)
+	if err != nil {
+		t.Fatalf("CollectRetained: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].Topic != root+"/a" || msgs[1].Topic != root+"/b" {
+		t.Fatalf("CollectRetained = %v, want %s/a and %s/b", msgs, root, root)
+	}
+	if !msgs[0].Retained || string(msgs[0].Data) != "state" || msgs[0].ContentType != "text/plain" {
+		t.Errorf("retained message = %+v", msgs[0])
+	}
+
+	for _, m := range msgs {
+		if err := pub.Publish(context.Background(), backends.PublishOptions{Topic: m.Topic, Extra: retain}); err != nil {
+			t.Fatalf("clear %s: %v", m.Topic, err)
+		}
+	}
+	msgs, err = CollectRetained(context.Background(), makeConnArgs(), root+"/#", opts)
+	if err != nil {
+		t.Fatalf("CollectRetained after clear: %v", err)
+	}
+	if len(msgs) != 0 {
+		t.Errorf("CollectRetained after clear = %v, want none", msgs)
+	}
+}
+
+// TestMQTT_WebSocket publishes and subscribes over ws:// with both clients,
+// sending a custom upgrade header (Mosquitto ignores the path and headers).
+func TestMQTT_WebSocket(t *testing.T) {
+	t.Parallel()
+	wsArgs := func() ConnArguments {
+		args := makeConnArgs()
+		args.Server = testWSServer
+		args.WSHeaders = []string{"X-Test=websocket"}
+		return args
+	}
+
+	for _, v3 := range []bool{false, true} {
+		topic := fmt.Sprintf("ws%s/v3=%v", randomSuffix(), v3)
+		var pub, sub backends.TopicBackend
+		var err error
+		if v3 {
+			pub, err = NewTopicAdapterV3(wsArgs())
+		} else {
+			pub, err = NewTopicAdapter(wsArgs())
+		}
+		if err != nil {
+			t.Fatalf("connect over WebSocket (v3=%v): %v", v3, err)
+		}
+		defer pub.Close()
+		if v3 {
+			sub, err = NewTopicAdapterV3(wsArgs())
+		} else {
+			sub, err = NewTopicAdapter(wsArgs())
+		}
+		if err != nil {
+			t.Fatalf("connect over WebSocket (v3=%v): %v", v3, err)
+		}
+		defer sub.Close()
+
+		retain := map[string]string{"retain": "true"}
+		if err := pub.Publish(context.Background(), backends.PublishOptions{Topic: topic, Message: []byte("over ws"), Extra: retain}); err != nil {
+			t.Fatalf("Publish (v3=%v): %v", v3, err)
+		}
+		msg, err := sub.Subscribe(context.Background(), backends.SubscribeOptions{Topic: topic, Timeout: 5})
+		if err != nil {
+			t.Fatalf("Subscribe (v3=%v): %v", v3, err)
+		}
+		if string(msg.Data) != "over ws" || !msg.Retained {
+			t.Errorf("received (v3=%v) %q retained=%v, want retained \"over ws\"", v3, msg.Data, msg.Retained)
+		}
+		_ = pub.Publish(context.Background(), backends.PublishOptions{Topic: topic, Extra: retain})
+	}
+}
+
+// TestMQTT_PersistentSession checks that a subscriber with --client-id and
+// --session-expiry picks up the QoS 1 messages published while it was away,
+// with both clients.
+func TestMQTT_PersistentSession(t *testing.T) {
+	t.Parallel()
+	for _, v3 := range []bool{false, true} {
+		topic := fmt.Sprintf("session%s/v3=%v", randomSuffix(), v3)
+		args := makeConnArgs()
+		args.SessionExpiry = time.Minute
+		connect := func() (backends.TopicBackend, error) {
+			if v3 {
+				return NewTopicAdapterV3(args)
+			}
+			return NewTopicAdapter(args)
+		}
+
+		sub, err := connect()
+		if err != nil {
+			t.Fatalf("connect (v3=%v): %v", v3, err)
+		}
+		_, err = sub.Subscribe(context.Background(), backends.SubscribeOptions{Topic: topic, Timeout: 0.2})
+		if err != backends.ErrNoMessageAvailable {
+			t.Fatalf("Subscribe (v3=%v): %v", v3, err)
+		}
+		sub.Close()
+
+		pub, err := NewTopicAdapter(makeConnArgs())
+		if err != nil {
+			t.Fatalf("NewTopicAdapter: %v", err)
+		}
+		defer pub.Close()
+		if err := pub.Publish(context.Background(), backends.PublishOptions{Topic: topic, Message: []byte("missed")}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+
+		sub, err = connect()
+		if err != nil {
+			t.Fatalf("reconnect (v3=%v): %v", v3, err)
+		}
+		defer sub.Close()
+		msg, err := sub.Subscribe(context.Background(), backends.SubscribeOptions{Topic: topic, Timeout: 5})
+		if err != nil {
+			t.Fatalf("Subscribe after reconnect (v3=%v): %v", v3, err)
+		}
+		if string(msg.Data) != "missed" {
+			t.Errorf("received (v3=%v) %q, want \"missed\"", v3, msg.Data)
+		}
+	}
+}
+
+// TestMQTT_Will checks that the broker publishes the will when the
+// connection drops without a DISCONNECT.
+func TestMQTT_Will(t *testing.T) {
+	t.Parallel()
+	topic := "will" + randomSuffix()
+
+	sub, err := NewTopicAdapter(makeConnArgs())
+	if err != nil {
+		t.Fatalf("NewTopicAdapter: %v", err)
+	}
+	defer sub.Close()
+	_, err = sub.Subscribe(context.Background(), backends.SubscribeOptions{Topic: topic, Timeout: 0.2})
+	if err != backends.ErrNoMessageAvailable {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	args := makeConnArgs()
+	args.Will = WillOptions{Topic: topic, Message: "offline", QoS: 1}
+	device, err := NewTopicAdapter(args)
+	if err != nil {
+		t.Fatalf("NewTopicAdapter with will: %v", err)
+	}
+	defer device.Close()
+	device.cm.TerminateConnectionForTest()
+
+	msg, err := sub.Subscribe(context.Background(), backends.SubscribeOptions{Topic: topic, Timeout: 5})
+	if err != nil {
+		t.Fatalf("Subscribe to will: %v", err)
+	}
+	if string(msg.Data) != "offline" {
+		t.Errorf("will = %q, want \"offline\"", msg.Data)
+	}
+}
@@ -0,0 +1,100 @@
+//go:build mqtt
+
+package mqtt
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// SubscribeTopics implements backends.MultiTopicBackend.
+// Every topic is an MQTT topic filter (+ and # wildcards) with its own cached
+// subscription; the next message from any of them is returned, labelled with
+// the topic it was published on. Overlapping filters deliver a matching
+// message once per filter, as MQTT does for overlapping subscriptions.
+func (a *TopicAdapter) SubscribeTopics(ctx context.Context, topics []string, opts backends.SubscribeOptions) (*backends.Message, error) {
+	chans := make([]<-chan *paho.Publish, 0, len(topics))
+	for _, t := range topics {
+		sub, err := subscribeOptions(sharedFilter(t, opts.GroupID), opts.Extra)
+		if err != nil {
+			return nil, err
+		}
+		ch, err := a.subs.channelFor(ctx, a.cm, sub)
+		if err != nil {
+			return nil, err
+		}
+		chans = append(chans, ch)
+	}
+	return waitForAny(ctx, chans, opts.Timeout, opts.Wait, func(p *paho.Publish) *backends.Message {
+		msg := convertPublish(p, false)
+		msg.Topic = p.Topic
+		return msg
+	})
+}
+
+// SubscribeTopics implements backends.MultiTopicBackend for MQTT 3.1.1.
+func (a *TopicAdapterV3) SubscribeTopics(ctx context.Context, topics []string, opts backends.SubscribeOptions) (*backends.Message, error) {
+	if err := rejectV3SubscribeOptions(opts.Extra); err != nil {
+		return nil, err
+	}
+	qos := byte(1)
+	if v, err := strconv.Atoi(opts.Extra["qos"]); err == nil {
+		qos = byte(v)
+	}
+	chans := make([]<-chan pahomqtt.Message, 0, len(topics))
+	for _, t := range topics {
+		ch, err := a.subs.channelFor(a.client, sharedFilter(t, opts.GroupID), qos)
+		if err != nil {
+			return nil, err
+		}
+		chans = append(chans, ch)
+	}
+	return waitForAny(ctx, chans, opts.Timeout, opts.Wait, func(m pahomqtt.Message) *backends.Message {
+		msg := convertMessageV3(m)
+		msg.Topic = m.Topic()
+		return msg
+	})
+}
+
+// sharedFilter turns a topic filter into a shared subscription when a group
+// is given, as Subscribe does. An explicit "$share/<group>/<filter>" is
+// already one and passes through unchanged.
+func sharedFilter(topic, group string) string {
+	if group == "" || strings.HasPrefix(topic, "$share/") {
+		return topic
+	}
+	return "$share/" + group + "/" + topic
+}
+
+// waitForAny is waitForMessage over several subscription channels: it returns
+// the first message to arrive on any of them.
+func waitForAny[T any](ctx context.Context, chans []<-chan T, timeout float32, wait bool, convert func(T) *backends.Message) (*backends.Message, error) {
+	timer := time.NewTimer(backends.TimeoutDuration(timeout, wait))
+	defer timer.Stop()
+
+	cases := make([]reflect.SelectCase, 0, len(chans)+2)
+	for _, ch := range chans {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)})
+	}
+	cases = append(cases,
+		reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timer.C)},
+	)
+
+	chosen, value, _ := reflect.Select(cases)
+	switch chosen {
+	case len(chans):
+		return nil, ctx.Err()
+	case len(chans) + 1:
+		return nil, backends.ErrNoMessageAvailable
+	}
+	return convert(value.Interface().(T)), nil
+}
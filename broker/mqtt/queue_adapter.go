@@ -26,11 +26,13 @@ type QueueAdapter struct {
 
 // NewQueueAdapter creates a connected MQTT 5 QueueAdapter.
 func NewQueueAdapter(args ConnArguments) (*QueueAdapter, error) {
-	cm, err := Connect5(args)
+	a := &QueueAdapter{connArgs: args}
+	cm, err := connect5(args, a.subs.dispatch)
 	if err != nil {
 		return nil, err
 	}
-	return &QueueAdapter{connArgs: args, cm: cm}, nil
+	a.cm = cm
+	return a, nil
 }
 
 // Send implements backends.QueueBackend.
@@ -67,21 +69,23 @@ func (a *QueueAdapter) Send(ctx context.Context, opts backends.SendOptions) erro
 // lifetime so consecutive reads (-n, --for) don't drop messages that arrive
 // between calls.
 func (a *QueueAdapter) Receive(ctx context.Context, opts backends.ReceiveOptions) (*backends.Message, error) {
-	qos := qosFromExtra(opts.Extra)
-
 	if !opts.Acknowledge {
 		// Peek: use a fresh client with a unique clientID so the broker
 		// delivers a copy while shared-group consumers keep theirs.
-		peekArgs := a.connArgs
+		sub, err := subscribeOptions(queueTopicPrefix+opts.Queue, opts.Extra)
+		if err != nil {
+			return nil, err
+		}
+		peekArgs := ephemeral(a.connArgs)
 		peekArgs.ClientID = fmt.Sprintf("xmc-peek-%d-%s", os.Getpid(), backends.RandomSuffix())
-		cm, err := Connect5(peekArgs)
+		var peekSubs subCache5
+		cm, err := connect5(peekArgs, peekSubs.dispatch)
 		if err != nil {
 			return nil, fmt.Errorf("peek connect: %w", err)
 		}
 		defer cm.Disconnect(context.Background()) //nolint:errcheck
 
-		var peekSubs subCache5
-		msgCh, err := peekSubs.channelFor(ctx, cm, queueTopicPrefix+opts.Queue, qos)
+		msgCh, err := peekSubs.channelFor(ctx, cm, sub)
 		if err != nil {
 			return nil, err
 		}
@@ -93,9 +97,11 @@ func (a *QueueAdapter) Receive(ctx context.Context, opts backends.ReceiveOptions
 	if g := opts.Extra["group"]; g != "" {
 		group = g
 	}
-	topic := "$share/" + group + "/" + queueTopicPrefix + opts.Queue
-
-	msgCh, err := a.subs.channelFor(ctx, a.cm, topic, qos)
+	sub, err := subscribeOptions("$share/"+group+"/"+queueTopicPrefix+opts.Queue, opts.Extra)
+	if err != nil {
+		return nil, err
+	}
+	msgCh, err := a.subs.channelFor(ctx, a.cm, sub)
 	if err != nil {
 		return nil, err
 	}
@@ -22,11 +22,13 @@ type QueueAdapterV3 struct {
 
 // NewQueueAdapterV3 creates a connected QueueAdapterV3.
 func NewQueueAdapterV3(args ConnArguments) (*QueueAdapterV3, error) {
-	client, err := ConnectV3(args)
+	a := &QueueAdapterV3{connArgs: args}
+	client, err := connectV3(args, a.subs.unrouted)
 	if err != nil {
 		return nil, err
 	}
-	return &QueueAdapterV3{connArgs: args, client: client}, nil
+	a.client = client
+	return a, nil
 }
 
 // Send implements backends.QueueBackend.
@@ -61,6 +63,9 @@ func (a *QueueAdapterV3) Send(ctx context.Context, opts backends.SendOptions) er
 // lifetime so consecutive reads (-n, --for) don't drop messages that arrive
 // between calls.
 func (a *QueueAdapterV3) Receive(ctx context.Context, opts backends.ReceiveOptions) (*backends.Message, error) {
+	if err := rejectV3SubscribeOptions(opts.Extra); err != nil {
+		return nil, err
+	}
 	qos := byte(1)
 	if v, err := strconv.Atoi(opts.Extra["qos"]); err == nil {
 		qos = byte(v)
@@ -69,7 +74,7 @@ func (a *QueueAdapterV3) Receive(ctx context.Context, opts backends.ReceiveOptio
 	if !opts.Acknowledge {
 		// Peek: use a fresh client with a unique clientID so the broker
 		// delivers a copy while shared-group consumers keep theirs.
-		peekArgs := a.connArgs
+		peekArgs := ephemeral(a.connArgs)
 		peekArgs.ClientID = fmt.Sprintf("xmc-peek-%s-%d", opts.Queue, os.Getpid())
 		peekArgs.ClientID = peekArgs.ClientID[:min(len(peekArgs.ClientID), 23)] // MQTT max 23 chars
 		client, err := ConnectV3(peekArgs)
@@ -143,5 +148,5 @@ func convertMessageV3(msg pahomqtt.Message) *backends.Message {
 	payload := msg.Payload()
 	data := make([]byte, len(payload))
 	copy(data, payload)
-	return &backends.Message{Data: data}
+	return &backends.Message{Data: data, Retained: msg.Retained()}
 }
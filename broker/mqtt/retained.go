@@ -0,0 +1,53 @@
+//go:build mqtt
+
+package mqtt
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// retainedQuiet is how long CollectRetained waits for another retained
+// message before it assumes the broker has sent them all. Brokers send the
+// retained messages matching a filter in one burst right after subscribing.
+const retainedQuiet = 500 * time.Millisecond
+
+// CollectRetained returns the retained messages on the topics matching
+// filter, sorted by topic, each with Topic and Retained set. Live messages
+// published meanwhile are ignored. Once subscribed, it listens until no
+// retained message has arrived for a moment; the whole call takes at most
+// opts.Window.
+func CollectRetained(ctx context.Context, args ConnArguments, filter string, opts ManageOptions) ([]*backends.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Window)
+	defer cancel()
+
+	// The quiet period starts at the SUBACK, not before connecting: a slow
+	// connect or TLS handshake must not end the call before the burst.
+	var quiet *time.Timer
+	var msgs []*backends.Message
+	err := watch(ctx, args, opts.V3, []string{filter}, func() {
+		quiet = time.AfterFunc(retainedQuiet, cancel)
+	}, func(msg *backends.Message) bool {
+		// Clearing a retained message leaves no retained message to deliver,
+		// but a broker may still send an empty one until it has forgotten it.
+		if msg.Retained && len(msg.Data) > 0 {
+			msgs = append(msgs, msg)
+			if quiet != nil {
+				quiet.Reset(retainedQuiet)
+			}
+		}
+		return false
+	})
+	if quiet != nil {
+		quiet.Stop()
+	}
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(msgs, func(a, b *backends.Message) int { return strings.Compare(a.Topic, b.Topic) })
+	return msgs, nil
+}
@@ -0,0 +1,157 @@
+//go:build mqtt
+
+package mqtt
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+)
+
+// WillOptions is the last-will message: the broker publishes it when the
+// connection ends without a DISCONNECT (the process was killed, the network
+// dropped). A clean exit, Ctrl-C included, discards it.
+type WillOptions struct {
+	Topic   string
+	Message string
+	QoS     int
+	Retain  bool
+	Delay   time.Duration // MQTT 5: publish only after this delay, and not if the session resumes in time
+}
+
+// validateSession checks the session, will and topic alias settings.
+func validateSession(args ConnArguments, v3 bool) error {
+	if args.SessionExpiry > 0 && args.ClientID == "" {
+		return fmt.Errorf("--session-expiry requires --client-id: the broker finds the session by client ID")
+	}
+	w := args.Will
+	if w.Topic == "" && (w.Message != "" || w.Retain || w.Delay > 0) {
+		return fmt.Errorf("--will-message, --will-retain and --will-delay require --will-topic")
+	}
+	if w.QoS < 0 || w.QoS > 2 {
+		return fmt.Errorf("invalid --will-qos %d (use 0, 1 or 2)", w.QoS)
+	}
+	if args.TopicAliasMax < 0 || args.TopicAliasMax > math.MaxUint16 {
+		return fmt.Errorf("invalid --topic-alias-max %d (use 0 to %d)", args.TopicAliasMax, math.MaxUint16)
+	}
+	if v3 && (w.Delay > 0 || args.TopicAliasMax > 0) {
+		return fmt.Errorf("--will-delay and --topic-alias-max require MQTT 5; remove --mqtt-version 3")
+	}
+	return nil
+}
+
+// ephemeral returns the arguments for a helper connection (peek, manage,
+// retained) that must neither take over the configured session nor fire its
+// will. It keeps the client ID unless that names a persistent session.
+func ephemeral(args ConnArguments) ConnArguments {
+	if args.SessionExpiry > 0 {
+		args.ClientID = ""
+	}
+	args.SessionExpiry = 0
+	args.Will = WillOptions{}
+	return args
+}
+
+// expirySeconds converts a session expiry or will delay to the seconds MQTT 5
+// carries, rounding up and capping at the protocol maximum (which means
+// "never expires" for sessions).
+func expirySeconds(d time.Duration) uint32 {
+	secs := (d + time.Second - 1) / time.Second
+	return uint32(min(int64(secs), math.MaxUint32))
+}
+
+// topicAliases replaces topic names with aliases on outgoing MQTT 5
+// publishes: the first publish to a topic carries the name and a new alias,
+// later ones only the alias. The broker's limit arrives in CONNACK and
+// aliases don't outlive a connection, so reset starts over on each one.
+type topicAliases struct {
+	mu      sync.Mutex
+	max     uint16
+	aliases map[string]uint16
+}
+
+func (t *topicAliases) reset(max uint16) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.max = max
+	t.aliases = make(map[string]uint16)
+}
+
+// publishHook is the client's PublishHook.
+func (t *topicAliases) publishHook(p *paho.Publish) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if p.Properties != nil && p.Properties.TopicAlias != nil {
+		return
+	}
+	alias, known := t.aliases[p.Topic]
+	if !known {
+		if len(t.aliases) >= int(t.max) {
+			return // all aliases in use: send the name
+		}
+		alias = uint16(len(t.aliases) + 1)
+		t.aliases[p.Topic] = alias
+	}
+	if p.Properties == nil {
+		p.Properties = &paho.PublishProperties{}
+	}
+	p.Properties.TopicAlias = paho.Uint16(alias)
+	if known {
+		p.Topic = ""
+	}
+}
+
+// inboundAliases resolves the topic aliases the broker uses on the messages
+// it sends (up to --topic-alias-max), so every message handler sees the
+// topic name. It must be the connection's first publish handler.
+type inboundAliases struct {
+	mu     sync.Mutex
+	topics map[uint16]string
+}
+
+func (t *inboundAliases) resolve(pr paho.PublishReceived) (bool, error) {
+	p := pr.Packet
+	if p.Properties == nil || p.Properties.TopicAlias == nil {
+		return false, nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if p.Topic != "" {
+		t.topics[*p.Properties.TopicAlias] = p.Topic
+	} else {
+		p.Topic = t.topics[*p.Properties.TopicAlias]
+	}
+	return false, nil
+}
+
+// subscribeOptions builds the MQTT 5 subscription to topic from the consume
+// flags: --qos, --no-local, --retain-as-published and --retain-handling.
+func subscribeOptions(topic string, extra map[string]string) (paho.SubscribeOptions, error) {
+	opts := paho.SubscribeOptions{
+		Topic:             topic,
+		QoS:               qosFromExtra(extra),
+		NoLocal:           extra["no-local"] == "true",
+		RetainAsPublished: extra["retain-as-published"] == "true",
+	}
+	if v := extra["retain-handling"]; v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 2 {
+			return opts, fmt.Errorf("invalid --retain-handling %q: use 0 (send retained messages), 1 (only for a new subscription) or 2 (never)", v)
+		}
+		opts.RetainHandling = byte(n)
+	}
+	return opts, nil
+}
+
+// rejectV3SubscribeOptions fails a v3 subscription that asks for MQTT 5
+// subscription options, instead of silently ignoring them.
+func rejectV3SubscribeOptions(extra map[string]string) error {
+	if extra["no-local"] != "" || extra["retain-as-published"] != "" || extra["retain-handling"] != "" {
+		return fmt.Errorf("--no-local, --retain-as-published and --retain-handling require MQTT 5; remove --mqtt-version 3")
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+//go:build mqtt
+
+package mqtt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+)
+
+func TestValidateSession(t *testing.T) {
+	ok := []ConnArguments{
+		{},
+		{ClientID: "dev-1", SessionExpiry: time.Hour},
+		{Will: WillOptions{Topic: "dev/1/status", Message: "offline", QoS: 1, Retain: true, Delay: time.Second}},
+		{TopicAliasMax: 10},
+	}
+	for _, args := range ok {
+		if err := validateSession(args, false); err != nil {
+			t.Errorf("validateSession(%+v): %v", args, err)
+		}
+	}
+
+	bad := []struct {
+		args ConnArguments
+		v3   bool
+	}{
+		{args: ConnArguments{SessionExpiry: time.Hour}},
+		{args: ConnArguments{Will: WillOptions{Message: "offline"}}},
+		{args: ConnArguments{Will: WillOptions{Topic: "t", QoS: 3}}},
+		{args: ConnArguments{TopicAliasMax: 70000}},
+		{args: ConnArguments{TopicAliasMax: 10}, v3: true},
+		{args: ConnArguments{Will: WillOptions{Topic: "t", Delay: time.Second}}, v3: true},
+	}
+	for _, tt := range bad {
+		if err := validateSession(tt.args, tt.v3); err == nil {
+			t.Errorf("validateSession(%+v, v3=%v) accepted invalid settings", tt.args, tt.v3)
+		}
+	}
+}
+
+func TestEphemeral(t *testing.T) {
+	args := ephemeral(ConnArguments{ClientID: "dev-1", SessionExpiry: time.Hour, Will: WillOptions{Topic: "t"}, TopicAliasMax: 5})
+	if args.ClientID != "" || args.SessionExpiry != 0 || args.Will.Topic != "" || args.TopicAliasMax != 5 {
+		t.Errorf("ephemeral = %+v", args)
+	}
+	if args := ephemeral(ConnArguments{ClientID: "dev-1"}); args.ClientID != "dev-1" {
+		t.Errorf("ephemeral dropped the client ID of a clean session: %+v", args)
+	}
+}
+
+func TestExpirySeconds(t *testing.T) {
+	for d, want := range map[time.Duration]uint32{0: 0, 1500 * time.Millisecond: 2, time.Hour: 3600} {
+		if got := expirySeconds(d); got != want {
+			t.Errorf("expirySeconds(%s) = %d, want %d", d, got, want)
+		}
+	}
+}
+
+func TestTopicAliases(t *testing.T) {
+	var out topicAliases
+	out.reset(1)
+	publish := func(topic string) *paho.Publish {
+		p := &paho.Publish{Topic: topic}
+		out.publishHook(p)
+		return p
+	}
+
+	if p := publish("a"); p.Topic != "a" || p.Properties == nil || *p.Properties.TopicAlias != 1 {
+		t.Errorf("first publish to a = %+v", p)
+	}
+	if p := publish("a"); p.Topic != "" || *p.Properties.TopicAlias != 1 {
+		t.Errorf("second publish to a = %+v", p)
+	}
+	if p := publish("b"); p.Topic != "b" || p.Properties != nil {
+		t.Errorf("publish beyond the alias maximum = %+v", p)
+	}
+
+	in := inboundAliases{topics: make(map[uint16]string)}
+	first := &paho.Publish{Topic: "c", Properties: &paho.PublishProperties{TopicAlias: paho.Uint16(3)}}
+	later := &paho.Publish{Properties: &paho.PublishProperties{TopicAlias: paho.Uint16(3)}}
+	for _, p := range []*paho.Publish{first, later} {
+		if _, err := in.resolve(paho.PublishReceived{Packet: p}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if later.Topic != "c" {
+		t.Errorf("aliased message topic = %q, want c", later.Topic)
+	}
+}
+
+func TestSubscribeOptions(t *testing.T) {
+	opts, err := subscribeOptions("a/#", map[string]string{"qos": "2", "no-local": "true", "retain-as-published": "true", "retain-handling": "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := paho.SubscribeOptions{Topic: "a/#", QoS: 2, NoLocal: true, RetainAsPublished: true, RetainHandling: 2}
+	if opts != want {
+		t.Errorf("subscribeOptions = %+v, want %+v", opts, want)
+	}
+	if _, err := subscribeOptions("a", map[string]string{"retain-handling": "3"}); err == nil {
+		t.Error("subscribeOptions accepted --retain-handling 3")
+	}
+	if err := rejectV3SubscribeOptions(map[string]string{"qos": "1"}); err != nil {
+		t.Errorf("rejectV3SubscribeOptions without MQTT 5 options: %v", err)
+	}
+	if err := rejectV3SubscribeOptions(map[string]string{"no-local": "true"}); err == nil {
+		t.Error("rejectV3SubscribeOptions accepted --no-local")
+	}
+}
@@ -19,60 +19,104 @@ import (
 // subscriptionCacheV3: subscribing per call would drop every message that
 // arrives between calls, breaking streaming reads (-n 0, --for).
 //
-// paho.golang has no per-subscription router, so each cached subscription
-// registers a publish callback that filter-matches incoming topics itself.
+// paho.golang has no per-subscription router, so dispatch — the connection's
+// publish handler, see connect5 — filter-matches incoming topics itself.
+// Messages no subscription matches wait in a backlog for one that does: a
+// resumed session (--session-expiry) delivers what it queued right after
+// connecting, before the command has subscribed again.
 type subCache5 struct {
-	mu   sync.Mutex
-	subs map[string]chan *paho.Publish
+	mu      sync.Mutex
+	subs    map[string]*sub5
+	backlog []*paho.Publish
 }
 
-// channelFor returns the message channel for (topic, qos), subscribing on
-// first use. Messages beyond the buffer are dropped with a verbose note —
-// MQTT QoS 0/1 semantics allow this, and blocking would stall the client.
-func (c *subCache5) channelFor(ctx context.Context, cm *autopaho.ConnectionManager, topic string, qos byte) (<-chan *paho.Publish, error) {
-	key := fmt.Sprintf("%s|%d", topic, qos)
+type sub5 struct {
+	filter string // the topic filter messages match, without a $share/ prefix
+	ch     chan *paho.Publish
+}
 
+// dispatch hands a message to every subscription it matches, or to the
+// backlog. Messages beyond a buffer are dropped with a verbose note — MQTT
+// QoS 0/1 semantics allow this, and blocking would stall the client.
+func (c *subCache5) dispatch(pr paho.PublishReceived) (bool, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	if c.subs == nil {
-		c.subs = make(map[string]chan *paho.Publish)
+	matched := false
+	for _, s := range c.subs {
+		if topicMatchesFilter(s.filter, pr.Packet.Topic) {
+			matched = true
+			s.deliver(pr.Packet)
+		}
 	}
-	if ch, ok := c.subs[key]; ok {
-		return ch, nil
+	if !matched {
+		if len(c.backlog) < subscriptionBuffer {
+			c.backlog = append(c.backlog, pr.Packet)
+		} else {
+			log.Verbose("⚠️  backlog full, dropping message from %s", pr.Packet.Topic)
+		}
 	}
+	return matched, nil
+}
 
-	// Messages arrive on the actual topic, not the subscription filter:
-	// strip the shared-subscription prefix before matching.
-	filter := topic
-	if strings.HasPrefix(filter, "$share/") {
-		if parts := strings.SplitN(filter, "/", 3); len(parts) == 3 {
-			filter = parts[2]
-		}
+func (s *sub5) deliver(p *paho.Publish) {
+	select {
+	case s.ch <- p:
+	default:
+		log.Verbose("⚠️  subscription buffer full, dropping message from %s", p.Topic)
 	}
+}
 
-	ch := make(chan *paho.Publish, subscriptionBuffer)
-	cm.AddOnPublishReceived(func(pr autopaho.PublishReceived) (bool, error) {
-		if !topicMatchesFilter(filter, pr.Packet.Topic) {
-			return false, nil
-		}
-		select {
-		case ch <- pr.Packet:
-		default:
-			log.Verbose("⚠️  subscription buffer full, dropping message from %s", pr.Packet.Topic)
+// channelFor returns the message channel for the subscription, subscribing
+// on first use.
+func (c *subCache5) channelFor(ctx context.Context, cm *autopaho.ConnectionManager, opts paho.SubscribeOptions) (<-chan *paho.Publish, error) {
+	key := fmt.Sprintf("%s|%d|%t|%t|%d", opts.Topic, opts.QoS, opts.NoLocal, opts.RetainAsPublished, opts.RetainHandling)
+
+	c.mu.Lock()
+	if c.subs == nil {
+		c.subs = make(map[string]*sub5)
+	}
+	if s, ok := c.subs[key]; ok {
+		c.mu.Unlock()
+		return s.ch, nil
+	}
+	// Register before subscribing so nothing the broker sends in between
+	// is missed, and take over the backlog this subscription matches.
+	s := &sub5{filter: subscriptionFilter(opts.Topic), ch: make(chan *paho.Publish, subscriptionBuffer)}
+	c.subs[key] = s
+	rest := c.backlog[:0]
+	for _, p := range c.backlog {
+		if topicMatchesFilter(s.filter, p.Topic) {
+			s.deliver(p)
+		} else {
+			rest = append(rest, p)
 		}
-		return true, nil
-	})
+	}
+	c.backlog = rest
+	c.mu.Unlock()
 
+	// Not under mu: dispatch runs on the client's goroutine, which the
+	// SUBACK may queue behind.
 	subCtx, cancel := context.WithTimeout(ctx, tokenTimeout)
 	defer cancel()
-	if _, err := cm.Subscribe(subCtx, &paho.Subscribe{
-		Subscriptions: []paho.SubscribeOptions{{Topic: topic, QoS: qos}},
-	}); err != nil {
-		return nil, fmt.Errorf("MQTT subscribe to %q: %w", topic, err)
+	if _, err := cm.Subscribe(subCtx, &paho.Subscribe{Subscriptions: []paho.SubscribeOptions{opts}}); err != nil {
+		c.mu.Lock()
+		delete(c.subs, key)
+		c.mu.Unlock()
+		return nil, fmt.Errorf("MQTT subscribe to %q: %w", opts.Topic, err)
 	}
+	return s.ch, nil
+}
 
-	c.subs[key] = ch
-	return ch, nil
+// subscriptionFilter returns the filter messages of a subscription match:
+// they arrive on the actual topic, so a shared subscription's
+// "$share/<group>/" prefix is stripped.
+func subscriptionFilter(topic string) string {
+	if strings.HasPrefix(topic, "$share/") {
+		if parts := strings.SplitN(topic, "/", 3); len(parts) == 3 {
+			return parts[2]
+		}
+	}
+	return topic
 }
 
 // topicMatchesFilter reports whether a published topic matches an MQTT topic
@@ -2,7 +2,13 @@
 
 package mqtt
 
-import "testing"
+import (
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+)
 
 func TestTopicMatchesFilter(t *testing.T) {
 	cases := []struct {
@@ -27,3 +33,117 @@ func TestTopicMatchesFilter(t *testing.T) {
 		}
 	}
 }
+
+func TestSubCache5Dispatch(t *testing.T) {
+	var c subCache5
+	s := &sub5{filter: subscriptionFilter("$share/g/sensors/+"), ch: make(chan *paho.Publish, 1)}
+	c.subs = map[string]*sub5{"sensors": s}
+
+	if matched, _ := c.dispatch(paho.PublishReceived{Packet: &paho.Publish{Topic: "sensors/a"}}); !matched {
+		t.Error("dispatch did not match sensors/a")
+	}
+	if p := <-s.ch; p.Topic != "sensors/a" {
+		t.Errorf("subscription got %q", p.Topic)
+	}
+	if matched, _ := c.dispatch(paho.PublishReceived{Packet: &paho.Publish{Topic: "lamp/a"}}); matched {
+		t.Error("dispatch matched lamp/a")
+	}
+	if len(c.backlog) != 1 || c.backlog[0].Topic != "lamp/a" {
+		t.Errorf("backlog = %v", c.backlog)
+	}
+}
+
+func TestSharedFilter(t *testing.T) {
+	cases := []struct{ topic, group, want string }{
+		{"a/b", "", "a/b"},
+		{"a/b", "g", "$share/g/a/b"},
+		{"$share/devices/a/#", "g", "$share/devices/a/#"},
+	}
+	for _, c := range cases {
+		if got := sharedFilter(c.topic, c.group); got != c.want {
+			t.Errorf("sharedFilter(%q, %q) = %q, want %q", c.topic, c.group, got, c.want)
+		}
+	}
+	if got := subscriptionFilter("$share/devices/a/#"); got != "a/#" {
+		t.Errorf("subscriptionFilter = %q", got)
+	}
+}
+
+// unroutedClient is a v3 client whose SUBACK only arrives after the broker
+// has sent messages no route took yet, as on a resumed session: paho hands
+// them to the default handler, in order, on its router goroutine.
+type unroutedClient struct {
+	pahomqtt.Client
+	unrouted pahomqtt.MessageHandler
+	topics   []string
+}
+
+func (c *unroutedClient) Subscribe(string, byte, pahomqtt.MessageHandler) pahomqtt.Token {
+	tok := &doneToken{done: make(chan struct{})}
+	go func() {
+		for _, topic := range c.topics {
+			c.unrouted(nil, fakeMessage(topic))
+		}
+		close(tok.done)
+	}()
+	return tok
+}
+
+type doneToken struct{ done chan struct{} }
+
+func (t *doneToken) Wait() bool { <-t.done; return true }
+func (t *doneToken) WaitTimeout(d time.Duration) bool {
+	select {
+	case <-t.done:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+func (t *doneToken) Done() <-chan struct{} { return t.done }
+func (t *doneToken) Error() error          { return nil }
+
+type fakeMessage string
+
+func (m fakeMessage) Duplicate() bool   { return false }
+func (m fakeMessage) Qos() byte         { return 1 }
+func (m fakeMessage) Retained() bool    { return false }
+func (m fakeMessage) Topic() string     { return string(m) }
+func (m fakeMessage) MessageID() uint16 { return 0 }
+func (m fakeMessage) Payload() []byte   { return nil }
+func (m fakeMessage) Ack()              {}
+
+func TestSubscriptionCacheV3_UnroutedDuringSubscribe(t *testing.T) {
+	var c subscriptionCacheV3
+	client := &unroutedClient{unrouted: c.unrouted, topics: []string{"dev/1/cmd", "dev/2/cmd", "other", "dev/3/cmd"}}
+
+	done := make(chan struct{})
+	var ch <-chan pahomqtt.Message
+	var err error
+	go func() {
+		defer close(done)
+		ch, err = c.channelFor(client, "dev/+/cmd", 1)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("channelFor blocked the unrouted handler until the SUBACK timed out")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"dev/1/cmd", "dev/2/cmd", "dev/3/cmd"} {
+		select {
+		case msg := <-ch:
+			if msg.Topic() != want {
+				t.Errorf("got %s, want %s", msg.Topic(), want)
+			}
+		default:
+			t.Fatalf("missing %s", want)
+		}
+	}
+	if len(c.backlog) != 1 || c.backlog[0].Topic() != "other" {
+		t.Errorf("backlog = %v", c.backlog)
+	}
+}
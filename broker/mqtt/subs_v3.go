@@ -21,43 +21,92 @@ const tokenTimeout = 30 * time.Second
 // with CleanSession the broker forgets the subscription immediately — which
 // breaks streaming reads (-n 0, --for). Buffered channels absorb bursts while
 // the caller processes the previous message.
+//
+// Messages paho routes to no subscription (unrouted, the connection's default
+// handler) wait in a backlog for one that matches, like subCache5's.
 type subscriptionCacheV3 struct {
-	mu   sync.Mutex
-	subs map[string]chan pahomqtt.Message
+	mu      sync.Mutex
+	subs    map[string]chan pahomqtt.Message
+	backlog []pahomqtt.Message
 }
 
 const subscriptionBuffer = 256
 
+// unrouted is the connection's default publish handler (see connectV3).
+func (c *subscriptionCacheV3) unrouted(_ pahomqtt.Client, msg pahomqtt.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.backlog) < subscriptionBuffer {
+		c.backlog = append(c.backlog, msg)
+	} else {
+		log.Verbose("⚠️  backlog full, dropping message from %s", msg.Topic())
+	}
+}
+
 // channelFor returns the message channel for (topic, qos), subscribing on
 // first use. Messages beyond the buffer are dropped with a verbose note —
 // MQTT QoS 0/1 semantics allow this, and blocking would stall paho's router.
 func (c *subscriptionCacheV3) channelFor(client pahomqtt.Client, topic string, qos byte) (<-chan pahomqtt.Message, error) {
 	key := fmt.Sprintf("%s|%d", topic, qos)
+	filter := subscriptionFilter(topic)
 
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	if c.subs == nil {
 		c.subs = make(map[string]chan pahomqtt.Message)
 	}
 	if ch, ok := c.subs[key]; ok {
+		c.mu.Unlock()
 		return ch, nil
 	}
-
 	ch := make(chan pahomqtt.Message, subscriptionBuffer)
-	token := client.Subscribe(topic, qos, func(_ pahomqtt.Client, msg pahomqtt.Message) {
-		select {
-		case ch <- msg:
-		default:
-			log.Verbose("⚠️  subscription buffer full, dropping message from %s", msg.Topic())
-		}
-	})
+	c.subs[key] = ch
+	c.takeBacklog(filter, ch)
+	c.mu.Unlock()
+
+	// Not under mu: unrouted runs on paho's router goroutine, and the
+	// SUBACK can't be read while that goroutine waits for the lock.
+	token := client.Subscribe(topic, qos, func(_ pahomqtt.Client, msg pahomqtt.Message) { deliverV3(ch, msg) })
 	if !token.WaitTimeout(tokenTimeout) {
+		c.forget(key)
 		return nil, fmt.Errorf("MQTT subscribe to %q timed out", topic)
 	}
 	if err := token.Error(); err != nil {
+		c.forget(key)
 		return nil, fmt.Errorf("MQTT subscribe to %q: %w", topic, err)
 	}
 
-	c.subs[key] = ch
+	// paho routes to the subscription from now on; take over what arrived
+	// unrouted while subscribing.
+	c.mu.Lock()
+	c.takeBacklog(filter, ch)
+	c.mu.Unlock()
 	return ch, nil
 }
+
+func (c *subscriptionCacheV3) forget(key string) {
+	c.mu.Lock()
+	delete(c.subs, key)
+	c.mu.Unlock()
+}
+
+// takeBacklog moves the backlog messages matching filter to ch. Must be
+// called with c.mu held.
+func (c *subscriptionCacheV3) takeBacklog(filter string, ch chan pahomqtt.Message) {
+	rest := c.backlog[:0]
+	for _, msg := range c.backlog {
+		if topicMatchesFilter(filter, msg.Topic()) {
+			deliverV3(ch, msg)
+		} else {
+			rest = append(rest, msg)
+		}
+	}
+	c.backlog = rest
+}
+
+func deliverV3(ch chan pahomqtt.Message, msg pahomqtt.Message) {
+	select {
+	case ch <- msg:
+	default:
+		log.Verbose("⚠️  subscription buffer full, dropping message from %s", msg.Topic())
+	}
+}
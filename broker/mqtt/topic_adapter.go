@@ -21,11 +21,13 @@ type TopicAdapter struct {
 
 // NewTopicAdapter creates a connected MQTT 5 TopicAdapter.
 func NewTopicAdapter(args ConnArguments) (*TopicAdapter, error) {
-	cm, err := Connect5(args)
+	a := &TopicAdapter{connArgs: args}
+	cm, err := connect5(args, a.subs.dispatch)
 	if err != nil {
 		return nil, err
 	}
-	return &TopicAdapter{connArgs: args, cm: cm}, nil
+	a.cm = cm
+	return a, nil
 }
 
 // Publish implements backends.TopicBackend.
@@ -55,12 +57,11 @@ func (a *TopicAdapter) Publish(ctx context.Context, opts backends.PublishOptions
 // adapter's lifetime so consecutive reads (-n, --for) don't drop messages
 // that arrive between calls.
 func (a *TopicAdapter) Subscribe(ctx context.Context, opts backends.SubscribeOptions) (*backends.Message, error) {
-	topic := opts.Topic
-	if opts.GroupID != "" {
-		topic = "$share/" + opts.GroupID + "/" + opts.Topic
+	sub, err := subscribeOptions(sharedFilter(opts.Topic, opts.GroupID), opts.Extra)
+	if err != nil {
+		return nil, err
 	}
-
-	msgCh, err := a.subs.channelFor(ctx, a.cm, topic, qosFromExtra(opts.Extra))
+	msgCh, err := a.subs.channelFor(ctx, a.cm, sub)
 	if err != nil {
 		return nil, err
 	}
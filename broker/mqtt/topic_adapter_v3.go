@@ -20,11 +20,13 @@ type TopicAdapterV3 struct {
 
 // NewTopicAdapterV3 creates a connected TopicAdapterV3.
 func NewTopicAdapterV3(args ConnArguments) (*TopicAdapterV3, error) {
-	client, err := ConnectV3(args)
+	a := &TopicAdapterV3{connArgs: args}
+	client, err := connectV3(args, a.subs.unrouted)
 	if err != nil {
 		return nil, err
 	}
-	return &TopicAdapterV3{connArgs: args, client: client}, nil
+	a.client = client
+	return a, nil
 }
 
 // Publish implements backends.TopicBackend.
@@ -56,10 +58,10 @@ func (a *TopicAdapterV3) Publish(_ context.Context, opts backends.PublishOptions
 // adapter's lifetime so consecutive reads (-n, --for) don't drop messages
 // that arrive between calls.
 func (a *TopicAdapterV3) Subscribe(ctx context.Context, opts backends.SubscribeOptions) (*backends.Message, error) {
-	topic := opts.Topic
-	if opts.GroupID != "" {
-		topic = "$share/" + opts.GroupID + "/" + opts.Topic
+	if err := rejectV3SubscribeOptions(opts.Extra); err != nil {
+		return nil, err
 	}
+	topic := sharedFilter(opts.Topic, opts.GroupID)
 
 	qos := byte(1)
 	if v, err := strconv.Atoi(opts.Extra["qos"]); err == nil {
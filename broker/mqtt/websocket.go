@@ -0,0 +1,88 @@
+//go:build mqtt
+
+package mqtt
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/makibytes/xmc/broker/tlsutil"
+)
+
+// defaultWSPath is where brokers serve MQTT over WebSockets by convention
+// (EMQX, HiveMQ, VerneMQ; Mosquitto accepts any path).
+const defaultWSPath = "/mqtt"
+
+// brokerURL parses the server URL for both clients. --tls upgrades a plain
+// scheme to its TLS counterpart (tcp → ssl, ws → wss). WebSocket URLs get
+// --ws-path, or their own path, or /mqtt; the --ws-* flags are rejected for
+// other schemes.
+func brokerURL(args ConnArguments) (*url.URL, error) {
+	u, err := url.Parse(args.Server)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MQTT server URL %q: %w", args.Server, err)
+	}
+	if args.TLS.Enabled {
+		switch u.Scheme {
+		case "tcp", "mqtt", "":
+			u.Scheme = "ssl"
+		case "ws":
+			u.Scheme = "wss"
+		}
+	}
+
+	if !isWebSocket(u) {
+		if args.WSPath != "" || len(args.WSHeaders) > 0 {
+			return nil, fmt.Errorf("--ws-path and --ws-header require a ws:// or wss:// server, not %q", args.Server)
+		}
+		return u, nil
+	}
+	switch {
+	case args.WSPath != "":
+		u.Path = "/" + strings.TrimPrefix(args.WSPath, "/")
+	case u.Path == "" || u.Path == "/":
+		u.Path = defaultWSPath
+	}
+	return u, nil
+}
+
+func isWebSocket(u *url.URL) bool {
+	return u.Scheme == "ws" || u.Scheme == "wss"
+}
+
+// brokerTLS builds the TLS configuration for --tls or a TLS scheme (ssl://,
+// wss://, ...), so --ca-cert, --cert and --insecure apply without --tls too.
+// It returns nil for plain connections.
+func brokerTLS(args ConnArguments, u *url.URL) (*tls.Config, error) {
+	switch u.Scheme {
+	case "ssl", "tls", "mqtts", "mqtt+ssl", "tcps", "wss":
+	default:
+		return nil, nil
+	}
+	cfg, err := tlsutil.BuildTLSConfig(args.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("TLS configuration error: %w", err)
+	}
+	return cfg, nil
+}
+
+// wsHeader parses the --ws-header "Name=value" entries into the headers of
+// the WebSocket upgrade request. A name may repeat.
+func wsHeader(entries []string) (http.Header, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	h := make(http.Header, len(entries))
+	for _, e := range entries {
+		name, value, ok := strings.Cut(e, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --ws-header %q: use Name=value", e)
+		}
+		h.Add(name, strings.TrimSpace(value))
+	}
+	return h, nil
+}
@@ -0,0 +1,74 @@
+//go:build mqtt
+
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+func TestBrokerURL(t *testing.T) {
+	tests := []struct {
+		server  string
+		tls     bool
+		wsPath  string
+		want    string
+		wantTLS bool
+	}{
+		{server: "tcp://localhost:1883", want: "tcp://localhost:1883"},
+		{server: "tcp://localhost:8883", tls: true, want: "ssl://localhost:8883", wantTLS: true},
+		{server: "ws://lb.example.com", want: "ws://lb.example.com/mqtt"},
+		{server: "ws://lb.example.com:8080/", want: "ws://lb.example.com:8080/mqtt"},
+		{server: "ws://lb.example.com/broker/ws", want: "ws://lb.example.com/broker/ws"},
+		{server: "ws://lb.example.com/broker/ws", wsPath: "mqtt-ws", want: "ws://lb.example.com/mqtt-ws"},
+		{server: "ws://lb.example.com", tls: true, want: "wss://lb.example.com/mqtt", wantTLS: true},
+		{server: "wss://lb.example.com:443", want: "wss://lb.example.com:443/mqtt", wantTLS: true},
+	}
+	for _, tt := range tests {
+		args := ConnArguments{CommonConnArgs: backends.CommonConnArgs{Server: tt.server}, WSPath: tt.wsPath}
+		args.TLS.Enabled = tt.tls
+		u, err := brokerURL(args)
+		if err != nil {
+			t.Errorf("brokerURL(%q): %v", tt.server, err)
+			continue
+		}
+		if u.String() != tt.want {
+			t.Errorf("brokerURL(%q, tls=%v, ws-path=%q) = %s, want %s", tt.server, tt.tls, tt.wsPath, u, tt.want)
+		}
+		tlsCfg, err := brokerTLS(args, u)
+		if err != nil {
+			t.Errorf("brokerTLS(%s): %v", u, err)
+		}
+		if (tlsCfg != nil) != tt.wantTLS {
+			t.Errorf("brokerTLS(%s) = %v, want TLS %v", u, tlsCfg, tt.wantTLS)
+		}
+	}
+
+	args := ConnArguments{CommonConnArgs: backends.CommonConnArgs{Server: "tcp://localhost:1883"}, WSHeaders: []string{"X-Token=abc"}}
+	if _, err := brokerURL(args); err == nil {
+		t.Error("brokerURL accepted --ws-header for a tcp:// server")
+	}
+}
+
+func TestWSHeader(t *testing.T) {
+	h, err := wsHeader([]string{"Authorization=Bearer a=b", "X-Tenant = blue", "X-Tenant=green"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Get("Authorization"); got != "Bearer a=b" {
+		t.Errorf("Authorization = %q", got)
+	}
+	if got := h.Values("X-Tenant"); len(got) != 2 || got[0] != "blue" || got[1] != "green" {
+		t.Errorf("X-Tenant = %v", got)
+	}
+
+	for _, bad := range []string{"Authorization", "=value"} {
+		if _, err := wsHeader([]string{bad}); err == nil {
+			t.Errorf("wsHeader(%q) accepted an invalid header", bad)
+		}
+	}
+	if h, err := wsHeader(nil); h != nil || err != nil {
+		t.Errorf("wsHeader(nil) = %v, %v", h, err)
+	}
+}
@@ -0,0 +1,99 @@
+//go:build nats
+
+package nats
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	natsclient "github.com/nats-io/nats.go"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// multiSubBuffer is the capacity of the channel shared by a multi-subject
+// subscription; NATS drops messages for a slow consumer beyond it.
+const multiSubBuffer = 256
+
+// multiSub is a set of subject subscriptions delivering into one channel.
+type multiSub struct {
+	key  string
+	ch   chan *natsclient.Msg
+	subs []*natsclient.Subscription
+}
+
+func (m *multiSub) unsubscribe() {
+	for _, s := range m.subs {
+		s.Unsubscribe() //nolint:errcheck
+	}
+}
+
+// multiState caches the adapter's multi-subject subscription. Unlike
+// Subscribe it stays open between calls, so a merged stream (-n, --for) does
+// not lose messages published between two reads.
+type multiState struct {
+	mu  sync.Mutex
+	sub *multiSub
+}
+
+// SubscribeTopics implements backends.MultiTopicBackend.
+// Every topic is a NATS subject, wildcards (* for one token, > for the rest)
+// included; all of them feed one channel, and each message is labelled with
+// the subject it was published on. A group makes every subscription a queue
+// subscription in that group.
+func (a *TopicAdapter) SubscribeTopics(ctx context.Context, topics []string, opts backends.SubscribeOptions) (*backends.Message, error) {
+	sub, err := a.multiSubscription(topics, opts.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(backends.TimeoutDuration(opts.Timeout, opts.Wait))
+	defer timer.Stop()
+	select {
+	case msg := <-sub.ch:
+		result := natsToBackendMessage(msg)
+		result.Topic = msg.Subject
+		return result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, backends.ErrNoMessageAvailable
+	}
+}
+
+func (a *TopicAdapter) multiSubscription(topics []string, group string) (*multiSub, error) {
+	key := group + "|" + strings.Join(topics, ",")
+
+	a.multi.mu.Lock()
+	defer a.multi.mu.Unlock()
+	if a.multi.sub != nil {
+		if a.multi.sub.key == key {
+			return a.multi.sub, nil
+		}
+		a.multi.sub.unsubscribe()
+		a.multi.sub = nil
+	}
+
+	sub := &multiSub{key: key, ch: make(chan *natsclient.Msg, multiSubBuffer)}
+	for _, subject := range topics {
+		var (
+			s   *natsclient.Subscription
+			err error
+		)
+		if group != "" {
+			s, err = a.nc.ChanQueueSubscribe(subject, group, sub.ch)
+		} else {
+			s, err = a.nc.ChanSubscribe(subject, sub.ch)
+		}
+		if err != nil {
+			sub.unsubscribe()
+			return nil, fmt.Errorf("subscribing to topic %s: %w", subject, err)
+		}
+		sub.subs = append(sub.subs, s)
+	}
+	a.multi.sub = sub
+	return sub, nil
+}
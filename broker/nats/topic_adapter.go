@@ -14,7 +14,8 @@ import (
 
 // TopicAdapter adapts core NATS pub/sub to the TopicBackend interface.
 type TopicAdapter struct {
-	nc *natsclient.Conn
+	nc    *natsclient.Conn
+	multi multiState
 }
 
 // NewTopicAdapter creates a new NATS topic adapter.
@@ -85,6 +86,11 @@ func (a *TopicAdapter) Subscribe(ctx context.Context, opts backends.SubscribeOpt
 
 // Close implements backends.TopicBackend.
 func (a *TopicAdapter) Close() error {
+	a.multi.mu.Lock()
+	if a.multi.sub != nil {
+		a.multi.sub.unsubscribe()
+	}
+	a.multi.mu.Unlock()
 	if a.nc != nil {
 		a.nc.Close()
 	}
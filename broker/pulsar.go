@@ -4,6 +4,7 @@ package broker
 
 import (
 	"os"
+	"time"
 
 	"github.com/makibytes/xmc/broker/backends"
 	pulsarpkg "github.com/makibytes/xmc/broker/pulsar"
@@ -18,6 +19,17 @@ func GetRootCommand() *cobra.Command {
 	var pulsarPartitions int
 	var tenant, namespace string
 	var nonPersistent bool
+	var tenantAdminRoles, tenantClusters []string
+	var forceDeleteNamespace bool
+
+	// resolveTopic maps a manage command's topic argument to its full name.
+	resolveTopic := func(topic string) (string, error) {
+		return pulsarpkg.ResolveTarget(true, topic, tenant, namespace, nonPersistent)
+	}
+	// resolveNamespace maps a namespace argument to tenant/namespace.
+	resolveNamespace := func(ns string) (string, error) {
+		return pulsarpkg.ResolveNamespace(ns, tenant)
+	}
 
 	defaultServer := os.Getenv("PMC_SERVER")
 	if defaultServer == "" {
@@ -33,41 +45,143 @@ func GetRootCommand() *cobra.Command {
 		ResolveTarget: func(t cmd.TargetSpec) (string, error) {
 			return pulsarpkg.ResolveTarget(t.IsTopic, t.To, tenant, namespace, nonPersistent)
 		},
+		ProduceFlags: func(c *cobra.Command) {
+			c.Flags().String("schema", "", "Publish with a schema: auto (the topic's), json[:<file>], avro[:<file>], string or bytes; Avro messages are given as JSON")
+		},
+		ProduceExtra: func(c *cobra.Command) map[string]string {
+			extra := make(map[string]string)
+			if v, _ := c.Flags().GetString("schema"); v != "" {
+				extra["schema"] = v
+			}
+			return extra
+		},
+		ConsumeFlags: func(c *cobra.Command) {
+			c.Flags().String("schema", "", "Consume with a schema: auto (the topic's), json[:<file>], avro[:<file>], string or bytes; Avro messages are shown as JSON")
+			c.Flags().String("subscription-type", "", "Subscription type: exclusive, shared, failover or key_shared (default shared for queues and -g, else exclusive)")
+			c.Flags().String("initial-position", "", "Where a new subscription starts: earliest or latest")
+			c.Flags().Uint32("max-redeliveries", 0, "Move a message to the dead-letter topic after this many deliveries (shared/key_shared only)")
+			c.Flags().String("dead-letter-topic", "", "Dead-letter topic (default <topic>-<subscription>-DLQ)")
+			c.Flags().Bool("retry", false, "Enable the retry topic: --nack redelivers through <topic>-<subscription>-RETRY")
+			c.Flags().String("retry-topic", "", "Retry topic (implies --retry)")
+			c.Flags().Duration("retry-delay", time.Second, "Delay before a --nack'ed message is redelivered from the retry topic")
+			c.Flags().Bool("nack", false, "Negatively acknowledge received messages so they are redelivered")
+			c.Flags().Duration("nack-delay", time.Second, "Delay before the broker redelivers a nack'ed message")
+		},
+		ConsumeExtra: func(c *cobra.Command) map[string]string {
+			extra := make(map[string]string)
+			for _, name := range []string{"schema", "subscription-type", "initial-position", "max-redeliveries", "dead-letter-topic", "retry", "retry-topic", "retry-delay", "nack", "nack-delay"} {
+				if f := c.Flags().Lookup(name); f != nil && f.Changed {
+					extra[name] = f.Value.String()
+				}
+			}
+			return extra
+		},
 		RegisterFlags: func(c *cobra.Command) {
 			backends.RegisterCommonFlags(c, &connArgs, "PMC_", defaultServer)
 			c.PersistentFlags().StringVar(&connArgs.Token, "token", os.Getenv("PMC_TOKEN"), "Authentication token (mutually exclusive with --user/--password)")
 			c.PersistentFlags().StringVar(&tenant, "tenant", "public", "Pulsar tenant")
 			c.PersistentFlags().StringVar(&namespace, "namespace", "default", "Pulsar namespace")
 			c.PersistentFlags().BoolVar(&nonPersistent, "non-persistent", false, "Use non-persistent topics")
+			c.PersistentFlags().IntVar(&adminPort, "admin-port", 8080, "Pulsar admin REST API port (manage, --schema)")
 			backends.RegisterTLSFlags(c, &connArgs.TLS)
 		},
-		Queue: func() (backends.QueueBackend, error) { return pulsarpkg.NewQueueAdapter(connArgs) },
-		Topic: func() (backends.TopicBackend, error) { return pulsarpkg.NewTopicAdapter(connArgs) },
-		Ping:  func() (cmd.Closeable, error) { return pulsarpkg.NewQueueAdapter(connArgs) },
+		Queue: func() (backends.QueueBackend, error) { return pulsarpkg.NewQueueAdapter(connArgs, adminPort) },
+		Topic: func() (backends.TopicBackend, error) { return pulsarpkg.NewTopicAdapter(connArgs, adminPort) },
+		Ping:  func() (cmd.Closeable, error) { return pulsarpkg.NewQueueAdapter(connArgs, adminPort) },
 		ManageSpec: &cmd.ManageSpec{
-			SetupFlags: func(c *cobra.Command) {
-				c.PersistentFlags().IntVar(&adminPort, "admin-port", 8080, "Pulsar admin REST API port")
-			},
 			Objects: []cmd.ObjectType{
 				{
-					Label: "Topics",
+					Label:        "Topics",
+					Hierarchical: true,
+					List: func() ([]backends.ObjectNode, error) {
+						return pulsarpkg.ListTopicsWithSubscriptions(connArgs, adminPort, tenant, namespace, nonPersistent)
+					},
+				},
+				{
+					Label:        "Tenants",
+					Hierarchical: true,
 					List: func() ([]backends.ObjectNode, error) {
-						topics, err := pulsarpkg.ListTopics(connArgs, adminPort, tenant, namespace, nonPersistent)
-						if err != nil {
-							return nil, err
-						}
-						out := make([]backends.ObjectNode, len(topics))
-						kind := "persistent"
-						if nonPersistent {
-							kind = "non-persist"
-						}
-						for i, t := range topics {
-							out[i] = backends.ObjectNode{Name: t.Name, Kind: kind}
-						}
-						return out, nil
+						return pulsarpkg.ListTenantsWithNamespaces(connArgs, adminPort, nonPersistent)
 					},
 				},
 			},
+			Purge: func(topic string) (int64, error) {
+				t, err := resolveTopic(topic)
+				if err != nil {
+					return 0, err
+				}
+				return pulsarpkg.PurgeTopic(connArgs, adminPort, t)
+			},
+			PurgeSubscription: func(topic, sub string) (int64, error) {
+				t, err := resolveTopic(topic)
+				if err != nil {
+					return 0, err
+				}
+				return pulsarpkg.PurgeSubscription(connArgs, adminPort, t, sub)
+			},
+			TopicStats: func(topic string) (*backends.TopicStats, error) {
+				t, err := resolveTopic(topic)
+				if err != nil {
+					return nil, err
+				}
+				return pulsarpkg.TopicStats(connArgs, adminPort, t)
+			},
+			ListSubscriptions: func(topic string) ([]backends.SubscriptionStats, error) {
+				t, err := resolveTopic(topic)
+				if err != nil {
+					return nil, err
+				}
+				return pulsarpkg.ListSubscriptions(connArgs, adminPort, t)
+			},
+			CreateSubscription: func(topic, sub string, earliest bool) error {
+				t, err := resolveTopic(topic)
+				if err != nil {
+					return err
+				}
+				return pulsarpkg.CreateSubscription(connArgs, adminPort, t, sub, earliest)
+			},
+			DeleteSubscription: func(topic, sub string) error {
+				t, err := resolveTopic(topic)
+				if err != nil {
+					return err
+				}
+				return pulsarpkg.DeleteSubscription(connArgs, adminPort, t, sub)
+			},
+			ExpireMessages: func(topic, sub string, olderThan time.Duration) error {
+				t, err := resolveTopic(topic)
+				if err != nil {
+					return err
+				}
+				return pulsarpkg.ExpireMessages(connArgs, adminPort, t, sub, olderThan)
+			},
+			Seek: func(topic, sub, to string) error {
+				t, err := resolveTopic(topic)
+				if err != nil {
+					return err
+				}
+				return pulsarpkg.SeekSubscription(connArgs, adminPort, t, sub, to)
+			},
+			GetSchema: func(topic string) (*backends.TopicSchema, error) {
+				t, err := resolveTopic(topic)
+				if err != nil {
+					return nil, err
+				}
+				return pulsarpkg.GetSchema(connArgs, adminPort, t)
+			},
+			UploadSchema: func(topic string, schema backends.TopicSchema) error {
+				t, err := resolveTopic(topic)
+				if err != nil {
+					return err
+				}
+				return pulsarpkg.UploadSchema(connArgs, adminPort, t, schema)
+			},
+			DeleteSchema: func(topic string) error {
+				t, err := resolveTopic(topic)
+				if err != nil {
+					return err
+				}
+				return pulsarpkg.DeleteSchema(connArgs, adminPort, t)
+			},
 			CreateTopic: &cmd.ManageAction{
 				SetupFlags: func(c *cobra.Command) {
 					c.Flags().IntVar(&pulsarPartitions, "partitions", 0, "Number of partitions (0 = non-partitioned)")
@@ -79,6 +193,51 @@ func GetRootCommand() *cobra.Command {
 			DeleteTopic: &cmd.ManageAction{Run: func(topic string) error {
 				return pulsarpkg.DeleteTopic(connArgs, adminPort, topic, tenant, namespace, nonPersistent)
 			}},
+			CreateTenant: &cmd.ManageAction{
+				SetupFlags: func(c *cobra.Command) {
+					c.Flags().StringSliceVar(&tenantAdminRoles, "admin-roles", nil, "Roles allowed to administer the tenant")
+					c.Flags().StringSliceVar(&tenantClusters, "allowed-clusters", nil, "Clusters the tenant may use (default: all)")
+				},
+				Run: func(t string) error {
+					return pulsarpkg.CreateTenant(connArgs, adminPort, t, tenantAdminRoles, tenantClusters)
+				},
+			},
+			DeleteTenant: &cmd.ManageAction{Run: func(t string) error {
+				return pulsarpkg.DeleteTenant(connArgs, adminPort, t)
+			}},
+			CreateNamespace: &cmd.ManageAction{Run: func(ns string) error {
+				n, err := resolveNamespace(ns)
+				if err != nil {
+					return err
+				}
+				return pulsarpkg.CreateNamespace(connArgs, adminPort, n)
+			}},
+			DeleteNamespace: &cmd.ManageAction{
+				SetupFlags: func(c *cobra.Command) {
+					c.Flags().BoolVar(&forceDeleteNamespace, "force", false, "Also delete the namespace's topics (needs forceDeleteNamespaceAllowed on the broker)")
+				},
+				Run: func(ns string) error {
+					n, err := resolveNamespace(ns)
+					if err != nil {
+						return err
+					}
+					return pulsarpkg.DeleteNamespace(connArgs, adminPort, n, forceDeleteNamespace)
+				},
+			},
+			GetNamespacePolicies: func(ns string) (*backends.NamespacePolicies, error) {
+				n, err := resolveNamespace(ns)
+				if err != nil {
+					return nil, err
+				}
+				return pulsarpkg.GetNamespacePolicies(connArgs, adminPort, n)
+			},
+			SetNamespacePolicies: func(ns string, set backends.NamespacePolicies, remove []string) error {
+				n, err := resolveNamespace(ns)
+				if err != nil {
+					return err
+				}
+				return pulsarpkg.SetNamespacePolicies(connArgs, adminPort, n, set, remove)
+			},
 		},
 		Extra: []*cobra.Command{
 			mcp.NewCommand(mcp.Deps{
@@ -86,10 +245,10 @@ func GetRootCommand() *cobra.Command {
 				ServerVersion: cmd.Version(),
 				Target:        connArgs.Server,
 				NewQueue: func() (backends.QueueBackend, error) {
-					return pulsarpkg.NewQueueAdapter(connArgs)
+					return pulsarpkg.NewQueueAdapter(connArgs, adminPort)
 				},
 				NewTopic: func() (backends.TopicBackend, error) {
-					return pulsarpkg.NewTopicAdapter(connArgs)
+					return pulsarpkg.NewTopicAdapter(connArgs, adminPort)
 				},
 			}),
 		},
@@ -4,49 +4,98 @@ package pulsar
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	pulsar "github.com/apache/pulsar-client-go/pulsar"
 )
 
 type consumerKey struct {
-	topic string
-	sub   string
+	topic    string
+	sub      string
+	settings string // type, position and DLQ policy; a change needs a new consumer
 }
 
 // clientCache holds a Pulsar client plus caches of producers/consumers so that
 // repeated Send/Receive calls on the same topic reuse the same resources.
+// connArgs and adminPort reach the schema registry for --schema.
 type clientCache struct {
 	client    pulsar.Client
+	connArgs  ConnArguments
+	adminPort int
 	mu        sync.Mutex
 	producers map[string]pulsar.Producer
 	consumers map[consumerKey]pulsar.Consumer
+	schemas   map[string]pulsar.Schema
 }
 
-func newClientCache(client pulsar.Client) *clientCache {
+func newClientCache(client pulsar.Client, connArgs ConnArguments, adminPort int) *clientCache {
 	return &clientCache{
 		client:    client,
+		connArgs:  connArgs,
+		adminPort: adminPort,
 		producers: make(map[string]pulsar.Producer),
 		consumers: make(map[consumerKey]pulsar.Consumer),
+		schemas:   make(map[string]pulsar.Schema),
 	}
 }
 
-func (c *clientCache) getProducer(topic string) (pulsar.Producer, error) {
+// getSchema resolves a --schema value for topic once per adapter; nil means
+// raw bytes.
+func (c *clientCache) getSchema(flag, topic string, publish bool) (pulsar.Schema, error) {
+	spec, err := parseSchemaSpec(flag)
+	if err != nil || spec.kind == "" {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s|%s|%v", flag, topic, publish)
+	c.mu.Lock()
+	schema, ok := c.schemas[key]
+	c.mu.Unlock()
+	if ok {
+		return schema, nil
+	}
+	if topic == "" && spec.needsRegistry() {
+		return nil, fmt.Errorf("--schema %s needs a single topic; give a definition file instead", flag)
+	}
+	schema, err = newSchema(c.connArgs, c.adminPort, spec, topic, publish)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.schemas[key] = schema
+	c.mu.Unlock()
+	return schema, nil
+}
+
+func (c *clientCache) getProducer(topic string, schema pulsar.Schema) (pulsar.Producer, error) {
+	key := topic + "|" + schemaKey(schema)
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	if p, ok := c.producers[topic]; ok {
+	if p, ok := c.producers[key]; ok {
 		return p, nil
 	}
-	p, err := c.client.CreateProducer(pulsar.ProducerOptions{Topic: topic})
+	p, err := c.client.CreateProducer(pulsar.ProducerOptions{Topic: topic, Schema: schema})
 	if err != nil {
 		return nil, fmt.Errorf("creating producer for %s: %w", topic, err)
 	}
-	c.producers[topic] = p
+	c.producers[key] = p
 	return p, nil
 }
 
 func (c *clientCache) getConsumer(opts pulsar.ConsumerOptions) (pulsar.Consumer, error) {
-	key := consumerKey{topic: opts.Topic, sub: opts.SubscriptionName}
+	topic := opts.Topic
+	switch {
+	case opts.TopicsPattern != "":
+		topic = opts.TopicsPattern
+	case len(opts.Topics) > 0:
+		topic = strings.Join(opts.Topics, ",")
+	}
+	settings := fmt.Sprintf("%d/%d/%v/%s", opts.Type, opts.SubscriptionInitialPosition, opts.RetryEnable, opts.NackRedeliveryDelay)
+	settings += "/" + schemaKey(opts.Schema)
+	if opts.DLQ != nil {
+		settings += fmt.Sprintf("/%d/%s/%s", opts.DLQ.MaxDeliveries, opts.DLQ.DeadLetterTopic, opts.DLQ.RetryLetterTopic)
+	}
+	key := consumerKey{topic: topic, sub: opts.SubscriptionName, settings: settings}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	if cons, ok := c.consumers[key]; ok {
@@ -54,7 +103,7 @@ func (c *clientCache) getConsumer(opts pulsar.ConsumerOptions) (pulsar.Consumer,
 	}
 	cons, err := c.client.Subscribe(opts)
 	if err != nil {
-		return nil, fmt.Errorf("subscribing to %s: %w", opts.Topic, err)
+		return nil, fmt.Errorf("subscribing to %s: %w", topic, err)
 	}
 	c.consumers[key] = cons
 	return cons, nil
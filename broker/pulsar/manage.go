@@ -18,6 +18,16 @@ type TopicInfo struct {
 	Name string
 }
 
+// adminError is a non-success response from the Admin REST API.
+type adminError struct {
+	Status int
+	Body   string
+}
+
+func (e *adminError) Error() string {
+	return fmt.Sprintf("admin API returned %d: %s", e.Status, e.Body)
+}
+
 // applyAdminAuth adds the CLI's credentials to an Admin REST API request:
 // --token as a Bearer token, --user/--password as basic auth.
 func applyAdminAuth(req *http.Request, args ConnArguments) {
@@ -51,10 +61,11 @@ func adminRequest(args ConnArguments, method, endpoint string) error {
 	return nil
 }
 
-// adminPutJSON performs a PUT with a JSON body against the Pulsar Admin REST API.
-func adminPutJSON(args ConnArguments, endpoint string, body []byte) error {
+// adminSendJSON performs a request with a JSON body (PUT, POST) against the
+// Pulsar Admin REST API.
+func adminSendJSON(args ConnArguments, method, endpoint string, body []byte) error {
 	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("PUT", endpoint, bytes.NewReader(body))
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
@@ -74,6 +85,31 @@ func adminPutJSON(args ConnArguments, endpoint string, body []byte) error {
 	return nil
 }
 
+// adminGetJSON performs a GET against the Pulsar Admin REST API and decodes
+// the JSON response into out.
+func adminGetJSON(args ConnArguments, endpoint string, out any) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	applyAdminAuth(req, args)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("querying Pulsar admin API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &adminError{Status: resp.StatusCode, Body: string(body)}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding admin response: %w", err)
+	}
+	return nil
+}
+
 // CreateTopic creates a topic via the Admin REST API. If partitions > 0,
 // a partitioned topic is created; otherwise a non-partitioned topic.
 func CreateTopic(connArgs ConnArguments, adminPort int, topic, tenant, namespace string, nonPersistent bool, partitions int) error {
@@ -82,7 +118,7 @@ func CreateTopic(connArgs ConnArguments, adminPort int, topic, tenant, namespace
 	if partitions > 0 {
 		endpoint := fmt.Sprintf("%s/admin/v2/%s/%s/%s/%s/partitions", adminURL, persistence, tenant, namespace, url.PathEscape(topic))
 		body := fmt.Sprintf("%d", partitions)
-		return adminPutJSON(connArgs, endpoint, []byte(body))
+		return adminSendJSON(connArgs, "PUT", endpoint, []byte(body))
 	}
 	endpoint := fmt.Sprintf("%s/admin/v2/%s/%s/%s/%s", adminURL, persistence, tenant, namespace, url.PathEscape(topic))
 	return adminRequest(connArgs, "PUT", endpoint)
@@ -102,26 +138,9 @@ func ListTopics(connArgs ConnArguments, adminPort int, tenant, namespace string,
 	persistence := persistenceScheme(nonPersistent)
 	endpoint := fmt.Sprintf("%s/admin/v2/%s/%s/%s", adminURL, persistence, tenant, namespace)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
-	applyAdminAuth(req, connArgs)
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("querying Pulsar admin API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("admin API returned %d: %s", resp.StatusCode, string(body))
-	}
-
 	var topics []string
-	if err := json.NewDecoder(resp.Body).Decode(&topics); err != nil {
-		return nil, fmt.Errorf("decoding admin response: %w", err)
+	if err := adminGetJSON(connArgs, endpoint, &topics); err != nil {
+		return nil, err
 	}
 
 	result := make([]TopicInfo, len(topics))
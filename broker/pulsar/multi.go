@@ -0,0 +1,71 @@
+//go:build pulsar
+
+package pulsar
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// SubscribeTopics implements backends.MultiTopicBackend.
+// Plain topics become one multi-topic consumer. As soon as one topic has
+// shell wildcards (*, ?, [...]) the whole set becomes a Pulsar topic pattern
+// (a regex over one namespace), which also picks up matching topics created
+// while subscribed. Each message is labelled with its fully-qualified topic.
+func (a *TopicAdapter) SubscribeTopics(ctx context.Context, topics []string, opts backends.SubscribeOptions) (*backends.Message, error) {
+	consumerOpts := a.subscription(opts)
+	if !containsGlob(topics) {
+		consumerOpts.Topics = topics
+	} else {
+		pattern, err := topicsPattern(topics)
+		if err != nil {
+			return nil, err
+		}
+		consumerOpts.TopicsPattern = pattern
+	}
+
+	return a.receive(ctx, consumerOpts, opts)
+}
+
+func containsGlob(topics []string) bool {
+	for _, t := range topics {
+		if backends.HasGlob(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// topicsPattern folds fully-qualified topics and globs into a single
+// TopicsPattern: <scheme>://<tenant>/<namespace>/(alt|alt...). Pulsar pattern
+// subscriptions are scoped to one namespace, so all topics must share it.
+func topicsPattern(topics []string) (string, error) {
+	var namespace string
+	alts := make([]string, 0, len(topics))
+	for _, t := range topics {
+		i := strings.LastIndexByte(t, '/')
+		if i < 0 {
+			return "", fmt.Errorf("topic %q is not fully qualified", t)
+		}
+		ns, name := t[:i+1], t[i+1:]
+		if namespace == "" {
+			namespace = ns
+		} else if ns != namespace {
+			return "", fmt.Errorf("topic patterns must stay within one namespace: %s and %s differ", strings.TrimSuffix(namespace, "/"), strings.TrimSuffix(ns, "/"))
+		}
+		if backends.HasGlob(name) {
+			alts = append(alts, strings.TrimSuffix(strings.TrimPrefix(backends.GlobRegexp(name), "^"), "$"))
+		} else {
+			alts = append(alts, regexp.QuoteMeta(name))
+		}
+	}
+	pattern := namespace + "(" + strings.Join(alts, "|") + ")$"
+	if _, err := regexp.Compile(pattern); err != nil {
+		return "", fmt.Errorf("invalid topic pattern: %w", err)
+	}
+	return pattern, nil
+}
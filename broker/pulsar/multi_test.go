@@ -0,0 +1,19 @@
+//go:build pulsar
+
+package pulsar
+
+import "testing"
+
+func TestTopicsPattern(t *testing.T) {
+	got, err := topicsPattern([]string{"persistent://public/default/orders-*", "persistent://public/default/audit.log"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `persistent://public/default/(orders-.*|audit\.log)$`; got != want {
+		t.Errorf("pattern = %q, want %q", got, want)
+	}
+
+	if _, err := topicsPattern([]string{"persistent://public/a/x*", "persistent://public/b/y"}); err == nil {
+		t.Error("topics in different namespaces should be rejected")
+	}
+}
@@ -0,0 +1,280 @@
+//go:build pulsar
+
+package pulsar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// backlogQuotaType is the quota pmc manages; Pulsar also has a message-age
+// quota that xmc leaves alone.
+const backlogQuotaType = "destination_storage"
+
+// ResolveNamespace maps a namespace argument to tenant/namespace: a bare name
+// is placed in tenant.
+func ResolveNamespace(namespace, tenant string) (string, error) {
+	if !strings.Contains(namespace, "/") {
+		namespace = tenant + "/" + namespace
+	}
+	t, ns, _ := strings.Cut(namespace, "/")
+	if t == "" || ns == "" || strings.Contains(ns, "/") {
+		return "", fmt.Errorf("invalid namespace %q: expected tenant/namespace", namespace)
+	}
+	return namespace, nil
+}
+
+func namespaceEndpoint(connArgs ConnArguments, adminPort int, namespace string) string {
+	t, ns, _ := strings.Cut(namespace, "/")
+	return fmt.Sprintf("%s/admin/v2/namespaces/%s/%s", buildAdminURL(connArgs.Server, adminPort), url.PathEscape(t), url.PathEscape(ns))
+}
+
+// ListTenants returns the names of all tenants.
+func ListTenants(connArgs ConnArguments, adminPort int) ([]string, error) {
+	var tenants []string
+	err := adminGetJSON(connArgs, buildAdminURL(connArgs.Server, adminPort)+"/admin/v2/tenants", &tenants)
+	return tenants, err
+}
+
+// ListNamespaces returns a tenant's namespaces as tenant/namespace.
+func ListNamespaces(connArgs ConnArguments, adminPort int, tenant string) ([]string, error) {
+	var namespaces []string
+	err := adminGetJSON(connArgs, buildAdminURL(connArgs.Server, adminPort)+"/admin/v2/namespaces/"+url.PathEscape(tenant), &namespaces)
+	return namespaces, err
+}
+
+// ListTenantsWithNamespaces lists the tenants with their namespaces as
+// children and the namespaces' topics below those. A namespace whose topics
+// cannot be listed (e.g. a 403 for a namespace the role may not read) is
+// listed without children.
+func ListTenantsWithNamespaces(connArgs ConnArguments, adminPort int, nonPersistent bool) ([]backends.ObjectNode, error) {
+	tenants, err := ListTenants(connArgs, adminPort)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]backends.ObjectNode, len(tenants))
+	for i, tenant := range tenants {
+		nodes[i] = backends.ObjectNode{Name: tenant, Kind: "tenant"}
+		namespaces, err := ListNamespaces(connArgs, adminPort, tenant)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range namespaces {
+			node := backends.ObjectNode{Name: ns, Kind: "namespace"}
+			_, name, _ := strings.Cut(ns, "/")
+			if topics, err := ListTopics(connArgs, adminPort, tenant, name, nonPersistent); err == nil {
+				for _, t := range topics {
+					node.Children = append(node.Children, backends.ObjectNode{Name: t.Name, Kind: "topic"})
+				}
+				node.Metrics = []backends.Metric{{Label: "topics", Value: int64(len(topics))}}
+			}
+			nodes[i].Children = append(nodes[i].Children, node)
+		}
+		nodes[i].Metrics = []backends.Metric{{Label: "namespaces", Value: int64(len(namespaces))}}
+	}
+	return nodes, nil
+}
+
+// CreateTenant creates a tenant administered by adminRoles. Without
+// allowedClusters the tenant may use every cluster of the instance.
+func CreateTenant(connArgs ConnArguments, adminPort int, tenant string, adminRoles, allowedClusters []string) error {
+	adminURL := buildAdminURL(connArgs.Server, adminPort)
+	if len(allowedClusters) == 0 {
+		if err := adminGetJSON(connArgs, adminURL+"/admin/v2/clusters", &allowedClusters); err != nil {
+			return fmt.Errorf("listing clusters: %w", err)
+		}
+	}
+	if adminRoles == nil {
+		adminRoles = []string{}
+	}
+	body, err := json.Marshal(map[string][]string{"adminRoles": adminRoles, "allowedClusters": allowedClusters})
+	if err != nil {
+		return err
+	}
+	return adminSendJSON(connArgs, "PUT", adminURL+"/admin/v2/tenants/"+url.PathEscape(tenant), body)
+}
+
+// DeleteTenant deletes a tenant. The broker refuses while it has namespaces.
+func DeleteTenant(connArgs ConnArguments, adminPort int, tenant string) error {
+	return adminRequest(connArgs, "DELETE", buildAdminURL(connArgs.Server, adminPort)+"/admin/v2/tenants/"+url.PathEscape(tenant))
+}
+
+// CreateNamespace creates a namespace (tenant/namespace).
+func CreateNamespace(connArgs ConnArguments, adminPort int, namespace string) error {
+	return adminRequest(connArgs, "PUT", namespaceEndpoint(connArgs, adminPort, namespace))
+}
+
+// DeleteNamespace deletes a namespace. Without force the broker refuses while
+// it has topics; force also deletes them (if the broker allows it).
+func DeleteNamespace(connArgs ConnArguments, adminPort int, namespace string, force bool) error {
+	endpoint := namespaceEndpoint(connArgs, adminPort, namespace)
+	if force {
+		endpoint += "?force=true"
+	}
+	return adminRequest(connArgs, "DELETE", endpoint)
+}
+
+type adminRetention struct {
+	RetentionTimeInMinutes int64 `json:"retentionTimeInMinutes"`
+	RetentionSizeInMB      int64 `json:"retentionSizeInMB"`
+}
+
+type adminBacklogQuota struct {
+	LimitSize int64  `json:"limitSize"`
+	Policy    string `json:"policy"`
+}
+
+// getPolicy reads one namespace policy into out, which stays untouched when
+// the policy is not set.
+func getPolicy(connArgs ConnArguments, endpoint string, out any) error {
+	err := adminGetJSON(connArgs, endpoint, out)
+	var apiErr *adminError
+	if errors.Is(err, io.EOF) || errors.As(err, &apiErr) && apiErr.Status == http.StatusNoContent {
+		return nil
+	}
+	return err
+}
+
+// GetNamespacePolicies returns a namespace's retention, TTL, backlog quota and
+// deduplication policies.
+func GetNamespacePolicies(connArgs ConnArguments, adminPort int, namespace string) (*backends.NamespacePolicies, error) {
+	endpoint := namespaceEndpoint(connArgs, adminPort, namespace)
+	var policies backends.NamespacePolicies
+
+	var retention *adminRetention
+	if err := getPolicy(connArgs, endpoint+"/retention", &retention); err != nil {
+		return nil, err
+	}
+	if retention != nil {
+		t := time.Duration(retention.RetentionTimeInMinutes) * time.Minute
+		if retention.RetentionTimeInMinutes < 0 {
+			t = -1
+		}
+		policies.RetentionTime, policies.RetentionSizeMB = &t, &retention.RetentionSizeInMB
+	}
+
+	var ttl *int64
+	if err := getPolicy(connArgs, endpoint+"/messageTTL", &ttl); err != nil {
+		return nil, err
+	}
+	if ttl != nil {
+		d := time.Duration(*ttl) * time.Second
+		policies.MessageTTL = &d
+	}
+
+	var quotas map[string]adminBacklogQuota
+	if err := getPolicy(connArgs, endpoint+"/backlogQuotaMap", &quotas); err != nil {
+		return nil, err
+	}
+	if quota, ok := quotas[backlogQuotaType]; ok {
+		policies.BacklogQuotaLimit, policies.BacklogQuotaPolicy = &quota.LimitSize, quota.Policy
+	}
+
+	if err := getPolicy(connArgs, endpoint+"/deduplication", &policies.Deduplication); err != nil {
+		return nil, err
+	}
+	return &policies, nil
+}
+
+// namespacePolicyNames are the policies SetNamespacePolicies can remove.
+var namespacePolicyNames = []string{"retention", "ttl", "backlog-quota", "deduplication"}
+
+// SetNamespacePolicies sets the non-nil policies of set and removes the ones
+// named in remove (retention, ttl, backlog-quota, deduplication), so the
+// broker defaults apply again. A retention time or size given alone keeps the
+// namespace's current value for the other, and likewise for the backlog
+// quota's limit and policy.
+func SetNamespacePolicies(connArgs ConnArguments, adminPort int, namespace string, set backends.NamespacePolicies, remove []string) error {
+	for _, name := range remove {
+		if !slices.Contains(namespacePolicyNames, name) {
+			return fmt.Errorf("unknown policy %q (use %s)", name, strings.Join(namespacePolicyNames, ", "))
+		}
+	}
+	endpoint := namespaceEndpoint(connArgs, adminPort, namespace)
+	current := &backends.NamespacePolicies{}
+	if (set.RetentionTime == nil) != (set.RetentionSizeMB == nil) || (set.BacklogQuotaLimit == nil) != (set.BacklogQuotaPolicy == "") {
+		var err error
+		if current, err = GetNamespacePolicies(connArgs, adminPort, namespace); err != nil {
+			return err
+		}
+	}
+
+	if set.RetentionTime != nil || set.RetentionSizeMB != nil {
+		var retention adminRetention
+		if t := firstNonNil(set.RetentionTime, current.RetentionTime); t != nil {
+			retention.RetentionTimeInMinutes = int64(*t / time.Minute)
+			if *t < 0 {
+				retention.RetentionTimeInMinutes = -1
+			}
+		}
+		if size := firstNonNil(set.RetentionSizeMB, current.RetentionSizeMB); size != nil {
+			retention.RetentionSizeInMB = *size
+		}
+		if err := postPolicy(connArgs, endpoint+"/retention", retention); err != nil {
+			return err
+		}
+	}
+	if set.MessageTTL != nil {
+		if err := postPolicy(connArgs, endpoint+"/messageTTL", int64(*set.MessageTTL/time.Second)); err != nil {
+			return err
+		}
+	}
+	if set.BacklogQuotaLimit != nil || set.BacklogQuotaPolicy != "" {
+		quota := adminBacklogQuota{LimitSize: -1, Policy: "producer_request_hold"}
+		if limit := firstNonNil(set.BacklogQuotaLimit, current.BacklogQuotaLimit); limit != nil {
+			quota.LimitSize = *limit
+		}
+		if set.BacklogQuotaPolicy != "" {
+			quota.Policy = set.BacklogQuotaPolicy
+		} else if current.BacklogQuotaPolicy != "" {
+			quota.Policy = current.BacklogQuotaPolicy
+		}
+		if err := postPolicy(connArgs, endpoint+"/backlogQuota?backlogQuotaType="+backlogQuotaType, quota); err != nil {
+			return err
+		}
+	}
+	if set.Deduplication != nil {
+		if err := postPolicy(connArgs, endpoint+"/deduplication", *set.Deduplication); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range remove {
+		path := map[string]string{
+			"retention":     "/retention",
+			"ttl":           "/messageTTL",
+			"backlog-quota": "/backlogQuota?backlogQuotaType=" + backlogQuotaType,
+			"deduplication": "/deduplication",
+		}[name]
+		if err := adminRequest(connArgs, "DELETE", endpoint+path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func postPolicy(connArgs ConnArguments, endpoint string, value any) error {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return adminSendJSON(connArgs, "POST", endpoint, body)
+}
+
+func firstNonNil[T any](values ...*T) *T {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+//go:build pulsar
+
+package pulsar
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+func TestResolveNamespace(t *testing.T) {
+	for in, want := range map[string]string{"pr-1": "acme/pr-1", "other/pr-1": "other/pr-1"} {
+		if got, err := ResolveNamespace(in, "acme"); err != nil || got != want {
+			t.Errorf("ResolveNamespace(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"a/b/c", "/pr-1"} {
+		if _, err := ResolveNamespace(bad, "acme"); err == nil {
+			t.Errorf("ResolveNamespace(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestListTenantsWithNamespaces(t *testing.T) {
+	connArgs, port, _ := fakeAdmin(t, map[string]string{
+		"/admin/v2/tenants":              `["acme","public"]`,
+		"/admin/v2/namespaces/acme":      `["acme/pr-1","acme/pr-2"]`,
+		"/admin/v2/namespaces/public":    `["public/default"]`,
+		"/admin/v2/persistent/acme/pr-1": `["persistent://acme/pr-1/orders"]`,
+		"/admin/v2/persistent/acme/pr-2": `[]`,
+		// public/default's topics fail to list (404 here, 403 without permission)
+	})
+	tenants, err := ListTenantsWithNamespaces(connArgs, port, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tenants) != 2 || len(tenants[0].Children) != 2 || tenants[0].Children[1].Name != "acme/pr-2" {
+		t.Fatalf("tenants = %+v", tenants)
+	}
+	if topics := tenants[0].Children[0].Children; len(topics) != 1 || topics[0].Name != "persistent://acme/pr-1/orders" {
+		t.Errorf("acme/pr-1 topics = %+v", topics)
+	}
+	if public := tenants[1].Children; len(public) != 1 || public[0].Name != "public/default" || public[0].Children != nil {
+		t.Errorf("public namespaces = %+v, want public/default without children", public)
+	}
+}
+
+func TestNamespacePolicies(t *testing.T) {
+	base := "/admin/v2/namespaces/acme/pr-1"
+	connArgs, port, requests := fakeAdmin(t, map[string]string{
+		base + "/retention":       `{"retentionTimeInMinutes":60,"retentionSizeInMB":-1}`,
+		base + "/messageTTL":      `null`,
+		base + "/backlogQuotaMap": `{"destination_storage":{"limitSize":1024,"policy":"producer_exception"}}`,
+		base + "/deduplication":   `true`,
+	})
+
+	p, err := GetNamespacePolicies(connArgs, port, "acme/pr-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *p.RetentionTime != time.Hour || *p.RetentionSizeMB != -1 || p.MessageTTL != nil || *p.BacklogQuotaLimit != 1024 ||
+		p.BacklogQuotaPolicy != "producer_exception" || !*p.Deduplication {
+		t.Errorf("policies = %+v", p)
+	}
+
+	*requests = nil
+	week, ttl, dedup := 7*24*time.Hour, 90*time.Second, false
+	err = SetNamespacePolicies(connArgs, port, "acme/pr-1", backends.NamespacePolicies{
+		RetentionTime: &week, MessageTTL: &ttl, BacklogQuotaPolicy: "consumer_backlog_eviction", Deduplication: &dedup,
+	}, []string{"ttl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The retention size and the quota limit are kept from the current policies.
+	for _, want := range []string{
+		"POST " + base + `/retention {"retentionTimeInMinutes":10080,"retentionSizeInMB":-1}`,
+		"POST " + base + "/messageTTL 90",
+		"POST " + base + `/backlogQuota?backlogQuotaType=destination_storage {"limitSize":1024,"policy":"consumer_backlog_eviction"}`,
+		"POST " + base + "/deduplication false",
+		"DELETE " + base + "/messageTTL",
+	} {
+		if !slices.Contains(*requests, want) {
+			t.Errorf("requests %v miss %q", *requests, want)
+		}
+	}
+
+	if err := SetNamespacePolicies(connArgs, port, "acme/pr-1", backends.NamespacePolicies{}, []string{"compaction"}); err == nil {
+		t.Error("removing an unknown policy: expected an error")
+	}
+}
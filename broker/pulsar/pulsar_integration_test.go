@@ -8,6 +8,8 @@ import (
 	"math/rand"
 	"os"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -17,6 +19,7 @@ import (
 )
 
 var testServer string
+var testAdminPort int
 
 func TestMain(m *testing.M) {
 	ctx := context.Background()
@@ -27,6 +30,12 @@ func TestMain(m *testing.M) {
 	}
 	defer broker.Terminate(ctx)
 	testServer = broker.URL
+	adminPort, err := broker.Container.MappedPort(ctx, "8080")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to map the Pulsar admin port: %v\n", err)
+		os.Exit(1)
+	}
+	testAdminPort, _ = strconv.Atoi(adminPort.Port())
 	os.Exit(m.Run())
 }
 
@@ -42,7 +51,7 @@ func newQueueAdapter(t *testing.T) *QueueAdapter {
 	var a *QueueAdapter
 	err := integration.WaitForBroker(func() error {
 		var e error
-		a, e = NewQueueAdapter(makeConnArgs())
+		a, e = NewQueueAdapter(makeConnArgs(), testAdminPort)
 		return e
 	}, 30*time.Second)
 	if err != nil {
@@ -57,7 +66,7 @@ func newTopicAdapter(t *testing.T) *TopicAdapter {
 	var a *TopicAdapter
 	err := integration.WaitForBroker(func() error {
 		var e error
-		a, e = NewTopicAdapter(makeConnArgs())
+		a, e = NewTopicAdapter(makeConnArgs(), testAdminPort)
 		return e
 	}, 30*time.Second)
 	if err != nil {
@@ -332,3 +341,165 @@ func TestPulsar_TopicSubscribe_GroupID(t *testing.T) {
 		t.Errorf("payload: got %q, want %q", res.msg.Data, payload)
 	}
 }
+
+// TestPulsar_SubscriptionAdmin verifies that a subscription created at the
+// earliest position counts the topic's messages as backlog, that purging it
+// clears the backlog, and that it can be deleted.
+func TestPulsar_SubscriptionAdmin(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	topic := "persistent://public/default/test-sub-admin-" + randomSuffix()
+
+	pubAdapter := newTopicAdapter(t)
+	defer pubAdapter.Close()
+	for i := 0; i < 3; i++ {
+		if err := pubAdapter.Publish(ctx, backends.PublishOptions{Topic: topic, Message: []byte(fmt.Sprintf("m%d", i))}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	if err := CreateSubscription(makeConnArgs(), testAdminPort, topic, "replay", true); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	subs, err := ListSubscriptions(makeConnArgs(), testAdminPort, topic)
+	if err != nil {
+		t.Fatalf("ListSubscriptions: %v", err)
+	}
+	if len(subs) != 1 || subs[0].Name != "replay" || subs[0].Backlog != 3 {
+		t.Fatalf("subscriptions = %+v, want replay with a backlog of 3", subs)
+	}
+
+	purged, err := PurgeSubscription(makeConnArgs(), testAdminPort, topic, "replay")
+	if err != nil || purged != 3 {
+		t.Fatalf("PurgeSubscription = %d, %v; want 3", purged, err)
+	}
+	stats, err := TopicStats(makeConnArgs(), testAdminPort, topic)
+	if err != nil {
+		t.Fatalf("TopicStats: %v", err)
+	}
+	if len(stats.Subscriptions) != 1 || stats.Subscriptions[0].Backlog != 0 {
+		t.Errorf("after purge: subscriptions = %+v, want no backlog", stats.Subscriptions)
+	}
+
+	if err := DeleteSubscription(makeConnArgs(), testAdminPort, topic, "replay"); err != nil {
+		t.Fatalf("DeleteSubscription: %v", err)
+	}
+	if subs, _ := ListSubscriptions(makeConnArgs(), testAdminPort, topic); len(subs) != 0 {
+		t.Errorf("after delete: subscriptions = %+v", subs)
+	}
+}
+
+func TestPulsar_DeadLetterAndSeek(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	topic := "persistent://public/default/test-dlq-" + randomSuffix()
+	dlq := topic + "-dlq"
+
+	adapter := newTopicAdapter(t)
+	defer adapter.Close()
+	extra := map[string]string{"subscription-type": "key_shared", "initial-position": "earliest", "max-redeliveries": "2", "dead-letter-topic": dlq, "nack": "true", "nack-delay": "100ms"}
+	// Create the subscription before publishing.
+	if _, err := adapter.Subscribe(ctx, backends.SubscribeOptions{Topic: topic, GroupID: "workers", Timeout: 1, Extra: extra}); err != backends.ErrNoMessageAvailable {
+		t.Fatalf("Subscribe on an empty topic: %v", err)
+	}
+	if err := adapter.Publish(ctx, backends.PublishOptions{Topic: topic, Key: "k", Message: []byte("poison")}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := adapter.Subscribe(ctx, backends.SubscribeOptions{Topic: topic, GroupID: "workers", Timeout: 15, Extra: extra}); err != nil {
+			t.Fatalf("delivery %d: %v", i+1, err)
+		}
+	}
+
+	msg, err := adapter.Subscribe(ctx, backends.SubscribeOptions{Topic: dlq, GroupID: "dlq-reader", Timeout: 30, Extra: map[string]string{"initial-position": "earliest"}})
+	if err != nil {
+		t.Fatalf("reading the dead-letter topic: %v", err)
+	}
+	if string(msg.Data) != "poison" {
+		t.Errorf("dead-lettered message = %q, want poison", msg.Data)
+	}
+
+	// Rewind the dead-letter subscription and read the message again.
+	if err := SeekSubscription(makeConnArgs(), testAdminPort, dlq, "dlq-reader", "earliest"); err != nil {
+		t.Fatalf("SeekSubscription: %v", err)
+	}
+	msg, err = adapter.Subscribe(ctx, backends.SubscribeOptions{Topic: dlq, GroupID: "dlq-reader", Timeout: 30, Extra: map[string]string{"initial-position": "earliest"}})
+	if err != nil || string(msg.Data) != "poison" {
+		t.Errorf("after seek: %v, %v; want poison again", msg, err)
+	}
+}
+
+func TestPulsar_AvroSchema(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	topic := "persistent://public/default/test-schema-" + randomSuffix()
+	definition := `{"type":"record","name":"Order","fields":[{"name":"id","type":"long"},{"name":"note","type":["null","string"],"default":null}]}`
+	if err := UploadSchema(makeConnArgs(), testAdminPort, topic, backends.TopicSchema{Type: "avro", Definition: definition}); err != nil {
+		t.Fatalf("UploadSchema: %v", err)
+	}
+	schema, err := GetSchema(makeConnArgs(), testAdminPort, topic)
+	if err != nil || schema == nil || schema.Type != "AVRO" {
+		t.Fatalf("GetSchema = %+v, %v; want an AVRO schema", schema, err)
+	}
+
+	adapter := newTopicAdapter(t)
+	defer adapter.Close()
+	auto := map[string]string{"schema": "auto"}
+	if _, err := adapter.Subscribe(ctx, backends.SubscribeOptions{Topic: topic, GroupID: "readers", Timeout: 1, Extra: auto}); err != backends.ErrNoMessageAvailable {
+		t.Fatalf("Subscribe on an empty topic: %v", err)
+	}
+	if err := adapter.Publish(ctx, backends.PublishOptions{Topic: topic, Message: []byte(`{"id":42,"note":"rush"}`), Extra: auto}); err != nil {
+		t.Fatalf("Publish with the topic's schema: %v", err)
+	}
+	msg, err := adapter.Subscribe(ctx, backends.SubscribeOptions{Topic: topic, GroupID: "readers", Timeout: 15, Extra: auto})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if string(msg.Data) != `{"id":42,"note":"rush"}` {
+		t.Errorf("decoded message = %s", msg.Data)
+	}
+
+	if err := DeleteSchema(makeConnArgs(), testAdminPort, topic); err != nil {
+		t.Fatalf("DeleteSchema: %v", err)
+	}
+}
+
+func TestPulsar_TenantNamespacePolicies(t *testing.T) {
+	t.Parallel()
+	tenant := "xmc-" + randomSuffix()
+	namespace := tenant + "/pr-1"
+	if err := CreateTenant(makeConnArgs(), testAdminPort, tenant, nil, nil); err != nil {
+		t.Fatalf("CreateTenant: %v", err)
+	}
+	if err := CreateNamespace(makeConnArgs(), testAdminPort, namespace); err != nil {
+		t.Fatalf("CreateNamespace: %v", err)
+	}
+
+	ttl, dedup := 10*time.Minute, true
+	if err := SetNamespacePolicies(makeConnArgs(), testAdminPort, namespace, backends.NamespacePolicies{MessageTTL: &ttl, Deduplication: &dedup}, nil); err != nil {
+		t.Fatalf("SetNamespacePolicies: %v", err)
+	}
+	p, err := GetNamespacePolicies(makeConnArgs(), testAdminPort, namespace)
+	if err != nil {
+		t.Fatalf("GetNamespacePolicies: %v", err)
+	}
+	if p.MessageTTL == nil || *p.MessageTTL != ttl || p.Deduplication == nil || !*p.Deduplication {
+		t.Errorf("policies = %+v, want a 10m TTL and deduplication", p)
+	}
+
+	tenants, err := ListTenantsWithNamespaces(makeConnArgs(), testAdminPort, false)
+	if err != nil {
+		t.Fatalf("ListTenantsWithNamespaces: %v", err)
+	}
+	i := slices.IndexFunc(tenants, func(n backends.ObjectNode) bool { return n.Name == tenant })
+	if i < 0 || len(tenants[i].Children) != 1 || tenants[i].Children[0].Name != namespace {
+		t.Errorf("tenants = %+v, want %s with %s", tenants, tenant, namespace)
+	}
+
+	if err := DeleteNamespace(makeConnArgs(), testAdminPort, namespace, false); err != nil {
+		t.Fatalf("DeleteNamespace: %v", err)
+	}
+	if err := DeleteTenant(makeConnArgs(), testAdminPort, tenant); err != nil {
+		t.Fatalf("DeleteTenant: %v", err)
+	}
+}
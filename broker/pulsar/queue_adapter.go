@@ -6,7 +6,6 @@ import (
 	"context"
 	"errors"
 	"strconv"
-	"time"
 
 	pulsar "github.com/apache/pulsar-client-go/pulsar"
 	"github.com/makibytes/xmc/broker/backends"
@@ -22,27 +21,34 @@ type QueueAdapter struct {
 	*clientCache
 }
 
-// NewQueueAdapter creates a new Pulsar queue adapter.
-func NewQueueAdapter(connArgs ConnArguments) (*QueueAdapter, error) {
+// NewQueueAdapter creates a new Pulsar queue adapter. adminPort is used to
+// look up topic schemas for --schema.
+func NewQueueAdapter(connArgs ConnArguments, adminPort int) (*QueueAdapter, error) {
 	client, err := Connect(connArgs)
 	if err != nil {
 		return nil, err
 	}
-	return &QueueAdapter{clientCache: newClientCache(client)}, nil
+	return &QueueAdapter{clientCache: newClientCache(client, connArgs, adminPort)}, nil
 }
 
 // Send implements backends.QueueBackend.
 func (a *QueueAdapter) Send(ctx context.Context, opts backends.SendOptions) error {
-	producer, err := a.getProducer(opts.Queue)
+	schema, err := a.getSchema(opts.Extra["schema"], opts.Queue, true)
+	if err != nil {
+		return err
+	}
+	producer, err := a.getProducer(opts.Queue, schema)
 	if err != nil {
 		return err
 	}
 
 	msg := &pulsar.ProducerMessage{
-		Payload:    opts.Message,
 		Key:        opts.Key,
 		Properties: backends.StringifyProps(opts.Properties),
 	}
+	if err := setSchemaPayload(msg, schema, opts.Message); err != nil {
+		return err
+	}
 	if opts.MessageID != "" {
 		msg.Properties[backends.PropMessageID] = opts.MessageID
 	}
@@ -64,15 +70,28 @@ func (a *QueueAdapter) Send(ctx context.Context, opts backends.SendOptions) erro
 }
 
 // Receive implements backends.QueueBackend.
-// Uses Shared subscription for queue semantics (each message delivered to one consumer).
+// Uses Shared subscription for queue semantics (each message delivered to one
+// consumer) unless --subscription-type says otherwise.
 func (a *QueueAdapter) Receive(ctx context.Context, opts backends.ReceiveOptions) (*backends.Message, error) {
-	consumer, err := a.getConsumer(pulsar.ConsumerOptions{
+	cfg, err := parseConsumeConfig(opts.Extra)
+	if err != nil {
+		return nil, err
+	}
+	consumerOpts := pulsar.ConsumerOptions{
 		Topic:                       opts.Queue,
 		SubscriptionName:            queueSubscription,
 		Type:                        pulsar.Shared,
 		SubscriptionInitialPosition: pulsar.SubscriptionPositionEarliest,
-		NackRedeliveryDelay:         1 * time.Second,
-	})
+	}
+	if err := cfg.apply(&consumerOpts); err != nil {
+		return nil, err
+	}
+	schema, err := a.getSchema(opts.Extra["schema"], opts.Queue, false)
+	if err != nil {
+		return nil, err
+	}
+	consumerOpts.Schema = schema
+	consumer, err := a.getConsumer(consumerOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -90,12 +109,12 @@ func (a *QueueAdapter) Receive(ctx context.Context, opts backends.ReceiveOptions
 	}
 
 	if opts.Acknowledge {
-		consumer.Ack(msg) //nolint:errcheck
+		cfg.settle(consumer, msg)
 	} else {
 		consumer.Nack(msg)
 	}
 
-	return pulsarToBackendMessage(msg), nil
+	return pulsarToBackendMessage(msg, schema), nil
 }
 
 // Close implements backends.QueueBackend.
@@ -104,7 +123,9 @@ func (a *QueueAdapter) Close() error {
 	return nil
 }
 
-func pulsarToBackendMessage(msg pulsar.Message) *backends.Message {
+// pulsarToBackendMessage converts msg, rendering Avro payloads of schema as
+// JSON.
+func pulsarToBackendMessage(msg pulsar.Message, schema pulsar.Schema) *backends.Message {
 	rawProps := msg.Properties()
 	props := make(map[string]any, len(rawProps))
 	for k, v := range rawProps {
@@ -129,12 +150,22 @@ func pulsarToBackendMessage(msg pulsar.Message) *backends.Message {
 	// value once received, so it wouldn't be meaningful there anyway).
 	delete(props, propTTLMs)
 	return &backends.Message{
-		Data:          msg.Payload(),
-		Properties:    props,
-		MessageID:     msgID,
-		CorrelationID: corrID,
-		ReplyTo:       replyTo,
-		ContentType:   contentType,
-		Key:           msg.Key(),
+		Data:             msg.Payload(),
+		Properties:       props,
+		MessageID:        msgID,
+		CorrelationID:    corrID,
+		ReplyTo:          replyTo,
+		ContentType:      contentType,
+		Key:              msg.Key(),
+		InternalMetadata: redeliveryMetadata(msg),
 	}
 }
+
+// redeliveryMetadata reports how often a message was redelivered, which
+// matters when it is approaching --max-redeliveries.
+func redeliveryMetadata(msg pulsar.Message) map[string]any {
+	if n := msg.RedeliveryCount(); n > 0 {
+		return map[string]any{"RedeliveryCount": n}
+	}
+	return nil
+}
@@ -0,0 +1,394 @@
+//go:build pulsar
+
+package pulsar
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	pulsar "github.com/apache/pulsar-client-go/pulsar"
+	"github.com/hamba/avro/v2"
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// schemaSpec is a parsed --schema flag: auto, json[:<file>], avro[:<file>],
+// string or bytes. Without a file, json and avro use the topic's registered
+// definition.
+type schemaSpec struct {
+	kind string
+	file string
+}
+
+func parseSchemaSpec(v string) (schemaSpec, error) {
+	kind, file, _ := strings.Cut(v, ":")
+	spec := schemaSpec{kind: strings.ToLower(kind), file: file}
+	switch spec.kind {
+	case "", "auto", "string", "bytes":
+		if file != "" {
+			return spec, fmt.Errorf("invalid --schema %q: only json and avro take a definition file", v)
+		}
+	case "json", "avro":
+	default:
+		return spec, fmt.Errorf("invalid --schema %q (use auto, json[:<file>], avro[:<file>], string or bytes)", v)
+	}
+	return spec, nil
+}
+
+// needsRegistry reports whether the spec is resolved against the topic's
+// registered schema.
+func (s schemaSpec) needsRegistry() bool {
+	return s.kind == "auto" || (s.kind == "json" || s.kind == "avro") && s.file == ""
+}
+
+// schemaEndpoint returns the Admin REST API path of a topic's schema. The
+// schema registry addresses topics without their persistence scheme.
+func schemaEndpoint(connArgs ConnArguments, adminPort int, topic string) (string, error) {
+	_, rest, ok := strings.Cut(topic, "://")
+	parts := strings.SplitN(rest, "/", 3)
+	if !ok || len(parts) != 3 || parts[2] == "" {
+		return "", fmt.Errorf("invalid topic %q: expected persistent://tenant/namespace/topic", topic)
+	}
+	return fmt.Sprintf("%s/admin/v2/schemas/%s/%s/%s/schema", buildAdminURL(connArgs.Server, adminPort), parts[0], parts[1], url.PathEscape(parts[2])), nil
+}
+
+// GetSchema returns the latest schema registered for a topic, or nil when it
+// has none.
+func GetSchema(connArgs ConnArguments, adminPort int, topic string) (*backends.TopicSchema, error) {
+	endpoint, err := schemaEndpoint(connArgs, adminPort, topic)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Version    int64             `json:"version"`
+		Type       string            `json:"type"`
+		Data       string            `json:"data"`
+		Properties map[string]string `json:"properties"`
+	}
+	if err := adminGetJSON(connArgs, endpoint, &raw); err != nil {
+		var apiErr *adminError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &backends.TopicSchema{Type: raw.Type, Version: raw.Version, Definition: raw.Data, Properties: raw.Properties}, nil
+}
+
+// UploadSchema registers a new schema version for a topic. The broker rejects
+// definitions that are incompatible with the namespace's compatibility
+// strategy.
+func UploadSchema(connArgs ConnArguments, adminPort int, topic string, schema backends.TopicSchema) error {
+	endpoint, err := schemaEndpoint(connArgs, adminPort, topic)
+	if err != nil {
+		return err
+	}
+	properties := schema.Properties
+	if properties == nil {
+		properties = map[string]string{}
+	}
+	body, err := json.Marshal(map[string]any{
+		"type":       strings.ToUpper(schema.Type),
+		"schema":     schema.Definition,
+		"properties": properties,
+	})
+	if err != nil {
+		return err
+	}
+	return adminSendJSON(connArgs, "POST", endpoint, body)
+}
+
+// DeleteSchema deletes all schema versions of a topic.
+func DeleteSchema(connArgs ConnArguments, adminPort int, topic string) error {
+	endpoint, err := schemaEndpoint(connArgs, adminPort, topic)
+	if err != nil {
+		return err
+	}
+	return adminRequest(connArgs, "DELETE", endpoint)
+}
+
+// newSchema builds the client schema for spec on topic. It returns nil for
+// raw bytes. When publishing, a registered schema xmc cannot encode for is an
+// error; when consuming it just leaves payloads undecoded.
+func newSchema(connArgs ConnArguments, adminPort int, spec schemaSpec, topic string, publish bool) (pulsar.Schema, error) {
+	kind, definition := spec.kind, ""
+	if spec.file != "" {
+		data, err := os.ReadFile(spec.file)
+		if err != nil {
+			return nil, fmt.Errorf("reading schema definition: %w", err)
+		}
+		definition = string(data)
+	}
+	if spec.needsRegistry() {
+		registered, err := GetSchema(connArgs, adminPort, topic)
+		if err != nil {
+			return nil, fmt.Errorf("looking up the schema of %s: %w", topic, err)
+		}
+		switch {
+		case registered == nil && kind == "auto":
+			return nil, nil
+		case registered == nil:
+			return nil, fmt.Errorf("%s has no registered schema; use --schema %s:<file>", topic, kind)
+		case kind != "auto" && !strings.EqualFold(registered.Type, kind):
+			return nil, fmt.Errorf("%s has a %s schema, not %s", topic, registered.Type, strings.ToUpper(kind))
+		}
+		kind, definition = strings.ToLower(registered.Type), registered.Definition
+	}
+
+	switch kind {
+	case "", "bytes":
+		return nil, nil
+	case "string":
+		return pulsar.NewStringSchema(nil), nil
+	case "json":
+		return pulsar.NewJSONSchemaWithValidation(definition, nil)
+	case "avro":
+		return pulsar.NewAvroSchemaWithValidation(definition, nil)
+	}
+	if publish {
+		return nil, fmt.Errorf("%s has a %s schema; pmc publishes json, avro, string or bytes", topic, strings.ToUpper(kind))
+	}
+	return nil, nil
+}
+
+// schemaKey identifies a schema in the producer and consumer caches.
+func schemaKey(schema pulsar.Schema) string {
+	if schema == nil {
+		return ""
+	}
+	info := schema.GetSchemaInfo()
+	return fmt.Sprintf("%d:%s", info.Type, info.Schema)
+}
+
+// setSchemaPayload puts payload into msg in the form schema expects: JSON
+// payloads are checked, Avro payloads are read as JSON and encoded by the
+// client.
+func setSchemaPayload(msg *pulsar.ProducerMessage, schema pulsar.Schema, payload []byte) error {
+	msg.Payload = payload
+	avroSchema, ok := schema.(*pulsar.AvroSchema)
+	switch {
+	case schema == nil:
+		return nil
+	case schema.GetSchemaInfo().Type == pulsar.JSON:
+		if !json.Valid(payload) {
+			return fmt.Errorf("message is not valid JSON for the topic's JSON schema")
+		}
+		return nil
+	case !ok:
+		return nil
+	}
+
+	// Numbers stay json.Number, so longs beyond 2^53 keep every digit.
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("message is not valid JSON for the topic's Avro schema: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("message is not valid JSON for the topic's Avro schema: data after the value")
+	}
+	value, err := avroFromJSON(avroSchema.Codec, v)
+	if err != nil {
+		return fmt.Errorf("message does not match the topic's Avro schema: %w", err)
+	}
+	msg.Payload, msg.Value = nil, value
+	return nil
+}
+
+// decodeSchemaPayload renders an Avro payload as JSON for display. Payloads
+// that don't decode (e.g. written with an older schema version) are returned
+// unchanged.
+func decodeSchemaPayload(schema pulsar.Schema, payload []byte) []byte {
+	if _, ok := schema.(*pulsar.AvroSchema); !ok {
+		return payload
+	}
+	var v any
+	if err := schema.Decode(payload, &v); err != nil {
+		return payload
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return payload
+	}
+	return data
+}
+
+// avroFromJSON converts a JSON value decoded with UseNumber into the Go form
+// the Avro encoder expects for s: integers for int and long, []byte for bytes and
+// fixed, and unions as single-entry maps keyed by the branch name. Union
+// values may be given plainly or in Avro's JSON encoding ({"string": "x"}).
+func avroFromJSON(s avro.Schema, v any) (any, error) {
+	if ref, ok := s.(*avro.RefSchema); ok {
+		s = ref.Schema()
+	}
+	switch s := s.(type) {
+	case *avro.RecordSchema:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected an object", s.FullName())
+		}
+		out := make(map[string]any, len(s.Fields()))
+		for _, f := range s.Fields() {
+			fv, present := obj[f.Name()]
+			if !present && f.HasDefault() {
+				// The library returns defaults in the Go form it encodes.
+				out[f.Name()] = f.Default()
+				continue
+			}
+			conv, err := avroFromJSON(f.Type(), fv)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%w", f.Name(), err)
+			}
+			out[f.Name()] = conv
+		}
+		return out, nil
+	case *avro.UnionSchema:
+		return avroUnionFromJSON(s, v)
+	case *avro.ArraySchema:
+		items, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an array")
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			conv, err := avroFromJSON(s.Items(), item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = conv
+		}
+		return out, nil
+	case *avro.MapSchema:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an object")
+		}
+		out := make(map[string]any, len(obj))
+		for k, item := range obj {
+			conv, err := avroFromJSON(s.Values(), item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = conv
+		}
+		return out, nil
+	}
+
+	switch s.Type() {
+	case avro.Null:
+		if v != nil {
+			return nil, fmt.Errorf("expected null")
+		}
+		return nil, nil
+	case avro.Boolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case avro.Int, avro.Long:
+		if n, ok := v.(json.Number); ok {
+			i, err := jsonInteger(n)
+			if err != nil {
+				return nil, err
+			}
+			if s.Type() == avro.Long {
+				return i, nil
+			}
+			if i < math.MinInt32 || i > math.MaxInt32 {
+				return nil, fmt.Errorf("%s is out of range for int", n)
+			}
+			return int(i), nil
+		}
+	case avro.Float:
+		if n, ok := v.(json.Number); ok {
+			f, err := n.Float64()
+			if err == nil {
+				return float32(f), nil
+			}
+		}
+	case avro.Double:
+		if n, ok := v.(json.Number); ok {
+			f, err := n.Float64()
+			if err == nil {
+				return f, nil
+			}
+		}
+	case avro.String, avro.Enum:
+		if str, ok := v.(string); ok {
+			return str, nil
+		}
+	case avro.Bytes, avro.Fixed:
+		if str, ok := v.(string); ok {
+			return []byte(str), nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %v", s.Type(), v)
+}
+
+// jsonInteger reads n as an integer. Digits are parsed exactly; a number
+// written with a fraction or exponent (2.0, 1e3) is accepted when it is whole
+// and small enough for a float64 to hold it exactly.
+func jsonInteger(n json.Number) (int64, error) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return i, nil
+	} else if errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("%s is out of range for long", n)
+	}
+	f, err := n.Float64()
+	if err != nil || f != math.Trunc(f) || math.Abs(f) > 1<<53 {
+		return 0, fmt.Errorf("expected an integer, got %s", n)
+	}
+	return int64(f), nil
+}
+
+func avroUnionFromJSON(s *avro.UnionSchema, v any) (any, error) {
+	if v == nil {
+		if _, pos := s.Types().Get(string(avro.Null)); pos < 0 {
+			return nil, fmt.Errorf("null is not allowed")
+		}
+		return map[string]any(nil), nil
+	}
+	if obj, ok := v.(map[string]any); ok && len(obj) == 1 {
+		for name, inner := range obj {
+			if branch, _ := s.Types().Get(name); branch != nil {
+				conv, err := avroFromJSON(branch, inner)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{name: conv}, nil
+			}
+		}
+	}
+	for _, branch := range s.Types() {
+		if branch.Type() == avro.Null {
+			continue
+		}
+		if conv, err := avroFromJSON(branch, v); err == nil {
+			return map[string]any{avroTypeName(branch): conv}, nil
+		}
+	}
+	return nil, fmt.Errorf("%v matches no branch of the union", v)
+}
+
+// avroTypeName is the name a union branch is selected by: the full name of
+// named types, otherwise the type (plus logical type).
+func avroTypeName(s avro.Schema) string {
+	if ref, ok := s.(*avro.RefSchema); ok {
+		s = ref.Schema()
+	}
+	if named, ok := s.(avro.NamedSchema); ok {
+		return named.FullName()
+	}
+	if ls, ok := s.(avro.LogicalTypeSchema); ok && ls.Logical() != nil {
+		return string(s.Type()) + "." + string(ls.Logical().Type())
+	}
+	return string(s.Type())
+}
@@ -0,0 +1,143 @@
+//go:build pulsar
+
+package pulsar
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	pulsar "github.com/apache/pulsar-client-go/pulsar"
+)
+
+const orderSchema = `{"type":"record","name":"Order","namespace":"acme","fields":[
+	{"name":"id","type":"long"},
+	{"name":"qty","type":"int"},
+	{"name":"note","type":["null","string"],"default":null},
+	{"name":"tags","type":{"type":"array","items":"string"}}]}`
+
+func TestParseSchemaSpec(t *testing.T) {
+	for _, v := range []string{"", "auto", "JSON", "avro:order.avsc", "string", "bytes"} {
+		if _, err := parseSchemaSpec(v); err != nil {
+			t.Errorf("parseSchemaSpec(%q): %v", v, err)
+		}
+	}
+	for _, v := range []string{"protobuf", "string:x.txt", "auto:x"} {
+		if _, err := parseSchemaSpec(v); err == nil {
+			t.Errorf("parseSchemaSpec(%q): expected an error", v)
+		}
+	}
+}
+
+func TestAvroSchemaPayload(t *testing.T) {
+	schema, err := pulsar.NewAvroSchemaWithValidation(orderSchema, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg pulsar.ProducerMessage
+	if err := setSchemaPayload(&msg, schema, []byte(`{"id":7,"qty":2,"note":"rush","tags":["a"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Payload != nil || msg.Value == nil {
+		t.Fatalf("Avro message should carry a Value, got payload %q", msg.Payload)
+	}
+	encoded, err := schema.Encode(msg.Value)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if got := string(decodeSchemaPayload(schema, encoded)); got != `{"id":7,"note":"rush","qty":2,"tags":["a"]}` {
+		t.Errorf("decoded = %s", got)
+	}
+
+	// The nullable field falls back to its default.
+	if err := setSchemaPayload(&msg, schema, []byte(`{"id":1,"qty":1,"tags":[]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := schema.Encode(msg.Value); err != nil {
+		t.Errorf("Encode with default: %v", err)
+	}
+
+	// Numeric and bytes defaults are used as the Avro library returns them.
+	defaults, err := pulsar.NewAvroSchemaWithValidation(`{"type":"record","name":"Reading","fields":[
+		{"name":"sensor","type":"string"},
+		{"name":"count","type":"int","default":0},
+		{"name":"seq","type":"long","default":-1},
+		{"name":"ratio","type":"float","default":0.5},
+		{"name":"value","type":"double","default":1.25},
+		{"name":"raw","type":"bytes","default":"ab"}]}`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := setSchemaPayload(&msg, defaults, []byte(`{"sensor":"s1"}`)); err != nil {
+		t.Fatalf("setSchemaPayload with numeric defaults: %v", err)
+	}
+	encoded, err = defaults.Encode(msg.Value)
+	if err != nil {
+		t.Fatalf("Encode with numeric defaults: %v", err)
+	}
+	if got := string(decodeSchemaPayload(defaults, encoded)); got != `{"count":0,"ratio":0.5,"raw":"YWI=","sensor":"s1","seq":-1,"value":1.25}` {
+		t.Errorf("decoded with defaults = %s", got)
+	}
+
+	// Longs beyond 2^53 keep every digit; whole numbers in other notations
+	// are still accepted.
+	if err := setSchemaPayload(&msg, schema, []byte(`{"id":9007199254740993,"qty":2e1,"tags":[]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if rec := msg.Value.(map[string]any); rec["id"] != int64(9007199254740993) || rec["qty"] != 20 {
+		t.Errorf("id, qty = %v, %v; want 9007199254740993, 20", rec["id"], rec["qty"])
+	}
+	encoded, err = schema.Encode(msg.Value)
+	if err != nil {
+		t.Fatalf("Encode large long: %v", err)
+	}
+	if got := string(decodeSchemaPayload(schema, encoded)); got != `{"id":9007199254740993,"note":null,"qty":20,"tags":[]}` {
+		t.Errorf("decoded large long = %s", got)
+	}
+
+	for _, bad := range []string{`not json`, `{"id":1.5,"qty":1,"tags":[]}`, `{"id":1,"qty":1}`,
+		`{"id":9223372036854775808,"qty":1,"tags":[]}`, `{"id":1,"qty":2147483648,"tags":[]}`, `{"id":1,"qty":1,"tags":[]} {}`} {
+		if err := setSchemaPayload(&msg, schema, []byte(bad)); err == nil {
+			t.Errorf("setSchemaPayload(%s): expected an error", bad)
+		}
+	}
+	if err := setSchemaPayload(&msg, pulsar.NewJSONSchema(orderSchema, nil), []byte(`{"id":`)); err == nil {
+		t.Error("invalid JSON for a JSON schema: expected an error")
+	}
+}
+
+func TestNewSchema(t *testing.T) {
+	path := "/admin/v2/schemas/public/default/orders/schema"
+	connArgs, port, requests := fakeAdmin(t, map[string]string{
+		path: `{"version":2,"type":"AVRO","data":` + strconv.Quote(orderSchema) + `}`,
+	})
+
+	schema, err := newSchema(connArgs, port, schemaSpec{kind: "auto"}, "persistent://public/default/orders", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := schema.(*pulsar.AvroSchema); !ok {
+		t.Errorf("auto on an Avro topic = %T, want *pulsar.AvroSchema", schema)
+	}
+	if _, err := newSchema(connArgs, port, schemaSpec{kind: "json"}, "persistent://public/default/orders", true); err == nil {
+		t.Error("json on an Avro topic: expected an error")
+	}
+	// A topic without a schema keeps raw bytes under auto.
+	if schema, err := newSchema(connArgs, port, schemaSpec{kind: "auto"}, "persistent://public/default/raw", true); err != nil || schema != nil {
+		t.Errorf("auto on a topic without schema = %v, %v; want nil", schema, err)
+	}
+	if len(*requests) != 3 || (*requests)[0] != "GET "+path {
+		t.Errorf("requests = %v", *requests)
+	}
+
+	file := filepath.Join(t.TempDir(), "order.avsc")
+	if err := os.WriteFile(file, []byte(orderSchema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	*requests = nil
+	schema, err = newSchema(connArgs, port, schemaSpec{kind: "json", file: file}, "persistent://public/default/raw", true)
+	if err != nil || schema.GetSchemaInfo().Type != pulsar.JSON || len(*requests) != 0 {
+		t.Errorf("json:<file> = %v, %v after %v; want a JSON schema without registry lookups", schema, err, *requests)
+	}
+}
@@ -0,0 +1,141 @@
+//go:build pulsar
+
+package pulsar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	pulsar "github.com/apache/pulsar-client-go/pulsar"
+)
+
+var subscriptionTypes = map[string]pulsar.SubscriptionType{
+	"exclusive":  pulsar.Exclusive,
+	"shared":     pulsar.Shared,
+	"failover":   pulsar.Failover,
+	"key_shared": pulsar.KeyShared,
+}
+
+// consumeConfig holds the consume flags passed through ReceiveOptions.Extra
+// and SubscribeOptions.Extra. Unset fields keep the adapter's defaults.
+type consumeConfig struct {
+	subType         *pulsar.SubscriptionType
+	initialPosition *pulsar.SubscriptionInitialPosition
+	maxRedeliveries uint32 // > 0 enables the dead-letter policy
+	deadLetterTopic string
+	retry           bool
+	retryTopic      string
+	retryDelay      time.Duration
+	nack            bool
+	nackDelay       time.Duration
+}
+
+func parseConsumeConfig(extra map[string]string) (consumeConfig, error) {
+	cfg := consumeConfig{retryDelay: time.Second, nackDelay: time.Second}
+	if v, ok := extra["subscription-type"]; ok {
+		t, ok := subscriptionTypes[strings.ToLower(strings.ReplaceAll(v, "-", "_"))]
+		if !ok {
+			return cfg, fmt.Errorf("invalid --subscription-type %q (use exclusive, shared, failover or key_shared)", v)
+		}
+		cfg.subType = &t
+	}
+	if v, ok := extra["initial-position"]; ok {
+		var pos pulsar.SubscriptionInitialPosition
+		switch strings.ToLower(v) {
+		case "earliest":
+			pos = pulsar.SubscriptionPositionEarliest
+		case "latest":
+			pos = pulsar.SubscriptionPositionLatest
+		default:
+			return cfg, fmt.Errorf("invalid --initial-position %q (use earliest or latest)", v)
+		}
+		cfg.initialPosition = &pos
+	}
+	if v, ok := extra["max-redeliveries"]; ok {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil || n < 1 {
+			return cfg, fmt.Errorf("invalid --max-redeliveries %q: must be a positive number", v)
+		}
+		cfg.maxRedeliveries = uint32(n)
+	}
+	cfg.deadLetterTopic = extra["dead-letter-topic"]
+	cfg.retryTopic = extra["retry-topic"]
+	cfg.retry = extra["retry"] == "true" || cfg.retryTopic != ""
+	for name, dst := range map[string]*time.Duration{"retry-delay": &cfg.retryDelay, "nack-delay": &cfg.nackDelay} {
+		if v, ok := extra[name]; ok {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return cfg, fmt.Errorf("invalid --%s %q: must be a duration", name, v)
+			}
+			*dst = d
+		}
+	}
+	cfg.nack = extra["nack"] == "true"
+	if cfg.deadLetterTopic != "" && cfg.maxRedeliveries == 0 && !cfg.retry {
+		return cfg, fmt.Errorf("--dead-letter-topic requires --max-redeliveries or --retry")
+	}
+	return cfg, nil
+}
+
+// apply overlays cfg on consumer options that already carry the topic(s),
+// subscription name and the adapter's default type.
+func (cfg consumeConfig) apply(opts *pulsar.ConsumerOptions) error {
+	if cfg.subType != nil {
+		opts.Type = *cfg.subType
+	}
+	if cfg.initialPosition != nil {
+		opts.SubscriptionInitialPosition = *cfg.initialPosition
+	}
+	opts.NackRedeliveryDelay = cfg.nackDelay
+	if cfg.maxRedeliveries == 0 && !cfg.retry {
+		return nil
+	}
+
+	// The broker tracks redeliveries per message only for subscriptions that
+	// spread messages across consumers.
+	if opts.Type != pulsar.Shared && opts.Type != pulsar.KeyShared {
+		return fmt.Errorf("dead-letter and retry policies require --subscription-type shared or key_shared")
+	}
+	if opts.TopicsPattern != "" && cfg.retry {
+		return fmt.Errorf("--retry is not supported with topic patterns")
+	}
+	if cfg.retry {
+		// The client defaults both topics to <topic>-<subscription>-RETRY/-DLQ
+		// and MaxDeliveries to its MaxReconsumeTimes.
+		opts.RetryEnable = true
+		opts.DLQ = &pulsar.DLQPolicy{
+			MaxDeliveries:    cfg.maxRedeliveries,
+			DeadLetterTopic:  cfg.deadLetterTopic,
+			RetryLetterTopic: cfg.retryTopic,
+		}
+		if opts.DLQ.MaxDeliveries == 0 {
+			opts.DLQ.MaxDeliveries = pulsar.MaxReconsumeTimes
+		}
+		return nil
+	}
+	dlq := cfg.deadLetterTopic
+	if dlq == "" {
+		if opts.Topic == "" {
+			return fmt.Errorf("--dead-letter-topic is required when subscribing to several topics")
+		}
+		dlq = opts.Topic + "-" + opts.SubscriptionName + pulsar.DlqTopicSuffix
+	}
+	opts.DLQ = &pulsar.DLQPolicy{MaxDeliveries: cfg.maxRedeliveries, DeadLetterTopic: dlq}
+	return nil
+}
+
+// settle acknowledges msg, or with --nack hands it back for redelivery: to
+// the retry topic after --retry-delay when retries are enabled, otherwise
+// through the broker after --nack-delay.
+func (cfg consumeConfig) settle(consumer pulsar.Consumer, msg pulsar.Message) {
+	switch {
+	case !cfg.nack:
+		consumer.Ack(msg) //nolint:errcheck
+	case cfg.retry:
+		consumer.ReconsumeLater(msg, cfg.retryDelay)
+	default:
+		consumer.Nack(msg)
+	}
+}
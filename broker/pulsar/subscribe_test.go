@@ -0,0 +1,63 @@
+//go:build pulsar
+
+package pulsar
+
+import (
+	"testing"
+	"time"
+
+	pulsar "github.com/apache/pulsar-client-go/pulsar"
+)
+
+func TestConsumeConfig(t *testing.T) {
+	topic := "persistent://public/default/orders"
+
+	cfg, err := parseConsumeConfig(map[string]string{"subscription-type": "key-shared", "initial-position": "earliest", "max-redeliveries": "3", "nack": "true", "nack-delay": "5s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := pulsar.ConsumerOptions{Topic: topic, SubscriptionName: "billing", Type: pulsar.Exclusive}
+	if err := cfg.apply(&opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Type != pulsar.KeyShared || opts.SubscriptionInitialPosition != pulsar.SubscriptionPositionEarliest || opts.NackRedeliveryDelay != 5*time.Second {
+		t.Errorf("options = %+v", opts)
+	}
+	if opts.DLQ == nil || opts.DLQ.MaxDeliveries != 3 || opts.DLQ.DeadLetterTopic != topic+"-billing-DLQ" || opts.RetryEnable {
+		t.Errorf("DLQ = %+v, retry %v", opts.DLQ, opts.RetryEnable)
+	}
+
+	cfg, err = parseConsumeConfig(map[string]string{"retry-topic": "persistent://public/default/later"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts = pulsar.ConsumerOptions{Topic: topic, SubscriptionName: "billing", Type: pulsar.Shared}
+	if err := cfg.apply(&opts); err != nil {
+		t.Fatal(err)
+	}
+	if !opts.RetryEnable || opts.DLQ.RetryLetterTopic != "persistent://public/default/later" || opts.DLQ.MaxDeliveries != pulsar.MaxReconsumeTimes {
+		t.Errorf("retry DLQ = %+v, retry %v", opts.DLQ, opts.RetryEnable)
+	}
+
+	for _, extra := range []map[string]string{
+		{"subscription-type": "round_robin"},
+		{"initial-position": "middle"},
+		{"max-redeliveries": "0"},
+		{"dead-letter-topic": "dlq"},
+		{"nack-delay": "soon"},
+	} {
+		if _, err := parseConsumeConfig(extra); err == nil {
+			t.Errorf("parseConsumeConfig(%v): expected an error", extra)
+		}
+	}
+
+	// DLQ policies need a subscription that spreads messages, and a default
+	// dead-letter topic needs a single topic.
+	cfg, _ = parseConsumeConfig(map[string]string{"max-redeliveries": "3"})
+	if err := cfg.apply(&pulsar.ConsumerOptions{Topic: topic, Type: pulsar.Exclusive}); err == nil {
+		t.Error("DLQ on an exclusive subscription: expected an error")
+	}
+	if err := cfg.apply(&pulsar.ConsumerOptions{Topics: []string{topic, topic + "2"}, Type: pulsar.Shared}); err == nil {
+		t.Error("default DLQ topic on several topics: expected an error")
+	}
+}
@@ -0,0 +1,265 @@
+//go:build pulsar
+
+package pulsar
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// topicEndpoint returns the Admin REST API path of a fully-qualified topic
+// (persistent://tenant/namespace/name), as resolved by ResolveTarget.
+func topicEndpoint(adminURL, topic string) (string, error) {
+	scheme, rest, ok := strings.Cut(topic, "://")
+	parts := strings.SplitN(rest, "/", 3)
+	if !ok || len(parts) != 3 || parts[2] == "" {
+		return "", fmt.Errorf("invalid topic %q: expected persistent://tenant/namespace/topic", topic)
+	}
+	return fmt.Sprintf("%s/admin/v2/%s/%s/%s/%s", adminURL, scheme, parts[0], parts[1], url.PathEscape(parts[2])), nil
+}
+
+// subscriptionEndpoint returns the Admin REST API path of a subscription.
+func subscriptionEndpoint(connArgs ConnArguments, adminPort int, topic, subscription string) (string, error) {
+	base, err := topicEndpoint(buildAdminURL(connArgs.Server, adminPort), topic)
+	if err != nil {
+		return "", err
+	}
+	return base + "/subscription/" + url.PathEscape(subscription), nil
+}
+
+// adminTopicStats is the part of the broker's TopicStats (and
+// PartitionedTopicStats) JSON that xmc reports.
+type adminTopicStats struct {
+	MsgRateIn        float64                           `json:"msgRateIn"`
+	MsgRateOut       float64                           `json:"msgRateOut"`
+	MsgThroughputIn  float64                           `json:"msgThroughputIn"`
+	MsgThroughputOut float64                           `json:"msgThroughputOut"`
+	StorageSize      int64                             `json:"storageSize"`
+	Publishers       []struct{}                        `json:"publishers"`
+	Subscriptions    map[string]adminSubscriptionStats `json:"subscriptions"`
+}
+
+type adminSubscriptionStats struct {
+	MsgRateOut float64    `json:"msgRateOut"`
+	MsgBacklog int64      `json:"msgBacklog"`
+	Type       string     `json:"type"`
+	Consumers  []struct{} `json:"consumers"`
+}
+
+// TopicStats returns a topic's rates, storage size and subscriptions. A
+// partitioned topic reports the sum over its partitions.
+func TopicStats(connArgs ConnArguments, adminPort int, topic string) (*backends.TopicStats, error) {
+	base, err := topicEndpoint(buildAdminURL(connArgs.Server, adminPort), topic)
+	if err != nil {
+		return nil, err
+	}
+	var meta struct {
+		Partitions int `json:"partitions"`
+	}
+	if err := adminGetJSON(connArgs, base+"/partitions", &meta); err != nil {
+		return nil, err
+	}
+	endpoint := base + "/stats"
+	if meta.Partitions > 0 {
+		endpoint = base + "/partitioned-stats"
+	}
+
+	var raw adminTopicStats
+	if err := adminGetJSON(connArgs, endpoint, &raw); err != nil {
+		return nil, err
+	}
+	stats := &backends.TopicStats{
+		Name:          topic,
+		MsgRateIn:     raw.MsgRateIn,
+		MsgRateOut:    raw.MsgRateOut,
+		ThroughputIn:  raw.MsgThroughputIn,
+		ThroughputOut: raw.MsgThroughputOut,
+		StorageSize:   raw.StorageSize,
+		Publishers:    len(raw.Publishers),
+	}
+	for name, sub := range raw.Subscriptions {
+		stats.Subscriptions = append(stats.Subscriptions, backends.SubscriptionStats{
+			Name:       name,
+			Type:       sub.Type,
+			Backlog:    sub.MsgBacklog,
+			Consumers:  len(sub.Consumers),
+			MsgRateOut: sub.MsgRateOut,
+		})
+	}
+	slices.SortFunc(stats.Subscriptions, func(a, b backends.SubscriptionStats) int { return strings.Compare(a.Name, b.Name) })
+	return stats, nil
+}
+
+// ListSubscriptions returns a topic's subscriptions with their backlog.
+func ListSubscriptions(connArgs ConnArguments, adminPort int, topic string) ([]backends.SubscriptionStats, error) {
+	stats, err := TopicStats(connArgs, adminPort, topic)
+	if err != nil {
+		return nil, err
+	}
+	return stats.Subscriptions, nil
+}
+
+// ListTopicsWithSubscriptions lists the namespace's topics with their
+// subscriptions as children. A topic whose stats cannot be read is listed
+// without children.
+func ListTopicsWithSubscriptions(connArgs ConnArguments, adminPort int, tenant, namespace string, nonPersistent bool) ([]backends.ObjectNode, error) {
+	topics, err := ListTopics(connArgs, adminPort, tenant, namespace, nonPersistent)
+	if err != nil {
+		return nil, err
+	}
+	kind := "persistent"
+	if nonPersistent {
+		kind = "non-persist"
+	}
+	nodes := make([]backends.ObjectNode, len(topics))
+	for i, t := range topics {
+		nodes[i] = backends.ObjectNode{Name: t.Name, Kind: kind}
+		stats, err := TopicStats(connArgs, adminPort, t.Name)
+		if err != nil {
+			continue
+		}
+		var backlog int64
+		for _, sub := range stats.Subscriptions {
+			backlog += sub.Backlog
+			nodes[i].Children = append(nodes[i].Children, backends.ObjectNode{
+				Name: sub.Name,
+				Kind: "subscription",
+				Metrics: []backends.Metric{
+					{Label: "backlog", Value: sub.Backlog},
+					{Label: "consumers", Value: int64(sub.Consumers)},
+				},
+			})
+		}
+		nodes[i].Metrics = []backends.Metric{{Label: "backlog", Value: backlog}}
+	}
+	return nodes, nil
+}
+
+// CreateSubscription creates a durable subscription positioned at the
+// earliest retained message or at the end of the topic. An existing
+// subscription is left as it is.
+func CreateSubscription(connArgs ConnArguments, adminPort int, topic, subscription string, earliest bool) error {
+	endpoint, err := subscriptionEndpoint(connArgs, adminPort, topic, subscription)
+	if err != nil {
+		return err
+	}
+	if !earliest {
+		// Without a message ID the broker starts the subscription at the end.
+		return adminRequest(connArgs, "PUT", endpoint)
+	}
+	return adminSendJSON(connArgs, "PUT", endpoint, []byte(`{"ledgerId":-1,"entryId":-1,"partitionIndex":-1}`))
+}
+
+// DeleteSubscription deletes a subscription. The broker refuses while it has
+// connected consumers.
+func DeleteSubscription(connArgs ConnArguments, adminPort int, topic, subscription string) error {
+	endpoint, err := subscriptionEndpoint(connArgs, adminPort, topic, subscription)
+	if err != nil {
+		return err
+	}
+	return adminRequest(connArgs, "DELETE", endpoint)
+}
+
+// PurgeSubscription skips all messages of one subscription and returns how
+// many were in its backlog.
+func PurgeSubscription(connArgs ConnArguments, adminPort int, topic, subscription string) (int64, error) {
+	stats, err := TopicStats(connArgs, adminPort, topic)
+	if err != nil {
+		return 0, err
+	}
+	i := slices.IndexFunc(stats.Subscriptions, func(s backends.SubscriptionStats) bool { return s.Name == subscription })
+	if i < 0 {
+		return 0, fmt.Errorf("subscription %s not found on %s", subscription, topic)
+	}
+	if err := skipAll(connArgs, adminPort, topic, subscription); err != nil {
+		return 0, err
+	}
+	return stats.Subscriptions[i].Backlog, nil
+}
+
+// PurgeTopic clears the backlog of every subscription of a topic and returns
+// the number of messages skipped. The stored messages themselves are removed
+// by retention once no subscription needs them.
+func PurgeTopic(connArgs ConnArguments, adminPort int, topic string) (int64, error) {
+	stats, err := TopicStats(connArgs, adminPort, topic)
+	if err != nil {
+		return 0, err
+	}
+	var purged int64
+	for _, sub := range stats.Subscriptions {
+		if err := skipAll(connArgs, adminPort, topic, sub.Name); err != nil {
+			return purged, err
+		}
+		purged += sub.Backlog
+	}
+	return purged, nil
+}
+
+func skipAll(connArgs ConnArguments, adminPort int, topic, subscription string) error {
+	endpoint, err := subscriptionEndpoint(connArgs, adminPort, topic, subscription)
+	if err != nil {
+		return err
+	}
+	return adminRequest(connArgs, "POST", endpoint+"/skip_all")
+}
+
+// ExpireMessages acknowledges the messages older than olderThan on one
+// subscription, or on every subscription of the topic when subscription is "".
+func ExpireMessages(connArgs ConnArguments, adminPort int, topic, subscription string, olderThan time.Duration) error {
+	seconds := max(int64(olderThan.Seconds()), 1)
+	if subscription == "" {
+		base, err := topicEndpoint(buildAdminURL(connArgs.Server, adminPort), topic)
+		if err != nil {
+			return err
+		}
+		return adminRequest(connArgs, "POST", fmt.Sprintf("%s/all_subscription/expireMessages/%d", base, seconds))
+	}
+	endpoint, err := subscriptionEndpoint(connArgs, adminPort, topic, subscription)
+	if err != nil {
+		return err
+	}
+	return adminRequest(connArgs, "POST", fmt.Sprintf("%s/expireMessages/%d", endpoint, seconds))
+}
+
+// messageIDPattern matches a message ID as xmc prints it:
+// ledger:entry:partition, optionally followed by :batch.
+var messageIDPattern = regexp.MustCompile(`^(-?\d+):(-?\d+):(-?\d+)(?::(-?\d+))?$`)
+
+// SeekSubscription moves a subscription's cursor to a message ID
+// (ledger:entry:partition[:batch]), a timestamp, earliest, or latest.
+// Consumers connected to the subscription are disconnected by the broker and
+// resume from the new position.
+func SeekSubscription(connArgs ConnArguments, adminPort int, topic, subscription, to string) error {
+	endpoint, err := subscriptionEndpoint(connArgs, adminPort, topic, subscription)
+	if err != nil {
+		return err
+	}
+	if m := messageIDPattern.FindStringSubmatch(to); m != nil {
+		batch := m[4]
+		if batch == "" {
+			batch = "-1"
+		}
+		body := fmt.Sprintf(`{"ledgerId":%s,"entryId":%s,"partitionIndex":%s,"batchIndex":%s}`, m[1], m[2], m[3], batch)
+		return adminSendJSON(connArgs, "POST", endpoint+"/resetcursor", []byte(body))
+	}
+
+	target, err := backends.ParseOffsetTarget(to, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid --to %q: use earliest, latest, a message ID (ledger:entry:partition) or a timestamp", to)
+	}
+	switch target.Kind {
+	case backends.OffsetEarliest:
+		return adminRequest(connArgs, "POST", endpoint+"/resetcursor/0")
+	case backends.OffsetLatest:
+		return adminRequest(connArgs, "POST", endpoint+"/skip_all")
+	case backends.OffsetTimestamp:
+		return adminRequest(connArgs, "POST", fmt.Sprintf("%s/resetcursor/%d", endpoint, target.Time.UnixMilli()))
+	}
+	return fmt.Errorf("invalid --to %q: use earliest, latest, a message ID (ledger:entry:partition) or a timestamp", to)
+}
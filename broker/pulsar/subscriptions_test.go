@@ -0,0 +1,135 @@
+//go:build pulsar
+
+package pulsar
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeAdmin serves the given JSON bodies by request path and records every
+// request as "METHOD path[?query][ body]".
+func fakeAdmin(t *testing.T, bodies map[string]string) (ConnArguments, int, *[]string) {
+	t.Helper()
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := r.Method + " " + r.URL.EscapedPath()
+		if r.URL.RawQuery != "" {
+			request += "?" + r.URL.RawQuery
+		}
+		if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+			request += " " + string(body)
+		}
+		requests = append(requests, request)
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		body, ok := bodies[r.URL.EscapedPath()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	adminPort, _ := strconv.Atoi(port)
+	return ConnArguments{Server: "pulsar://127.0.0.1:6650"}, adminPort, &requests
+}
+
+func TestTopicEndpoint(t *testing.T) {
+	got, err := topicEndpoint("http://h:8080", "non-persistent://acme/app/a b")
+	if want := "http://h:8080/admin/v2/non-persistent/acme/app/a%20b"; err != nil || got != want {
+		t.Errorf("topicEndpoint = %q, %v; want %q", got, err, want)
+	}
+	for _, bad := range []string{"orders", "persistent://acme/orders"} {
+		if _, err := topicEndpoint("http://h:8080", bad); err == nil {
+			t.Errorf("topicEndpoint(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestTopicStats(t *testing.T) {
+	base := "/admin/v2/persistent/public/default/orders"
+	connArgs, port, requests := fakeAdmin(t, map[string]string{
+		base + "/partitions": `{"partitions":3}`,
+		base + "/partitioned-stats": `{"msgRateIn":4.5,"storageSize":2048,"publishers":[{}],
+			"subscriptions":{"b":{"msgBacklog":7,"type":"Shared","consumers":[{},{}]},"a":{"msgBacklog":1,"type":"Exclusive"}}}`,
+	})
+
+	stats, err := TopicStats(connArgs, port, "persistent://public/default/orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.MsgRateIn != 4.5 || stats.StorageSize != 2048 || stats.Publishers != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if len(stats.Subscriptions) != 2 || stats.Subscriptions[0].Name != "a" || stats.Subscriptions[1].Consumers != 2 || stats.Subscriptions[1].Backlog != 7 {
+		t.Errorf("subscriptions = %+v, want a then b", stats.Subscriptions)
+	}
+
+	*requests = nil
+	purged, err := PurgeTopic(connArgs, port, "persistent://public/default/orders")
+	if err != nil || purged != 8 {
+		t.Errorf("PurgeTopic = %d, %v; want 8", purged, err)
+	}
+	for _, want := range []string{"POST " + base + "/subscription/a/skip_all", "POST " + base + "/subscription/b/skip_all"} {
+		if !slices.Contains(*requests, want) {
+			t.Errorf("requests %v miss %q", *requests, want)
+		}
+	}
+	if _, err := PurgeSubscription(connArgs, port, "persistent://public/default/orders", "missing"); err == nil {
+		t.Error("PurgeSubscription of an unknown subscription: expected an error")
+	}
+}
+
+func TestExpireMessages(t *testing.T) {
+	connArgs, port, requests := fakeAdmin(t, nil)
+	topic := "persistent://public/default/orders"
+	if err := ExpireMessages(connArgs, port, topic, "", 90*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExpireMessages(connArgs, port, topic, "billing", 0); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"POST /admin/v2/persistent/public/default/orders/all_subscription/expireMessages/5400",
+		"POST /admin/v2/persistent/public/default/orders/subscription/billing/expireMessages/1",
+	}
+	if !slices.Equal(*requests, want) {
+		t.Errorf("requests = %v, want %v", *requests, want)
+	}
+}
+
+func TestSeekSubscription(t *testing.T) {
+	connArgs, port, requests := fakeAdmin(t, nil)
+	topic := "persistent://public/default/orders"
+	for _, to := range []string{"12:3:-1", "12:3:0:5", "earliest", "latest", "2026-01-02T03:04:05Z"} {
+		if err := SeekSubscription(connArgs, port, topic, "billing", to); err != nil {
+			t.Fatalf("SeekSubscription(%q): %v", to, err)
+		}
+	}
+	sub := "POST /admin/v2/persistent/public/default/orders/subscription/billing"
+	want := []string{
+		sub + `/resetcursor {"ledgerId":12,"entryId":3,"partitionIndex":-1,"batchIndex":-1}`,
+		sub + `/resetcursor {"ledgerId":12,"entryId":3,"partitionIndex":0,"batchIndex":5}`,
+		sub + "/resetcursor/0",
+		sub + "/skip_all",
+		sub + "/resetcursor/1767323045000",
+	}
+	if !slices.Equal(*requests, want) {
+		t.Errorf("requests = %v, want %v", *requests, want)
+	}
+	for _, bad := range []string{"42", "yesterday"} {
+		if err := SeekSubscription(connArgs, port, topic, "billing", bad); err == nil {
+			t.Errorf("SeekSubscription(%q): expected an error", bad)
+		}
+	}
+}
@@ -17,29 +17,36 @@ type TopicAdapter struct {
 	ephemeralSub string // per-adapter name for group-less, non-durable subscriptions
 }
 
-// NewTopicAdapter creates a new Pulsar topic adapter.
-func NewTopicAdapter(connArgs ConnArguments) (*TopicAdapter, error) {
+// NewTopicAdapter creates a new Pulsar topic adapter. adminPort is used to
+// look up topic schemas for --schema.
+func NewTopicAdapter(connArgs ConnArguments, adminPort int) (*TopicAdapter, error) {
 	client, err := Connect(connArgs)
 	if err != nil {
 		return nil, err
 	}
 	return &TopicAdapter{
-		clientCache:  newClientCache(client),
+		clientCache:  newClientCache(client, connArgs, adminPort),
 		ephemeralSub: "xmc-sub-" + backends.RandomSuffix(),
 	}, nil
 }
 
 // Publish implements backends.TopicBackend.
 func (a *TopicAdapter) Publish(ctx context.Context, opts backends.PublishOptions) error {
-	producer, err := a.getProducer(opts.Topic)
+	schema, err := a.getSchema(opts.Extra["schema"], opts.Topic, true)
+	if err != nil {
+		return err
+	}
+	producer, err := a.getProducer(opts.Topic, schema)
 	if err != nil {
 		return err
 	}
 
 	msg := &pulsar.ProducerMessage{
-		Payload:    opts.Message,
 		Properties: backends.StringifyProps(opts.Properties),
 	}
+	if err := setSchemaPayload(msg, schema, opts.Message); err != nil {
+		return err
+	}
 	if opts.Key != "" {
 		msg.Key = opts.Key
 	}
@@ -68,28 +75,49 @@ func (a *TopicAdapter) Publish(ctx context.Context, opts backends.PublishOptions
 // consumers); --durable an Exclusive durable one. Without either, the
 // subscription is NonDurable with a per-adapter unique name, so no cursor is
 // left behind on the topic and concurrent group-less subscribers don't
-// collide on an Exclusive subscription name.
+// collide on an Exclusive subscription name. --subscription-type,
+// --initial-position and the dead-letter/retry flags in opts.Extra override
+// these defaults.
 func (a *TopicAdapter) Subscribe(ctx context.Context, opts backends.SubscribeOptions) (*backends.Message, error) {
-	subType := pulsar.Exclusive
-	subMode := pulsar.Durable
-	var subName string
+	consumerOpts := a.subscription(opts)
+	consumerOpts.Topic = opts.Topic
+	return a.receive(ctx, consumerOpts, opts)
+}
+
+// subscription picks the subscription name, type and mode for opts, as
+// documented on Subscribe.
+func (a *TopicAdapter) subscription(opts backends.SubscribeOptions) pulsar.ConsumerOptions {
 	switch {
 	case opts.GroupID != "":
-		subType = pulsar.Shared
-		subName = opts.GroupID
+		return pulsar.ConsumerOptions{SubscriptionName: opts.GroupID, Type: pulsar.Shared, SubscriptionMode: pulsar.Durable}
 	case opts.Durable:
-		subName = "xmc-durable"
+		return pulsar.ConsumerOptions{SubscriptionName: "xmc-durable", Type: pulsar.Exclusive, SubscriptionMode: pulsar.Durable}
 	default:
-		subName = a.ephemeralSub
-		subMode = pulsar.NonDurable
+		return pulsar.ConsumerOptions{SubscriptionName: a.ephemeralSub, Type: pulsar.Exclusive, SubscriptionMode: pulsar.NonDurable}
 	}
+}
 
-	consumer, err := a.getConsumer(pulsar.ConsumerOptions{
-		Topic:            opts.Topic,
-		SubscriptionName: subName,
-		Type:             subType,
-		SubscriptionMode: subMode,
-	})
+// receive applies the consume flags to consumerOpts, then waits for and
+// settles (acks, or with --nack redelivers) the next message on the cached
+// consumer. Messages from a multi-topic consumer are labelled with their topic.
+func (a *TopicAdapter) receive(ctx context.Context, consumerOpts pulsar.ConsumerOptions, opts backends.SubscribeOptions) (*backends.Message, error) {
+	cfg, err := parseConsumeConfig(opts.Extra)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.apply(&consumerOpts); err != nil {
+		return nil, err
+	}
+	schemaTopic := consumerOpts.Topic
+	if len(consumerOpts.Topics) == 1 {
+		schemaTopic = consumerOpts.Topics[0]
+	}
+	schema, err := a.getSchema(opts.Extra["schema"], schemaTopic, false)
+	if err != nil {
+		return nil, err
+	}
+	consumerOpts.Schema = schema
+	consumer, err := a.getConsumer(consumerOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -106,8 +134,12 @@ func (a *TopicAdapter) Subscribe(ctx context.Context, opts backends.SubscribeOpt
 		return nil, err
 	}
 
-	consumer.Ack(msg) //nolint:errcheck
-	return pulsarToBackendMessage(msg), nil
+	cfg.settle(consumer, msg)
+	result := pulsarToBackendMessage(msg, schema)
+	if consumerOpts.Topic == "" {
+		result.Topic = msg.Topic()
+	}
+	return result, nil
 }
 
 // Close implements backends.TopicBackend.
@@ -0,0 +1,111 @@
+//go:build rabbitmq
+
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/go-amqp"
+	"github.com/makibytes/xmc/broker/amqpcommon"
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// exchangeBinding is one (exchange, binding key) pair of a subscription.
+type exchangeBinding struct {
+	exchange, key string
+}
+
+// SubscribeTopics implements backends.MultiTopicBackend.
+// All topics are bound to one backing queue, one binding each, so the
+// exchanges do the merging: topic-exchange binding keys keep their native *
+// (one word) and # (zero or more words) wildcards. Each message is labelled
+// with the routing key it was published with.
+func (a *TopicAdapter) SubscribeTopics(ctx context.Context, topics []string, opts backends.SubscribeOptions) (*backends.Message, error) {
+	bindings := make([]exchangeBinding, 0, len(topics))
+	for _, t := range topics {
+		exchange, key, ok := parseExchangeAddress(topicAddress(t, ""))
+		if !ok {
+			return nil, fmt.Errorf("%s is not an exchange address: only exchange topics can be merged into one subscription", t)
+		}
+		bindings = append(bindings, exchangeBinding{exchange: exchange, key: key})
+	}
+
+	queueName, err := a.ensureMultiSubscriptionQueue(bindings, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := ReceiveMessage(ctx, a.session, ReceiveArguments{
+		Queue:       "/queues/" + escapeName(queueName),
+		Acknowledge: true,
+		Selector:    opts.Selector,
+		Timeout:     opts.Timeout,
+		Wait:        opts.Wait,
+	})
+	if err != nil {
+		if !opts.Wait && errors.Is(err, context.DeadlineExceeded) {
+			return nil, backends.ErrNoMessageAvailable
+		}
+		return nil, err
+	}
+	if message == nil {
+		return nil, backends.ErrNoMessageAvailable
+	}
+
+	msg := amqpcommon.ConvertAMQPToBackendMessage(message, opts.Verbosity >= backends.VerbosityVerbose)
+	msg.Topic = routingKey(message)
+	return msg, nil
+}
+
+// routingKey returns the routing key RabbitMQ records in the x-routing-key
+// message annotation on delivery.
+func routingKey(m *amqp.Message) string {
+	if m.Annotations == nil {
+		return ""
+	}
+	key, _ := m.Annotations["x-routing-key"].(string)
+	return key
+}
+
+// ensureMultiSubscriptionQueue is ensureSubscriptionQueue for a set of
+// bindings: one queue, declared once per adapter, bound once per binding.
+func (a *TopicAdapter) ensureMultiSubscriptionQueue(bindings []exchangeBinding, opts backends.SubscribeOptions) (string, error) {
+	scopes := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		scopes = append(scopes, b.exchange+"/"+b.key)
+	}
+	scope := strings.Join(scopes, ",")
+	cacheKey := subscriptionCacheKey("", scope, opts)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if q, ok := a.subQueues[cacheKey]; ok {
+		return q, nil
+	}
+
+	// Named like a single-binding subscription, with the binding keys as
+	// scope, so a group or durable subscription finds its queue again.
+	keys := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		keys = append(keys, b.key)
+	}
+	name, ephemeral := subscriptionQueueName("", strings.Join(keys, "+"), opts)
+
+	mgmt := ManagementArgs{Server: a.connArgs.Server, User: a.connArgs.User, Password: a.connArgs.Password}
+	if err := DeclareSubscriptionQueue(mgmt, name, ephemeral); err != nil {
+		return "", fmt.Errorf("declaring subscription queue %s: %w", name, err)
+	}
+	if ephemeral {
+		a.ephemeral = append(a.ephemeral, name)
+	}
+	for _, b := range bindings {
+		if err := BindQueue(mgmt, name, b.exchange, b.key); err != nil {
+			return "", fmt.Errorf("binding queue %s to exchange %s: %w", name, b.exchange, err)
+		}
+	}
+	a.subQueues[cacheKey] = name
+	return name, nil
+}
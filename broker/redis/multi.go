@@ -0,0 +1,224 @@
+//go:build redis
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// patternRescan is how often stream keys matching a wildcard topic are
+// rescanned, so streams created while subscribed join the merged stream.
+const patternRescan = 5 * time.Second
+
+// streamEntry is a stream entry together with the stream it was read from.
+type streamEntry struct {
+	stream string
+	entry  redis.XMessage
+}
+
+// multiState tracks a multi-topic subscription across SubscribeTopics calls.
+type multiState struct {
+	key     string // topics the state belongs to
+	streams []string
+	scanned time.Time
+	seeded  bool          // initial key set resolved; later streams start at their beginning
+	next    int           // stream the next non-blocking pass starts at
+	pending []streamEntry // further entries returned by a blocking read, not yet delivered
+}
+
+// SubscribeTopics implements backends.MultiTopicBackend.
+// Topics are stream keys; shell wildcards (*, ?, [...]) are resolved with a
+// SCAN over stream keys (Redis's own glob syntax), repeated every few seconds.
+// Streams with waiting entries are served round-robin, then one blocking
+// XREAD/XREADGROUP covers them all. Each message is labelled with its stream.
+func (a *TopicAdapter) SubscribeTopics(ctx context.Context, topics []string, opts backends.SubscribeOptions) (*backends.Message, error) {
+	if key := strings.Join(topics, ","); a.multi.key != key {
+		a.multi = multiState{key: key}
+	}
+	if len(a.multi.pending) > 0 {
+		e := a.multi.pending[0]
+		a.multi.pending = a.multi.pending[1:]
+		return a.deliver(ctx, e, opts.GroupID), nil
+	}
+
+	deadline := time.Now().Add(backends.TimeoutDuration(opts.Timeout, opts.Wait))
+	for {
+		streams, err := a.multiStreams(ctx, topics, opts.GroupID)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := a.readAny(ctx, streams, opts.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil {
+			return a.deliver(ctx, *e, opts.GroupID), nil
+		}
+
+		block := time.Until(deadline)
+		if slices.ContainsFunc(topics, backends.HasGlob) {
+			block = min(block, patternRescan)
+		}
+		if block <= 0 {
+			return nil, backends.ErrNoMessageAvailable
+		}
+		if len(streams) == 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(block):
+			}
+			continue
+		}
+
+		entries, err := a.readStreams(ctx, streams, opts.GroupID, block)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			a.multi.pending = append(a.multi.pending, entries[1:]...)
+			return a.deliver(ctx, entries[0], opts.GroupID), nil
+		}
+	}
+}
+
+// multiStreams resolves topics to stream keys, rescanning wildcard topics
+// every patternRescan. Group mode makes sure every stream has the group.
+func (a *TopicAdapter) multiStreams(ctx context.Context, topics []string, group string) ([]string, error) {
+	if a.multi.streams != nil && time.Since(a.multi.scanned) < patternRescan {
+		return a.multi.streams, nil
+	}
+
+	var streams []string
+	for _, t := range topics {
+		if !backends.HasGlob(t) {
+			if !slices.Contains(streams, t) {
+				streams = append(streams, t)
+			}
+			continue
+		}
+		var cursor uint64
+		for {
+			keys, next, err := a.client.ScanType(ctx, cursor, t, 100, "stream").Result()
+			if err != nil {
+				return nil, fmt.Errorf("scanning streams matching %s: %w", t, err)
+			}
+			for _, k := range keys {
+				if !slices.Contains(streams, k) {
+					streams = append(streams, k)
+				}
+			}
+			if cursor = next; cursor == 0 {
+				break
+			}
+		}
+	}
+
+	for _, s := range streams {
+		if group != "" {
+			if err := a.ensureTopicGroup(ctx, s, group); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if _, ok := a.lastID[s]; ok {
+			continue
+		}
+		if !a.multi.seeded {
+			id, err := a.startID(ctx, s)
+			if err != nil {
+				return nil, err
+			}
+			a.lastID[s] = id
+		} else {
+			a.lastID[s] = "0-0" // created since the first scan: everything in it is new
+		}
+	}
+	a.multi.seeded = true
+	a.multi.streams = streams
+	a.multi.scanned = time.Now()
+	return streams, nil
+}
+
+// readAny returns the next waiting entry without blocking, trying the streams
+// round-robin from where the previous call left off so a busy stream cannot
+// starve the others. It returns nil when no stream has anything waiting.
+func (a *TopicAdapter) readAny(ctx context.Context, streams []string, group string) (*streamEntry, error) {
+	for range len(streams) {
+		s := streams[a.multi.next%len(streams)]
+		a.multi.next = (a.multi.next + 1) % len(streams)
+
+		entries, err := a.readStreams(ctx, []string{s}, group, -1)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			return &entries[0], nil
+		}
+	}
+	return nil, nil
+}
+
+// readStreams reads at most one entry per stream, blocking up to block
+// (negative: not at all). Independent reads start after each stream's last
+// delivered ID, group reads take new entries (">").
+func (a *TopicAdapter) readStreams(ctx context.Context, streams []string, group string, block time.Duration) ([]streamEntry, error) {
+	ids := make([]string, 0, 2*len(streams))
+	ids = append(ids, streams...)
+	for _, s := range streams {
+		if group != "" {
+			ids = append(ids, ">")
+		} else {
+			ids = append(ids, a.lastID[s])
+		}
+	}
+
+	var (
+		result []redis.XStream
+		err    error
+	)
+	if group != "" {
+		result, err = a.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group: group, Consumer: "xmc", Streams: ids, Count: 1, Block: block,
+		}).Result()
+	} else {
+		result, err = a.client.XRead(ctx, &redis.XReadArgs{Streams: ids, Count: 1, Block: block}).Result()
+	}
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("subscribing to topics %s: %w", strings.Join(streams, ", "), err)
+	}
+
+	var entries []streamEntry
+	for _, r := range result {
+		for _, m := range r.Messages {
+			entries = append(entries, streamEntry{stream: r.Stream, entry: m})
+		}
+	}
+	return entries, nil
+}
+
+// deliver acknowledges a group entry, or advances the stream's read position,
+// and converts it.
+func (a *TopicAdapter) deliver(ctx context.Context, e streamEntry, group string) *backends.Message {
+	if group != "" {
+		a.client.XAck(ctx, e.stream, group, e.entry.ID) //nolint:errcheck
+	} else {
+		a.lastID[e.stream] = e.entry.ID
+	}
+	msg := streamToMessage(e.entry.ID, e.entry.Values)
+	msg.Topic = e.stream
+	return msg
+}
@@ -19,6 +19,7 @@ type TopicAdapter struct {
 	maxLen  int64
 	lastID  map[string]string
 	ensured map[string]struct{}
+	multi   multiState
 }
 
 func NewTopicAdapter(connArgs ConnArguments, maxLen int64) (*TopicAdapter, error) {
@@ -92,17 +93,9 @@ func (a *TopicAdapter) subscribeGroup(ctx context.Context, key, group string, du
 func (a *TopicAdapter) subscribeIndependent(ctx context.Context, key string, timeout time.Duration) (*backends.Message, error) {
 	startID, ok := a.lastID[key]
 	if !ok {
-		// Resolve "now" to a concrete ID once: XRead's "$" is re-evaluated on
-		// every call, so a polling loop (-n 0, --for) would drop entries
-		// published between two calls.
-		info, err := a.client.XInfoStream(ctx, key).Result()
-		switch {
-		case err == nil:
-			startID = info.LastGeneratedID
-		case strings.Contains(err.Error(), "no such key"):
-			startID = "0-0" // stream doesn't exist yet: deliver from its beginning
-		default:
-			return nil, fmt.Errorf("resolving stream position for %s: %w", key, err)
+		var err error
+		if startID, err = a.startID(ctx, key); err != nil {
+			return nil, err
 		}
 		a.lastID[key] = startID
 	}
@@ -129,6 +122,21 @@ func (a *TopicAdapter) subscribeIndependent(ctx context.Context, key string, tim
 	return streamToMessage(entry.ID, entry.Values), nil
 }
 
+// startID resolves "now" on a stream to a concrete ID once: XRead's "$" is
+// re-evaluated on every call, so a polling loop (-n 0, --for) would drop
+// entries published between two calls.
+func (a *TopicAdapter) startID(ctx context.Context, key string) (string, error) {
+	info, err := a.client.XInfoStream(ctx, key).Result()
+	switch {
+	case err == nil:
+		return info.LastGeneratedID, nil
+	case strings.Contains(err.Error(), "no such key"):
+		return "0-0", nil // stream doesn't exist yet: deliver from its beginning
+	default:
+		return "", fmt.Errorf("resolving stream position for %s: %w", key, err)
+	}
+}
+
 func (a *TopicAdapter) Close() error {
 	if a.client != nil {
 		return a.client.Close()
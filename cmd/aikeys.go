@@ -1126,6 +1126,7 @@ func (m *aiTUIModel) cycleFocus(forward bool) {
 type sidebarRow struct {
 	node       backends.ObjectNode
 	parentName string // "" for top-level rows
+	depth      int    // 0 top-level, 1 child, 2 grandchild (e.g. a Pulsar topic under its namespace)
 }
 
 // sidebarRows returns the flattened, navigable rows for window idx, in the
@@ -1144,14 +1145,22 @@ func (m aiTUIModel) sidebarRows(idx int) []sidebarRow {
 	for _, node := range items {
 		rows = append(rows, sidebarRow{node: node})
 		if w.treeView && w.hierarchical {
-			for _, child := range node.Children {
-				rows = append(rows, sidebarRow{node: child, parentName: node.Name})
-			}
+			rows = appendChildRows(rows, node, 1)
 		}
 	}
 	return rows
 }
 
+// appendChildRows appends parent's children at depth, each followed by its
+// own children one level deeper.
+func appendChildRows(rows []sidebarRow, parent backends.ObjectNode, depth int) []sidebarRow {
+	for _, child := range parent.Children {
+		rows = append(rows, sidebarRow{node: child, parentName: parent.Name, depth: depth})
+		rows = appendChildRows(rows, child, depth+1)
+	}
+	return rows
+}
+
 // indexOfRowNamed returns the index of the first row in rows whose node has
 // the given name, or 0 if not found (an empty/not-found name also lands on 0,
 // which is always a safe selection when rows is non-empty).
@@ -2007,6 +2007,19 @@ func TestSidebarRows_TreeViewOn_IncludesChildrenWithParentName(t *testing.T) {
 	}
 }
 
+func TestSidebarRows_TreeViewOn_IncludesGrandchildren(t *testing.T) {
+	m := newTestModelWithExchangeChildren(nil, nil)
+	m.objTypes[0].nodes[0].Children[0].Children = []backends.ObjectNode{{Name: "deep", Kind: "topic"}}
+	m.objTypes[0].treeView = true
+	rows := m.sidebarRows(0)
+	if len(rows) != 3 || m.windowNaturalRows(0) != 3 {
+		t.Fatalf("sidebarRows = %d rows, natural rows %d; want 3 (parent, child, grandchild)", len(rows), m.windowNaturalRows(0))
+	}
+	if rows[2].node.Name != "deep" || rows[2].parentName != "my-queue" || rows[2].depth != 2 {
+		t.Errorf("row 2 = %+v, want deep under my-queue at depth 2", rows[2])
+	}
+}
+
 func TestAITUI_ChildRowSelectable_NavigatesAndHighlights(t *testing.T) {
 	m := newTestModelWithExchangeChildren(nil, nil)
 	m.objTypes[0].treeView = true
@@ -247,12 +247,7 @@ func (m aiTUIModel) windowNaturalRows(idx int) int {
 		return 1 // "(none)" line
 	}
 	if w.treeView && w.hierarchical {
-		rows := 0
-		for _, node := range items {
-			rows++ // parent row
-			rows += len(node.Children)
-		}
-		return rows
+		return len(m.sidebarRows(idx))
 	}
 	return len(items)
 }
@@ -345,7 +340,7 @@ func (m aiTUIModel) writeObjectSection(b *strings.Builder, width, bodyLines, idx
 	type displayRow struct {
 		name   string
 		metric string
-		indent bool
+		depth  int
 	}
 	rows := make([]displayRow, len(sRows))
 	for i, r := range sRows {
@@ -357,7 +352,7 @@ func (m aiTUIModel) writeObjectSection(b *strings.Builder, width, bodyLines, idx
 		if r.node.Kind != "" {
 			label = r.node.Kind + " " + r.node.Name
 		}
-		rows[i] = displayRow{name: label, metric: fmtNodeMetric(r.node), indent: true}
+		rows[i] = displayRow{name: label, metric: fmtNodeMetric(r.node), depth: r.depth}
 	}
 
 	selRow := w.sel
@@ -385,8 +380,8 @@ func (m aiTUIModel) writeObjectSection(b *strings.Builder, width, bodyLines, idx
 	for ri := start; ri < limit; ri++ {
 		r := rows[ri]
 		prefix := "  "
-		if r.indent {
-			prefix = "  └ "
+		if r.depth > 0 {
+			prefix = "  " + strings.Repeat("  ", r.depth-1) + "└ "
 		}
 
 		name := r.name
@@ -399,8 +394,8 @@ func (m aiTUIModel) writeObjectSection(b *strings.Builder, width, bodyLines, idx
 			name = string(nameRunes[:maxName-1]) + "…"
 		}
 
-		if focused && ri == w.sel && r.indent {
-			marker := "▸ └ "
+		if focused && ri == w.sel && r.depth > 0 {
+			marker := "▸ " + strings.Repeat("  ", r.depth-1) + "└ "
 			pad := width - len(marker) - 2 - len(name) - len(metricStr)
 			if pad < 1 {
 				pad = 1
@@ -0,0 +1,604 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+	"github.com/makibytes/xmc/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+// Properties stamped on every benchmark message. The run ID lets consumers
+// ignore leftovers from earlier runs (or unrelated traffic) on the same
+// destination; the timestamp is the producer's send time in Unix nanoseconds,
+// from which the consumer computes end-to-end latency.
+//
+// With --stamp payload (for brokers that cannot carry properties, e.g. MQTT
+// 3.1.1) the same two values lead the payload instead, as
+// "xmc-bench:<run>:<nanos>;".
+const (
+	benchRunProp  = "xmc-bench-run"
+	benchTimeProp = "xmc-bench-ts"
+
+	benchPayloadPrefix = "xmc-bench:"
+)
+
+// NewBenchCommand creates the bench command: configurable producer and consumer
+// pools run against one queue or topic and the run is summarised as throughput,
+// end-to-end latency percentiles and error rates.
+//
+// Every worker opens its own connection through the adapter factory, the way a
+// fleet of independent clients would, so the adapters never have to be safe for
+// concurrent use. The factories are the (reconnect-aware) ones built by
+// NewRootCommand; either may be nil when the broker lacks that model.
+func NewBenchCommand(queueFactory QueueAdapterFactory, topicFactory TopicAdapterFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bench <queue|topic>",
+		Short: "Benchmark throughput and end-to-end latency against a queue or topic",
+		Long: `Runs --producers producer and --consumers consumer goroutines, each with its own
+connection, against a single queue or topic. Every message carries its send time
+in the xmc-bench-ts property, so consumers can measure end-to-end latency. On
+brokers that cannot carry properties (MQTT 3.1.1) use --stamp payload to put it
+at the start of the payload instead.
+
+The run is bounded by --count (total measured messages) or --duration, and is
+preceded by an optional --warmup whose messages are excluded from the results.
+Payload sizes follow --size: a fixed size ("512"), a uniform range ("64-4096"),
+or a weighted mix ("128:70,4096:30"); sizes accept a k or m suffix.
+
+The report shows throughput, latency percentiles (p50/p95/p99/max), error rates
+and lost messages, as text or as JSON with -J.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			return doBench(c, args[0], queueFactory, topicFactory)
+		},
+	}
+
+	if queueFactory != nil && topicFactory != nil {
+		cmd.Flags().Bool("topic", false, "Benchmark a topic (publish/subscribe) instead of a queue")
+	}
+	if topicFactory != nil {
+		cmd.Flags().StringP("group", "g", "", "Consumer group ID shared by the consumers (default: none; each consumer subscribes non-durably and receives every message)")
+	}
+	cmd.Flags().Int("producers", 1, "Number of concurrent producers")
+	cmd.Flags().Int("consumers", 1, "Number of concurrent consumers (0 = measure producers only)")
+	cmd.Flags().IntP("count", "n", 0, "Total number of measured messages to produce (0 = run for --duration)")
+	cmd.Flags().Var(newDurationValue(10*time.Second, time.Second), "duration", "Measurement window when --count is 0 (e.g. \"30s\", \"1m\")")
+	cmd.Flags().Var(newDurationValue(0, time.Second), "warmup", "Produce and consume for this long before measuring (e.g. \"5s\")")
+	cmd.Flags().String("size", "256", "Payload size: fixed (\"512\"), range (\"64-4096\") or weighted mix (\"128:70,4096:30\")")
+	cmd.Flags().Float64("rate", 0, "Throttle total production to this many messages per second (0 = unlimited)")
+	cmd.Flags().Var(newDurationValue(5*time.Second, time.Second), "drain-timeout", "How long consumers wait for in-flight messages after producers stop")
+	cmd.Flags().String("stamp", "property", "Where to carry the send timestamp: property or payload (for brokers without properties)")
+	cmd.Flags().BoolP("json", "J", false, "Output the report as JSON")
+
+	return cmd
+}
+
+// benchConfig holds the parsed bench flags.
+type benchConfig struct {
+	destination  string
+	topic        bool
+	group        string
+	producers    int
+	consumers    int
+	count        int
+	duration     time.Duration
+	warmup       time.Duration
+	sizes        *sizeDist
+	rate         float64
+	drainTimeout time.Duration
+	stampPayload bool
+	jsonOutput   bool
+}
+
+func parseBenchFlags(c *cobra.Command, destination string, queueCapable, topicCapable bool) (benchConfig, error) {
+	cfg := benchConfig{destination: destination}
+	cfg.topic = topicCapable && !queueCapable
+	if queueCapable && topicCapable {
+		cfg.topic, _ = c.Flags().GetBool("topic")
+	}
+	cfg.group, _ = c.Flags().GetString("group")
+	cfg.producers, _ = c.Flags().GetInt("producers")
+	cfg.consumers, _ = c.Flags().GetInt("consumers")
+	cfg.count, _ = c.Flags().GetInt("count")
+	cfg.duration = getDuration(c, "duration")
+	cfg.warmup = getDuration(c, "warmup")
+	cfg.rate, _ = c.Flags().GetFloat64("rate")
+	cfg.drainTimeout = getDuration(c, "drain-timeout")
+	cfg.jsonOutput, _ = c.Flags().GetBool("json")
+
+	if cfg.producers < 1 {
+		return cfg, fmt.Errorf("--producers must be at least 1")
+	}
+	if cfg.consumers < 0 {
+		return cfg, fmt.Errorf("--consumers must not be negative")
+	}
+	if cfg.count < 0 {
+		return cfg, fmt.Errorf("--count must not be negative")
+	}
+	if cfg.count == 0 && cfg.duration <= 0 {
+		return cfg, fmt.Errorf("either --count or --duration must be set")
+	}
+	switch stamp, _ := c.Flags().GetString("stamp"); stamp {
+	case "property":
+	case "payload":
+		cfg.stampPayload = true
+	default:
+		return cfg, fmt.Errorf("invalid --stamp %q (expected property or payload)", stamp)
+	}
+	size, _ := c.Flags().GetString("size")
+	sizes, err := parseSizeDist(size)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.sizes = sizes
+	return cfg, nil
+}
+
+// sizeDist is a payload size distribution for --size.
+type sizeDist struct {
+	min, max int   // uniform range (min == max for a fixed size)
+	sizes    []int // weighted mix; empty unless the "size:weight,..." form was used
+	weights  []int // cumulative weights, parallel to sizes
+}
+
+// parseSizeDist parses a --size value: "512", "64-4096" or "128:70,4096:30".
+func parseSizeDist(s string) (*sizeDist, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, ":") {
+		d := &sizeDist{}
+		total := 0
+		for _, part := range strings.Split(s, ",") {
+			sizeStr, weightStr, _ := strings.Cut(part, ":")
+			size, err := parseSize(sizeStr)
+			if err != nil {
+				return nil, err
+			}
+			weight, err := strconv.Atoi(strings.TrimSpace(weightStr))
+			if err != nil || weight <= 0 {
+				return nil, fmt.Errorf("invalid --size weight %q in %q (expected a positive integer)", weightStr, s)
+			}
+			total += weight
+			d.sizes = append(d.sizes, size)
+			d.weights = append(d.weights, total)
+			d.max = max(d.max, size)
+		}
+		return d, nil
+	}
+	if lo, hi, ok := strings.Cut(s, "-"); ok {
+		minSize, err := parseSize(lo)
+		if err != nil {
+			return nil, err
+		}
+		maxSize, err := parseSize(hi)
+		if err != nil {
+			return nil, err
+		}
+		if minSize > maxSize {
+			return nil, fmt.Errorf("invalid --size range %q (min exceeds max)", s)
+		}
+		return &sizeDist{min: minSize, max: maxSize}, nil
+	}
+	size, err := parseSize(s)
+	if err != nil {
+		return nil, err
+	}
+	return &sizeDist{min: size, max: size}, nil
+}
+
+// parseSize parses a byte count with an optional k (KiB) or m (MiB) suffix.
+func parseSize(s string) (int, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	mult := 1
+	switch {
+	case strings.HasSuffix(v, "k"):
+		mult, v = 1024, strings.TrimSuffix(v, "k")
+	case strings.HasSuffix(v, "m"):
+		mult, v = 1024*1024, strings.TrimSuffix(v, "m")
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid --size %q (expected a byte count such as 512, 4k or 1m)", s)
+	}
+	return n * mult, nil
+}
+
+// next draws a payload size from the distribution.
+func (d *sizeDist) next(r *rand.Rand) int {
+	if len(d.sizes) > 0 {
+		n := r.IntN(d.weights[len(d.weights)-1])
+		i, _ := slices.BinarySearch(d.weights, n+1)
+		return d.sizes[i]
+	}
+	if d.max == d.min {
+		return d.min
+	}
+	return d.min + r.IntN(d.max-d.min+1)
+}
+
+// benchRun is the state shared by the producer and consumer pools of one run.
+type benchRun struct {
+	cfg          benchConfig
+	runID        string
+	measureStart time.Time
+	payload      []byte // filler sliced to each message's size
+
+	remaining atomic.Int64 // measured messages still to produce (--count mode)
+	sent      atomic.Int64
+	sendErrs  atomic.Int64
+	bytesSent atomic.Int64
+	received  atomic.Int64
+	recvErrs  atomic.Int64
+	lastSent  atomic.Int64 // Unix nanos of the last measured send
+	lastRecv  atomic.Int64 // Unix nanos of the last measured receive
+
+	mu        sync.Mutex
+	latencies []time.Duration
+}
+
+// measured reports whether a message sent at t falls inside the measurement
+// window (i.e. after the warmup).
+func (r *benchRun) measured(t time.Time) bool {
+	return !t.Before(r.measureStart)
+}
+
+// produce sends messages until the count is exhausted or ctx ends.
+func (r *benchRun) produce(ctx context.Context, send func(context.Context, []byte, map[string]any) error, limiter *rateLimiter) {
+	rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	for ctx.Err() == nil {
+		limiter.wait()
+		now := time.Now()
+		measured := r.measured(now)
+		if measured && r.cfg.count > 0 && r.remaining.Add(-1) < 0 {
+			return
+		}
+		size := r.cfg.sizes.next(rng)
+		data, props := r.stamp(now, size)
+		err := send(ctx, data, props)
+		if !measured {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			r.sendErrs.Add(1)
+			log.Verbose("bench: send: %s", err)
+			continue
+		}
+		r.sent.Add(1)
+		r.bytesSent.Add(int64(size))
+		r.lastSent.Store(time.Now().UnixNano())
+	}
+}
+
+// stamp builds a size-byte payload (or longer, if the payload stamp does not
+// fit) and the properties identifying a message of this run sent at now.
+func (r *benchRun) stamp(now time.Time, size int) ([]byte, map[string]any) {
+	nanos := strconv.FormatInt(now.UnixNano(), 10)
+	if !r.cfg.stampPayload {
+		return r.payload[:size], map[string]any{benchRunProp: r.runID, benchTimeProp: nanos}
+	}
+	header := benchPayloadPrefix + r.runID + ":" + nanos + ";"
+	return append([]byte(header), r.payload[:max(size-len(header), 0)]...), nil
+}
+
+// benchStamp extracts the run ID and send time from a received message, from
+// its properties or, failing that, from a payload stamp.
+func benchStamp(msg *backends.Message) (runID string, sentAt time.Time, ok bool) {
+	runID, nanosStr := fmt.Sprint(msg.Properties[benchRunProp]), fmt.Sprint(msg.Properties[benchTimeProp])
+	if _, found := msg.Properties[benchRunProp]; !found {
+		rest, found := strings.CutPrefix(string(msg.Data[:min(len(msg.Data), 64)]), benchPayloadPrefix)
+		if !found {
+			return "", time.Time{}, false
+		}
+		stamp, _, found := strings.Cut(rest, ";")
+		if !found {
+			return "", time.Time{}, false
+		}
+		runID, nanosStr, _ = strings.Cut(stamp, ":")
+	}
+	nanos, err := strconv.ParseInt(nanosStr, 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return runID, time.Unix(0, nanos), true
+}
+
+// consume reads messages until ctx ends, recording the latency of every
+// measured message that belongs to this run.
+func (r *benchRun) consume(ctx context.Context, receive messageReceiver) {
+	var local []time.Duration
+	defer func() {
+		r.mu.Lock()
+		r.latencies = append(r.latencies, local...)
+		r.mu.Unlock()
+	}()
+	for ctx.Err() == nil {
+		msg, err := receive(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case errors.Is(err, backends.ErrNoMessageAvailable), errors.Is(err, context.DeadlineExceeded), msg == nil && err == nil:
+			continue
+		case err != nil:
+			r.recvErrs.Add(1)
+			log.Verbose("bench: receive: %s", err)
+			continue
+		}
+		now := time.Now()
+		runID, sentAt, ok := benchStamp(msg)
+		if !ok || runID != r.runID || !r.measured(sentAt) {
+			continue
+		}
+		local = append(local, now.Sub(sentAt))
+		r.received.Add(1)
+		r.lastRecv.Store(now.UnixNano())
+	}
+}
+
+func doBench(c *cobra.Command, destination string, queueFactory QueueAdapterFactory, topicFactory TopicAdapterFactory) error {
+	cfg, err := parseBenchFlags(c, destination, queueFactory != nil, topicFactory != nil)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := interruptContext(c.Context())
+	defer stop()
+
+	var senders []func(context.Context, []byte, map[string]any) error
+	var receivers []messageReceiver
+	var closers []interface{ Close() error }
+	defer func() {
+		for _, a := range closers {
+			closeAdapter(a)
+		}
+	}()
+
+	for i := 0; i < cfg.producers+cfg.consumers; i++ {
+		isProducer := i < cfg.producers
+		if cfg.topic {
+			a, err := topicFactory()
+			if err != nil {
+				return err
+			}
+			closers = append(closers, a)
+			if isProducer {
+				senders = append(senders, func(ctx context.Context, data []byte, props map[string]any) error {
+					return a.Publish(ctx, backends.PublishOptions{Topic: cfg.destination, Message: data, Properties: props})
+				})
+			} else {
+				opts := backends.SubscribeOptions{Topic: cfg.destination, GroupID: cfg.group, Timeout: 0.5, Acknowledge: true, Verbosity: backends.VerbosityNormal}
+				receivers = append(receivers, func(ctx context.Context) (*backends.Message, error) { return a.Subscribe(ctx, opts) })
+			}
+			continue
+		}
+		if queueFactory == nil {
+			return fmt.Errorf("this broker does not support queue operations")
+		}
+		a, err := queueFactory()
+		if err != nil {
+			return err
+		}
+		closers = append(closers, a)
+		if isProducer {
+			senders = append(senders, func(ctx context.Context, data []byte, props map[string]any) error {
+				return a.Send(ctx, backends.SendOptions{Queue: cfg.destination, Message: data, Properties: props})
+			})
+		} else {
+			opts := backends.ReceiveOptions{Queue: cfg.destination, Timeout: 0.5, Acknowledge: true, Verbosity: backends.VerbosityNormal}
+			receivers = append(receivers, func(ctx context.Context) (*backends.Message, error) { return a.Receive(ctx, opts) })
+		}
+	}
+
+	run := &benchRun{cfg: cfg, runID: backends.RandomSuffix(), payload: []byte(strings.Repeat("x", cfg.sizes.max))}
+	run.remaining.Store(int64(cfg.count))
+
+	// Consumers poll once before any message is produced so that topic
+	// subscriptions exist by the time the producers start publishing.
+	consumeCtx, stopConsumers := context.WithCancel(ctx)
+	defer stopConsumers()
+	var consumers sync.WaitGroup
+	var ready sync.WaitGroup
+	for _, receive := range receivers {
+		consumers.Add(1)
+		ready.Add(1)
+		go func() {
+			defer consumers.Done()
+			primed := false
+			run.consume(consumeCtx, func(ctx context.Context) (*backends.Message, error) {
+				if !primed {
+					primed = true
+					defer ready.Done()
+				}
+				return receive(ctx)
+			})
+			if !primed {
+				ready.Done()
+			}
+		}()
+	}
+	ready.Wait()
+
+	start := time.Now()
+	run.measureStart = start.Add(cfg.warmup)
+	produceCtx, stopProducers := context.WithCancel(ctx)
+	if cfg.count == 0 {
+		stopProducers()
+		produceCtx, stopProducers = context.WithDeadline(ctx, run.measureStart.Add(cfg.duration))
+	}
+	defer stopProducers()
+
+	if !cfg.jsonOutput {
+		fmt.Fprintf(c.ErrOrStderr(), "BENCH %s: %d producer(s), %d consumer(s)\n", cfg.destination, cfg.producers, cfg.consumers)
+	}
+	var producers errgroup.Group
+	for _, send := range senders {
+		limiter := newRateLimiter(cfg.rate / float64(cfg.producers))
+		producers.Go(func() error {
+			run.produce(produceCtx, send, limiter)
+			return nil
+		})
+	}
+	_ = producers.Wait()
+	produceEnd := time.Now()
+
+	// Let consumers catch up with in-flight messages, giving up once nothing
+	// has arrived for --drain-timeout.
+	if len(receivers) > 0 {
+		ticker := time.NewTicker(50 * time.Millisecond)
+		lastProgress, lastCount := time.Now(), run.received.Load()
+		for run.received.Load() < run.sent.Load() && ctx.Err() == nil {
+			<-ticker.C
+			if n := run.received.Load(); n != lastCount {
+				lastProgress, lastCount = time.Now(), n
+			} else if time.Since(lastProgress) >= cfg.drainTimeout {
+				break
+			}
+		}
+		ticker.Stop()
+	}
+	stopConsumers()
+	consumers.Wait()
+
+	report := run.report(produceEnd, len(receivers) > 0)
+	if cfg.jsonOutput {
+		return writeBenchJSON(c.OutOrStdout(), report)
+	}
+	return writeBenchText(c.OutOrStdout(), report)
+}
+
+// benchLatency summarises end-to-end latencies in milliseconds.
+type benchLatency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// benchReport is the result of one bench run, rendered as text or JSON.
+type benchReport struct {
+	Destination     string        `json:"destination"`
+	Model           string        `json:"model"` // "queue" or "topic"
+	Producers       int           `json:"producers"`
+	Consumers       int           `json:"consumers"`
+	DurationSeconds float64       `json:"durationSeconds"`
+	Sent            int64         `json:"sent"`
+	SendErrors      int64         `json:"sendErrors"`
+	BytesSent       int64         `json:"bytesSent"`
+	SendRate        float64       `json:"sendRate"` // messages per second
+	Received        int64         `json:"received"`
+	ReceiveErrors   int64         `json:"receiveErrors"`
+	ReceiveRate     float64       `json:"receiveRate"` // messages per second
+	Lost            int64         `json:"lost"`
+	ErrorRate       float64       `json:"errorRate"` // failed operations / attempted operations
+	LatencyMs       *benchLatency `json:"latencyMs,omitempty"`
+}
+
+func (r *benchRun) report(produceEnd time.Time, consumed bool) benchReport {
+	rep := benchReport{
+		Destination:   r.cfg.destination,
+		Model:         "queue",
+		Producers:     r.cfg.producers,
+		Consumers:     r.cfg.consumers,
+		Sent:          r.sent.Load(),
+		SendErrors:    r.sendErrs.Load(),
+		BytesSent:     r.bytesSent.Load(),
+		Received:      r.received.Load(),
+		ReceiveErrors: r.recvErrs.Load(),
+	}
+	if r.cfg.topic {
+		rep.Model = "topic"
+	}
+
+	window := produceEnd.Sub(r.measureStart)
+	rep.DurationSeconds = math.Max(window.Seconds(), 0)
+	if last := r.lastSent.Load(); last > 0 {
+		rep.SendRate = perSecond(rep.Sent, time.Unix(0, last).Sub(r.measureStart))
+	}
+	if last := r.lastRecv.Load(); last > 0 {
+		rep.ReceiveRate = perSecond(rep.Received, time.Unix(0, last).Sub(r.measureStart))
+	}
+	if consumed && rep.Sent > rep.Received {
+		rep.Lost = rep.Sent - rep.Received
+	}
+	if attempts := rep.Sent + rep.SendErrors + rep.ReceiveErrors; attempts > 0 {
+		rep.ErrorRate = float64(rep.SendErrors+rep.ReceiveErrors) / float64(attempts)
+	}
+	rep.LatencyMs = summarizeLatencies(r.latencies)
+	return rep
+}
+
+func perSecond(n int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
+
+// summarizeLatencies computes min/mean/percentiles/max (nearest-rank) in
+// milliseconds. It returns nil when there are no samples.
+func summarizeLatencies(samples []time.Duration) *benchLatency {
+	if len(samples) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	rank := func(p float64) time.Duration {
+		i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		return sorted[max(i, 0)]
+	}
+	return &benchLatency{
+		Min:  ms(sorted[0]),
+		Mean: ms(sum / time.Duration(len(sorted))),
+		P50:  ms(rank(50)),
+		P95:  ms(rank(95)),
+		P99:  ms(rank(99)),
+		Max:  ms(sorted[len(sorted)-1]),
+	}
+}
+
+func writeBenchJSON(w io.Writer, rep benchReport) error {
+	data, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bench report: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func writeBenchText(w io.Writer, rep benchReport) error {
+	fmt.Fprintf(w, "--- %s %s bench ---\n", rep.Model, rep.Destination)
+	fmt.Fprintf(w, "duration:   %.1fs, %d producer(s), %d consumer(s)\n", rep.DurationSeconds, rep.Producers, rep.Consumers)
+	fmt.Fprintf(w, "produced:   %d msgs (%s), %.0f msg/s, %d error(s)\n", rep.Sent, humanBytes(rep.BytesSent), rep.SendRate, rep.SendErrors)
+	if rep.Consumers > 0 {
+		fmt.Fprintf(w, "consumed:   %d msgs, %.0f msg/s, %d error(s), %d lost\n", rep.Received, rep.ReceiveRate, rep.ReceiveErrors, rep.Lost)
+	}
+	fmt.Fprintf(w, "error rate: %.2f%%\n", rep.ErrorRate*100)
+	if l := rep.LatencyMs; l != nil {
+		_, err := fmt.Fprintf(w, "latency ms: min/avg/p50/p95/p99/max = %.2f/%.2f/%.2f/%.2f/%.2f/%.2f\n",
+			l.Min, l.Mean, l.P50, l.P95, l.P99, l.Max)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// chanQueue is a concurrency-safe in-memory queue shared by every adapter a
+// bench factory hands out, standing in for a real broker destination.
+type chanQueue struct{ ch chan *backends.Message }
+
+func (q *chanQueue) Send(_ context.Context, opts backends.SendOptions) error {
+	q.ch <- &backends.Message{Data: opts.Message, Properties: opts.Properties}
+	return nil
+}
+
+func (q *chanQueue) Receive(ctx context.Context, opts backends.ReceiveOptions) (*backends.Message, error) {
+	select {
+	case m := <-q.ch:
+		return m, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(backends.TimeoutDuration(opts.Timeout, opts.Wait)):
+		return nil, backends.ErrNoMessageAvailable
+	}
+}
+
+func (q *chanQueue) Close() error { return nil }
+
+func TestParseSizeDist(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	fixed, err := parseSizeDist("4k")
+	if err != nil || fixed.next(rng) != 4096 {
+		t.Fatalf("fixed size: %+v, %v", fixed, err)
+	}
+
+	rangeDist, err := parseSizeDist("10-20")
+	if err != nil {
+		t.Fatalf("range: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if n := rangeDist.next(rng); n < 10 || n > 20 {
+			t.Fatalf("range size %d outside 10-20", n)
+		}
+	}
+
+	mix, err := parseSizeDist("1:1,100:3")
+	if err != nil || mix.max != 100 {
+		t.Fatalf("mix: %+v, %v", mix, err)
+	}
+	for i := 0; i < 100; i++ {
+		if n := mix.next(rng); n != 1 && n != 100 {
+			t.Fatalf("mix drew %d, want 1 or 100", n)
+		}
+	}
+
+	for _, bad := range []string{"", "abc", "20-10", "1:0", "1:x"} {
+		if _, err := parseSizeDist(bad); err == nil {
+			t.Errorf("parseSizeDist(%q) should fail", bad)
+		}
+	}
+}
+
+func TestSummarizeLatencies(t *testing.T) {
+	if summarizeLatencies(nil) != nil {
+		t.Fatal("no samples should yield nil")
+	}
+	var samples []time.Duration
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	l := summarizeLatencies(samples)
+	if l.Min != 1 || l.P50 != 50 || l.P95 != 95 || l.P99 != 99 || l.Max != 100 || l.Mean != 50.5 {
+		t.Errorf("latency summary = %+v", l)
+	}
+}
+
+// chanTopic is chanQueue as a topic, recording the group of every subscribe.
+type chanTopic struct {
+	chanQueue
+	mu     sync.Mutex
+	groups map[string]bool
+}
+
+func (t *chanTopic) Publish(ctx context.Context, opts backends.PublishOptions) error {
+	return t.Send(ctx, backends.SendOptions{Message: opts.Message, Properties: opts.Properties})
+}
+
+func (t *chanTopic) Subscribe(ctx context.Context, opts backends.SubscribeOptions) (*backends.Message, error) {
+	t.mu.Lock()
+	t.groups[opts.GroupID] = true
+	t.mu.Unlock()
+	return t.Receive(ctx, backends.ReceiveOptions{Timeout: opts.Timeout, Wait: opts.Wait})
+}
+
+func TestBenchCommand_TopicGroup(t *testing.T) {
+	for _, tc := range []struct {
+		args  []string
+		group string
+	}{
+		{nil, ""}, // no durable group is left behind by default
+		{[]string{"-g", "bench-group"}, "bench-group"},
+	} {
+		tp := &chanTopic{chanQueue: chanQueue{ch: make(chan *backends.Message, 100)}, groups: map[string]bool{}}
+		factory := TopicAdapterFactory(func() (backends.TopicBackend, error) { return tp, nil })
+
+		cmd := NewBenchCommand(nil, factory)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetArgs(append([]string{"bench-t", "-n", "5"}, tc.args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.args, err)
+		}
+		if len(tp.groups) != 1 || !tp.groups[tc.group] {
+			t.Errorf("%v: subscribed with groups %v, want only %q", tc.args, tp.groups, tc.group)
+		}
+	}
+}
+
+func TestBenchCommand_CountJSON(t *testing.T) {
+	q := &chanQueue{ch: make(chan *backends.Message, 1000)}
+	factory := QueueAdapterFactory(func() (backends.QueueBackend, error) { return q, nil })
+
+	cmd := NewBenchCommand(factory, nil)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"bench-q", "-n", "50", "--producers", "2", "--consumers", "2", "--size", "16-64", "-J"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var rep benchReport
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("report is not JSON: %v (%q)", err, out.String())
+	}
+	if rep.Sent != 50 || rep.Received != 50 || rep.Lost != 0 {
+		t.Errorf("sent/received/lost = %d/%d/%d, want 50/50/0", rep.Sent, rep.Received, rep.Lost)
+	}
+	if rep.Model != "queue" || rep.ErrorRate != 0 || rep.LatencyMs == nil {
+		t.Errorf("unexpected report: %+v", rep)
+	}
+	if rep.BytesSent < 50*16 || rep.BytesSent > 50*64 {
+		t.Errorf("bytesSent = %d, want within the --size range", rep.BytesSent)
+	}
+}
+
+func TestBenchCommand_IgnoresForeignMessages(t *testing.T) {
+	q := &chanQueue{ch: make(chan *backends.Message, 100)}
+	q.ch <- &backends.Message{Data: []byte("leftover"), Properties: map[string]any{benchRunProp: "old-run", benchTimeProp: "1"}}
+	factory := QueueAdapterFactory(func() (backends.QueueBackend, error) { return q, nil })
+
+	cmd := NewBenchCommand(factory, nil)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"bench-q", "-n", "5"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "consumed:   5 msgs") {
+		t.Errorf("leftover message should not be counted:\n%s", out.String())
+	}
+}
+
+func TestBenchCommand_PayloadStamp(t *testing.T) {
+	q := &chanQueue{ch: make(chan *backends.Message, 100)}
+	factory := QueueAdapterFactory(func() (backends.QueueBackend, error) { return q, nil })
+
+	cmd := NewBenchCommand(factory, nil)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"bench-q", "-n", "10", "--stamp", "payload", "--size", "8", "-J"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rep benchReport
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("report is not JSON: %v (%q)", err, out.String())
+	}
+	if rep.Received != 10 || rep.LatencyMs == nil {
+		t.Errorf("payload-stamped messages not measured: %+v", rep)
+	}
+}
+
+func TestBenchStamp(t *testing.T) {
+	msg := &backends.Message{Data: []byte("xmc-bench:run1:42;xxxx")}
+	if run, at, ok := benchStamp(msg); !ok || run != "run1" || at.UnixNano() != 42 {
+		t.Errorf("payload stamp = %q, %v, %v", run, at.UnixNano(), ok)
+	}
+	msg = &backends.Message{Properties: map[string]any{benchRunProp: "run2", benchTimeProp: "7"}}
+	if run, at, ok := benchStamp(msg); !ok || run != "run2" || at.UnixNano() != 7 {
+		t.Errorf("property stamp = %q, %v, %v", run, at.UnixNano(), ok)
+	}
+	if _, _, ok := benchStamp(&backends.Message{Data: []byte("plain")}); ok {
+		t.Error("unstamped message should not parse")
+	}
+}
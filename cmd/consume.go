@@ -54,6 +54,10 @@ type consumeConfig struct {
 	stats      *streamStats
 	dataOut    io.Writer // message payload output; nil defaults to os.Stdout
 	metaOut    io.Writer // metadata/properties output; nil defaults to os.Stderr
+
+	// observe, when set, is handed each message instead of outputMessage, for
+	// commands that aggregate rather than print (stats-stream).
+	observe func(*backends.Message) error
 }
 
 func consumeMessages(ctx context.Context, receive messageReceiver, cfg consumeConfig) error {
@@ -106,7 +110,12 @@ func consumeMessages(ctx context.Context, receive messageReceiver, cfg consumeCo
 			continue
 		}
 
-		if err := outputMessage(message, cfg); err != nil {
+		if cfg.observe != nil {
+			err = cfg.observe(message)
+		} else {
+			err = outputMessage(message, cfg)
+		}
+		if err != nil {
 			return err
 		}
 		if cfg.stats != nil {
@@ -185,6 +194,22 @@ func displayMessage(dataOut, metaOut io.Writer, message *backends.Message, verbo
 	}
 
 	if verbosity >= backends.VerbosityNormal {
+		// Only multi-topic and wildcard subscriptions label the source topic.
+		if message.Topic != "" {
+			if _, err := fmt.Fprintf(metaOut, "Topic: %s\n", message.Topic); err != nil {
+				return err
+			}
+		}
+		if message.Tombstone {
+			if _, err := fmt.Fprintln(metaOut, "Tombstone: true"); err != nil {
+				return err
+			}
+		}
+		if message.Retained {
+			if _, err := fmt.Fprintln(metaOut, "Retained: true"); err != nil {
+				return err
+			}
+		}
 		if err := writeProperties(metaOut, message.Properties); err != nil {
 			return err
 		}
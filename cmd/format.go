@@ -16,11 +16,12 @@ import (
 // Field tokens (substituted from the message):
 //
 //	%s        message payload (data)
-//	%S        payload length in bytes
+//	%S        payload length in bytes, -1 for a tombstone (null value)
 //	%i        message ID
 //	%c        correlation ID
 //	%r        reply-to
 //	%y        content type
+//	%t        source topic (set on subscribe)
 //	%P        priority
 //	%u        persistent flag (true/false)
 //	%h        all application properties as sorted key=value pairs, comma-separated
@@ -80,7 +81,11 @@ func writeToken(b *strings.Builder, message *backends.Message, format string, i
 	case 's':
 		b.Write(message.Data)
 	case 'S':
-		b.WriteString(strconv.Itoa(len(message.Data)))
+		if message.Tombstone {
+			b.WriteString("-1")
+		} else {
+			b.WriteString(strconv.Itoa(len(message.Data)))
+		}
 	case 'i':
 		b.WriteString(message.MessageID)
 	case 'c':
@@ -89,6 +94,8 @@ func writeToken(b *strings.Builder, message *backends.Message, format string, i
 		b.WriteString(message.ReplyTo)
 	case 'y':
 		b.WriteString(message.ContentType)
+	case 't':
+		b.WriteString(message.Topic)
 	case 'P':
 		b.WriteString(strconv.Itoa(message.Priority))
 	case 'u':
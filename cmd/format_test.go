@@ -59,6 +59,13 @@ func TestFormatMessage_Tokens(t *testing.T) {
 	}
 }
 
+func TestFormatMessage_Tombstone(t *testing.T) {
+	msg := &backends.Message{Key: "k1", Tombstone: true}
+	if got := formatMessage(msg, "[%s] %S"); got != "[] -1" {
+		t.Errorf("formatMessage = %q, want %q", got, "[] -1")
+	}
+}
+
 func TestReceiveCommand_FormatOutput(t *testing.T) {
 	msg := &backends.Message{
 		Data:       []byte("payload"),
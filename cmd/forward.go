@@ -223,6 +223,8 @@ func doForward(cmd *cobra.Command, args []string, queueBackend backends.QueueBac
 				ContentType:   src.ContentType,
 				Priority:      src.Priority,
 				Persistent:    src.Persistent,
+				// A tombstone stays one unless -x gave it a payload.
+				Tombstone: src.Tombstone && len(body) == 0,
 			})
 		}
 	} else {
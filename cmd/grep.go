@@ -0,0 +1,435 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+	"github.com/spf13/cobra"
+)
+
+// NewGrepCommand creates the grep command: a non-destructive search through
+// one or more queues (or, on brokers whose topics retain messages, topics).
+// Each destination is read with a browse cursor — BrowseBackend for queues,
+// TopicBrowseBackend for topics — so nothing is consumed, acknowledged or
+// committed, and matching messages are printed with their position.
+func NewGrepCommand(queueFactory QueueAdapterFactory, topicFactory TopicAdapterFactory, resolver TargetResolver) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "grep <pattern> <queue...>",
+		Short: "Search queues for messages matching a pattern without consuming them",
+		Long: `Browses every given queue from head to tail and prints the messages whose
+payload or properties match <pattern>, a regular expression (or a plain
+substring with --fixed). Properties are matched as name=value, together with
+the key, message ID and correlation ID. With --path the pattern is matched
+against a value inside a JSON payload instead, e.g. --path order.id or
+--path '$.items[*].sku'.
+
+Each hit is reported with the destination and its 1-based position in the
+scan. Messages are browsed, never consumed.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(c *cobra.Command, args []string) error {
+			return doGrep(c, args, queueFactory, topicFactory, resolver)
+		},
+	}
+
+	if queueFactory != nil && topicFactory != nil {
+		cmd.Flags().Bool("topic", false, "Search topics instead of queues (brokers whose topics retain messages)")
+	}
+	cmd.Flags().BoolP("ignore-case", "i", false, "Match case-insensitively")
+	cmd.Flags().Bool("fixed", false, "Treat the pattern as a plain substring, not a regular expression")
+	cmd.Flags().String("path", "", "Match the value at this JSON path in the payload (e.g. \"order.id\", \"$.items[*].sku\")")
+	cmd.Flags().String("in", "all", "Where to search without --path: payload, properties or all")
+	cmd.Flags().Int("max", 0, "Stop after this many matches (0 = all)")
+	cmd.Flags().VarP(newDurationValue(time.Second, time.Second), "timeout", "t", "Time to wait for the next message before a queue counts as exhausted")
+	cmd.Flags().BoolP("quiet", "q", false, "Quiet about properties, show data only")
+	cmd.Flags().BoolP("json", "J", false, "Output matches as JSON")
+	cmd.Flags().StringP("format", "F", "", "Output format string, e.g. \"%m{position} %i\\n\" (overrides --json)")
+	cmd.Flags().Bool("ndjson", false, "Output one lossless JSON record per match (overrides --format/--json)")
+
+	return cmd
+}
+
+// grepConfig holds the parsed grep flags.
+type grepConfig struct {
+	topic     bool
+	matcher   *grepMatcher
+	max       int
+	timeout   float32
+	verbosity backends.Verbosity
+	output    consumeConfig
+}
+
+func doGrep(c *cobra.Command, args []string, queueFactory QueueAdapterFactory, topicFactory TopicAdapterFactory, resolver TargetResolver) error {
+	cfg, err := parseGrepFlags(c, args[0], queueFactory != nil, topicFactory != nil)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := interruptContext(c.Context())
+	defer stop()
+
+	browse, closer, err := openGrepBrowse(cfg, queueFactory, topicFactory)
+	if err != nil {
+		return err
+	}
+	defer closeAdapter(closer)
+
+	matches, scanned := 0, 0
+	for _, name := range args[1:] {
+		dest := name
+		if resolver != nil {
+			if dest, err = resolver(TargetSpec{IsTopic: cfg.topic, To: name}); err != nil {
+				return err
+			}
+		}
+		browser, err := browse(ctx, dest)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		m, s, err := grepBrowser(ctx, browser, name, cfg, cfg.max-matches)
+		browser.Close()
+		matches += m
+		scanned += s
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if ctx.Err() != nil || (cfg.max > 0 && matches >= cfg.max) {
+			break
+		}
+	}
+
+	if matches == 0 {
+		return fmt.Errorf("no matching messages (%d scanned)", scanned)
+	}
+	fmt.Fprintf(c.ErrOrStderr(), "%d match(es) in %d message(s) scanned\n", matches, scanned)
+	return nil
+}
+
+func parseGrepFlags(c *cobra.Command, pattern string, queueCapable, topicCapable bool) (grepConfig, error) {
+	cfg := grepConfig{topic: topicCapable && !queueCapable}
+	if queueCapable && topicCapable {
+		cfg.topic, _ = c.Flags().GetBool("topic")
+	}
+	ignoreCase, _ := c.Flags().GetBool("ignore-case")
+	fixed, _ := c.Flags().GetBool("fixed")
+	path, _ := c.Flags().GetString("path")
+	in, _ := c.Flags().GetString("in")
+	cfg.max, _ = c.Flags().GetInt("max")
+	cfg.timeout = float32(getDuration(c, "timeout").Seconds())
+	quiet, _ := c.Flags().GetBool("quiet")
+	jsonOutput, _ := c.Flags().GetBool("json")
+	format, _ := c.Flags().GetString("format")
+	ndjson, _ := c.Flags().GetBool("ndjson")
+
+	if cfg.max < 0 {
+		return cfg, fmt.Errorf("--max must not be negative")
+	}
+	if path != "" && c.Flags().Changed("in") {
+		return cfg, fmt.Errorf("--path and --in are mutually exclusive (--path always searches the payload)")
+	}
+
+	var err error
+	if cfg.matcher, err = newGrepMatcher(pattern, fixed, ignoreCase, path, in); err != nil {
+		return cfg, err
+	}
+
+	cfg.verbosity = commandVerbosity(quiet)
+	cfg.output = consumeConfig{
+		jsonOutput: jsonOutput,
+		verbosity:  cfg.verbosity,
+		format:     format,
+		ndjson:     ndjson,
+		dataOut:    c.OutOrStdout(),
+		metaOut:    c.ErrOrStderr(),
+	}
+	return cfg, nil
+}
+
+// grepBrowse opens a browse cursor on one destination.
+type grepBrowse func(ctx context.Context, dest string) (backends.Browser, error)
+
+// openGrepBrowse connects once and returns a function that opens a browse
+// cursor per destination, plus the adapter to close afterwards. Brokers
+// without a browse cursor are rejected: a plain non-acknowledging Receive
+// would keep returning the queue head.
+func openGrepBrowse(cfg grepConfig, queueFactory QueueAdapterFactory, topicFactory TopicAdapterFactory) (grepBrowse, interface{ Close() error }, error) {
+	if cfg.topic {
+		adapter, err := topicFactory()
+		if err != nil {
+			return nil, nil, err
+		}
+		tb, ok := adapter.(backends.TopicBrowseBackend)
+		if !ok {
+			closeAdapter(adapter)
+			return nil, nil, fmt.Errorf("this broker cannot browse topics non-destructively")
+		}
+		return func(ctx context.Context, dest string) (backends.Browser, error) {
+			b, err := tb.BrowseTopic(ctx, backends.SubscribeOptions{Topic: dest, Timeout: cfg.timeout, Verbosity: cfg.verbosity})
+			if errors.Is(err, backends.ErrBrowseUnsupported) {
+				return nil, fmt.Errorf("this broker cannot browse topics non-destructively")
+			}
+			return b, err
+		}, adapter, nil
+	}
+
+	if queueFactory == nil {
+		return nil, nil, fmt.Errorf("this broker does not support queue operations")
+	}
+	adapter, err := queueFactory()
+	if err != nil {
+		return nil, nil, err
+	}
+	bb, ok := adapter.(backends.BrowseBackend)
+	if !ok {
+		closeAdapter(adapter)
+		return nil, nil, fmt.Errorf("this broker cannot browse queues non-destructively")
+	}
+	return func(ctx context.Context, dest string) (backends.Browser, error) {
+		b, err := bb.Browse(ctx, backends.ReceiveOptions{Queue: dest, Timeout: cfg.timeout, Verbosity: cfg.verbosity})
+		if errors.Is(err, backends.ErrBrowseUnsupported) {
+			return nil, fmt.Errorf("this broker cannot browse queues non-destructively")
+		}
+		return b, err
+	}, adapter, nil
+}
+
+// grepBrowser scans one destination to its end and outputs every match. limit
+// caps the matches taken from it (<= 0 = no cap). It returns the number of
+// matches and of messages scanned.
+func grepBrowser(ctx context.Context, browser backends.Browser, dest string, cfg grepConfig, limit int) (int, int, error) {
+	if cfg.max <= 0 {
+		limit = 0
+	}
+	matches, scanned := 0, 0
+	for limit <= 0 || matches < limit {
+		if ctx.Err() != nil {
+			return matches, scanned, nil
+		}
+		msg, err := browser.Next(ctx)
+		switch {
+		case errors.Is(err, backends.ErrNoMessageAvailable), msg == nil && err == nil:
+			return matches, scanned, nil
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			return matches, scanned, nil
+		case err != nil:
+			return matches, scanned, err
+		}
+		scanned++
+		if !cfg.matcher.match(msg) {
+			continue
+		}
+		matches++
+		if err := outputGrepHit(msg, dest, scanned, cfg.output); err != nil {
+			return matches, scanned, err
+		}
+	}
+	return matches, scanned, nil
+}
+
+// grepHit is the JSON form of a match: the message record plus where it was
+// found. Extra fields are ignored by send/publish --ndjson, so matches can be
+// re-imported directly.
+type grepHit struct {
+	Destination string `json:"destination"`
+	Position    int    `json:"position"`
+	messageRecord
+}
+
+// outputGrepHit renders one match. -F can reference the location as
+// %m{destination} and %m{position}; text output prints it on a header line
+// to the metadata writer.
+func outputGrepHit(msg *backends.Message, dest string, position int, cfg consumeConfig) error {
+	w := cfg.dataWriter()
+	switch {
+	case cfg.ndjson, cfg.jsonOutput && cfg.format == "":
+		hit := grepHit{Destination: dest, Position: position}
+		if cfg.ndjson {
+			hit.messageRecord = newMessageRecord(msg, true)
+		} else {
+			hit.messageRecord = recordForDisplay(msg, true, cfg.verbosity >= backends.VerbosityVerbose)
+		}
+		data, err := json.Marshal(hit)
+		if err != nil {
+			return fmt.Errorf("failed to marshal match: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	case cfg.format != "":
+		located := *msg
+		located.InternalMetadata = maps.Clone(msg.InternalMetadata)
+		if located.InternalMetadata == nil {
+			located.InternalMetadata = map[string]any{}
+		}
+		located.InternalMetadata["destination"] = dest
+		located.InternalMetadata["position"] = position
+		return displayMessageFormat(w, &located, cfg.format)
+	}
+
+	header := fmt.Sprintf("%s #%d", dest, position)
+	if msg.MessageID != "" {
+		header += " id=" + msg.MessageID
+	}
+	fmt.Fprintln(cfg.metaWriter(), header)
+	return displayMessage(w, cfg.metaWriter(), msg, cfg.verbosity)
+}
+
+// grepMatcher decides whether a message matches the grep pattern.
+type grepMatcher struct {
+	re      *regexp.Regexp
+	payload bool
+	props   bool
+	path    []string // JSON path segments; non-nil means match inside the payload
+}
+
+func newGrepMatcher(pattern string, fixed, ignoreCase bool, path, in string) (*grepMatcher, error) {
+	expr := pattern
+	if fixed {
+		expr = regexp.QuoteMeta(pattern)
+	}
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	m := &grepMatcher{re: re}
+
+	if path != "" {
+		if m.path, err = parseJSONPath(path); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+	switch in {
+	case "payload":
+		m.payload = true
+	case "properties":
+		m.props = true
+	case "all":
+		m.payload, m.props = true, true
+	default:
+		return nil, fmt.Errorf("invalid --in %q (expected payload, properties or all)", in)
+	}
+	return m, nil
+}
+
+func (m *grepMatcher) match(msg *backends.Message) bool {
+	if m.path != nil {
+		var doc any
+		dec := json.NewDecoder(bytes.NewReader(msg.Data))
+		dec.UseNumber()
+		if dec.Decode(&doc) != nil {
+			return false
+		}
+		return slices.ContainsFunc(jsonPathValues(doc, m.path), m.re.MatchString)
+	}
+	if m.payload && m.re.Match(msg.Data) {
+		return true
+	}
+	if m.props {
+		fields := map[string]string{
+			"key":           msg.Key,
+			"messageId":     msg.MessageID,
+			"correlationId": msg.CorrelationID,
+		}
+		for k, v := range msg.Properties {
+			fields[k] = fmt.Sprint(v)
+		}
+		for k, v := range fields {
+			if v != "" && m.re.MatchString(k+"="+v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseJSONPath splits a simple JSON path into segments. It accepts dotted
+// names with an optional leading "$", bracketed indexes and "*" wildcards:
+// "order.id", "$.items[0].sku", "items[*].sku" or "items.*.sku".
+func parseJSONPath(path string) ([]string, error) {
+	p := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	invalid := fmt.Errorf("invalid --path %q", path)
+	var segs []string
+	for part := range strings.SplitSeq(p, ".") {
+		name, rest, indexed := strings.Cut(part, "[")
+		if name == "" && !indexed {
+			return nil, invalid
+		}
+		if name != "" {
+			segs = append(segs, name)
+		}
+		for indexed {
+			var idx string
+			var closed bool
+			if idx, rest, closed = strings.Cut(rest, "]"); !closed || idx == "" {
+				return nil, invalid
+			}
+			segs = append(segs, strings.Trim(idx, `'"`))
+			if rest == "" {
+				break
+			}
+			if !strings.HasPrefix(rest, "[") {
+				return nil, invalid
+			}
+			rest = rest[1:]
+		}
+	}
+	if len(segs) == 0 {
+		return nil, invalid
+	}
+	return segs, nil
+}
+
+// jsonPathValues returns the string form of every value at path in doc. A "*"
+// segment fans out over all elements of an array or all values of an object.
+func jsonPathValues(doc any, path []string) []string {
+	if len(path) == 0 {
+		switch v := doc.(type) {
+		case string:
+			return []string{v}
+		case json.Number:
+			return []string{v.String()}
+		case nil:
+			return []string{"null"}
+		default:
+			data, _ := json.Marshal(v)
+			return []string{string(data)}
+		}
+	}
+
+	seg, rest := path[0], path[1:]
+	var children []any
+	switch v := doc.(type) {
+	case map[string]any:
+		if seg == "*" {
+			for _, k := range slices.Sorted(maps.Keys(v)) {
+				children = append(children, v[k])
+			}
+		} else if child, ok := v[seg]; ok {
+			children = append(children, child)
+		}
+	case []any:
+		if seg == "*" {
+			children = v
+		} else if i, err := strconv.Atoi(seg); err == nil && i >= 0 && i < len(v) {
+			children = append(children, v[i])
+		}
+	}
+
+	var out []string
+	for _, child := range children {
+		out = append(out, jsonPathValues(child, rest)...)
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// browseQueue is a queue backend with a browse cursor over fixed contents,
+// keyed by queue name.
+type browseQueue struct {
+	mockQueueBackend
+	queues map[string][]*backends.Message
+}
+
+func (q *browseQueue) Browse(_ context.Context, opts backends.ReceiveOptions) (backends.Browser, error) {
+	return &sliceBrowser{msgs: q.queues[opts.Queue]}, nil
+}
+
+type sliceBrowser struct{ msgs []*backends.Message }
+
+func (b *sliceBrowser) Next(context.Context) (*backends.Message, error) {
+	if len(b.msgs) == 0 {
+		return nil, backends.ErrNoMessageAvailable
+	}
+	m := b.msgs[0]
+	b.msgs = b.msgs[1:]
+	return m, nil
+}
+
+func (b *sliceBrowser) Close() error { return nil }
+
+func newGrepTestQueue() *browseQueue {
+	return &browseQueue{queues: map[string][]*backends.Message{
+		"dlq": {
+			{Data: []byte(`{"order":{"id":"A-1"},"items":[{"sku":"x"}]}`), MessageID: "m1"},
+			{Data: []byte(`{"order":{"id":"B-2"},"items":[{"sku":"y"},{"sku":"z"}]}`), MessageID: "m2"},
+			{Data: []byte(`plain text`), MessageID: "m3", Properties: map[string]any{"tenant": "acme"}},
+		},
+		"other": {
+			{Data: []byte(`B-2 again`), MessageID: "m4"},
+		},
+	}}
+}
+
+func runGrep(t *testing.T, q backends.QueueBackend, args ...string) (string, string, error) {
+	t.Helper()
+	cmd := NewGrepCommand(func() (backends.QueueBackend, error) { return q, nil }, nil, nil)
+	var out, errOut bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), errOut.String(), err
+}
+
+func TestGrepCommand_RegexAcrossQueues(t *testing.T) {
+	out, errOut, err := runGrep(t, newGrepTestQueue(), "-F", "%m{destination}#%m{position} %i\n", "B-\\d", "dlq", "other")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "dlq#2 m2\nother#1 m4\n" {
+		t.Errorf("output = %q", out)
+	}
+	if !strings.Contains(errOut, "2 match(es) in 4 message(s) scanned") {
+		t.Errorf("summary = %q", errOut)
+	}
+}
+
+func TestGrepCommand_PropertiesAndMax(t *testing.T) {
+	out, _, err := runGrep(t, newGrepTestQueue(), "--in", "properties", "-F", "%i\n", "tenant=acme", "dlq")
+	if err != nil || out != "m3\n" {
+		t.Errorf("properties match = %q, %v", out, err)
+	}
+	out, _, err = runGrep(t, newGrepTestQueue(), "--max", "1", "-F", "%i\n", "-i", "--fixed", "SKU", "dlq", "other")
+	if err != nil || out != "m1\n" {
+		t.Errorf("--max 1 = %q, %v", out, err)
+	}
+}
+
+func TestGrepCommand_JSONPath(t *testing.T) {
+	out, _, err := runGrep(t, newGrepTestQueue(), "--path", "$.items[*].sku", "--ndjson", "^z$", "dlq")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var hit grepHit
+	if err := json.Unmarshal([]byte(out), &hit); err != nil {
+		t.Fatalf("not JSON: %v (%q)", err, out)
+	}
+	if hit.Destination != "dlq" || hit.Position != 2 || hit.MessageID != "m2" {
+		t.Errorf("hit = %+v", hit)
+	}
+}
+
+func TestGrepCommand_NoMatch(t *testing.T) {
+	_, _, err := runGrep(t, newGrepTestQueue(), "nothing-like-this", "dlq")
+	if err == nil || !strings.Contains(err.Error(), "3 scanned") {
+		t.Errorf("error = %v, want no-match error", err)
+	}
+}
+
+func TestGrepCommand_RequiresBrowse(t *testing.T) {
+	_, _, err := runGrep(t, &mockQueueBackend{}, "x", "q")
+	if err == nil || !strings.Contains(err.Error(), "browse") {
+		t.Errorf("error = %v, want browse-unsupported error", err)
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	for in, want := range map[string]string{
+		"order.id":       "order|id",
+		"$.items[0].sku": "items|0|sku",
+		"items[*].sku":   "items|*|sku",
+		"$['a'].b":       "a|b",
+		"matrix[1][2]":   "matrix|1|2",
+		"$.items.*.sku":  "items|*|sku",
+	} {
+		got, err := parseJSONPath(in)
+		if err != nil || strings.Join(got, "|") != want {
+			t.Errorf("parseJSONPath(%q) = %v, %v; want %s", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "$", "a[", "a[]", "a[0]b", "a..b"} {
+		if _, err := parseJSONPath(bad); err == nil {
+			t.Errorf("parseJSONPath(%q) should fail", bad)
+		}
+	}
+}
@@ -1,8 +1,16 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/makibytes/xmc/broker/backends"
 	"github.com/spf13/cobra"
@@ -67,6 +75,7 @@ type ObjectType struct {
 //	"Streams" → CreateQueue / DeleteQueue (NATS)
 //	"Consumer Groups" → nil / DeleteConsumerGroup (Kafka; groups are created
 //	  implicitly by the first consumer to join, so there is no create action)
+//	"Tenants" → CreateTenant / DeleteTenant (Pulsar)
 func (s *ManageSpec) SidebarActions(label string) (create, delete *ManageAction) {
 	switch label {
 	case "Queues":
@@ -81,10 +90,31 @@ func (s *ManageSpec) SidebarActions(label string) (create, delete *ManageAction)
 		return s.CreateQueue, s.DeleteQueue
 	case "Consumer Groups":
 		return nil, s.DeleteConsumerGroup
+	case "Tenants":
+		return s.CreateTenant, s.DeleteTenant
 	}
 	return nil, nil
 }
 
+// writeChildNodes prints the children of a listed node, one "└" row each,
+// and their own children indented one level further (e.g. Pulsar's
+// tenant → namespace → topic tree).
+func writeChildNodes(w io.Writer, indent string, children []backends.ObjectNode) {
+	for _, child := range children {
+		childMetrics := formatMetrics(child.Metrics)
+		kind := child.Kind
+		if kind != "" {
+			kind += " "
+		}
+		if childMetrics != "" {
+			fmt.Fprintf(w, "%s└ %s%s  %s\n", indent, kind, child.Name, childMetrics)
+		} else {
+			fmt.Fprintf(w, "%s└ %s%s\n", indent, kind, child.Name)
+		}
+		writeChildNodes(w, indent+"  ", child.Children)
+	}
+}
+
 // ManageSpec describes the management capabilities a broker exposes. Each
 // closure is optional — nil means the broker does not support that operation,
 // and the corresponding subcommand is omitted.
@@ -106,30 +136,98 @@ type ManageSpec struct {
 	PurgeSubscription func(topic, subscription string) (int64, error)
 	// Stats returns detailed statistics for a single queue.
 	Stats func(queue string) (*backends.QueueStats, error)
+	// StatsFlags registers broker-specific flags on "stats <queue>" (e.g.
+	// IBM MQ's --reset); Stats reads them through variables it closes over.
+	StatsFlags func(c *cobra.Command)
+	// TopicStats returns a topic's message rates, storage size and
+	// per-subscription backlog (Pulsar). It provides "stats <topic>", so a
+	// broker sets Stats or TopicStats, not both.
+	TopicStats func(topic string) (*backends.TopicStats, error)
+	// BrokerStats returns the broker's own status and counters (MQTT $SYS).
+	// It provides "stats" without arguments, so a broker sets only one of
+	// Stats, TopicStats and BrokerStats.
+	BrokerStats func() (*backends.BrokerStats, error)
 	// SetupFlags registers additional persistent flags on the manage command
-	// (e.g. Pulsar's --admin-port).
+	// (e.g. an admin API port).
 	SetupFlags func(cmd *cobra.Command)
 
 	// Resource lifecycle operations — all optional.
-	CreateQueue    *ManageAction
-	DeleteQueue    *ManageAction
-	UpdateQueue    *ManageAction // change settings of an existing queue (e.g. Artemis filter)
-	EnableQueue    *ManageAction // enable message dispatch on a queue (Artemis)
-	DisableQueue   *ManageAction // disable message dispatch on a queue (Artemis)
-	CreateTopic    *ManageAction
-	DeleteTopic    *ManageAction
-	UpdateTopic    *ManageAction // change settings of an existing topic (e.g. Kafka partitions/config)
-	CreateAddress  *ManageAction // Artemis-only: bare address (routing namespace)
-	DeleteAddress  *ManageAction // Artemis-only: bare address
-	CreateExchange *ManageAction
-	DeleteExchange *ManageAction
-	BindQueue      *BindAction
-	UnbindQueue    *BindAction
+	CreateQueue     *ManageAction
+	DeleteQueue     *ManageAction
+	UpdateQueue     *ManageAction // change settings of an existing queue (e.g. Artemis filter)
+	EnableQueue     *ManageAction // enable message dispatch on a queue (Artemis)
+	DisableQueue    *ManageAction // disable message dispatch on a queue (Artemis)
+	CreateTopic     *ManageAction
+	DeleteTopic     *ManageAction
+	UpdateTopic     *ManageAction // change settings of an existing topic (e.g. Kafka partitions/config)
+	CreateAddress   *ManageAction // Artemis-only: bare address (routing namespace)
+	DeleteAddress   *ManageAction // Artemis-only: bare address
+	CreateExchange  *ManageAction
+	DeleteExchange  *ManageAction
+	CreateTenant    *ManageAction // Pulsar
+	DeleteTenant    *ManageAction // Pulsar
+	CreateNamespace *ManageAction // Pulsar namespace (tenant/namespace)
+	DeleteNamespace *ManageAction // Pulsar namespace (tenant/namespace)
+	BindQueue       *BindAction
+	UnbindQueue     *BindAction
 
 	// DeleteConsumerGroup deletes an inactive consumer group (Kafka). There is
 	// no matching create action: Kafka creates groups implicitly the moment a
 	// consumer with that group.id first joins, and has no API to pre-create one.
 	DeleteConsumerGroup *ManageAction
+	// DescribeGroup reports a consumer group's members and its committed
+	// offset, log-end offset and lag on every partition (Kafka).
+	DescribeGroup func(group string) (*backends.GroupDescription, error)
+	// ResetOffsets moves a consumer group's committed offsets on topic — on
+	// all of its partitions, or only those listed — and returns the changes.
+	// With dryRun it only computes them. Kafka only accepts the commit while
+	// the group has no active members.
+	ResetOffsets func(group, topic string, partitions []int, to backends.OffsetTarget, dryRun bool) ([]backends.OffsetChange, error)
+
+	// ListACLs returns the ACLs matching filter; empty filter fields match
+	// anything (Kafka).
+	ListACLs func(filter backends.ACL) ([]backends.ACL, error)
+	// CreateACL adds one ACL.
+	CreateACL func(acl backends.ACL) error
+	// DeleteACLs removes every ACL matching filter and returns the removed ones.
+	DeleteACLs func(filter backends.ACL) ([]backends.ACL, error)
+	// DescribeCluster reports the cluster's brokers and its controller.
+	DescribeCluster func() (*backends.ClusterDescription, error)
+	// DescribeConfigs returns the config entries of a resource; resourceType
+	// is "broker" (name: broker ID) or "topic".
+	DescribeConfigs func(resourceType, name string) ([]backends.ConfigEntry, error)
+	// AlterConfigs sets config entries of a resource and reverts the ones in
+	// remove to their defaults, leaving all others unchanged.
+	AlterConfigs func(resourceType, name string, set map[string]string, remove []string) error
+
+	// ListSubscriptions returns a topic's subscriptions with their backlog
+	// (Pulsar).
+	ListSubscriptions func(topic string) ([]backends.SubscriptionStats, error)
+	// CreateSubscription creates a durable subscription on topic, starting at
+	// the earliest retained message or, by default, at the end of the topic.
+	CreateSubscription func(topic, subscription string, earliest bool) error
+	// DeleteSubscription deletes a subscription and its backlog.
+	DeleteSubscription func(topic, subscription string) error
+	// ExpireMessages acknowledges the messages older than olderThan on one
+	// subscription, or on all of them when subscription is "".
+	ExpireMessages func(topic, subscription string, olderThan time.Duration) error
+	// Seek moves a subscription's cursor to a message ID, a timestamp,
+	// earliest or latest.
+	Seek func(topic, subscription, to string) error
+	// GetSchema returns the schema registered for a topic, or nil when it has
+	// none (Pulsar).
+	GetSchema func(topic string) (*backends.TopicSchema, error)
+	// UploadSchema registers a new schema version for a topic.
+	UploadSchema func(topic string, schema backends.TopicSchema) error
+	// DeleteSchema deletes a topic's schema.
+	DeleteSchema func(topic string) error
+
+	// GetNamespacePolicies returns a namespace's retention, TTL, backlog
+	// quota and deduplication policies (Pulsar).
+	GetNamespacePolicies func(namespace string) (*backends.NamespacePolicies, error)
+	// SetNamespacePolicies sets the non-nil policies of set and removes the
+	// ones named in remove (retention, ttl, backlog-quota, deduplication).
+	SetNamespacePolicies func(namespace string, set backends.NamespacePolicies, remove []string) error
 }
 
 // NewManageCommand builds a standardised "manage" command tree from spec,
@@ -169,18 +267,7 @@ func NewManageCommand(spec ManageSpec) *cobra.Command {
 						} else {
 							fmt.Fprintf(w, "%s%s\n", prefix, n.Name)
 						}
-						for _, child := range n.Children {
-							childMetrics := formatMetrics(child.Metrics)
-							kind := child.Kind
-							if kind != "" {
-								kind += " "
-							}
-							if childMetrics != "" {
-								fmt.Fprintf(w, "%s  └ %s%s  %s\n", prefix, kind, child.Name, childMetrics)
-							} else {
-								fmt.Fprintf(w, "%s  └ %s%s\n", prefix, kind, child.Name)
-							}
-						}
+						writeChildNodes(w, prefix+"  ", n.Children)
 					}
 				}
 				return nil
@@ -235,7 +322,7 @@ func NewManageCommand(spec ManageSpec) *cobra.Command {
 	}
 
 	if spec.Stats != nil {
-		mgmtCmd.AddCommand(&cobra.Command{
+		statsCmd := &cobra.Command{
 			Use:   "stats <queue>",
 			Short: "Show queue statistics",
 			Args:  cobra.ExactArgs(1),
@@ -256,6 +343,42 @@ func NewManageCommand(spec ManageSpec) *cobra.Command {
 				}
 				return nil
 			},
+		}
+		if spec.StatsFlags != nil {
+			spec.StatsFlags(statsCmd)
+		}
+		mgmtCmd.AddCommand(statsCmd)
+	}
+
+	if spec.TopicStats != nil {
+		mgmtCmd.AddCommand(&cobra.Command{
+			Use:   "stats <topic>",
+			Short: "Show topic statistics and subscription backlog",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(c *cobra.Command, args []string) error {
+				stats, err := spec.TopicStats(args[0])
+				if err != nil {
+					return err
+				}
+				writeTopicStats(c.OutOrStdout(), stats)
+				return nil
+			},
+		})
+	}
+
+	if spec.BrokerStats != nil {
+		mgmtCmd.AddCommand(&cobra.Command{
+			Use:   "stats",
+			Short: "Show broker statistics",
+			Args:  cobra.NoArgs,
+			RunE: func(c *cobra.Command, args []string) error {
+				stats, err := spec.BrokerStats()
+				if err != nil {
+					return err
+				}
+				writeBrokerStats(c.OutOrStdout(), stats)
+				return nil
+			},
 		})
 	}
 
@@ -271,13 +394,557 @@ func NewManageCommand(spec ManageSpec) *cobra.Command {
 	addManageAction(mgmtCmd, "delete-address", "Delete an address", "<address>", "Deleted address %s\n", spec.DeleteAddress)
 	addManageAction(mgmtCmd, "create-exchange", "Create an exchange", "<exchange>", "Created exchange %s\n", spec.CreateExchange)
 	addManageAction(mgmtCmd, "delete-exchange", "Delete an exchange", "<exchange>", "Deleted exchange %s\n", spec.DeleteExchange)
+	addManageAction(mgmtCmd, "create-tenant", "Create a tenant", "<tenant>", "Created tenant %s\n", spec.CreateTenant)
+	addManageAction(mgmtCmd, "delete-tenant", "Delete a tenant", "<tenant>", "Deleted tenant %s\n", spec.DeleteTenant)
+	addManageAction(mgmtCmd, "create-namespace", "Create a namespace", "<namespace>", "Created namespace %s\n", spec.CreateNamespace)
+	addManageAction(mgmtCmd, "delete-namespace", "Delete a namespace", "<namespace>", "Deleted namespace %s\n", spec.DeleteNamespace)
+	if spec.GetNamespacePolicies != nil {
+		mgmtCmd.AddCommand(&cobra.Command{
+			Use:   "get-policies <namespace>",
+			Short: "Show a namespace's retention, TTL, backlog quota and deduplication",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(c *cobra.Command, args []string) error {
+				policies, err := spec.GetNamespacePolicies(args[0])
+				if err != nil {
+					return err
+				}
+				writeNamespacePolicies(c.OutOrStdout(), args[0], policies)
+				return nil
+			},
+		})
+	}
+	if spec.SetNamespacePolicies != nil {
+		mgmtCmd.AddCommand(newSetPoliciesCommand(spec.SetNamespacePolicies))
+	}
 	addBindAction(mgmtCmd, "bind-queue", "Bind a queue to an %s", "Bound queue %%s to %s %%s\n", spec.BindQueue)
 	addBindAction(mgmtCmd, "unbind-queue", "Unbind a queue from an %s", "Unbound queue %%s from %s %%s\n", spec.UnbindQueue)
 	addManageAction(mgmtCmd, "delete-consumer-group", "Delete a consumer group", "<group>", "Deleted consumer group %s\n", spec.DeleteConsumerGroup)
 
+	if spec.ListSubscriptions != nil {
+		mgmtCmd.AddCommand(&cobra.Command{
+			Use:   "list-subscriptions <topic>",
+			Short: "List a topic's subscriptions and their backlog",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(c *cobra.Command, args []string) error {
+				subs, err := spec.ListSubscriptions(args[0])
+				if err != nil {
+					return err
+				}
+				if len(subs) == 0 {
+					fmt.Fprintf(c.OutOrStdout(), "No subscriptions on %s\n", args[0])
+					return nil
+				}
+				writeSubscriptions(c.OutOrStdout(), subs)
+				return nil
+			},
+		})
+	}
+	if spec.CreateSubscription != nil {
+		mgmtCmd.AddCommand(newCreateSubscriptionCommand(spec.CreateSubscription))
+	}
+	if spec.DeleteSubscription != nil {
+		mgmtCmd.AddCommand(&cobra.Command{
+			Use:   "delete-subscription <topic> <subscription>",
+			Short: "Delete a subscription and its backlog",
+			Args:  cobra.ExactArgs(2),
+			RunE: func(c *cobra.Command, args []string) error {
+				if err := spec.DeleteSubscription(args[0], args[1]); err != nil {
+					return err
+				}
+				fmt.Fprintf(c.OutOrStdout(), "Deleted subscription %s on topic %s\n", args[1], args[0])
+				return nil
+			},
+		})
+	}
+	if spec.ExpireMessages != nil {
+		mgmtCmd.AddCommand(newExpireMessagesCommand(spec.ExpireMessages))
+	}
+	if spec.Seek != nil {
+		seekCmd := &cobra.Command{
+			Use:   "seek <topic> <subscription>",
+			Short: "Move a subscription to a message ID, timestamp, earliest or latest",
+			Args:  cobra.ExactArgs(2),
+			RunE: func(c *cobra.Command, args []string) error {
+				to, _ := c.Flags().GetString("to")
+				if err := spec.Seek(args[0], args[1], to); err != nil {
+					return err
+				}
+				fmt.Fprintf(c.OutOrStdout(), "Moved subscription %s on topic %s to %s\n", args[1], args[0], to)
+				return nil
+			},
+		}
+		seekCmd.Flags().String("to", "", "Target: a message ID (ledger:entry:partition), an RFC 3339 timestamp, earliest or latest")
+		seekCmd.MarkFlagRequired("to") //nolint:errcheck
+		mgmtCmd.AddCommand(seekCmd)
+	}
+	if spec.GetSchema != nil {
+		mgmtCmd.AddCommand(&cobra.Command{
+			Use:   "get-schema <topic>",
+			Short: "Show the schema registered for a topic",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(c *cobra.Command, args []string) error {
+				schema, err := spec.GetSchema(args[0])
+				if err != nil {
+					return err
+				}
+				if schema == nil {
+					fmt.Fprintf(c.OutOrStdout(), "No schema on %s\n", args[0])
+					return nil
+				}
+				writeTopicSchema(c.OutOrStdout(), args[0], schema)
+				return nil
+			},
+		})
+	}
+	if spec.UploadSchema != nil {
+		mgmtCmd.AddCommand(newUploadSchemaCommand(spec.UploadSchema))
+	}
+	if spec.DeleteSchema != nil {
+		mgmtCmd.AddCommand(&cobra.Command{
+			Use:   "delete-schema <topic>",
+			Short: "Delete a topic's schema",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(c *cobra.Command, args []string) error {
+				if err := spec.DeleteSchema(args[0]); err != nil {
+					return err
+				}
+				fmt.Fprintf(c.OutOrStdout(), "Deleted schema of topic %s\n", args[0])
+				return nil
+			},
+		})
+	}
+
+	if spec.DescribeGroup != nil {
+		mgmtCmd.AddCommand(&cobra.Command{
+			Use:   "describe-group <group>",
+			Short: "Show a consumer group's members, offsets and lag",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(c *cobra.Command, args []string) error {
+				desc, err := spec.DescribeGroup(args[0])
+				if err != nil {
+					return err
+				}
+				writeGroupDescription(c.OutOrStdout(), desc)
+				return nil
+			},
+		})
+	}
+	if spec.ResetOffsets != nil {
+		mgmtCmd.AddCommand(newResetOffsetsCommand(spec.ResetOffsets))
+	}
+
+	if spec.ListACLs != nil {
+		var filter backends.ACL
+		c := &cobra.Command{
+			Use:   "list-acls",
+			Short: "List ACLs, optionally filtered",
+			Args:  cobra.NoArgs,
+			RunE: func(c *cobra.Command, args []string) error {
+				acls, err := spec.ListACLs(filter)
+				if err != nil {
+					return err
+				}
+				if len(acls) == 0 {
+					fmt.Fprintln(c.OutOrStdout(), "No ACLs found")
+					return nil
+				}
+				writeACLs(c.OutOrStdout(), acls)
+				return nil
+			},
+		}
+		addACLFlags(c, &filter, true)
+		mgmtCmd.AddCommand(c)
+	}
+	if spec.CreateACL != nil {
+		var acl backends.ACL
+		c := &cobra.Command{
+			Use:   "create-acl",
+			Short: "Create an ACL",
+			Example: `  create-acl --resource-type topic --resource-name orders --principal User:alice --operation read
+  create-acl --resource-type group --resource-name app- --pattern-type prefixed --principal User:alice --operation read`,
+			Args: cobra.NoArgs,
+			RunE: func(c *cobra.Command, args []string) error {
+				if err := spec.CreateACL(acl); err != nil {
+					return err
+				}
+				fmt.Fprintf(c.OutOrStdout(), "Created ACL: %s\n", formatACL(acl))
+				return nil
+			},
+		}
+		addACLFlags(c, &acl, false)
+		mgmtCmd.AddCommand(c)
+	}
+	if spec.DeleteACLs != nil {
+		var filter backends.ACL
+		c := &cobra.Command{
+			Use:   "delete-acl",
+			Short: "Delete the ACLs matching the filter flags",
+			Args:  cobra.NoArgs,
+			RunE: func(c *cobra.Command, args []string) error {
+				if filter == (backends.ACL{}) {
+					return fmt.Errorf("no filter given; pass at least one of the filter flags (e.g. --principal)")
+				}
+				acls, err := spec.DeleteACLs(filter)
+				if err != nil {
+					return err
+				}
+				w := c.OutOrStdout()
+				if len(acls) > 0 {
+					writeACLs(w, acls)
+				}
+				fmt.Fprintf(w, "Deleted %d ACL(s)\n", len(acls))
+				return nil
+			},
+		}
+		addACLFlags(c, &filter, true)
+		mgmtCmd.AddCommand(c)
+	}
+
+	if spec.DescribeCluster != nil {
+		mgmtCmd.AddCommand(&cobra.Command{
+			Use:   "describe-cluster",
+			Short: "Show the cluster's brokers and controller",
+			Args:  cobra.NoArgs,
+			RunE: func(c *cobra.Command, args []string) error {
+				desc, err := spec.DescribeCluster()
+				if err != nil {
+					return err
+				}
+				writeClusterDescription(c.OutOrStdout(), desc)
+				return nil
+			},
+		})
+	}
+	if spec.DescribeConfigs != nil {
+		mgmtCmd.AddCommand(newDescribeConfigsCommand(spec.DescribeConfigs))
+	}
+	if spec.AlterConfigs != nil {
+		mgmtCmd.AddCommand(newAlterConfigsCommand(spec.AlterConfigs))
+	}
+
 	return mgmtCmd
 }
 
+// writeTopicStats prints a topic's rates and storage, then one row per
+// subscription with its type, backlog and consumers.
+func writeTopicStats(w io.Writer, stats *backends.TopicStats) {
+	var backlog int64
+	for _, sub := range stats.Subscriptions {
+		backlog += sub.Backlog
+	}
+	fmt.Fprintf(w, "Topic:      %s\n", stats.Name)
+	fmt.Fprintf(w, "Rate in:    %.1f msg/s, %.0f bytes/s\n", stats.MsgRateIn, stats.ThroughputIn)
+	fmt.Fprintf(w, "Rate out:   %.1f msg/s, %.0f bytes/s\n", stats.MsgRateOut, stats.ThroughputOut)
+	fmt.Fprintf(w, "Storage:    %d bytes\n", stats.StorageSize)
+	fmt.Fprintf(w, "Publishers: %d\n", stats.Publishers)
+	fmt.Fprintf(w, "Backlog:    %d\n", backlog)
+	if len(stats.Subscriptions) > 0 {
+		fmt.Fprintln(w)
+		writeSubscriptions(w, stats.Subscriptions)
+	}
+}
+
+// writeBrokerStats prints the reported broker status, skipping what the
+// broker does not publish.
+func writeBrokerStats(w io.Writer, stats *backends.BrokerStats) {
+	if stats.Version != "" {
+		fmt.Fprintf(w, "Version:           %s\n", stats.Version)
+	}
+	if stats.Uptime != "" {
+		fmt.Fprintf(w, "Uptime:            %s\n", stats.Uptime)
+	}
+	for _, row := range []struct {
+		label string
+		value int64
+	}{
+		{"Clients connected", stats.ClientsConnected},
+		{"Messages received", stats.MessagesReceived},
+		{"Messages sent", stats.MessagesSent},
+		{"Subscriptions", stats.Subscriptions},
+		{"Retained messages", stats.RetainedMessages},
+	} {
+		if row.value >= 0 {
+			fmt.Fprintf(w, "%-18s %d\n", row.label+":", row.value)
+		}
+	}
+}
+
+// writeSubscriptions prints one row per subscription.
+func writeSubscriptions(w io.Writer, subs []backends.SubscriptionStats) {
+	fmt.Fprintf(w, "%-30s %-10s %10s %9s %10s\n", "SUBSCRIPTION", "TYPE", "BACKLOG", "CONSUMERS", "RATE OUT")
+	for _, sub := range subs {
+		fmt.Fprintf(w, "%-30s %-10s %10d %9d %10.1f\n", sub.Name, sub.Type, sub.Backlog, sub.Consumers, sub.MsgRateOut)
+	}
+}
+
+// newCreateSubscriptionCommand builds "create-subscription <topic>
+// <subscription>"; --position picks where the subscription starts.
+func newCreateSubscriptionCommand(create func(topic, subscription string, earliest bool) error) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "create-subscription <topic> <subscription>",
+		Short: "Create a durable subscription on a topic",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(c *cobra.Command, args []string) error {
+			position, _ := c.Flags().GetString("position")
+			var earliest bool
+			switch strings.ToLower(position) {
+			case "earliest":
+				earliest = true
+			case "latest":
+			default:
+				return fmt.Errorf("invalid --position %q: use earliest or latest", position)
+			}
+			if err := create(args[0], args[1], earliest); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.OutOrStdout(), "Created subscription %s on topic %s\n", args[1], args[0])
+			return nil
+		},
+	}
+	c.Flags().String("position", "latest", "Where the subscription starts: earliest (all retained messages) or latest")
+	return c
+}
+
+// newExpireMessagesCommand builds "expire-messages <topic> [subscription]
+// --older-than <duration>"; without a subscription every subscription of the
+// topic is expired.
+func newExpireMessagesCommand(expire func(topic, subscription string, olderThan time.Duration) error) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "expire-messages <topic> [subscription]",
+		Short: "Acknowledge the messages older than --older-than",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(c *cobra.Command, args []string) error {
+			olderThan := getDuration(c, "older-than")
+			if olderThan <= 0 {
+				return fmt.Errorf("--older-than is required (e.g. 1h)")
+			}
+			var subscription string
+			if len(args) > 1 {
+				subscription = args[1]
+			}
+			if err := expire(args[0], subscription, olderThan); err != nil {
+				return err
+			}
+			if subscription == "" {
+				fmt.Fprintf(c.OutOrStdout(), "Expired messages older than %s on all subscriptions of %s\n", olderThan, args[0])
+			} else {
+				fmt.Fprintf(c.OutOrStdout(), "Expired messages older than %s on subscription %s of %s\n", olderThan, subscription, args[0])
+			}
+			return nil
+		},
+	}
+	c.Flags().Var(newDurationValue(0, time.Second), "older-than", "Age of the messages to expire (e.g. \"30m\", \"2h\")")
+	return c
+}
+
+// writeTopicSchema prints a schema's type and version followed by its
+// definition, indented when it is JSON.
+func writeTopicSchema(w io.Writer, topic string, schema *backends.TopicSchema) {
+	fmt.Fprintf(w, "Topic:   %s\n", topic)
+	fmt.Fprintf(w, "Type:    %s\n", schema.Type)
+	fmt.Fprintf(w, "Version: %d\n", schema.Version)
+	for _, k := range slices.Sorted(maps.Keys(schema.Properties)) {
+		fmt.Fprintf(w, "Property %s: %s\n", k, schema.Properties[k])
+	}
+	if schema.Definition == "" {
+		return
+	}
+	fmt.Fprintln(w)
+	var indented bytes.Buffer
+	if json.Indent(&indented, []byte(schema.Definition), "", "  ") == nil {
+		fmt.Fprintln(w, indented.String())
+	} else {
+		fmt.Fprintln(w, schema.Definition)
+	}
+}
+
+// newUploadSchemaCommand builds "upload-schema <topic> --type <type>
+// [--file <definition>]"; --file - reads the definition from stdin.
+func newUploadSchemaCommand(upload func(topic string, schema backends.TopicSchema) error) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "upload-schema <topic>",
+		Short: "Register a new schema version for a topic",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			schemaType, _ := c.Flags().GetString("type")
+			file, _ := c.Flags().GetString("file")
+			schema := backends.TopicSchema{Type: strings.ToUpper(schemaType)}
+			if file != "" {
+				var data []byte
+				var err error
+				if file == "-" {
+					data, err = io.ReadAll(c.InOrStdin())
+				} else {
+					data, err = os.ReadFile(file)
+				}
+				if err != nil {
+					return fmt.Errorf("reading schema definition: %w", err)
+				}
+				schema.Definition = string(data)
+			}
+			if err := upload(args[0], schema); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.OutOrStdout(), "Uploaded %s schema for topic %s\n", schema.Type, args[0])
+			return nil
+		},
+	}
+	c.Flags().String("type", "", "Schema type: avro, json, protobuf, string, ...")
+	c.Flags().String("file", "", "File with the schema definition (\"-\" for stdin)")
+	c.MarkFlagRequired("type") //nolint:errcheck
+	return c
+}
+
+// writeNamespacePolicies prints one line per policy; unset policies fall back
+// to the broker defaults.
+func writeNamespacePolicies(w io.Writer, namespace string, p *backends.NamespacePolicies) {
+	const unset = "(broker default)"
+	duration := func(d *time.Duration) string {
+		switch {
+		case d == nil:
+			return unset
+		case *d < 0:
+			return "infinite"
+		}
+		return d.String()
+	}
+	size := func(n *int64, unit string) string {
+		switch {
+		case n == nil:
+			return unset
+		case *n < 0:
+			return "unlimited"
+		}
+		return fmt.Sprintf("%d %s", *n, unit)
+	}
+	fmt.Fprintf(w, "Namespace:      %s\n", namespace)
+	fmt.Fprintf(w, "Retention time: %s\n", duration(p.RetentionTime))
+	fmt.Fprintf(w, "Retention size: %s\n", size(p.RetentionSizeMB, "MB"))
+	fmt.Fprintf(w, "Message TTL:    %s\n", duration(p.MessageTTL))
+	quota := size(p.BacklogQuotaLimit, "bytes")
+	if p.BacklogQuotaPolicy != "" {
+		quota += " (" + p.BacklogQuotaPolicy + ")"
+	}
+	fmt.Fprintf(w, "Backlog quota:  %s\n", quota)
+	dedup := unset
+	if p.Deduplication != nil {
+		dedup = strconv.FormatBool(*p.Deduplication)
+	}
+	fmt.Fprintf(w, "Deduplication:  %s\n", dedup)
+}
+
+// newSetPoliciesCommand builds "set-policies <namespace>". Only the given
+// flags change; --remove reverts policies to the broker defaults.
+func newSetPoliciesCommand(set func(namespace string, set backends.NamespacePolicies, remove []string) error) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "set-policies <namespace>",
+		Short: "Set a namespace's retention, TTL, backlog quota or deduplication",
+		Example: `  pmc manage set-policies pr-123 --retention-time 7d --retention-size 10G
+  pmc manage set-policies acme/pr-123 --ttl 1h --deduplication
+  pmc manage set-policies pr-123 --backlog-quota-limit 2G --backlog-quota-policy producer_exception
+  pmc manage set-policies pr-123 --remove retention,ttl`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			var p backends.NamespacePolicies
+			flags := c.Flags()
+			if v, _ := flags.GetString("retention-time"); flags.Changed("retention-time") {
+				d, err := parsePolicyDuration(v)
+				if err != nil {
+					return fmt.Errorf("invalid --retention-time: %w", err)
+				}
+				p.RetentionTime = &d
+			}
+			if v, _ := flags.GetString("retention-size"); flags.Changed("retention-size") {
+				n, err := parseByteSize(v)
+				if err != nil {
+					return fmt.Errorf("invalid --retention-size: %w", err)
+				}
+				if n > 0 {
+					n = max(n>>20, 1)
+				}
+				p.RetentionSizeMB = &n
+			}
+			if v, _ := flags.GetString("ttl"); flags.Changed("ttl") {
+				d, err := parsePolicyDuration(v)
+				if err != nil || d < 0 {
+					return fmt.Errorf("invalid --ttl %q: use a duration such as 30m or 7d", v)
+				}
+				p.MessageTTL = &d
+			}
+			if v, _ := flags.GetString("backlog-quota-limit"); flags.Changed("backlog-quota-limit") {
+				n, err := parseByteSize(v)
+				if err != nil {
+					return fmt.Errorf("invalid --backlog-quota-limit: %w", err)
+				}
+				p.BacklogQuotaLimit = &n
+			}
+			p.BacklogQuotaPolicy, _ = flags.GetString("backlog-quota-policy")
+			if flags.Changed("deduplication") {
+				dedup, _ := flags.GetBool("deduplication")
+				p.Deduplication = &dedup
+			}
+			remove, _ := flags.GetStringSlice("remove")
+			if p == (backends.NamespacePolicies{}) && len(remove) == 0 {
+				return fmt.Errorf("nothing to change: give a policy flag or --remove")
+			}
+			if err := set(args[0], p, remove); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.OutOrStdout(), "Updated policies of namespace %s\n", args[0])
+			return nil
+		},
+	}
+	c.Flags().String("retention-time", "", "Keep acknowledged messages this long (e.g. 12h, 7d; -1 forever)")
+	c.Flags().String("retention-size", "", "Keep up to this much acknowledged data (e.g. 500M, 10G; -1 unlimited)")
+	c.Flags().String("ttl", "", "Expire unacknowledged messages after this long (e.g. 30m, 7d)")
+	c.Flags().String("backlog-quota-limit", "", "Maximum backlog size per topic (e.g. 2G; -1 unlimited)")
+	c.Flags().String("backlog-quota-policy", "", "When the quota is exceeded: producer_request_hold, producer_exception or consumer_backlog_eviction")
+	c.Flags().Bool("deduplication", false, "Enable (or with =false disable) message deduplication")
+	c.Flags().StringSlice("remove", nil, "Revert policies to the broker defaults: retention, ttl, backlog-quota, deduplication")
+	return c
+}
+
+// parsePolicyDuration parses a Go duration, a number of days ("7d"), or -1
+// for infinite.
+func parsePolicyDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "-1" {
+		return -1, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q (use e.g. 30m, 12h, 7d or -1)", s)
+	}
+	return d, nil
+}
+
+// parseByteSize parses a byte count with an optional K, M, G or T suffix
+// (binary units), or -1 for unlimited.
+func parseByteSize(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	if v == "-1" {
+		return -1, nil
+	}
+	v = strings.TrimSuffix(v, "B")
+	shift := 0
+	if i := strings.IndexAny(v, "KMGT"); i >= 0 && i == len(v)-1 {
+		shift = 10 * (strings.IndexByte("KMGT", v[i]) + 1)
+		v = v[:i]
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 512M, 10G or -1)", s)
+	}
+	return n << shift, nil
+}
+
 // addManageAction adds a single-arg management subcommand (create/delete) if
 // the action is non-nil.
 func addManageAction(parent *cobra.Command, use, short, argName, successFmt string, action *ManageAction) {
@@ -328,3 +995,274 @@ func addBindAction(parent *cobra.Command, use, shortFmt, successFmt string, acti
 	}
 	parent.AddCommand(c)
 }
+
+// writeGroupDescription prints a consumer group's header, its members, and
+// one row per partition with committed offset, log-end offset and lag.
+func writeGroupDescription(w io.Writer, desc *backends.GroupDescription) {
+	fmt.Fprintf(w, "Group:   %s\n", desc.Name)
+	fmt.Fprintf(w, "State:   %s\n", desc.State)
+	fmt.Fprintf(w, "Members: %d\n", len(desc.Members))
+	for _, m := range desc.Members {
+		fmt.Fprintf(w, "  %s  client=%s host=%s partitions=%d\n", m.ID, m.ClientID, m.Host, m.Assigned)
+	}
+	if len(desc.Offsets) == 0 {
+		fmt.Fprintln(w, "No committed offsets")
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%-30s %9s %12s %12s %10s  %s\n", "TOPIC", "PARTITION", "COMMITTED", "END", "LAG", "MEMBER")
+	var total int64
+	for _, o := range desc.Offsets {
+		member := o.Member
+		if member == "" {
+			member = "-"
+		}
+		fmt.Fprintf(w, "%-30s %9d %12s %12d %10d  %s\n", o.Topic, o.Partition, formatOffset(o.Committed), o.End, o.Lag, member)
+		total += o.Lag
+	}
+	fmt.Fprintf(w, "Total lag: %d\n", total)
+}
+
+// formatOffset renders a committed offset, "-" when there is none.
+func formatOffset(offset int64) string {
+	if offset < 0 {
+		return "-"
+	}
+	return strconv.FormatInt(offset, 10)
+}
+
+// newResetOffsetsCommand builds "reset-offsets <group>": exactly one of --to
+// and --shift-by picks the new position, --dry-run prints the plan without
+// committing it.
+func newResetOffsetsCommand(reset func(group, topic string, partitions []int, to backends.OffsetTarget, dryRun bool) ([]backends.OffsetChange, error)) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "reset-offsets <group>",
+		Short: "Move a consumer group's committed offsets on a topic",
+		Long: `Move a consumer group's committed offsets on a topic.
+
+--to takes earliest, latest, an offset, or a timestamp (RFC 3339, or a
+date/time in local time such as 2026-01-02T15:04:05 or 2026-01-02): the
+group resumes at the first message at or after it. --shift-by N moves the
+current committed offsets forward (N > 0) or back (N < 0). Offsets outside
+the retained range are clamped to it.
+
+The group must have no active members; stop its consumers first. Use
+--dry-run to see the old and new offsets without changing anything.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			f := c.Flags()
+			topic, _ := f.GetString("topic")
+			partitions, _ := f.GetIntSlice("partition")
+			dryRun, _ := f.GetBool("dry-run")
+
+			var target backends.OffsetTarget
+			switch {
+			case f.Changed("to") && f.Changed("shift-by"):
+				return fmt.Errorf("--to and --shift-by are mutually exclusive")
+			case f.Changed("shift-by"):
+				shift, _ := f.GetInt64("shift-by")
+				target = backends.OffsetTarget{Kind: backends.OffsetShift, Offset: shift}
+			case f.Changed("to"):
+				to, _ := f.GetString("to")
+				var err error
+				if target, err = parseOffsetTarget(to, time.Local); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("one of --to or --shift-by is required")
+			}
+
+			changes, err := reset(args[0], topic, partitions, target, dryRun)
+			if err != nil {
+				return err
+			}
+			w := c.OutOrStdout()
+			fmt.Fprintf(w, "%-30s %9s %12s %12s\n", "TOPIC", "PARTITION", "OLD", "NEW")
+			for _, ch := range changes {
+				fmt.Fprintf(w, "%-30s %9d %12s %12d\n", ch.Topic, ch.Partition, formatOffset(ch.Old), ch.New)
+			}
+			if dryRun {
+				fmt.Fprintf(w, "Dry run: offsets of group %s not changed\n", args[0])
+			} else {
+				fmt.Fprintf(w, "Reset offsets of group %s on %d partition(s)\n", args[0], len(changes))
+			}
+			return nil
+		},
+	}
+	c.Flags().String("topic", "", "Topic whose offsets to reset")
+	c.Flags().IntSlice("partition", nil, "Only reset these partitions (repeatable or comma-separated; default all)")
+	c.Flags().String("to", "", "New position: earliest, latest, <offset> or <timestamp>")
+	c.Flags().Int64("shift-by", 0, "Move the committed offsets by N (negative: back)")
+	c.Flags().Bool("dry-run", false, "Show the planned offsets without committing them")
+	_ = c.MarkFlagRequired("topic")
+	return c
+}
+
+// parseOffsetTarget parses a --to value: earliest, latest, a non-negative
+// offset, or a timestamp (see backends.ParseOffsetTarget).
+func parseOffsetTarget(s string, loc *time.Location) (backends.OffsetTarget, error) {
+	if strings.EqualFold(s, "shift-by") {
+		return backends.OffsetTarget{}, fmt.Errorf("use --shift-by N to move offsets relative to the committed ones")
+	}
+	target, err := backends.ParseOffsetTarget(s, loc)
+	if err != nil {
+		return target, fmt.Errorf("invalid --to: %w", err)
+	}
+	return target, nil
+}
+
+// addACLFlags binds the ACL fields to flags. As a filter (list-acls,
+// delete-acl) every flag is optional and also takes "any".
+func addACLFlags(c *cobra.Command, acl *backends.ACL, filter bool) {
+	f := c.Flags()
+	if filter {
+		f.StringVar(&acl.ResourceType, "resource-type", "", "Resource type: topic, group, cluster, transactional-id or any")
+		f.StringVar(&acl.ResourceName, "resource-name", "", "Resource name")
+		f.StringVar(&acl.PatternType, "pattern-type", "", "Pattern type: literal, prefixed, match (names covering --resource-name) or any")
+		f.StringVar(&acl.Principal, "principal", "", "Principal, e.g. User:alice")
+		f.StringVar(&acl.Host, "host", "", "Host the ACL applies to")
+		f.StringVar(&acl.Operation, "operation", "", "Operation: read, write, create, delete, alter, describe, all, ... or any")
+		f.StringVar(&acl.Permission, "permission", "", "Permission: allow, deny or any")
+		return
+	}
+	f.StringVar(&acl.ResourceType, "resource-type", "", "Resource type: topic, group, cluster or transactional-id")
+	f.StringVar(&acl.ResourceName, "resource-name", "", "Resource name, or prefix with --pattern-type prefixed (not needed for cluster)")
+	f.StringVar(&acl.PatternType, "pattern-type", "literal", "Pattern type: literal or prefixed")
+	f.StringVar(&acl.Principal, "principal", "", "Principal, e.g. User:alice")
+	f.StringVar(&acl.Host, "host", "*", "Host the ACL applies to")
+	f.StringVar(&acl.Operation, "operation", "", "Operation: read, write, create, delete, alter, describe, describe-configs, alter-configs, cluster-action, idempotent-write or all")
+	f.StringVar(&acl.Permission, "permission", "allow", "Permission: allow or deny")
+	_ = c.MarkFlagRequired("resource-type")
+	_ = c.MarkFlagRequired("principal")
+	_ = c.MarkFlagRequired("operation")
+}
+
+// writeACLs prints one ACL per row.
+func writeACLs(w io.Writer, acls []backends.ACL) {
+	fmt.Fprintf(w, "%-18s %-30s %-9s %-20s %-10s %-17s %s\n", "RESOURCE-TYPE", "RESOURCE-NAME", "PATTERN", "PRINCIPAL", "HOST", "OPERATION", "PERMISSION")
+	for _, a := range acls {
+		fmt.Fprintf(w, "%-18s %-30s %-9s %-20s %-10s %-17s %s\n", a.ResourceType, a.ResourceName, a.PatternType, a.Principal, a.Host, a.Operation, a.Permission)
+	}
+}
+
+// formatACL renders an ACL as one sentence, e.g.
+// "allow User:alice read on topic orders (literal) from *".
+func formatACL(a backends.ACL) string {
+	resource := a.ResourceType
+	if a.ResourceName != "" {
+		resource += " " + a.ResourceName
+	}
+	return fmt.Sprintf("%s %s %s on %s (%s) from %s", a.Permission, a.Principal, a.Operation, resource, a.PatternType, a.Host)
+}
+
+// writeClusterDescription prints the cluster ID, the controller and one row
+// per broker.
+func writeClusterDescription(w io.Writer, desc *backends.ClusterDescription) {
+	fmt.Fprintf(w, "Cluster ID: %s\n", desc.ClusterID)
+	if desc.Controller >= 0 {
+		fmt.Fprintf(w, "Controller: %d\n", desc.Controller)
+	} else {
+		fmt.Fprintln(w, "Controller: -")
+	}
+	fmt.Fprintf(w, "Brokers:    %d\n", len(desc.Brokers))
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%6s  %-40s %6s  %-12s %s\n", "ID", "HOST", "PORT", "RACK", "ROLE")
+	for _, b := range desc.Brokers {
+		rack := b.Rack
+		if rack == "" {
+			rack = "-"
+		}
+		role := ""
+		if b.ID == desc.Controller {
+			role = "controller"
+		}
+		fmt.Fprintf(w, "%6d  %-40s %6d  %-12s %s\n", b.ID, b.Host, b.Port, rack, role)
+	}
+}
+
+// configResourceArgs validates the "<broker|topic> <name>" arguments of
+// describe-configs and alter-configs.
+func configResourceArgs(c *cobra.Command, args []string) error {
+	if err := cobra.ExactArgs(2)(c, args); err != nil {
+		return err
+	}
+	if args[0] != "broker" && args[0] != "topic" {
+		return fmt.Errorf("invalid resource type %q (use broker or topic)", args[0])
+	}
+	return nil
+}
+
+// newDescribeConfigsCommand builds "describe-configs <broker|topic> <name>".
+func newDescribeConfigsCommand(describe func(resourceType, name string) ([]backends.ConfigEntry, error)) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "describe-configs <broker|topic> <name>",
+		Short: "Show the configuration of a broker (by ID) or topic",
+		Args:  configResourceArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			nonDefault, _ := c.Flags().GetBool("non-default")
+			entries, err := describe(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			w := c.OutOrStdout()
+			fmt.Fprintf(w, "%-45s %-30s %-22s %s\n", "NAME", "VALUE", "SOURCE", "FLAGS")
+			for _, e := range entries {
+				if nonDefault && e.Default {
+					continue
+				}
+				value := e.Value
+				if e.Sensitive {
+					value = "(sensitive)"
+				}
+				var flags []string
+				if e.ReadOnly {
+					flags = append(flags, "read-only")
+				}
+				fmt.Fprintf(w, "%-45s %-30s %-22s %s\n", e.Name, value, e.Source, strings.Join(flags, ","))
+			}
+			return nil
+		},
+	}
+	c.Flags().Bool("non-default", false, "Only show entries that differ from the defaults")
+	return c
+}
+
+// newAlterConfigsCommand builds "alter-configs <broker|topic> <name>" with
+// repeatable --set key=value and --delete key.
+func newAlterConfigsCommand(alter func(resourceType, name string, set map[string]string, remove []string) error) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "alter-configs <broker|topic> <name>",
+		Short: "Change the configuration of a broker (by ID) or topic",
+		Long: `Change the configuration of a broker (by ID) or topic.
+
+--set key=value sets an entry, --delete key reverts it to its default.
+Entries not named keep their current values.`,
+		Example: `  alter-configs topic orders --set retention.ms=86400000 --delete cleanup.policy
+  alter-configs broker 1 --set log.cleaner.threads=2`,
+		Args: configResourceArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			entries, _ := c.Flags().GetStringArray("set")
+			remove, _ := c.Flags().GetStringArray("delete")
+			if len(entries) == 0 && len(remove) == 0 {
+				return fmt.Errorf("no changes given; pass --set and/or --delete")
+			}
+			set := make(map[string]string, len(entries))
+			for _, entry := range entries {
+				k, v, ok := strings.Cut(entry, "=")
+				if !ok {
+					return fmt.Errorf("invalid config entry %q (expected key=value)", entry)
+				}
+				set[k] = v
+			}
+			if err := alter(args[0], args[1], set, remove); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.OutOrStdout(), "Updated config of %s %s\n", args[0], args[1])
+			return nil
+		},
+	}
+	c.Flags().StringArray("set", nil, "Config entry to set (key=value, repeatable)")
+	c.Flags().StringArray("delete", nil, "Config entry to revert to its default (repeatable)")
+	return c
+}
@@ -2,9 +2,14 @@ package cmd
 
 import (
 	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/makibytes/xmc/broker/backends"
 	"github.com/spf13/cobra"
 )
 
@@ -56,7 +61,7 @@ func TestManageCommand_QueueLifecycleActions(t *testing.T) {
 
 func TestManageCommand_ActionsOmittedWhenNil(t *testing.T) {
 	cmd := NewManageCommand(ManageSpec{})
-	for _, name := range []string{"update-queue", "enable-queue", "disable-queue", "bind-queue", "unbind-queue", "purge-subscription"} {
+	for _, name := range []string{"update-queue", "enable-queue", "disable-queue", "bind-queue", "unbind-queue", "purge-subscription", "describe-group", "reset-offsets", "list-acls", "create-acl", "delete-acl", "describe-cluster", "describe-configs", "alter-configs", "stats", "list-subscriptions", "create-subscription", "delete-subscription", "expire-messages", "seek", "get-schema", "upload-schema", "delete-schema", "create-tenant", "delete-tenant", "create-namespace", "delete-namespace", "get-policies", "set-policies"} {
 		for _, sub := range cmd.Commands() {
 			if strings.HasPrefix(sub.Use, name+" ") || sub.Name() == name {
 				t.Errorf("subcommand %q registered without a spec action", name)
@@ -84,6 +89,243 @@ func TestManageCommand_PurgeSubscription(t *testing.T) {
 	}
 }
 
+func TestManageCommand_StatsFlags(t *testing.T) {
+	var reset bool
+	spec := ManageSpec{
+		Stats: func(queue string) (*backends.QueueStats, error) {
+			stats := &backends.QueueStats{Name: queue, MessageCount: 3}
+			if reset {
+				stats.EnqueueCount = 9
+			}
+			return stats, nil
+		},
+		StatsFlags: func(c *cobra.Command) {
+			c.Flags().BoolVar(&reset, "reset", false, "")
+		},
+	}
+
+	if out := runManage(t, spec, "stats", "orders"); strings.Contains(out, "Enqueued") {
+		t.Errorf("stats without --reset reported counts:\n%s", out)
+	}
+	if out := runManage(t, spec, "stats", "orders", "--reset"); !strings.Contains(out, "Enqueued:  9") {
+		t.Errorf("stats --reset output missing counts:\n%s", out)
+	}
+}
+
+func TestManageCommand_TopicStats(t *testing.T) {
+	spec := ManageSpec{
+		TopicStats: func(topic string) (*backends.TopicStats, error) {
+			return &backends.TopicStats{
+				Name:        topic,
+				MsgRateIn:   12.5,
+				StorageSize: 4096,
+				Publishers:  1,
+				Subscriptions: []backends.SubscriptionStats{
+					{Name: "billing", Type: "Shared", Backlog: 40, Consumers: 2},
+					{Name: "audit", Type: "Exclusive", Backlog: 2},
+				},
+			}, nil
+		},
+	}
+
+	out := runManage(t, spec, "stats", "orders")
+	for _, want := range []string{"Topic:      orders", "Rate in:    12.5 msg/s", "Storage:    4096 bytes", "Backlog:    42"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if !strings.Contains(strings.Join(strings.Fields(out), " "), "billing Shared 40 2 0.0") {
+		t.Errorf("subscription row missing:\n%s", out)
+	}
+}
+
+func TestManageCommand_BrokerStats(t *testing.T) {
+	spec := ManageSpec{
+		BrokerStats: func() (*backends.BrokerStats, error) {
+			return &backends.BrokerStats{
+				Version:          "mosquitto version 2.0.18",
+				ClientsConnected: 3,
+				MessagesReceived: 120,
+				MessagesSent:     -1,
+				Subscriptions:    7,
+				RetainedMessages: -1,
+			}, nil
+		},
+	}
+
+	out := runManage(t, spec, "stats")
+	for _, want := range []string{"Version:           mosquitto version 2.0.18", "Clients connected: 3", "Messages received: 120", "Subscriptions:     7"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	for _, absent := range []string{"Uptime", "Messages sent", "Retained"} {
+		if strings.Contains(out, absent) {
+			t.Errorf("output shows unreported %q:\n%s", absent, out)
+		}
+	}
+}
+
+func TestManageCommand_Subscriptions(t *testing.T) {
+	var got []string
+	spec := ManageSpec{
+		ListSubscriptions: func(topic string) ([]backends.SubscriptionStats, error) {
+			return nil, nil
+		},
+		CreateSubscription: func(topic, sub string, earliest bool) error {
+			got = append(got, fmt.Sprintf("create:%s/%s:%v", topic, sub, earliest))
+			return nil
+		},
+		DeleteSubscription: func(topic, sub string) error {
+			got = append(got, "delete:"+topic+"/"+sub)
+			return nil
+		},
+		ExpireMessages: func(topic, sub string, olderThan time.Duration) error {
+			got = append(got, fmt.Sprintf("expire:%s/%s:%s", topic, sub, olderThan))
+			return nil
+		},
+		Seek: func(topic, sub, to string) error {
+			got = append(got, fmt.Sprintf("seek:%s/%s:%s", topic, sub, to))
+			return nil
+		},
+	}
+
+	if out := runManage(t, spec, "list-subscriptions", "orders"); !strings.Contains(out, "No subscriptions on orders") {
+		t.Errorf("list-subscriptions output = %q", out)
+	}
+	runManage(t, spec, "create-subscription", "orders", "replay", "--position", "earliest")
+	runManage(t, spec, "create-subscription", "orders", "live")
+	runManage(t, spec, "delete-subscription", "orders", "live")
+	runManage(t, spec, "expire-messages", "orders", "replay", "--older-than", "2h")
+	runManage(t, spec, "expire-messages", "orders", "--older-than", "30m")
+	if out := runManage(t, spec, "seek", "orders", "replay", "--to", "12:3:-1"); !strings.Contains(out, "Moved subscription replay on topic orders to 12:3:-1") {
+		t.Errorf("seek output = %q", out)
+	}
+
+	want := []string{"create:orders/replay:true", "create:orders/live:false", "delete:orders/live", "expire:orders/replay:2h0m0s", "expire:orders/:30m0s", "seek:orders/replay:12:3:-1"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("recorded calls = %v, want %v", got, want)
+	}
+
+	for _, args := range [][]string{
+		{"create-subscription", "orders", "s", "--position", "middle"},
+		{"expire-messages", "orders"},
+		{"seek", "orders", "replay"},
+	} {
+		cmd := NewManageCommand(spec)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestManageCommand_Schemas(t *testing.T) {
+	var uploaded backends.TopicSchema
+	var deleted string
+	spec := ManageSpec{
+		GetSchema: func(topic string) (*backends.TopicSchema, error) {
+			if topic == "raw" {
+				return nil, nil
+			}
+			return &backends.TopicSchema{Type: "AVRO", Version: 3, Definition: `{"type":"string"}`}, nil
+		},
+		UploadSchema: func(topic string, schema backends.TopicSchema) error {
+			uploaded = schema
+			return nil
+		},
+		DeleteSchema: func(topic string) error {
+			deleted = topic
+			return nil
+		},
+	}
+
+	out := runManage(t, spec, "get-schema", "orders")
+	for _, want := range []string{"Type:    AVRO", "Version: 3", `"type": "string"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("get-schema output %q misses %q", out, want)
+		}
+	}
+	if out := runManage(t, spec, "get-schema", "raw"); !strings.Contains(out, "No schema on raw") {
+		t.Errorf("get-schema without schema = %q", out)
+	}
+
+	file := filepath.Join(t.TempDir(), "order.avsc")
+	if err := os.WriteFile(file, []byte(`{"type":"record","name":"Order","fields":[]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runManage(t, spec, "upload-schema", "orders", "--type", "avro", "--file", file)
+	if uploaded.Type != "AVRO" || !strings.Contains(uploaded.Definition, `"Order"`) {
+		t.Errorf("uploaded = %+v", uploaded)
+	}
+	runManage(t, spec, "delete-schema", "orders")
+	if deleted != "orders" {
+		t.Errorf("deleted = %q, want orders", deleted)
+	}
+}
+
+func TestManageCommand_NamespacePolicies(t *testing.T) {
+	week, mb, dedup := 7*24*time.Hour, int64(-1), true
+	var set backends.NamespacePolicies
+	var removed []string
+	spec := ManageSpec{
+		GetNamespacePolicies: func(ns string) (*backends.NamespacePolicies, error) {
+			return &backends.NamespacePolicies{RetentionTime: &week, RetentionSizeMB: &mb, Deduplication: &dedup}, nil
+		},
+		SetNamespacePolicies: func(ns string, p backends.NamespacePolicies, remove []string) error {
+			set, removed = p, remove
+			return nil
+		},
+	}
+
+	out := runManage(t, spec, "get-policies", "acme/pr-1")
+	for _, want := range []string{"Retention time: 168h0m0s", "Retention size: unlimited", "Message TTL:    (broker default)", "Deduplication:  true"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("get-policies output %q misses %q", out, want)
+		}
+	}
+
+	runManage(t, spec, "set-policies", "pr-1", "--retention-time", "7d", "--retention-size", "10G", "--ttl", "30m",
+		"--backlog-quota-limit", "-1", "--deduplication=false", "--remove", "retention")
+	if set.RetentionTime == nil || *set.RetentionTime != week || set.RetentionSizeMB == nil || *set.RetentionSizeMB != 10240 ||
+		set.MessageTTL == nil || *set.MessageTTL != 30*time.Minute || set.BacklogQuotaLimit == nil || *set.BacklogQuotaLimit != -1 ||
+		set.Deduplication == nil || *set.Deduplication {
+		t.Errorf("set = %+v", set)
+	}
+	if len(removed) != 1 || removed[0] != "retention" {
+		t.Errorf("removed = %v", removed)
+	}
+
+	for _, args := range [][]string{
+		{"set-policies", "pr-1"},
+		{"set-policies", "pr-1", "--ttl", "-1"},
+		{"set-policies", "pr-1", "--retention-size", "lots"},
+	} {
+		cmd := NewManageCommand(spec)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	for in, want := range map[string]int64{"512": 512, "4k": 4096, "10G": 10 << 30, "2MB": 2 << 20, "-1": -1} {
+		if got, err := parseByteSize(in); err != nil || got != want {
+			t.Errorf("parseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "G", "1.5G", "-2"} {
+		if _, err := parseByteSize(bad); err == nil {
+			t.Errorf("parseByteSize(%q): expected an error", bad)
+		}
+	}
+}
+
 func TestManageCommand_BindActionDefaultNoun(t *testing.T) {
 	var boundQueue, boundTarget string
 	spec := ManageSpec{
@@ -132,6 +374,294 @@ func TestManageCommand_BindActionTargetNoun(t *testing.T) {
 	}
 }
 
+func TestManageCommand_DescribeGroup(t *testing.T) {
+	spec := ManageSpec{
+		DescribeGroup: func(group string) (*backends.GroupDescription, error) {
+			return &backends.GroupDescription{
+				Name:    group,
+				State:   "Stable",
+				Members: []backends.GroupMember{{ID: "m-1", ClientID: "app", Host: "/10.0.0.1", Assigned: 1}},
+				Offsets: []backends.PartitionOffset{
+					{Topic: "orders", Partition: 0, Committed: 90, End: 100, Lag: 10, Member: "m-1"},
+					{Topic: "orders", Partition: 1, Committed: -1, End: 5, Lag: 5},
+				},
+			}, nil
+		},
+	}
+
+	out := runManage(t, spec, "describe-group", "billing")
+	for _, want := range []string{"Group:   billing", "State:   Stable", "Members: 1", "m-1  client=app", "Total lag: 15"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	lines := strings.Split(out, "\n")
+	row := func(prefix string) []string {
+		for _, l := range lines {
+			if f := strings.Fields(l); len(f) > 1 && f[0] == "orders" && f[1] == prefix {
+				return f
+			}
+		}
+		t.Fatalf("no row for partition %s:\n%s", prefix, out)
+		return nil
+	}
+	if got := strings.Join(row("0"), " "); got != "orders 0 90 100 10 m-1" {
+		t.Errorf("partition 0 row = %q", got)
+	}
+	if got := strings.Join(row("1"), " "); got != "orders 1 - 5 5 -" {
+		t.Errorf("partition 1 row = %q", got)
+	}
+}
+
+func TestManageCommand_ResetOffsets(t *testing.T) {
+	var (
+		gotGroup, gotTopic string
+		gotParts           []int
+		gotTarget          backends.OffsetTarget
+		gotDryRun          bool
+	)
+	spec := ManageSpec{
+		ResetOffsets: func(group, topic string, partitions []int, to backends.OffsetTarget, dryRun bool) ([]backends.OffsetChange, error) {
+			gotGroup, gotTopic, gotParts, gotTarget, gotDryRun = group, topic, partitions, to, dryRun
+			return []backends.OffsetChange{{Topic: topic, Partition: 2, Old: 40, New: 30}}, nil
+		},
+	}
+
+	out := runManage(t, spec, "reset-offsets", "billing", "--topic", "orders", "--partition", "2", "--shift-by", "-10", "--dry-run")
+	if gotGroup != "billing" || gotTopic != "orders" || len(gotParts) != 1 || gotParts[0] != 2 || !gotDryRun {
+		t.Errorf("ResetOffsets got (%q, %q, %v, dry-run %v)", gotGroup, gotTopic, gotParts, gotDryRun)
+	}
+	if gotTarget.Kind != backends.OffsetShift || gotTarget.Offset != -10 {
+		t.Errorf("target = %+v, want shift by -10", gotTarget)
+	}
+	if !strings.Contains(out, "Dry run: offsets of group billing not changed") {
+		t.Errorf("dry-run output = %q", out)
+	}
+
+	out = runManage(t, spec, "reset-offsets", "billing", "--topic", "orders", "--to", "earliest")
+	if gotTarget.Kind != backends.OffsetEarliest || gotDryRun {
+		t.Errorf("target = %+v, dry-run %v", gotTarget, gotDryRun)
+	}
+	if !strings.Contains(out, "Reset offsets of group billing on 1 partition(s)") {
+		t.Errorf("reset output = %q", out)
+	}
+}
+
+func TestManageCommand_ResetOffsetsRequiresOneTarget(t *testing.T) {
+	spec := ManageSpec{
+		ResetOffsets: func(string, string, []int, backends.OffsetTarget, bool) ([]backends.OffsetChange, error) {
+			t.Fatal("ResetOffsets called")
+			return nil, nil
+		},
+	}
+	for _, args := range [][]string{
+		{"reset-offsets", "g", "--topic", "t"},
+		{"reset-offsets", "g", "--topic", "t", "--to", "latest", "--shift-by", "1"},
+		{"reset-offsets", "g", "--to", "latest"},
+	} {
+		cmd := NewManageCommand(spec)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("manage %v: expected an error", args)
+		}
+	}
+}
+
+func TestManageCommand_ACLs(t *testing.T) {
+	var created, listFilter, deleteFilter backends.ACL
+	acl := backends.ACL{ResourceType: "topic", ResourceName: "orders", PatternType: "literal", Principal: "User:alice", Host: "*", Operation: "read", Permission: "allow"}
+	spec := ManageSpec{
+		CreateACL: func(a backends.ACL) error { created = a; return nil },
+		ListACLs: func(f backends.ACL) ([]backends.ACL, error) {
+			listFilter = f
+			return []backends.ACL{acl}, nil
+		},
+		DeleteACLs: func(f backends.ACL) ([]backends.ACL, error) {
+			deleteFilter = f
+			return []backends.ACL{acl}, nil
+		},
+	}
+
+	out := runManage(t, spec, "create-acl", "--resource-type", "topic", "--resource-name", "orders", "--principal", "User:alice", "--operation", "read")
+	if created != acl {
+		t.Errorf("CreateACL got %+v, want defaults filled in: %+v", created, acl)
+	}
+	if !strings.Contains(out, "Created ACL: allow User:alice read on topic orders (literal) from *") {
+		t.Errorf("create output = %q", out)
+	}
+
+	out = runManage(t, spec, "list-acls", "--principal", "User:alice")
+	if listFilter != (backends.ACL{Principal: "User:alice"}) {
+		t.Errorf("list filter = %+v, want only the principal set", listFilter)
+	}
+	if got := strings.Join(strings.Fields(strings.Split(out, "\n")[1]), " "); got != "topic orders literal User:alice * read allow" {
+		t.Errorf("list row = %q", got)
+	}
+
+	out = runManage(t, spec, "delete-acl", "--resource-type", "topic", "--resource-name", "orders")
+	if deleteFilter != (backends.ACL{ResourceType: "topic", ResourceName: "orders"}) {
+		t.Errorf("delete filter = %+v", deleteFilter)
+	}
+	if !strings.Contains(out, "Deleted 1 ACL(s)") {
+		t.Errorf("delete output = %q", out)
+	}
+}
+
+func TestManageCommand_ACLsRejectMissingFlags(t *testing.T) {
+	spec := ManageSpec{
+		CreateACL:  func(backends.ACL) error { t.Fatal("CreateACL called"); return nil },
+		DeleteACLs: func(backends.ACL) ([]backends.ACL, error) { t.Fatal("DeleteACLs called"); return nil, nil },
+	}
+	for _, args := range [][]string{
+		{"create-acl", "--resource-type", "topic", "--resource-name", "orders", "--principal", "User:alice"},
+		{"delete-acl"},
+	} {
+		cmd := NewManageCommand(spec)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("manage %v: expected an error", args)
+		}
+	}
+}
+
+func TestManageCommand_DescribeCluster(t *testing.T) {
+	spec := ManageSpec{
+		DescribeCluster: func() (*backends.ClusterDescription, error) {
+			return &backends.ClusterDescription{
+				ClusterID:  "abc123",
+				Controller: 2,
+				Brokers: []backends.BrokerInfo{
+					{ID: 1, Host: "kafka-1", Port: 9092, Rack: "eu-1a"},
+					{ID: 2, Host: "kafka-2", Port: 9092},
+				},
+			}, nil
+		},
+	}
+
+	out := runManage(t, spec, "describe-cluster")
+	for _, want := range []string{"Cluster ID: abc123", "Controller: 2", "Brokers:    2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	var rows []string
+	for _, l := range strings.Split(out, "\n") {
+		if f := strings.Fields(l); len(f) > 1 && strings.HasPrefix(f[1], "kafka-") {
+			rows = append(rows, strings.Join(f, " "))
+		}
+	}
+	if want := []string{"1 kafka-1 9092 eu-1a", "2 kafka-2 9092 - controller"}; strings.Join(rows, "|") != strings.Join(want, "|") {
+		t.Errorf("broker rows = %q, want %q", rows, want)
+	}
+}
+
+func TestManageCommand_DescribeConfigs(t *testing.T) {
+	var gotType, gotName string
+	spec := ManageSpec{
+		DescribeConfigs: func(resourceType, name string) ([]backends.ConfigEntry, error) {
+			gotType, gotName = resourceType, name
+			return []backends.ConfigEntry{
+				{Name: "cleanup.policy", Value: "compact", Source: "dynamic-topic"},
+				{Name: "retention.ms", Value: "604800000", Source: "default", Default: true},
+				{Name: "sasl.jaas.config", Source: "static-broker", Sensitive: true, ReadOnly: true},
+			}, nil
+		},
+	}
+
+	out := runManage(t, spec, "describe-configs", "topic", "orders")
+	if gotType != "topic" || gotName != "orders" {
+		t.Errorf("DescribeConfigs got (%q, %q)", gotType, gotName)
+	}
+	for _, want := range []string{"retention.ms", "(sensitive)", "read-only"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	out = runManage(t, spec, "describe-configs", "topic", "orders", "--non-default")
+	if strings.Contains(out, "retention.ms") || !strings.Contains(out, "cleanup.policy") {
+		t.Errorf("--non-default output = %q", out)
+	}
+
+	cmd := NewManageCommand(spec)
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"describe-configs", "group", "g"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("describe-configs group: expected an error")
+	}
+}
+
+func TestManageCommand_AlterConfigs(t *testing.T) {
+	var (
+		gotType, gotName string
+		gotSet           map[string]string
+		gotRemove        []string
+	)
+	spec := ManageSpec{
+		AlterConfigs: func(resourceType, name string, set map[string]string, remove []string) error {
+			gotType, gotName, gotSet, gotRemove = resourceType, name, set, remove
+			return nil
+		},
+	}
+
+	out := runManage(t, spec, "alter-configs", "broker", "1", "--set", "log.cleaner.threads=2", "--delete", "log.retention.ms")
+	if gotType != "broker" || gotName != "1" || len(gotSet) != 1 || gotSet["log.cleaner.threads"] != "2" || len(gotRemove) != 1 || gotRemove[0] != "log.retention.ms" {
+		t.Errorf("AlterConfigs got (%q, %q, %v, %v)", gotType, gotName, gotSet, gotRemove)
+	}
+	if !strings.Contains(out, "Updated config of broker 1") {
+		t.Errorf("output = %q", out)
+	}
+
+	for _, args := range [][]string{
+		{"alter-configs", "topic", "orders"},
+		{"alter-configs", "topic", "orders", "--set", "retention.ms"},
+	} {
+		cmd := NewManageCommand(spec)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("manage %v: expected an error", args)
+		}
+	}
+}
+
+func TestParseOffsetTarget(t *testing.T) {
+	loc := time.FixedZone("test", 2*60*60)
+	tests := []struct {
+		in   string
+		want backends.OffsetTarget
+	}{
+		{"earliest", backends.OffsetTarget{Kind: backends.OffsetEarliest}},
+		{"LATEST", backends.OffsetTarget{Kind: backends.OffsetLatest}},
+		{"1234", backends.OffsetTarget{Kind: backends.OffsetAbsolute, Offset: 1234}},
+		{"2026-03-01T10:00:00Z", backends.OffsetTarget{Kind: backends.OffsetTimestamp, Time: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)}},
+		{"2026-03-01T10:00:00", backends.OffsetTarget{Kind: backends.OffsetTimestamp, Time: time.Date(2026, 3, 1, 10, 0, 0, 0, loc)}},
+		{"2026-03-01", backends.OffsetTarget{Kind: backends.OffsetTimestamp, Time: time.Date(2026, 3, 1, 0, 0, 0, 0, loc)}},
+	}
+	for _, tt := range tests {
+		got, err := parseOffsetTarget(tt.in, loc)
+		if err != nil {
+			t.Errorf("parseOffsetTarget(%q): %v", tt.in, err)
+			continue
+		}
+		if got.Kind != tt.want.Kind || got.Offset != tt.want.Offset || !got.Time.Equal(tt.want.Time) {
+			t.Errorf("parseOffsetTarget(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"-5", "yesterday", "shift-by"} {
+		if _, err := parseOffsetTarget(bad, loc); err == nil {
+			t.Errorf("parseOffsetTarget(%q): expected an error", bad)
+		}
+	}
+}
+
 func findSubcommand(t *testing.T, parent *cobra.Command, name string) *cobra.Command {
 	t.Helper()
 	for _, sub := range parent.Commands() {
@@ -142,3 +672,20 @@ func findSubcommand(t *testing.T, parent *cobra.Command, name string) *cobra.Com
 	t.Fatalf("subcommand %q not found", name)
 	return nil
 }
+
+func TestManageCommand_ListNestedTree(t *testing.T) {
+	tree := []backends.ObjectNode{{
+		Name: "acme", Kind: "tenant",
+		Children: []backends.ObjectNode{{
+			Name: "acme/app", Kind: "namespace", Metrics: []backends.Metric{{Label: "topics", Value: 1}},
+			Children: []backends.ObjectNode{{Name: "persistent://acme/app/orders", Kind: "topic"}},
+		}},
+	}}
+	spec := ManageSpec{Objects: []ObjectType{{Label: "Tenants", Hierarchical: true, List: func() ([]backends.ObjectNode, error) { return tree, nil }}}}
+	out := runManage(t, spec, "list")
+	for _, want := range []string{"  └ namespace acme/app  ", "    └ topic persistent://acme/app/orders\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("list output lacks %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+	"github.com/makibytes/xmc/log"
+)
+
+// fanoutPoll bounds how long one topic is polled before the fan-out moves on
+// to the next, so a quiet topic delays a busy one by at most this much.
+const fanoutPoll = 100 * time.Millisecond
+
+// multiTopicReceiver consumes several topics, or wildcard patterns, as one
+// merged stream for subscribe. Brokers that implement
+// backends.MultiTopicBackend get a single native subscription; the rest are
+// fanned out over one subscription per topic, each on its own adapter, polled
+// round-robin. Polling instead of reading every topic concurrently means no
+// message is taken off a topic unless it is handed to the caller, so -n stops
+// without consuming and dropping messages from the other topics.
+type multiTopicReceiver struct {
+	topics  []string
+	opts    backends.SubscribeOptions
+	primary backends.TopicBackend
+	factory TopicAdapterFactory // opens the fan-out adapters; nil reuses primary
+
+	native   bool
+	adapters []backends.TopicBackend // fan-out adapter per topic, opened on first use
+	opened   []backends.TopicBackend // adapters opened here, released by close
+	next     int                     // topic the next fan-out round starts at
+}
+
+func newMultiTopicReceiver(primary backends.TopicBackend, factory TopicAdapterFactory, topics []string, opts backends.SubscribeOptions) *multiTopicReceiver {
+	_, native := primary.(backends.MultiTopicBackend)
+	return &multiTopicReceiver{topics: topics, opts: opts, primary: primary, factory: factory, native: native}
+}
+
+// receive returns the next message from any of the topics, labelled with its
+// source topic, honouring the --timeout/--wait contract across all of them.
+func (r *multiTopicReceiver) receive(ctx context.Context) (*backends.Message, error) {
+	if r.native {
+		msg, err := r.primary.(backends.MultiTopicBackend).SubscribeTopics(ctx, r.topics, r.opts)
+		if !errors.Is(err, backends.ErrMultiTopicUnsupported) {
+			return msg, err
+		}
+		log.Verbose("broker has no native multi-topic subscribe, fanning out over %d subscriptions", len(r.topics))
+		r.native = false
+	}
+	if err := r.openAdapters(); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(backends.TimeoutDuration(r.opts.Timeout, r.opts.Wait))
+	for {
+		for range len(r.topics) {
+			i := r.next
+			r.next = (r.next + 1) % len(r.topics)
+
+			opts := r.opts
+			opts.Topic = r.topics[i]
+			opts.Wait = false
+			opts.Timeout = float32(min(fanoutPoll, max(time.Until(deadline), time.Millisecond)).Seconds())
+			msg, err := r.adapters[i].Subscribe(ctx, opts)
+			if err == nil {
+				msg.Topic = r.topics[i]
+				return msg, nil
+			}
+			if !errors.Is(err, backends.ErrNoMessageAvailable) {
+				return nil, err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !time.Now().Before(deadline) {
+			return nil, backends.ErrNoMessageAvailable
+		}
+	}
+}
+
+// openAdapters gives every topic its own adapter: brokers cache one
+// subscription (or consumer) per adapter, and some name ephemeral
+// subscriptions per adapter, so sharing one across topics would thrash or
+// collide. The first topic uses the command's adapter.
+func (r *multiTopicReceiver) openAdapters() error {
+	if r.adapters != nil {
+		return nil
+	}
+	adapters := []backends.TopicBackend{r.primary}
+	for range len(r.topics) - 1 {
+		if r.factory == nil {
+			adapters = append(adapters, r.primary)
+			continue
+		}
+		a, err := r.factory()
+		if err != nil {
+			return err
+		}
+		if a != r.primary {
+			r.opened = append(r.opened, a)
+		}
+		adapters = append(adapters, a)
+	}
+	r.adapters = adapters
+	return nil
+}
+
+// close releases the fan-out adapters; the command's adapter is closed by its
+// owner.
+func (r *multiTopicReceiver) close() {
+	for _, a := range r.opened {
+		if err := a.Close(); err != nil {
+			log.Verbose("close: %s", err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// topicStore holds per-topic message backlogs shared by every topicReader a
+// test factory hands out, standing in for the broker.
+type topicStore struct {
+	msgs   map[string][]string
+	closed int
+}
+
+// topicReader is a single-topic adapter over a topicStore, like the real
+// adapters without native multi-topic support.
+type topicReader struct{ store *topicStore }
+
+func (r *topicReader) Publish(context.Context, backends.PublishOptions) error { return nil }
+
+func (r *topicReader) Subscribe(_ context.Context, opts backends.SubscribeOptions) (*backends.Message, error) {
+	pending := r.store.msgs[opts.Topic]
+	if len(pending) == 0 {
+		return nil, backends.ErrNoMessageAvailable
+	}
+	r.store.msgs[opts.Topic] = pending[1:]
+	return &backends.Message{Data: []byte(pending[0])}, nil
+}
+
+func (r *topicReader) Close() error {
+	r.store.closed++
+	return nil
+}
+
+// nativeMultiTopic implements backends.MultiTopicBackend.
+type nativeMultiTopic struct {
+	mockTopicBackend
+	topics []string
+}
+
+func (n *nativeMultiTopic) SubscribeTopics(_ context.Context, topics []string, _ backends.SubscribeOptions) (*backends.Message, error) {
+	n.topics = topics
+	return &backends.Message{Data: []byte("native"), Topic: "sensors/kitchen"}, nil
+}
+
+func decodeTopics(t *testing.T, out string) (topics, data []string) {
+	t.Helper()
+	for line := range strings.SplitSeq(strings.TrimSpace(out), "\n") {
+		var rec messageRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("bad NDJSON line %q: %v", line, err)
+		}
+		topics = append(topics, rec.Topic)
+		data = append(data, rec.Data)
+	}
+	return topics, data
+}
+
+func TestSubscribeCommand_FansOutOverTopics(t *testing.T) {
+	store := &topicStore{msgs: map[string][]string{"a": {"a1", "a2"}, "b": {"b1"}}}
+	primary := &topicReader{store: store}
+	opened := 0
+	factory := TopicAdapterFactory(func() (backends.TopicBackend, error) {
+		opened++
+		return &topicReader{store: store}, nil
+	})
+
+	cmd := NewSubscribeCommand(primary, factory, nil, nil)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"a", "b", "-n", "3", "--ndjson", "-t", "50ms"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	topics, data := decodeTopics(t, out.String())
+	if !slices.Equal(topics, []string{"a", "b", "a"}) || !slices.Equal(data, []string{"a1", "b1", "a2"}) {
+		t.Errorf("merged stream = %v %v, want round-robin a1 b1 a2 labelled by topic", topics, data)
+	}
+	if opened != 1 || store.closed != 1 {
+		t.Errorf("opened %d / closed %d fan-out adapters, want 1/1", opened, store.closed)
+	}
+}
+
+func TestSubscribeCommand_FanOutDoesNotOverRead(t *testing.T) {
+	store := &topicStore{msgs: map[string][]string{"a": {"a1", "a2"}, "b": {"b1", "b2"}}}
+	cmd := NewSubscribeCommand(&topicReader{store: store}, nil, nil, nil)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"a", "b", "-n", "1", "-F", "%t:%s\\n"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "a:a1\n" {
+		t.Errorf("output = %q, want %q", out.String(), "a:a1\n")
+	}
+	if len(store.msgs["a"]) != 1 || len(store.msgs["b"]) != 2 {
+		t.Errorf("fan-out consumed more than it delivered: %v", store.msgs)
+	}
+}
+
+func TestSubscribeCommand_NativeMultiTopic(t *testing.T) {
+	mock := &nativeMultiTopic{}
+	cmd := NewSubscribeCommand(mock, nil, nil, nil)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"sensors/+", "alerts/#", "-J"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(mock.topics, []string{"sensors/+", "alerts/#"}) {
+		t.Errorf("SubscribeTopics got %v", mock.topics)
+	}
+	if mock.subscribeCount != 0 {
+		t.Error("native multi-topic backend should not be polled per topic")
+	}
+	if !strings.Contains(out.String(), `"topic":"sensors/kitchen"`) {
+		t.Errorf("JSON output lacks the source topic: %s", out.String())
+	}
+}
+
+func TestSubscribeCommand_SingleTopicUnlabelled(t *testing.T) {
+	mock := &nativeMultiTopic{mockTopicBackend: mockTopicBackend{subscribeMsg: &backends.Message{Data: []byte("x")}}}
+	cmd := NewSubscribeCommand(mock, nil, nil, nil)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"plain", "--ndjson"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mock.topics != nil || mock.subscribeCount != 1 {
+		t.Error("a single plain topic should use Subscribe")
+	}
+	if strings.Contains(out.String(), `"topic"`) {
+		t.Errorf("single-topic record should not carry a topic: %s", out.String())
+	}
+}
+
+func TestIsTopicPattern(t *testing.T) {
+	for _, p := range []string{"orders.*", "sensors/+/temp", "alerts/#", "orders.>", "log-?"} {
+		if !isTopicPattern(p) {
+			t.Errorf("%q should be a pattern", p)
+		}
+	}
+	for _, p := range []string{"orders", "persistent://public/default/orders", "a.b-c_d"} {
+		if isTopicPattern(p) {
+			t.Errorf("%q should not be a pattern", p)
+		}
+	}
+}
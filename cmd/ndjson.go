@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/makibytes/xmc/broker/backends"
@@ -20,10 +21,13 @@ import (
 //
 // Payloads that are valid UTF-8 are stored as a plain string in Data; binary
 // payloads are base64-encoded into DataBase64 so the JSON stays well-formed and
-// the bytes survive a round-trip exactly.
+// the bytes survive a round-trip exactly. A tombstone (a Kafka record with a
+// null value) carries neither and sets Tombstone instead, so it stays distinct
+// from an empty payload.
 type messageRecord struct {
 	Data          string         `json:"data,omitempty"`
 	DataBase64    string         `json:"dataBase64,omitempty"`
+	Tombstone     bool           `json:"tombstone,omitempty"`
 	Key           string         `json:"key,omitempty"`
 	MessageID     string         `json:"messageId,omitempty"`
 	CorrelationID string         `json:"correlationId,omitempty"`
@@ -33,6 +37,22 @@ type messageRecord struct {
 	Persistent    bool           `json:"persistent,omitempty"`
 	Properties    map[string]any `json:"properties,omitempty"`
 
+	// ReceivedAt is the wall-clock time xmc received the message, stamped on
+	// export (displayMessageNDJSON). It is capture metadata rather than part
+	// of the message: imports ignore it unless --replay-timing asks to
+	// reproduce the original inter-arrival gaps (see cmd/replay.go).
+	ReceivedAt time.Time `json:"receivedAt,omitzero"`
+
+	// Topic is the topic a subscribed message arrived on, so a merged
+	// multi-topic or wildcard stream stays attributable. Like ReceivedAt it
+	// describes the capture, not the message: imports ignore it.
+	Topic string `json:"topic,omitempty"`
+
+	// Retained marks a message an MQTT broker delivered as the topic's
+	// retained message on subscribe, rather than live. Capture metadata
+	// too: imports ignore it (publish --retain stores one).
+	Retained bool `json:"retained,omitempty"`
+
 	// InternalMetadata carries broker-specific display fields (Kafka
 	// partition/offset, IBM MQ MQMD fields, ...) when requested — see
 	// recordForDisplay in message_schema.go. newMessageRecord (the NDJSON
@@ -57,8 +77,11 @@ func newMessageRecord(m *backends.Message, includePayload bool) messageRecord {
 		Priority:      m.Priority,
 		Persistent:    m.Persistent,
 		Properties:    pruneMap(m.Properties),
+		Topic:         m.Topic,
+		Retained:      m.Retained,
+		Tombstone:     m.Tombstone,
 	}
-	if includePayload {
+	if includePayload && !m.Tombstone {
 		if utf8.Valid(m.Data) {
 			rec.Data = string(m.Data)
 		} else {
@@ -81,11 +104,14 @@ func (r messageRecord) payload() ([]byte, error) {
 	return []byte(r.Data), nil
 }
 
-// displayMessageNDJSON writes a single message as one NDJSON record line. A
-// trailing newline is always written so records remain line-delimited
-// regardless of whether stdout is a terminal.
+// displayMessageNDJSON writes a single message as one NDJSON record line,
+// stamped with the current time as its receivedAt. A trailing newline is
+// always written so records remain line-delimited regardless of whether
+// stdout is a terminal.
 func displayMessageNDJSON(w io.Writer, message *backends.Message) error {
-	data, err := json.Marshal(newMessageRecord(message, true))
+	rec := newMessageRecord(message, true)
+	rec.ReceivedAt = time.Now().UTC()
+	data, err := json.Marshal(rec)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message record: %w", err)
 	}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"os"
@@ -54,6 +55,33 @@ func TestMessageRecord_IncludePayloadFalse_OmitsData(t *testing.T) {
 	}
 }
 
+func TestMessageRecord_Tombstone(t *testing.T) {
+	rec := newMessageRecord(&backends.Message{Key: "k1", Tombstone: true}, true)
+	data, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"tombstone":true,"key":"k1"}` {
+		t.Errorf("tombstone record = %s", data)
+	}
+
+	// An empty payload is not a tombstone.
+	data, _ = json.Marshal(newMessageRecord(&backends.Message{Key: "k1", Data: []byte{}}, true))
+	if strings.Contains(string(data), "tombstone") {
+		t.Errorf("empty payload record = %s, want no tombstone marker", data)
+	}
+}
+
+func TestMessageRecord_Retained(t *testing.T) {
+	data, err := json.Marshal(newMessageRecord(&backends.Message{Data: []byte("on"), Topic: "lamp/state", Retained: true}, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"data":"on","topic":"lamp/state","retained":true}` {
+		t.Errorf("retained record = %s", data)
+	}
+}
+
 func TestForEachRecord(t *testing.T) {
 	in := `{"data":"a"}` + "\n" +
 		"   \n" + // blank line should be skipped
@@ -253,6 +281,96 @@ func TestPublishCommand_NDJSONImport(t *testing.T) {
 	})
 }
 
+func TestPublishCommand_NDJSONImport_Tombstone(t *testing.T) {
+	input := `{"tombstone":true,"key":"cfg-1"}` + "\n"
+
+	withStdin(t, input, func() {
+		mock := &mockTopicBackend{}
+		cmd := newTombstonePublishCommand(mock)
+		cmd.SetArgs([]string{"topic", "--ndjson"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !mock.lastPublishOpts.Tombstone || mock.lastPublishOpts.Key != "cfg-1" || len(mock.lastPublishOpts.Message) != 0 {
+			t.Errorf("tombstone not restored: %+v", mock.lastPublishOpts)
+		}
+	})
+}
+
+// recordingTopic remembers every message published to it.
+type recordingTopic struct {
+	mockTopicBackend
+	published []backends.PublishOptions
+}
+
+func (r *recordingTopic) Publish(_ context.Context, opts backends.PublishOptions) error {
+	r.published = append(r.published, opts)
+	return nil
+}
+
+// TestNDJSON_TombstoneRoundTrip exports a tombstone next to an empty value and
+// imports the export again: the tombstone stays a delete, the empty value an
+// empty message.
+func TestNDJSON_TombstoneRoundTrip(t *testing.T) {
+	src := &mockTopicBackend{subscribeMsgs: []*backends.Message{
+		{Key: "cfg-1", Tombstone: true},
+		{Key: "cfg-2", Data: []byte{}},
+	}, subscribeErr: backends.ErrNoMessageAvailable}
+	export := NewSubscribeCommand(src, nil, nil, nil)
+	export.SetArgs([]string{"topic", "--ndjson", "-n", "2"})
+	out := captureStdout(t, func() {
+		if err := export.Execute(); err != nil {
+			t.Fatalf("export: %v", err)
+		}
+	})
+
+	dst := &recordingTopic{}
+	withStdin(t, out, func() {
+		imp := newTombstonePublishCommand(dst)
+		imp.SetArgs([]string{"topic", "--ndjson"})
+		if err := imp.Execute(); err != nil {
+			t.Fatalf("import: %v", err)
+		}
+	})
+	if len(dst.published) != 2 {
+		t.Fatalf("imported %d records, want 2 (export %q)", len(dst.published), out)
+	}
+	if got := dst.published[0]; !got.Tombstone || got.Key != "cfg-1" {
+		t.Errorf("first record = %+v, want a tombstone for cfg-1", got)
+	}
+	if got := dst.published[1]; got.Tombstone || got.Key != "cfg-2" || len(got.Message) != 0 {
+		t.Errorf("second record = %+v, want an empty message for cfg-2", got)
+	}
+}
+
+func TestNDJSONImport_TombstoneUnsupported(t *testing.T) {
+	input := `{"tombstone":true,"key":"cfg-1"}` + "\n"
+
+	withStdin(t, input, func() {
+		mock := &mockTopicBackend{}
+		cmd := NewPublishCommand(mock, nil, nil)
+		cmd.SetArgs([]string{"topic", "--ndjson"})
+		if err := cmd.Execute(); err == nil {
+			t.Error("publish: expected an error for a tombstone record")
+		}
+		if mock.publishCount != 0 {
+			t.Errorf("publish: published %d messages", mock.publishCount)
+		}
+	})
+
+	withStdin(t, input, func() {
+		mock := &mockQueueBackend{}
+		cmd := NewSendCommand(mock, nil, nil)
+		cmd.SetArgs([]string{"queue", "--ndjson"})
+		if err := cmd.Execute(); err == nil {
+			t.Error("send: expected an error for a tombstone record")
+		}
+		if mock.sendCount != 0 {
+			t.Errorf("send: sent %d messages", mock.sendCount)
+		}
+	})
+}
+
 func TestReceiveCommand_NDJSON_PrunesEmptyMetadataValues(t *testing.T) {
 	msg := &backends.Message{
 		Data: []byte("hi"),
@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
+	"github.com/makibytes/xmc/broker/backends"
+	"github.com/makibytes/xmc/log"
 	"github.com/spf13/cobra"
 )
 
@@ -25,7 +31,13 @@ type Connector func() (Closeable, error)
 //
 // The command exits non-zero if any attempt fails, which makes it suitable for
 // readiness checks and CI pipelines.
-func NewPingCommand(connect Connector) *cobra.Command {
+//
+// With --roundtrip <destination> it instead sends a probe message through the
+// broker and consumes it back (see doPingRoundtrip), proving that messages
+// actually flow. queueFactory/topicFactory provide that connection; either may
+// be nil when the broker lacks the model, and --roundtrip is only offered when
+// at least one is set.
+func NewPingCommand(connect Connector, queueFactory QueueAdapterFactory, topicFactory TopicAdapterFactory) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "ping",
 		Short: "Check connectivity to the broker",
@@ -33,15 +45,31 @@ func NewPingCommand(connect Connector) *cobra.Command {
 applicable) and reports whether it succeeds, with the round-trip time.
 
 By default a single attempt is made. Use --count for repeated probes and
---interval to space them out. The command exits non-zero if any attempt fails.`,
+--interval to space them out. The command exits non-zero if any attempt fails.
+
+--roundtrip <queue|topic> sends a probe message and consumes it back instead,
+reporting send, broker and total latency per attempt and min/avg/max/stddev at
+the end, like ICMP ping. A probe that does not come back within --timeout counts
+as lost, and any loss makes the command exit non-zero. Use a dedicated probe
+queue: other messages read from it while waiting for the probe are discarded.`,
 		Args: cobra.NoArgs,
 		RunE: func(c *cobra.Command, args []string) error {
+			if dest, _ := c.Flags().GetString("roundtrip"); dest != "" {
+				return doPingRoundtrip(c, dest, queueFactory, topicFactory)
+			}
 			return doPing(c, connect)
 		},
 	}
 
 	cmd.Flags().IntP("count", "n", 1, "Number of attempts (0 = until interrupted)")
 	cmd.Flags().VarP(newDurationValue(time.Second, time.Second), "interval", "i", "Time between attempts (e.g. \"1s\", \"500ms\")")
+	if queueFactory != nil || topicFactory != nil {
+		cmd.Flags().String("roundtrip", "", "Send a probe to this queue (or topic) and time its round trip")
+		cmd.Flags().VarP(newDurationValue(5*time.Second, time.Second), "timeout", "t", "Time to wait for each --roundtrip probe before counting it as lost")
+	}
+	if queueFactory != nil && topicFactory != nil {
+		cmd.Flags().Bool("topic", false, "Treat the --roundtrip destination as a topic (publish/subscribe)")
+	}
 
 	return cmd
 }
@@ -113,3 +141,175 @@ func doPing(c *cobra.Command, connect Connector) error {
 	}
 	return nil
 }
+
+// roundtripProbe sends one probe and waits for it to come back.
+type roundtripProbe struct {
+	send    func(ctx context.Context, data []byte) error
+	receive func(ctx context.Context, timeout time.Duration) (*backends.Message, error)
+}
+
+// roundtripEndpoint opens one connection for --roundtrip and returns the probe
+// functions and the adapter to close. A topic is subscribed without a group,
+// so the probe neither competes with (or commits offsets for) real consumers
+// nor leaves a durable queue, subscription or group behind.
+func roundtripEndpoint(c *cobra.Command, dest string, queueFactory QueueAdapterFactory, topicFactory TopicAdapterFactory) (roundtripProbe, Closeable, string, error) {
+	topic := queueFactory == nil
+	if queueFactory != nil && topicFactory != nil {
+		topic, _ = c.Flags().GetBool("topic")
+	}
+	if topic {
+		a, err := topicFactory()
+		if err != nil {
+			return roundtripProbe{}, nil, "", err
+		}
+		return roundtripProbe{
+			send: func(ctx context.Context, data []byte) error {
+				return a.Publish(ctx, backends.PublishOptions{Topic: dest, Message: data})
+			},
+			receive: func(ctx context.Context, timeout time.Duration) (*backends.Message, error) {
+				return a.Subscribe(ctx, backends.SubscribeOptions{Topic: dest, Timeout: float32(timeout.Seconds()), Acknowledge: true})
+			},
+		}, a, "topic", nil
+	}
+	a, err := queueFactory()
+	if err != nil {
+		return roundtripProbe{}, nil, "", err
+	}
+	return roundtripProbe{
+		send: func(ctx context.Context, data []byte) error {
+			return a.Send(ctx, backends.SendOptions{Queue: dest, Message: data})
+		},
+		receive: func(ctx context.Context, timeout time.Duration) (*backends.Message, error) {
+			return a.Receive(ctx, backends.ReceiveOptions{Queue: dest, Timeout: float32(timeout.Seconds()), Acknowledge: true})
+		},
+	}, a, "queue", nil
+}
+
+// doPingRoundtrip sends a probe message per attempt and consumes it back,
+// reporting send latency (the Send/Publish call), broker latency (from the send
+// completing until the probe is read back) and their total. Probes are matched
+// by payload, which every broker carries, so a stale probe from an earlier lost
+// attempt is skipped rather than mistaken for the current one.
+func doPingRoundtrip(c *cobra.Command, dest string, queueFactory QueueAdapterFactory, topicFactory TopicAdapterFactory) error {
+	count, _ := c.Flags().GetInt("count")
+	interval := getDuration(c, "interval")
+	timeout := getDuration(c, "timeout")
+	w := c.OutOrStdout()
+
+	probe, conn, model, err := roundtripEndpoint(c, dest, queueFactory, topicFactory)
+	if err != nil {
+		return err
+	}
+	defer closeAdapter(conn)
+
+	ctx, stop := interruptContext(c.Context())
+	defer stop()
+
+	// A topic only delivers to subscriptions that exist when the message is
+	// published, so subscribe (and discard anything already there) first.
+	if model == "topic" {
+		_, _ = probe.receive(ctx, 500*time.Millisecond)
+	}
+
+	fmt.Fprintf(w, "PING %s %s (roundtrip)\n", model, dest)
+	runID := backends.RandomSuffix()
+	var sent, received int
+	var minRTT, maxRTT time.Duration
+	var sum, sumSq float64
+
+	for seq := 1; count <= 0 || seq <= count; seq++ {
+		payload := fmt.Sprintf("xmc-ping %s seq=%d", runID, seq)
+		start := time.Now()
+		err := probe.send(ctx, []byte(payload))
+		sendDone := time.Now()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			sent++
+			fmt.Fprintf(w, "send failed: seq=%d error=%v\n", seq, err)
+		} else {
+			sent++
+			got, err := awaitProbe(ctx, probe, payload, runID, sendDone.Add(timeout))
+			total := time.Since(start)
+			switch {
+			case ctx.Err() != nil:
+				sent--
+			case err != nil:
+				fmt.Fprintf(w, "receive failed: seq=%d error=%v\n", seq, err)
+			case !got:
+				fmt.Fprintf(w, "timeout: seq=%d (no probe within %s)\n", seq, timeout)
+			default:
+				received++
+				if received == 1 || total < minRTT {
+					minRTT = total
+				}
+				maxRTT = max(maxRTT, total)
+				ms := float64(total) / float64(time.Millisecond)
+				sum += ms
+				sumSq += ms * ms
+				fmt.Fprintf(w, "reply from %s: seq=%d send=%s broker=%s total=%s\n", dest, seq,
+					sendDone.Sub(start).Round(time.Microsecond),
+					(total - sendDone.Sub(start)).Round(time.Microsecond),
+					total.Round(time.Microsecond))
+			}
+		}
+		if ctx.Err() != nil || (count > 0 && seq >= count) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(interval):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	lost := sent - received
+	loss := 0.0
+	if sent > 0 {
+		loss = float64(lost) / float64(sent) * 100
+	}
+	fmt.Fprintf(w, "--- %s roundtrip statistics ---\n", dest)
+	if received > 0 {
+		mean := sum / float64(received)
+		stddev := math.Sqrt(max(sumSq/float64(received)-mean*mean, 0))
+		fmt.Fprintf(w, "%d probe(s) sent, %d received, %.1f%% loss, rtt min/avg/max/stddev = %s/%.3fms/%s/%.3fms\n",
+			sent, received, loss, minRTT.Round(time.Microsecond), mean, maxRTT.Round(time.Microsecond), stddev)
+	} else {
+		fmt.Fprintf(w, "%d probe(s) sent, %d received, %.1f%% loss\n", sent, received, loss)
+	}
+
+	if lost > 0 {
+		return fmt.Errorf("%d of %d probe(s) lost", lost, sent)
+	}
+	return nil
+}
+
+// awaitProbe consumes from the probe destination until the message with the
+// given payload arrives (true), the deadline passes (false), or a receive
+// fails. Earlier probes of this run that arrive late are skipped.
+func awaitProbe(ctx context.Context, probe roundtripProbe, payload, runID string, deadline time.Time) (bool, error) {
+	for ctx.Err() == nil {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false, nil
+		}
+		msg, err := probe.receive(ctx, remaining)
+		switch {
+		case errors.Is(err, backends.ErrNoMessageAvailable), errors.Is(err, context.DeadlineExceeded), msg == nil && err == nil:
+			continue
+		case err != nil:
+			return false, err
+		}
+		if string(msg.Data) == payload {
+			return true, nil
+		}
+		if !strings.HasPrefix(string(msg.Data), "xmc-ping "+runID+" ") {
+			log.Verbose("ping: discarded non-probe message (%d bytes)", len(msg.Data))
+		}
+	}
+	return false, nil
+}
@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/makibytes/xmc/broker/backends"
 )
 
 type fakeCloser struct{ closed bool }
@@ -14,7 +19,7 @@ func TestPingCommand_Success(t *testing.T) {
 	cmd := NewPingCommand(func() (Closeable, error) {
 		calls++
 		return &fakeCloser{}, nil
-	})
+	}, nil, nil)
 	cmd.SetArgs([]string{"-n", "3", "-i", "0"})
 
 	out := captureStdout(t, func() {
@@ -33,7 +38,7 @@ func TestPingCommand_Success(t *testing.T) {
 func TestPingCommand_Failure(t *testing.T) {
 	cmd := NewPingCommand(func() (Closeable, error) {
 		return nil, fmt.Errorf("connection refused")
-	})
+	}, nil, nil)
 	cmd.SetArgs([]string{"-n", "1", "-i", "0"})
 
 	captureStdout(t, func() {
@@ -51,7 +56,7 @@ func TestPingCommand_PartialFailureExitsNonZero(t *testing.T) {
 			return nil, fmt.Errorf("flaky")
 		}
 		return &fakeCloser{}, nil
-	})
+	}, nil, nil)
 	cmd.SetArgs([]string{"-n", "3", "-i", "0"})
 
 	captureStdout(t, func() {
@@ -64,6 +69,49 @@ func TestPingCommand_PartialFailureExitsNonZero(t *testing.T) {
 	}
 }
 
+func TestPingCommand_Roundtrip(t *testing.T) {
+	q := &chanQueue{ch: make(chan *backends.Message, 10)}
+	factory := QueueAdapterFactory(func() (backends.QueueBackend, error) { return q, nil })
+	cmd := NewPingCommand(nil, factory, nil)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--roundtrip", "probe-q", "-n", "3", "-i", "0"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out.String())
+	}
+	if got := strings.Count(out.String(), "reply from probe-q"); got != 3 {
+		t.Errorf("replies = %d, want 3:\n%s", got, out.String())
+	}
+	if !containsAll(out.String(), "send=", "broker=", "total=", "3 received, 0.0% loss", "min/avg/max/stddev") {
+		t.Errorf("unexpected roundtrip output:\n%s", out.String())
+	}
+}
+
+// blackHoleQueue accepts every send and never returns a message.
+type blackHoleQueue struct{ chanQueue }
+
+func (q *blackHoleQueue) Send(_ context.Context, _ backends.SendOptions) error { return nil }
+
+func TestPingCommand_RoundtripLossExitsNonZero(t *testing.T) {
+	q := &blackHoleQueue{chanQueue{ch: make(chan *backends.Message)}}
+	factory := QueueAdapterFactory(func() (backends.QueueBackend, error) { return q, nil })
+	cmd := NewPingCommand(nil, factory, nil)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--roundtrip", "probe-q", "-t", "50ms"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "1 of 1 probe(s) lost") {
+		t.Fatalf("error = %v, want lost probe", err)
+	}
+	if !containsAll(out.String(), "timeout: seq=1", "100.0% loss") {
+		t.Errorf("unexpected roundtrip output:\n%s", out.String())
+	}
+}
+
 func containsAll(s string, subs ...string) bool {
 	for _, sub := range subs {
 		found := false
@@ -17,13 +17,15 @@ import (
 )
 
 // xmcVerbs is the set of first tokens that identify an xmc verb (as opposed to
-// an external command). The map is built once during init.
+// an external command). The map is built once during init. "grep" is left
+// out on purpose so that "peek -n 0 q | grep foo" keeps piping into the Unix
+// grep; the xmc grep command is still available outside the shell.
 var xmcVerbs = map[string]bool{
 	"send": true, "receive": true, "get": true, "peek": true,
 	"request": true, "reply": true, "respond": true,
 	"move": true, "forward": true, "bridge": true,
 	"publish": true, "subscribe": true,
-	"manage": true, "ping": true,
+	"manage": true, "ping": true, "bench": true, "stats-stream": true,
 	"help": true,
 }
 
@@ -401,7 +403,7 @@ func (s *shellSession) buildVerbCommand(verb string, rootCmd *cobra.Command) (*c
 			return applyProduce(NewPublishCommand(b, resolver, produceExtra, exchRouting))
 		},
 		"subscribe": func(b backends.TopicBackend) *cobra.Command {
-			return applyConsume(NewSubscribeCommand(b, resolver, consumeExtra, exchRouting))
+			return applyConsume(NewSubscribeCommand(b, s.topicFactory, resolver, consumeExtra, exchRouting))
 		},
 	}
 
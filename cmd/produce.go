@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"time"
 
@@ -23,7 +24,12 @@ func registerProduceFlags(cmd *cobra.Command) {
 	cmd.Flags().VarP(newDurationValue(0, time.Millisecond), "ttl", "E", "Message time-to-live (e.g. \"5s\", \"1m\"; 0 = no expiry)")
 	cmd.Flags().BoolP("lines", "l", false, "Read stdin line by line, send each line as a separate message")
 	cmd.Flags().Bool("ndjson", false, "Read newline-delimited JSON records from stdin (lossless import)")
+	cmd.Flags().Bool("template", false, "Render the message and -P values as a Go template per message (helpers: seq, uuid, ulid, now, randInt, randChoice, fake, file)")
 	cmd.Flags().Float64("rate", 0, "Throttle to at most this many messages per second (0 = unlimited)")
+	cmd.Flags().Bool("replay-timing", false, "With --ndjson, reproduce the captured inter-arrival gaps (receivedAt)")
+	cmd.Flags().String("speed", "1x", "Replay speed multiplier for --replay-timing (e.g. \"2x\", \"0.5x\")")
+	cmd.Flags().Var(newDurationValue(0, time.Second), "max-gap", "Compress captured idle periods longer than this during --replay-timing (0 = keep original gaps)")
+	cmd.Flags().Bool("loop", false, "Repeat the --replay-timing capture until interrupted")
 	cmd.Flags().SetNormalizeFunc(aliasNormalize)
 }
 
@@ -40,8 +46,10 @@ type produceFlags struct {
 	ttl           int64
 	lines         bool
 	ndjson        bool
+	template      bool
 	properties    map[string]any
 	limiter       *rateLimiter
+	replay        *replayTiming // nil unless --replay-timing
 }
 
 func parseProduceFlags(cmd *cobra.Command) (produceFlags, error) {
@@ -56,6 +64,7 @@ func parseProduceFlags(cmd *cobra.Command) (produceFlags, error) {
 	ttl := getDuration(cmd, "ttl").Milliseconds()
 	lines, _ := cmd.Flags().GetBool("lines")
 	ndjson, _ := cmd.Flags().GetBool("ndjson")
+	tmpl, _ := cmd.Flags().GetBool("template")
 	rate, _ := cmd.Flags().GetFloat64("rate")
 
 	properties, err := parsePropertiesFlag(cmd.Flags())
@@ -63,6 +72,15 @@ func parseProduceFlags(cmd *cobra.Command) (produceFlags, error) {
 		return produceFlags{}, err
 	}
 
+	if tmpl && (lines || ndjson) {
+		return produceFlags{}, fmt.Errorf("--template cannot be combined with --lines or --ndjson")
+	}
+
+	replay, err := parseReplayTiming(cmd, ndjson, rate)
+	if err != nil {
+		return produceFlags{}, err
+	}
+
 	return produceFlags{
 		contentType:   contenttype,
 		correlationID: correlationid,
@@ -75,8 +93,10 @@ func parseProduceFlags(cmd *cobra.Command) (produceFlags, error) {
 		ttl:           ttl,
 		lines:         lines,
 		ndjson:        ndjson,
+		template:      tmpl,
 		properties:    properties,
 		limiter:       newRateLimiter(rate),
+		replay:        replay,
 	}, nil
 }
 
@@ -90,19 +110,30 @@ func (pf produceFlags) resolveKey(recordKey string) string {
 	return pf.key
 }
 
-// emitter is a function that sends a single message payload to the broker.
-type emitter func(ctx context.Context, data []byte) error
+// emitter is a function that sends a single message payload with the given
+// application properties to the broker.
+type emitter func(ctx context.Context, data []byte, properties map[string]any) error
 
 // emitterFromRecord is a function that sends a pre-parsed NDJSON record to the
 // broker, preserving its full metadata.
 type emitterFromRecord func(ctx context.Context, rec messageRecord) error
 
 // runProduce drives the produce loop shared by send and publish:
-// NDJSON import, line-delimited mode, or a counted send of a single payload.
-// The input reader is used for stdin-based modes (lines, ndjson, pipe).
+// NDJSON import (optionally timing-faithful, see cmd/replay.go), line-delimited
+// mode, or a counted send of a single payload. The input reader is used for
+// stdin-based modes (lines, ndjson, pipe).
 func runProduce(ctx context.Context, input io.Reader, args []string, pf produceFlags,
 	emit emitter, emitRecord emitterFromRecord, verb string,
 ) error {
+	if pf.replay != nil {
+		sent, err := runReplay(ctx, input, *pf.replay, emitRecord)
+		if err != nil {
+			return err
+		}
+		log.Verbose("%s %d messages", verb, sent)
+		return nil
+	}
+
 	if pf.ndjson {
 		sent, err := forEachRecord(input, func(rec messageRecord) error {
 			pf.limiter.wait()
@@ -118,7 +149,7 @@ func runProduce(ctx context.Context, input io.Reader, args []string, pf produceF
 	if pf.lines {
 		sent, err := forEachInputLine(input, func(line string) error {
 			pf.limiter.wait()
-			return emit(ctx, []byte(line))
+			return emit(ctx, []byte(line), pf.properties)
 		})
 		if err != nil {
 			return err
@@ -132,12 +163,25 @@ func runProduce(ctx context.Context, input io.Reader, args []string, pf produceF
 		return err
 	}
 
+	var tmpl *messageTemplate
+	if pf.template {
+		if tmpl, err = newMessageTemplate(data, pf.properties); err != nil {
+			return err
+		}
+	}
+
 	for i := 0; i < pf.count; i++ {
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
+		payload, properties := data, pf.properties
+		if tmpl != nil {
+			if payload, properties, err = tmpl.render(); err != nil {
+				return err
+			}
+		}
 		pf.limiter.wait()
-		if err := emit(ctx, data); err != nil {
+		if err := emit(ctx, payload, properties); err != nil {
 			return err
 		}
 		if pf.count > 1 {
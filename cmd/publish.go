@@ -2,6 +2,10 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 
 	"github.com/makibytes/xmc/broker/backends"
 	"github.com/spf13/cobra"
@@ -48,17 +52,28 @@ func doPublish(cmd *cobra.Command, args []string, backend backends.TopicBackend,
 		return err
 	}
 
+	tombstone, _ := cmd.Flags().GetBool("tombstone")
+	if tombstone {
+		if err := checkTombstoneFlags(pf, msgArgs); err != nil {
+			return err
+		}
+		// A tombstone has no payload; don't read one from stdin.
+		msgArgs = []string{""}
+	}
+
 	var extra map[string]string
 	if extraFn != nil {
 		extra = extraFn(cmd)
 	}
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	rp := &receiptPublisher{backend: backend, w: cmd.OutOrStdout(), json: jsonOut}
 
-	emit := func(ctx context.Context, data []byte) error {
-		return backend.Publish(ctx, backends.PublishOptions{
+	emit := func(ctx context.Context, data []byte, properties map[string]any) error {
+		return rp.publish(ctx, backends.PublishOptions{
 			Topic:         topic,
 			Message:       data,
 			Key:           pf.key,
-			Properties:    pf.properties,
+			Properties:    properties,
 			MessageID:     pf.messageID,
 			CorrelationID: pf.correlationID,
 			ReplyTo:       pf.replyTo,
@@ -66,16 +81,20 @@ func doPublish(cmd *cobra.Command, args []string, backend backends.TopicBackend,
 			Priority:      pf.priority,
 			Persistent:    pf.persistent,
 			TTL:           pf.ttl,
+			Tombstone:     tombstone,
 			Extra:         extra,
 		})
 	}
 
 	emitRecord := func(ctx context.Context, rec messageRecord) error {
+		if rec.Tombstone && !tombstonesSupported(cmd) {
+			return errTombstoneUnsupported(rec.Key)
+		}
 		data, err := rec.payload()
 		if err != nil {
 			return err
 		}
-		return backend.Publish(ctx, backends.PublishOptions{
+		return rp.publish(ctx, backends.PublishOptions{
 			Topic:         topic,
 			Message:       data,
 			Key:           pf.resolveKey(rec.Key),
@@ -88,11 +107,113 @@ func doPublish(cmd *cobra.Command, args []string, backend backends.TopicBackend,
 			Persistent:    rec.Persistent,
 			// See cmd/send.go's emitRecord: messageRecord has no TTL field, so
 			// --ndjson publishes fall back to the --ttl flag as a per-batch default.
-			TTL: pf.ttl,
+			TTL:       pf.ttl,
+			Tombstone: rec.Tombstone,
+			Extra:     extra,
 		})
 	}
 
 	// Reconstruct args so that readCommandMessage sees args[1] as the message.
 	runArgs := append([]string{topic}, msgArgs...)
-	return runProduce(cmd.Context(), cmd.InOrStdin(), runArgs, pf, emit, emitRecord, "published")
+	err = runProduce(cmd.Context(), cmd.InOrStdin(), runArgs, pf, emit, emitRecord, "published")
+	if ferr := rp.flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// checkTombstoneFlags validates publish --tombstone: a tombstone deletes a
+// key, so it needs -K and takes no message from any source.
+func checkTombstoneFlags(pf produceFlags, msgArgs []string) error {
+	switch {
+	case pf.key == "":
+		return fmt.Errorf("--tombstone requires -K/--key")
+	case len(msgArgs) > 0:
+		return fmt.Errorf("--tombstone takes no message")
+	case pf.lines || pf.ndjson || pf.template:
+		return fmt.Errorf("--tombstone cannot be combined with --lines, --ndjson or --template")
+	}
+	return nil
+}
+
+// tombstonesSupported reports whether the broker registered publish
+// --tombstone through its ProduceFlags. Only Kafka does: other brokers have
+// no null value, so a tombstone would arrive as an empty message.
+func tombstonesSupported(cmd *cobra.Command) bool {
+	return cmd.Flags().Lookup("tombstone") != nil
+}
+
+// errTombstoneUnsupported rejects an NDJSON tombstone record (e.g. one
+// bridged from Kafka) on a broker that cannot publish a null value.
+func errTombstoneUnsupported(key string) error {
+	return fmt.Errorf("record for key %q is a tombstone, which this broker cannot publish", key)
+}
+
+// receiptPublisher publishes through backends.ReceiptBackend when the backend
+// supports it and reports each receipt on w: one text line, or one JSON
+// object per line with -J. Other backends publish silently. When the
+// backend batches (backends.BatchPublisher), messages are queued and their
+// receipts reported by flush once the last one is written.
+type receiptPublisher struct {
+	backend     backends.TopicBackend
+	w           io.Writer
+	json        bool
+	unsupported bool // the backend answered ErrReceiptUnsupported once
+	noBatch     bool // the backend answered ErrBatchUnsupported once
+	batched     bool // messages were queued; flush must report them
+}
+
+// publishReceipt is the -J form of a backends.PublishReceipt.
+type publishReceipt struct {
+	Topic     string `json:"topic"`
+	Partition int    `json:"partition"`
+	Offset    int64  `json:"offset"`
+}
+
+func (p *receiptPublisher) publish(ctx context.Context, opts backends.PublishOptions) error {
+	if bp, ok := p.backend.(backends.BatchPublisher); ok && !p.noBatch {
+		err := bp.PublishAsync(ctx, opts)
+		if !errors.Is(err, backends.ErrBatchUnsupported) {
+			p.batched = p.batched || err == nil
+			return err
+		}
+		p.noBatch = true
+	}
+
+	rb, ok := p.backend.(backends.ReceiptBackend)
+	if !ok || p.unsupported {
+		return p.backend.Publish(ctx, opts)
+	}
+	receipt, err := rb.PublishWithReceipt(ctx, opts)
+	if errors.Is(err, backends.ErrReceiptUnsupported) {
+		p.unsupported = true
+		return p.backend.Publish(ctx, opts)
+	}
+	if err != nil || receipt == nil {
+		return err
+	}
+	return p.report(*receipt)
+}
+
+// flush waits for queued messages to be written and reports their receipts.
+func (p *receiptPublisher) flush() error {
+	if !p.batched {
+		return nil
+	}
+	p.batched = false
+	receipts, err := p.backend.(backends.BatchPublisher).Flush()
+	for _, receipt := range receipts {
+		if rerr := p.report(receipt); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+func (p *receiptPublisher) report(receipt backends.PublishReceipt) error {
+	if p.json {
+		return json.NewEncoder(p.w).Encode(publishReceipt(receipt))
+	}
+	_, err := fmt.Fprintf(p.w, "Published to %s partition %d at offset %d\n", receipt.Topic, receipt.Partition, receipt.Offset)
+	return err
 }
@@ -11,6 +11,7 @@ import (
 
 	"github.com/makibytes/xmc/broker/backends"
 	"github.com/makibytes/xmc/log"
+	"github.com/spf13/cobra"
 )
 
 // mockTopicBackend is a test double for TopicBackend
@@ -116,7 +117,7 @@ func TestSubscribeCommand_DisplaysMessage(t *testing.T) {
 		Data: []byte("topic message"),
 	}
 	mock := &mockTopicBackend{subscribeMsg: msg}
-	cmd := NewSubscribeCommand(mock, nil, nil)
+	cmd := NewSubscribeCommand(mock, nil, nil, nil)
 	cmd.SetArgs([]string{"test-topic"})
 
 	err := cmd.Execute()
@@ -131,7 +132,7 @@ func TestSubscribeCommand_DisplaysMessage(t *testing.T) {
 
 func TestSubscribeCommand_TimeoutReturnsNil(t *testing.T) {
 	mock := &mockTopicBackend{subscribeErr: context.DeadlineExceeded}
-	cmd := NewSubscribeCommand(mock, nil, nil)
+	cmd := NewSubscribeCommand(mock, nil, nil, nil)
 	cmd.SetArgs([]string{"test-topic"})
 
 	err := cmd.Execute()
@@ -142,7 +143,7 @@ func TestSubscribeCommand_TimeoutReturnsNil(t *testing.T) {
 
 func TestSubscribeCommand_WrappedTimeoutReturnsNil(t *testing.T) {
 	mock := &mockTopicBackend{subscribeErr: fmt.Errorf("wrapped: %w", context.DeadlineExceeded)}
-	cmd := NewSubscribeCommand(mock, nil, nil)
+	cmd := NewSubscribeCommand(mock, nil, nil, nil)
 	cmd.SetArgs([]string{"test-topic"})
 
 	err := cmd.Execute()
@@ -192,6 +193,58 @@ func TestPublishCommand_InvalidProperty(t *testing.T) {
 	}
 }
 
+// newTombstonePublishCommand builds publish with --tombstone registered the
+// way Kafka's ProduceFlags does.
+func newTombstonePublishCommand(backend backends.TopicBackend) *cobra.Command {
+	cmd := NewPublishCommand(backend, nil, nil)
+	cmd.Flags().Bool("tombstone", false, "")
+	return cmd
+}
+
+func TestPublishCommand_Tombstone(t *testing.T) {
+	mock := &mockTopicBackend{}
+	cmd := newTombstonePublishCommand(mock)
+	cmd.SetArgs([]string{"test-topic", "--tombstone", "-K", "cfg-1"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !mock.lastPublishOpts.Tombstone || mock.lastPublishOpts.Key != "cfg-1" || len(mock.lastPublishOpts.Message) != 0 {
+		t.Errorf("publish opts = %+v, want a tombstone for cfg-1", mock.lastPublishOpts)
+	}
+
+	for _, args := range [][]string{
+		{"test-topic", "--tombstone"},                       // no key
+		{"test-topic", "msg", "--tombstone", "-K", "k"},     // message given
+		{"test-topic", "--tombstone", "-K", "k", "--lines"}, // payload source
+	} {
+		mock := &mockTopicBackend{}
+		cmd := newTombstonePublishCommand(mock)
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+		if mock.publishCount != 0 {
+			t.Errorf("%v: published %d messages", args, mock.publishCount)
+		}
+	}
+}
+
+func TestPublishCommand_TombstoneUnsupported(t *testing.T) {
+	mock := &mockTopicBackend{}
+	cmd := NewPublishCommand(mock, nil, nil)
+	cmd.SetArgs([]string{"test-topic", "--tombstone", "-K", "cfg-1"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected unknown flag error for --tombstone without Kafka's ProduceFlags")
+	}
+	if mock.publishCount != 0 {
+		t.Errorf("published %d messages", mock.publishCount)
+	}
+}
+
 func TestSubscribeCommand_CountFlag(t *testing.T) {
 	msgs := []*backends.Message{
 		{Data: []byte("sub1")},
@@ -199,7 +252,7 @@ func TestSubscribeCommand_CountFlag(t *testing.T) {
 		{Data: []byte("sub3")},
 	}
 	mock := &mockTopicBackend{subscribeMsgs: msgs}
-	cmd := NewSubscribeCommand(mock, nil, nil)
+	cmd := NewSubscribeCommand(mock, nil, nil, nil)
 	cmd.SetArgs([]string{"test-topic", "-n", "3"})
 
 	old := os.Stdout
@@ -235,7 +288,7 @@ func TestSubscribeCommand_JSONOutput(t *testing.T) {
 		Properties: map[string]any{"env": "staging"},
 	}
 	mock := &mockTopicBackend{subscribeMsg: msg}
-	cmd := NewSubscribeCommand(mock, nil, nil)
+	cmd := NewSubscribeCommand(mock, nil, nil, nil)
 	cmd.SetArgs([]string{"test-topic", "-J"})
 
 	old := os.Stdout
@@ -268,7 +321,7 @@ func TestSubscribeCommand_JSONOutput(t *testing.T) {
 func TestSubscribeCommand_SelectorFlag(t *testing.T) {
 	msg := &backends.Message{Data: []byte("filtered")}
 	mock := &mockTopicBackend{subscribeMsg: msg}
-	cmd := NewSubscribeCommand(mock, nil, nil)
+	cmd := NewSubscribeCommand(mock, nil, nil, nil)
 	cmd.SetArgs([]string{"test-topic", "-S", "type='order'"})
 
 	old := os.Stdout
@@ -293,7 +346,7 @@ func TestSubscribeCommand_SelectorFlag(t *testing.T) {
 func TestSubscribeCommand_DurableFlag(t *testing.T) {
 	msg := &backends.Message{Data: []byte("durable msg")}
 	mock := &mockTopicBackend{subscribeMsg: msg}
-	cmd := NewSubscribeCommand(mock, nil, nil)
+	cmd := NewSubscribeCommand(mock, nil, nil, nil)
 	cmd.SetArgs([]string{"test-topic", "-D"})
 
 	old := os.Stdout
@@ -321,7 +374,7 @@ func TestSubscribeCommand_QuietFlag(t *testing.T) {
 		Properties: map[string]any{"key": "val"},
 	}
 	mock := &mockTopicBackend{subscribeMsg: msg}
-	cmd := NewSubscribeCommand(mock, nil, nil)
+	cmd := NewSubscribeCommand(mock, nil, nil, nil)
 	cmd.SetArgs([]string{"test-topic", "-q"})
 
 	oldStderr := os.Stderr
@@ -360,7 +413,7 @@ func TestSubscribeCommand_QuietFlag(t *testing.T) {
 
 func TestSubscribeCommand_NilMessageReturnsError(t *testing.T) {
 	mock := &mockTopicBackend{subscribeMsg: nil}
-	cmd := NewSubscribeCommand(mock, nil, nil)
+	cmd := NewSubscribeCommand(mock, nil, nil, nil)
 	cmd.SetArgs([]string{"test-topic"})
 
 	err := cmd.Execute()
@@ -412,3 +465,149 @@ func TestPublishCommand_LinesModeWithError(t *testing.T) {
 		t.Fatal("expected error, got nil")
 	}
 }
+
+// receiptTopicBackend reports a partition/offset receipt per publish.
+type receiptTopicBackend struct {
+	mockTopicBackend
+	receiptErr error
+}
+
+func (m *receiptTopicBackend) PublishWithReceipt(_ context.Context, opts backends.PublishOptions) (*backends.PublishReceipt, error) {
+	if m.receiptErr != nil {
+		return nil, m.receiptErr
+	}
+	m.lastPublishOpts = opts
+	m.publishCount++
+	return &backends.PublishReceipt{Topic: opts.Topic, Partition: 2, Offset: int64(40 + m.publishCount)}, nil
+}
+
+func TestPublishCommand_ReportsReceipts(t *testing.T) {
+	mock := &receiptTopicBackend{}
+	cmd := NewPublishCommand(mock, nil, nil)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"orders", "hello", "-n", "2"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "Published to orders partition 2 at offset 41\nPublished to orders partition 2 at offset 42\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestPublishCommand_ReportsReceiptsAsJSON(t *testing.T) {
+	mock := &receiptTopicBackend{}
+	cmd := NewPublishCommand(mock, nil, nil)
+	cmd.Flags().BoolP("json", "J", false, "") // registered by Kafka's ProduceFlags
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"orders", "hello", "-J"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output %q is not JSON: %v", out.String(), err)
+	}
+	if got["topic"] != "orders" || got["partition"] != float64(2) || got["offset"] != float64(41) {
+		t.Errorf("receipt = %v", got)
+	}
+}
+
+func TestPublishCommand_ReceiptUnsupportedFallsBack(t *testing.T) {
+	mock := &receiptTopicBackend{receiptErr: backends.ErrReceiptUnsupported}
+	cmd := NewPublishCommand(mock, nil, nil)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"orders", "hello"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mock.publishCount != 1 || out.Len() != 0 {
+		t.Errorf("publishCount = %d, output = %q; want a silent plain publish", mock.publishCount, out.String())
+	}
+}
+
+func TestPublishCommand_JSONNeedsReceiptFlag(t *testing.T) {
+	mock := &mockTopicBackend{}
+	cmd := NewPublishCommand(mock, nil, nil)
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetArgs([]string{"orders", "hello", "-J"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected unknown flag error for -J without a broker that reports receipts")
+	}
+}
+
+// batchTopicBackend queues publishes and reports them on Flush.
+type batchTopicBackend struct {
+	receiptTopicBackend
+	queued   []backends.PublishOptions
+	flushes  int
+	noBatch  bool
+	flushErr error
+}
+
+func (m *batchTopicBackend) PublishAsync(_ context.Context, opts backends.PublishOptions) error {
+	if m.noBatch {
+		return backends.ErrBatchUnsupported
+	}
+	m.queued = append(m.queued, opts)
+	return nil
+}
+
+func (m *batchTopicBackend) Flush() ([]backends.PublishReceipt, error) {
+	m.flushes++
+	receipts := make([]backends.PublishReceipt, len(m.queued))
+	for i, opts := range m.queued {
+		receipts[i] = backends.PublishReceipt{Topic: opts.Topic, Partition: 1, Offset: int64(i)}
+	}
+	m.queued = nil
+	return receipts, m.flushErr
+}
+
+func TestPublishCommand_BatchedReceiptsReportedOnFlush(t *testing.T) {
+	mock := &batchTopicBackend{}
+	cmd := NewPublishCommand(mock, nil, nil)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"orders", "hello", "-n", "3"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mock.flushes != 1 || mock.publishCount != 0 {
+		t.Errorf("flushes = %d, sync publishes = %d; want 1 flush and no sync publish", mock.flushes, mock.publishCount)
+	}
+	want := "Published to orders partition 1 at offset 0\nPublished to orders partition 1 at offset 1\nPublished to orders partition 1 at offset 2\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	mock = &batchTopicBackend{flushErr: fmt.Errorf("broker down")}
+	cmd = NewPublishCommand(mock, nil, nil)
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetArgs([]string{"orders", "hello"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("a failed flush must fail the publish")
+	}
+}
+
+func TestPublishCommand_BatchUnsupportedFallsBack(t *testing.T) {
+	mock := &batchTopicBackend{noBatch: true}
+	cmd := NewPublishCommand(mock, nil, nil)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"orders", "hello", "-n", "2"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mock.publishCount != 2 || mock.flushes != 0 {
+		t.Errorf("sync publishes = %d, flushes = %d; want 2 and 0", mock.publishCount, mock.flushes)
+	}
+	if !strings.Contains(out.String(), "offset 42") {
+		t.Errorf("output = %q, want per-message receipts", out.String())
+	}
+}
@@ -342,6 +342,68 @@ func (r *reconnectingTopic) Publish(ctx context.Context, opts backends.PublishOp
 	})
 }
 
+// PublishWithReceipt implements backends.ReceiptBackend, reconnecting like
+// Publish. It returns backends.ErrReceiptUnsupported when the adapter does not
+// report where messages were stored.
+func (r *reconnectingTopic) PublishWithReceipt(ctx context.Context, opts backends.PublishOptions) (*backends.PublishReceipt, error) {
+	r.mu.Lock()
+	if err := r.ensureConnected(); err != nil {
+		r.mu.Unlock()
+		return nil, err
+	}
+	rb, ok := r.adapter.(backends.ReceiptBackend)
+	r.mu.Unlock()
+	if !ok {
+		return nil, backends.ErrReceiptUnsupported
+	}
+
+	receipt, err := rb.PublishWithReceipt(ctx, opts)
+	if err == nil || !isConnectionError(err) {
+		return receipt, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	retryErr := r.retryOp(ctx, fmt.Sprintf("publish to %s", opts.Topic), func() error {
+		rb, ok := r.adapter.(backends.ReceiptBackend)
+		if !ok {
+			return backends.ErrReceiptUnsupported
+		}
+		var innerErr error
+		receipt, innerErr = rb.PublishWithReceipt(ctx, opts)
+		return innerErr
+	})
+	return receipt, retryErr
+}
+
+// PublishAsync implements backends.BatchPublisher. Queued messages can't be
+// replayed after a reconnect, so it does not retry; it returns
+// backends.ErrBatchUnsupported when the adapter does not batch.
+func (r *reconnectingTopic) PublishAsync(ctx context.Context, opts backends.PublishOptions) error {
+	r.mu.Lock()
+	if err := r.ensureConnected(); err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	bp, ok := r.adapter.(backends.BatchPublisher)
+	r.mu.Unlock()
+	if !ok {
+		return backends.ErrBatchUnsupported
+	}
+	return bp.PublishAsync(ctx, opts)
+}
+
+// Flush implements backends.BatchPublisher.
+func (r *reconnectingTopic) Flush() ([]backends.PublishReceipt, error) {
+	r.mu.Lock()
+	bp, ok := r.adapter.(backends.BatchPublisher)
+	r.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	return bp.Flush()
+}
+
 func (r *reconnectingTopic) Subscribe(ctx context.Context, opts backends.SubscribeOptions) (*backends.Message, error) {
 	r.mu.Lock()
 	if err := r.ensureConnected(); err != nil {
@@ -367,6 +429,59 @@ func (r *reconnectingTopic) Subscribe(ctx context.Context, opts backends.Subscri
 	return result, retryErr
 }
 
+// SubscribeTopics implements backends.MultiTopicBackend, reconnecting like
+// Subscribe. It returns backends.ErrMultiTopicUnsupported when the adapter
+// cannot subscribe to several topics at once.
+func (r *reconnectingTopic) SubscribeTopics(ctx context.Context, topics []string, opts backends.SubscribeOptions) (*backends.Message, error) {
+	r.mu.Lock()
+	if err := r.ensureConnected(); err != nil {
+		r.mu.Unlock()
+		return nil, err
+	}
+	mb, ok := r.adapter.(backends.MultiTopicBackend)
+	r.mu.Unlock()
+	if !ok {
+		return nil, backends.ErrMultiTopicUnsupported
+	}
+
+	msg, err := mb.SubscribeTopics(ctx, topics, opts)
+	if err == nil || !isConnectionError(err) {
+		return msg, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result *backends.Message
+	retryErr := r.retryOp(ctx, fmt.Sprintf("subscribe on %s", strings.Join(topics, ", ")), func() error {
+		mb, ok := r.adapter.(backends.MultiTopicBackend)
+		if !ok {
+			return backends.ErrMultiTopicUnsupported
+		}
+		var innerErr error
+		result, innerErr = mb.SubscribeTopics(ctx, topics, opts)
+		return innerErr
+	})
+	return result, retryErr
+}
+
+// BrowseTopic implements backends.TopicBrowseBackend by delegating to the
+// underlying adapter, returning backends.ErrBrowseUnsupported when the adapter
+// cannot browse topics.
+func (r *reconnectingTopic) BrowseTopic(ctx context.Context, opts backends.SubscribeOptions) (backends.Browser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.ensureConnected(); err != nil {
+		return nil, err
+	}
+
+	bb, ok := r.adapter.(backends.TopicBrowseBackend)
+	if !ok {
+		return nil, backends.ErrBrowseUnsupported
+	}
+	return bb.BrowseTopic(ctx, opts)
+}
+
 // --- factory wrappers ---
 
 // wrapReconnectQueue returns a new factory that produces a reconnecting queue
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makibytes/xmc/log"
+	"github.com/spf13/cobra"
+)
+
+// replayTiming holds the --replay-timing options of send/publish --ndjson.
+// A nil *replayTiming in produceFlags means records are emitted as fast as
+// --rate allows.
+type replayTiming struct {
+	speed  float64       // playback speed multiplier (2 = twice as fast)
+	maxGap time.Duration // cap on any single captured gap (0 = keep original gaps)
+	loop   bool          // repeat the capture until interrupted
+}
+
+// parseReplayTiming reads the --replay-timing, --speed, --max-gap and --loop
+// flags. It returns nil when --replay-timing is not set, and rejects the
+// dependent flags on their own so they never silently do nothing.
+func parseReplayTiming(cmd *cobra.Command, ndjson bool, rate float64) (*replayTiming, error) {
+	enabled, _ := cmd.Flags().GetBool("replay-timing")
+	if !enabled {
+		for _, name := range []string{"speed", "max-gap", "loop"} {
+			if cmd.Flags().Changed(name) {
+				return nil, fmt.Errorf("--%s requires --replay-timing", name)
+			}
+		}
+		return nil, nil
+	}
+	if !ndjson {
+		return nil, fmt.Errorf("--replay-timing requires --ndjson")
+	}
+	if rate > 0 {
+		return nil, fmt.Errorf("--replay-timing and --rate are mutually exclusive")
+	}
+
+	speedStr, _ := cmd.Flags().GetString("speed")
+	speed, err := parseSpeed(speedStr)
+	if err != nil {
+		return nil, err
+	}
+	loop, _ := cmd.Flags().GetBool("loop")
+	return &replayTiming{
+		speed:  speed,
+		maxGap: getDuration(cmd, "max-gap"),
+		loop:   loop,
+	}, nil
+}
+
+// parseSpeed parses a --speed multiplier such as "2x", "0.5x" or "3".
+func parseSpeed(s string) (float64, error) {
+	v := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), "x")
+	speed, err := strconv.ParseFloat(v, 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("invalid --speed %q (use a positive multiplier such as %q or %q)", s, "2x", "0.5x")
+	}
+	return speed, nil
+}
+
+// replayPacer reproduces the inter-arrival gaps of a captured NDJSON stream
+// from each record's receivedAt. It schedules every record against the
+// wall-clock time of the first one rather than sleeping gap by gap, so the
+// time spent inside each Send/Publish does not accumulate as drift (the same
+// approach as rateLimiter).
+//
+// Records without a receivedAt (older captures, hand-written files) are
+// emitted immediately after their predecessor.
+type replayPacer struct {
+	timing replayTiming
+	start  time.Time     // wall-clock time the first record was emitted
+	offset time.Duration // captured time since the first record, after --max-gap
+	last   time.Time     // receivedAt of the previous timed record
+}
+
+func newReplayPacer(timing replayTiming) *replayPacer {
+	return &replayPacer{timing: timing}
+}
+
+// delay advances the pacer past a record captured at `at` and returns how long
+// to wait, measured from now, before emitting it.
+func (p *replayPacer) delay(at, now time.Time) time.Duration {
+	if p.start.IsZero() {
+		p.start = now
+	}
+	if at.IsZero() {
+		return 0
+	}
+	if !p.last.IsZero() {
+		gap := at.Sub(p.last)
+		if gap < 0 {
+			gap = 0
+		}
+		if p.timing.maxGap > 0 && gap > p.timing.maxGap {
+			gap = p.timing.maxGap
+		}
+		p.offset += gap
+	}
+	p.last = at
+
+	target := p.start.Add(time.Duration(float64(p.offset) / p.timing.speed))
+	if d := target.Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// rewind starts a new pass over the capture (--loop): the first record of the
+// next pass follows the last record of this one without a gap.
+func (p *replayPacer) rewind() {
+	p.last = time.Time{}
+}
+
+// wait blocks until the record captured at `at` is due, or ctx is done.
+func (p *replayPacer) wait(ctx context.Context, at time.Time) error {
+	d := p.delay(at, time.Now())
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// runReplay emits the NDJSON records read from input with their original
+// inter-arrival gaps. With --loop the capture is buffered and replayed until
+// the context is cancelled (Ctrl-C ends the replay cleanly).
+func runReplay(ctx context.Context, input io.Reader, timing replayTiming, emitRecord emitterFromRecord) (int, error) {
+	pacer := newReplayPacer(timing)
+	emit := func(rec messageRecord) error {
+		if err := pacer.wait(ctx, rec.ReceivedAt); err != nil {
+			return err
+		}
+		return emitRecord(ctx, rec)
+	}
+
+	if !timing.loop {
+		return forEachRecord(input, emit)
+	}
+
+	var records []messageRecord
+	if _, err := forEachRecord(input, func(rec messageRecord) error {
+		records = append(records, rec)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	ctx, stop := interruptContext(ctx)
+	defer stop()
+
+	sent := 0
+	for pass := 1; ; pass++ {
+		for _, rec := range records {
+			if err := emit(rec); err != nil {
+				if ctx.Err() != nil {
+					return sent, nil
+				}
+				return sent, err
+			}
+			sent++
+		}
+		log.Verbose("replay pass %d complete (%d messages)", pass, sent)
+		pacer.rewind()
+	}
+}
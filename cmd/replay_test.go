@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSpeed(t *testing.T) {
+	for in, want := range map[string]float64{"1x": 1, "2x": 2, "0.5X": 0.5, "3": 3} {
+		got, err := parseSpeed(in)
+		if err != nil || got != want {
+			t.Errorf("parseSpeed(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "x", "0x", "-1", "fast"} {
+		if _, err := parseSpeed(bad); err == nil {
+			t.Errorf("parseSpeed(%q) should fail", bad)
+		}
+	}
+}
+
+func TestReplayPacer_ReproducesGaps(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := newReplayPacer(replayTiming{speed: 1})
+
+	if d := p.delay(base, now); d != 0 {
+		t.Fatalf("first record delay = %v, want 0", d)
+	}
+	if d := p.delay(base.Add(300*time.Millisecond), now); d != 300*time.Millisecond {
+		t.Errorf("second record delay = %v, want 300ms", d)
+	}
+	// Time spent sending is absorbed: the third record is due 1s after the
+	// first regardless of when the second one went out.
+	if d := p.delay(base.Add(time.Second), now.Add(400*time.Millisecond)); d != 600*time.Millisecond {
+		t.Errorf("third record delay = %v, want 600ms", d)
+	}
+}
+
+func TestReplayPacer_SpeedAndMaxGap(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := newReplayPacer(replayTiming{speed: 2, maxGap: 5 * time.Second})
+
+	p.delay(base, now)
+	if d := p.delay(base.Add(time.Second), now); d != 500*time.Millisecond {
+		t.Errorf("2x delay = %v, want 500ms", d)
+	}
+	// A one-hour idle period is compressed to --max-gap (5s), then halved.
+	if d := p.delay(base.Add(time.Hour), now); d != 3*time.Second {
+		t.Errorf("compressed delay = %v, want 3s (0.5s + 2.5s)", d)
+	}
+}
+
+func TestReplayPacer_UntimedAndRewind(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := newReplayPacer(replayTiming{speed: 1})
+
+	if d := p.delay(time.Time{}, now); d != 0 {
+		t.Errorf("record without receivedAt delay = %v, want 0", d)
+	}
+	p.delay(base, now)
+	p.delay(base.Add(time.Second), now)
+	p.rewind()
+	// The next pass starts right after the previous one: no gap back to base.
+	if d := p.delay(base, now.Add(time.Second)); d != 0 {
+		t.Errorf("delay after rewind = %v, want 0", d)
+	}
+}
+
+func TestSendCommand_ReplayTiming(t *testing.T) {
+	input := `{"data":"a","receivedAt":"2026-01-01T00:00:00Z"}` + "\n" +
+		`{"data":"b","receivedAt":"2026-01-01T00:00:00.2Z"}` + "\n"
+
+	withStdin(t, input, func() {
+		mock := &mockQueueBackend{}
+		cmd := NewSendCommand(mock, nil, nil)
+		cmd.SetArgs([]string{"q", "--ndjson", "--replay-timing", "--speed", "2x"})
+		start := time.Now()
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if mock.sendCount != 2 {
+			t.Fatalf("sendCount = %d, want 2", mock.sendCount)
+		}
+		// 200ms captured gap at 2x => ~100ms. Lower bound only, to avoid flakiness.
+		if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+			t.Errorf("replay took %v, want >= ~100ms", elapsed)
+		}
+	})
+}
+
+func TestSendCommand_ReplayTimingFlagValidation(t *testing.T) {
+	cases := map[string][]string{
+		"requires --ndjson":        {"q", "msg", "--replay-timing"},
+		"mutually exclusive":       {"q", "--ndjson", "--replay-timing", "--rate", "10"},
+		"requires --replay-timing": {"q", "--ndjson", "--speed", "2x"},
+	}
+	for want, args := range cases {
+		cmd := NewSendCommand(&mockQueueBackend{}, nil, nil)
+		cmd.SetArgs(args)
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		err := cmd.Execute()
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("args %v: error = %v, want it to mention %q", args, err, want)
+		}
+	}
+}
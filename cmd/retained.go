@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/makibytes/xmc/broker/backends"
+	"github.com/spf13/cobra"
+)
+
+// RetainedSpec describes a broker's retained messages: the last message a
+// topic keeps for every new subscriber (MQTT). When set on BrokerSpec,
+// NewRootCommand adds the "retained" command.
+type RetainedSpec struct {
+	// SetupFlags registers broker-specific persistent flags on the retained
+	// command (e.g. how long to listen).
+	SetupFlags func(c *cobra.Command)
+
+	// Collect returns the retained messages on the topics matching filter,
+	// with Topic and Retained set, without consuming live traffic.
+	Collect func(ctx context.Context, filter string) ([]*backends.Message, error)
+
+	// Clear removes the retained message of each topic.
+	Clear func(topics []string) error
+
+	// Wildcards are the characters that make a topic a filter; get rejects
+	// them because it reads exactly one topic.
+	Wildcards string
+}
+
+// NewRetainedCommand builds the "retained" command with its list, get and
+// clear subcommands.
+func NewRetainedCommand(spec RetainedSpec) *cobra.Command {
+	retainedCmd := &cobra.Command{
+		Use:   "retained",
+		Short: "Inspect and clear retained messages",
+	}
+	if spec.SetupFlags != nil {
+		spec.SetupFlags(retainedCmd)
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list [filter]",
+		Short: "List the retained messages under a topic filter (default: all)",
+		Long: `List the retained messages under a topic filter (default: all).
+
+Prints each topic with the size of its retained message; --json, --format and
+--ndjson print the messages instead.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			filter := "#"
+			if len(args) > 0 {
+				filter = args[0]
+			}
+			msgs, err := spec.Collect(c.Context(), filter)
+			if err != nil {
+				return err
+			}
+			cfg := retainedOutput(c)
+			if !cfg.jsonOutput && cfg.format == "" && !cfg.ndjson {
+				w := c.OutOrStdout()
+				for _, m := range msgs {
+					fmt.Fprintf(w, "%-40s  %d bytes\n", m.Topic, len(m.Data))
+				}
+				return nil
+			}
+			for _, m := range msgs {
+				if err := outputMessage(m, cfg); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	addRetainedOutputFlags(listCmd)
+	retainedCmd.AddCommand(listCmd)
+
+	getCmd := &cobra.Command{
+		Use:   "get <topic>",
+		Short: "Show the retained message of a topic",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			if strings.ContainsAny(args[0], spec.Wildcards) {
+				return fmt.Errorf("get takes a topic, not a filter: use \"retained list %s\"", args[0])
+			}
+			msgs, err := spec.Collect(c.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			if len(msgs) == 0 {
+				return fmt.Errorf("no retained message on %s", args[0])
+			}
+			return outputMessage(msgs[0], retainedOutput(c))
+		},
+	}
+	addRetainedOutputFlags(getCmd)
+	getCmd.Flags().BoolP("quiet", "q", false, "Quiet about properties, show data only")
+	retainedCmd.AddCommand(getCmd)
+
+	clearCmd := &cobra.Command{
+		Use:   "clear <filter>",
+		Short: "Clear the retained messages under a topic filter",
+		Long: `Clear the retained messages under a topic filter.
+
+Finds the topics with a retained message, like list, and publishes an empty
+retained message to each, which makes the broker drop it. --dry-run only
+prints the topics.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			msgs, err := spec.Collect(c.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			topics := make([]string, len(msgs))
+			for i, m := range msgs {
+				topics[i] = m.Topic
+			}
+
+			w := c.OutOrStdout()
+			if dryRun, _ := c.Flags().GetBool("dry-run"); dryRun {
+				for _, t := range topics {
+					fmt.Fprintf(w, "Would clear %s\n", t)
+				}
+				fmt.Fprintf(w, "%d retained messages under %s\n", len(topics), args[0])
+				return nil
+			}
+			if len(topics) > 0 {
+				if err := spec.Clear(topics); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(w, "Cleared %d retained messages under %s\n", len(topics), args[0])
+			return nil
+		},
+	}
+	clearCmd.Flags().Bool("dry-run", false, "Show the topics without clearing them")
+	retainedCmd.AddCommand(clearCmd)
+
+	return retainedCmd
+}
+
+func addRetainedOutputFlags(c *cobra.Command) {
+	c.Flags().BoolP("json", "J", false, "Output messages as JSON")
+	c.Flags().StringP("format", "F", "", "Output format string, e.g. \"%t %s\\n\" (overrides --json)")
+	c.Flags().Bool("ndjson", false, "Output one lossless JSON record per line (overrides --format/--json)")
+}
+
+// retainedOutput reads the output flags of list and get.
+func retainedOutput(c *cobra.Command) consumeConfig {
+	jsonOutput, _ := c.Flags().GetBool("json")
+	format, _ := c.Flags().GetString("format")
+	ndjson, _ := c.Flags().GetBool("ndjson")
+	quiet, _ := c.Flags().GetBool("quiet")
+	return consumeConfig{
+		jsonOutput: jsonOutput,
+		verbosity:  commandVerbosity(quiet),
+		format:     format,
+		ndjson:     ndjson,
+		dataOut:    c.OutOrStdout(),
+		metaOut:    c.ErrOrStderr(),
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+// fakeRetained serves a fixed set of retained messages; filters match by
+// prefix up to a trailing "#".
+func fakeRetained(cleared *[]string) RetainedSpec {
+	store := []*backends.Message{
+		{Topic: "lamp/hall/state", Data: []byte("on"), Retained: true},
+		{Topic: "lamp/kitchen/state", Data: []byte("off"), Retained: true},
+		{Topic: "config/site", Data: []byte(`{"id":7}`), Retained: true},
+	}
+	return RetainedSpec{
+		Collect: func(_ context.Context, filter string) ([]*backends.Message, error) {
+			var out []*backends.Message
+			for _, m := range store {
+				if m.Topic == filter || filter == "#" || (strings.HasSuffix(filter, "#") && strings.HasPrefix(m.Topic, strings.TrimSuffix(filter, "#"))) {
+					out = append(out, m)
+				}
+			}
+			return out, nil
+		},
+		Clear: func(topics []string) error {
+			*cleared = append(*cleared, topics...)
+			return nil
+		},
+		Wildcards: "+#",
+	}
+}
+
+func runRetained(t *testing.T, spec RetainedSpec, args ...string) (string, error) {
+	t.Helper()
+	cmd := NewRetainedCommand(spec)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestRetainedCommand_List(t *testing.T) {
+	var cleared []string
+	spec := fakeRetained(&cleared)
+
+	out, err := runRetained(t, spec, "list", "lamp/#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "lamp/hall/state") || !strings.Contains(out, "3 bytes") || strings.Contains(out, "config/site") {
+		t.Errorf("list output = %q", out)
+	}
+
+	out, err = runRetained(t, spec, "list", "--ndjson", "config/#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, `{"data":"{\"id\":7}"`) || !strings.Contains(out, `"topic":"config/site","retained":true}`) {
+		t.Errorf("list --ndjson output = %q", out)
+	}
+}
+
+func TestRetainedCommand_Get(t *testing.T) {
+	var cleared []string
+	spec := fakeRetained(&cleared)
+
+	out, err := runRetained(t, spec, "get", "-q", "lamp/hall/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out) != "on" {
+		t.Errorf("get output = %q", out)
+	}
+	if _, err := runRetained(t, spec, "get", "lamp/+/state"); err == nil || !strings.Contains(err.Error(), "not a filter") {
+		t.Errorf("get with a wildcard: err = %v", err)
+	}
+	if _, err := runRetained(t, spec, "get", "lamp/attic/state"); err == nil || !strings.Contains(err.Error(), "no retained message") {
+		t.Errorf("get without a retained message: err = %v", err)
+	}
+}
+
+func TestRetainedCommand_Clear(t *testing.T) {
+	var cleared []string
+	spec := fakeRetained(&cleared)
+
+	out, err := runRetained(t, spec, "clear", "lamp/#", "--dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cleared) != 0 || !strings.Contains(out, "Would clear lamp/kitchen/state") {
+		t.Errorf("dry run cleared %v, output %q", cleared, out)
+	}
+
+	out, err = runRetained(t, spec, "clear", "lamp/#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(cleared, " ") != "lamp/hall/state lamp/kitchen/state" || !strings.Contains(out, "Cleared 2 retained messages") {
+		t.Errorf("cleared %v, output %q", cleared, out)
+	}
+}
@@ -80,6 +80,10 @@ type BrokerSpec struct {
 	// rebuild a fresh one per pipeline invocation).
 	ManageSpec *ManageSpec
 
+	// Retained describes the broker's retained messages. When set,
+	// NewRootCommand adds the "retained" command (see NewRetainedCommand).
+	Retained *RetainedSpec
+
 	// Extra holds broker-specific commands that don't fit the standard set
 	// (e.g. Kafka's forward-topic, Artemis's MCP server).
 	Extra []*cobra.Command
@@ -183,7 +187,7 @@ func NewRootCommand(spec BrokerSpec) *cobra.Command {
 			return c
 		}, topicFactory))
 		rootCmd.AddCommand(WrapTopicCommand(func(b backends.TopicBackend) *cobra.Command {
-			c := NewSubscribeCommand(b, resolver, consumeExtra, exchRouting)
+			c := NewSubscribeCommand(b, topicFactory, resolver, consumeExtra, exchRouting)
 			if consumeFlags != nil {
 				consumeFlags(c)
 			}
@@ -199,6 +203,9 @@ func NewRootCommand(spec BrokerSpec) *cobra.Command {
 	if spec.Queue != nil || spec.Topic != nil {
 		rootCmd.AddCommand(WrapForwardCommand(queueFactory, topicFactory))
 		rootCmd.AddCommand(WrapBridgeCommand(queueFactory, topicFactory))
+		rootCmd.AddCommand(NewBenchCommand(queueFactory, topicFactory))
+		rootCmd.AddCommand(NewGrepCommand(queueFactory, topicFactory, spec.ResolveTarget))
+		rootCmd.AddCommand(NewStatsStreamCommand(queueFactory, topicFactory, spec.ResolveTarget))
 	}
 
 	// Management — prefer ManageSpec (fresh command per invocation in the shell)
@@ -210,6 +217,10 @@ func NewRootCommand(spec BrokerSpec) *cobra.Command {
 		rootCmd.AddCommand(spec.Manage)
 	}
 
+	if spec.Retained != nil {
+		rootCmd.AddCommand(NewRetainedCommand(*spec.Retained))
+	}
+
 	// Extra broker-specific commands.
 	for _, extra := range spec.Extra {
 		rootCmd.AddCommand(extra)
@@ -221,7 +232,7 @@ func NewRootCommand(spec BrokerSpec) *cobra.Command {
 
 	// Connectivity check.
 	if spec.Ping != nil {
-		rootCmd.AddCommand(NewPingCommand(spec.Ping))
+		rootCmd.AddCommand(NewPingCommand(spec.Ping, queueFactory, topicFactory))
 	}
 
 	rootCmd.AddCommand(NewVersionCommand())
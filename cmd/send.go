@@ -56,11 +56,11 @@ func doSend(cmd *cobra.Command, args []string, backend backends.QueueBackend, re
 		extra = extraFn(cmd)
 	}
 
-	emit := func(ctx context.Context, data []byte) error {
+	emit := func(ctx context.Context, data []byte, properties map[string]any) error {
 		return backend.Send(ctx, backends.SendOptions{
 			Queue:         queue,
 			Message:       data,
-			Properties:    pf.properties,
+			Properties:    properties,
 			MessageID:     pf.messageID,
 			CorrelationID: pf.correlationID,
 			ReplyTo:       pf.replyTo,
@@ -74,6 +74,9 @@ func doSend(cmd *cobra.Command, args []string, backend backends.QueueBackend, re
 	}
 
 	emitRecord := func(ctx context.Context, rec messageRecord) error {
+		if rec.Tombstone {
+			return errTombstoneUnsupported(rec.Key)
+		}
 		data, err := rec.payload()
 		if err != nil {
 			return err
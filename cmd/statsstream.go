@@ -0,0 +1,443 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+	"github.com/spf13/cobra"
+)
+
+// statsSizeBuckets are the upper bounds (inclusive) of the payload size
+// histogram; larger payloads fall into a final open-ended bucket.
+var statsSizeBuckets = []int64{64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20}
+
+// NewStatsStreamCommand creates the stats-stream command: it reads a queue or
+// topic for a time window and reports what went past — counts per property
+// value, top keys, content types, a payload size histogram and the message
+// rate over time — instead of printing the messages. Messages come from the
+// same messageReceiver loop as receive/peek/subscribe (consumeMessages), with
+// consumeConfig.observe feeding the aggregate.
+//
+// Queues are browsed by default so sampling does not consume anything;
+// --consume reads destructively on brokers without a browse cursor.
+func NewStatsStreamCommand(queueFactory QueueAdapterFactory, topicFactory TopicAdapterFactory, resolver TargetResolver) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats-stream <queue|topic>",
+		Short: "Sample a queue or topic for a window and report what is in it",
+		Long: `Reads a queue or topic for --for (default 10s) or --count messages and reports
+aggregates instead of the messages themselves: message and byte totals, a
+payload size histogram, the content-type mix, the top --top message keys,
+counts per value of every --by property, and (for live reads) the message rate
+in --interval buckets.
+
+Queues are browsed, so nothing is consumed; browsing ends at the end of the
+queue. Brokers without a browse cursor need --consume, which receives (and
+removes) the messages. Topics are subscribed without a consumer group, so
+nothing durable is left behind, unless -g is given.
+
+The report is text, JSON (-J) or CSV (--csv).`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			return doStatsStream(c, args[0], queueFactory, topicFactory, resolver)
+		},
+	}
+
+	if queueFactory != nil && topicFactory != nil {
+		cmd.Flags().Bool("topic", false, "Sample a topic instead of a queue")
+	}
+	if topicFactory != nil {
+		cmd.Flags().StringP("group", "g", "", "Consumer group for topics (default: none, a non-durable subscription that leaves nothing behind)")
+	}
+	if queueFactory != nil {
+		cmd.Flags().Bool("consume", false, "Receive (remove) queue messages instead of browsing them")
+	}
+	cmd.Flags().Var(newDurationValue(10*time.Second, time.Second), "for", "Sampling window (e.g. \"30s\", \"5m\"; 0 = until interrupted or the queue ends)")
+	cmd.Flags().IntP("count", "n", 0, "Stop after this many messages (0 = sample for the whole window)")
+	cmd.Flags().StringSlice("by", nil, "Count messages per value of these properties (comma-separated or repeated)")
+	cmd.Flags().Int("top", 10, "Number of keys and property values to list")
+	cmd.Flags().Var(newDurationValue(time.Second, time.Second), "interval", "Bucket width of the rate timeline")
+	cmd.Flags().BoolP("json", "J", false, "Output the report as JSON")
+	cmd.Flags().Bool("csv", false, "Output the report as CSV (section,value,count)")
+
+	return cmd
+}
+
+func doStatsStream(c *cobra.Command, name string, queueFactory QueueAdapterFactory, topicFactory TopicAdapterFactory, resolver TargetResolver) error {
+	topic := topicFactory != nil && queueFactory == nil
+	if queueFactory != nil && topicFactory != nil {
+		topic, _ = c.Flags().GetBool("topic")
+	}
+	consume, _ := c.Flags().GetBool("consume")
+	group, _ := c.Flags().GetString("group")
+	window := getDuration(c, "for")
+	count, _ := c.Flags().GetInt("count")
+	by, _ := c.Flags().GetStringSlice("by")
+	top, _ := c.Flags().GetInt("top")
+	interval := getDuration(c, "interval")
+	jsonOutput, _ := c.Flags().GetBool("json")
+	csvOutput, _ := c.Flags().GetBool("csv")
+
+	switch {
+	case count < 0:
+		return fmt.Errorf("--count must not be negative")
+	case top < 1:
+		return fmt.Errorf("--top must be at least 1")
+	case interval <= 0:
+		return fmt.Errorf("--interval must be positive")
+	case jsonOutput && csvOutput:
+		return fmt.Errorf("--json and --csv are mutually exclusive")
+	}
+
+	dest := name
+	if resolver != nil {
+		var err error
+		if dest, err = resolver(TargetSpec{IsTopic: topic, To: name}); err != nil {
+			return err
+		}
+	}
+
+	agg := newStreamAggregate(by, interval)
+	agg.destination, agg.model = name, "queue"
+	receive, closeReceiver, err := openStatsReceiver(c.Context(), dest, topic, consume, group, queueFactory, topicFactory, agg)
+	if err != nil {
+		return err
+	}
+	defer closeReceiver()
+
+	cfg := consumeConfig{
+		count:   count,
+		follow:  agg.live,
+		observe: agg.add,
+		dataOut: c.OutOrStdout(),
+		metaOut: c.ErrOrStderr(),
+	}
+	agg.start = time.Now()
+	err = runConsume(receive, cfg, window, false, c.Context())
+	agg.end = time.Now()
+	if err != nil && !errors.Is(err, backends.ErrNoMessageAvailable) {
+		return err
+	}
+
+	rep := agg.report(top)
+	switch {
+	case jsonOutput:
+		enc := json.NewEncoder(c.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	case csvOutput:
+		return writeStreamReportCSV(c.OutOrStdout(), rep)
+	default:
+		return writeStreamReportText(c.OutOrStdout(), rep)
+	}
+}
+
+// openStatsReceiver opens the adapter for stats-stream and returns the
+// receiver to sample from. It records the read mode on agg: queues are
+// browsed (ending at the end of the queue) unless --consume is given, topics
+// are subscribed.
+func openStatsReceiver(ctx context.Context, dest string, topic, consume bool, group string, queueFactory QueueAdapterFactory, topicFactory TopicAdapterFactory, agg *streamAggregate) (messageReceiver, func(), error) {
+	if topic {
+		adapter, err := topicFactory()
+		if err != nil {
+			return nil, nil, err
+		}
+		agg.model, agg.mode, agg.live = "topic", "subscribe", true
+		opts := backends.SubscribeOptions{Topic: dest, GroupID: group, Timeout: 0.5, Acknowledge: true, Verbosity: backends.VerbosityNormal}
+		return func(ctx context.Context) (*backends.Message, error) {
+			return adapter.Subscribe(ctx, opts)
+		}, func() { closeAdapter(adapter) }, nil
+	}
+
+	if queueFactory == nil {
+		return nil, nil, fmt.Errorf("this broker does not support queue operations")
+	}
+	adapter, err := queueFactory()
+	if err != nil {
+		return nil, nil, err
+	}
+	opts := backends.ReceiveOptions{Queue: dest, Timeout: 0.5, Acknowledge: consume, Verbosity: backends.VerbosityNormal}
+	if consume {
+		agg.mode, agg.live = "consume", true
+		return func(ctx context.Context) (*backends.Message, error) {
+			return adapter.Receive(ctx, opts)
+		}, func() { closeAdapter(adapter) }, nil
+	}
+
+	bb, ok := adapter.(backends.BrowseBackend)
+	if ok {
+		browser, err := bb.Browse(ctx, opts)
+		switch {
+		case err == nil:
+			agg.mode = "browse"
+			return browser.Next, func() {
+				browser.Close()
+				closeAdapter(adapter)
+			}, nil
+		case !errors.Is(err, backends.ErrBrowseUnsupported):
+			closeAdapter(adapter)
+			return nil, nil, err
+		}
+	}
+	closeAdapter(adapter)
+	return nil, nil, fmt.Errorf("this broker cannot browse queues non-destructively; use --consume to sample by receiving")
+}
+
+// streamAggregate accumulates the stats-stream aggregates. It is only
+// touched from the consume loop, so it needs no locking.
+type streamAggregate struct {
+	destination, model, mode string
+	live                     bool // messages arrive in real time, so the rate timeline is meaningful
+
+	by         []string
+	interval   time.Duration
+	start, end time.Time
+
+	messages     int
+	bytes        int64
+	minSize      int
+	maxSize      int
+	sizes        []int // per statsSizeBuckets, plus the open-ended bucket
+	keys         map[string]int
+	contentTypes map[string]int
+	props        map[string]map[string]int
+	timeline     []int
+}
+
+func newStreamAggregate(by []string, interval time.Duration) *streamAggregate {
+	a := &streamAggregate{
+		by:           by,
+		interval:     interval,
+		sizes:        make([]int, len(statsSizeBuckets)+1),
+		keys:         map[string]int{},
+		contentTypes: map[string]int{},
+		props:        map[string]map[string]int{},
+	}
+	for _, p := range by {
+		a.props[p] = map[string]int{}
+	}
+	return a
+}
+
+// add records one message. Its signature matches consumeConfig.observe.
+func (a *streamAggregate) add(msg *backends.Message) error {
+	size := len(msg.Data)
+	if a.messages == 0 || size < a.minSize {
+		a.minSize = size
+	}
+	a.maxSize = max(a.maxSize, size)
+	a.messages++
+	a.bytes += int64(size)
+
+	bucket, _ := slices.BinarySearch(statsSizeBuckets, int64(size))
+	a.sizes[bucket]++
+
+	if msg.Key != "" {
+		a.keys[msg.Key]++
+	}
+	ct := msg.ContentType
+	if ct == "" {
+		ct = "(none)"
+	}
+	a.contentTypes[ct]++
+	for _, p := range a.by {
+		v := "(absent)"
+		if pv, ok := msg.Properties[p]; ok {
+			v = fmt.Sprint(pv)
+		}
+		a.props[p][v]++
+	}
+
+	if a.live {
+		slot := int(time.Since(a.start) / a.interval)
+		for len(a.timeline) <= slot {
+			a.timeline = append(a.timeline, 0)
+		}
+		a.timeline[slot]++
+	}
+	return nil
+}
+
+// valueCount is one row of a frequency table.
+type valueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type sizeBucketCount struct {
+	Label    string `json:"label"`
+	MaxBytes int64  `json:"maxBytes,omitempty"` // 0 for the open-ended last bucket
+	Count    int    `json:"count"`
+}
+
+type rateSample struct {
+	OffsetSeconds float64 `json:"offsetSeconds"`
+	Count         int     `json:"count"`
+	Rate          float64 `json:"rate"`
+}
+
+type sizeSummary struct {
+	Min  int     `json:"min"`
+	Mean float64 `json:"mean"`
+	Max  int     `json:"max"`
+}
+
+// streamReport is the stats-stream result; its JSON form is the -J output.
+type streamReport struct {
+	Destination   string                  `json:"destination"`
+	Model         string                  `json:"model"`
+	Mode          string                  `json:"mode"`
+	WindowSeconds float64                 `json:"windowSeconds"`
+	Messages      int                     `json:"messages"`
+	Bytes         int64                   `json:"bytes"`
+	Rate          float64                 `json:"rate"`
+	Size          sizeSummary             `json:"size"`
+	SizeHistogram []sizeBucketCount       `json:"sizeHistogram"`
+	ContentTypes  []valueCount            `json:"contentTypes"`
+	TopKeys       []valueCount            `json:"topKeys"`
+	Properties    map[string][]valueCount `json:"properties,omitempty"`
+	Timeline      []rateSample            `json:"timeline,omitempty"`
+}
+
+func (a *streamAggregate) report(top int) streamReport {
+	window := a.end.Sub(a.start)
+	rep := streamReport{
+		Destination:   a.destination,
+		Model:         a.model,
+		Mode:          a.mode,
+		WindowSeconds: window.Seconds(),
+		Messages:      a.messages,
+		Bytes:         a.bytes,
+		Size:          sizeSummary{Min: a.minSize, Max: a.maxSize},
+		ContentTypes:  topCounts(a.contentTypes, 0),
+		TopKeys:       topCounts(a.keys, top),
+	}
+	if secs := window.Seconds(); secs > 0 {
+		rep.Rate = float64(a.messages) / secs
+	}
+	if a.messages > 0 {
+		rep.Size.Mean = float64(a.bytes) / float64(a.messages)
+	}
+	for i, n := range a.sizes {
+		b := sizeBucketCount{Count: n}
+		if i < len(statsSizeBuckets) {
+			b.MaxBytes = statsSizeBuckets[i]
+			b.Label = "<= " + humanBytes(b.MaxBytes)
+		} else {
+			b.Label = "> " + humanBytes(statsSizeBuckets[len(statsSizeBuckets)-1])
+		}
+		rep.SizeHistogram = append(rep.SizeHistogram, b)
+	}
+	if len(a.by) > 0 {
+		rep.Properties = make(map[string][]valueCount, len(a.by))
+		for _, p := range a.by {
+			rep.Properties[p] = topCounts(a.props[p], top)
+		}
+	}
+	for i, n := range a.timeline {
+		offset := time.Duration(i) * a.interval
+		width := min(a.interval, window-offset)
+		s := rateSample{OffsetSeconds: offset.Seconds(), Count: n}
+		if width > 0 {
+			s.Rate = float64(n) / width.Seconds()
+		}
+		rep.Timeline = append(rep.Timeline, s)
+	}
+	return rep
+}
+
+// topCounts sorts a frequency map by descending count (ties by value) and
+// keeps the first n entries (n <= 0 keeps all).
+func topCounts(counts map[string]int, n int) []valueCount {
+	out := make([]valueCount, 0, len(counts))
+	for _, v := range slices.Sorted(maps.Keys(counts)) {
+		out = append(out, valueCount{Value: v, Count: counts[v]})
+	}
+	slices.SortStableFunc(out, func(x, y valueCount) int { return y.Count - x.Count })
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+func writeStreamReportText(w io.Writer, rep streamReport) error {
+	fmt.Fprintf(w, "--- %s %s (%s) ---\n", rep.Model, rep.Destination, rep.Mode)
+	fmt.Fprintf(w, "messages: %d in %.1fs (%.1f msg/s, %s)\n", rep.Messages, rep.WindowSeconds, rep.Rate, humanBytes(rep.Bytes))
+	if rep.Messages == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "size:     min/avg/max = %s/%s/%s\n",
+		humanBytes(int64(rep.Size.Min)), humanBytes(int64(rep.Size.Mean)), humanBytes(int64(rep.Size.Max)))
+
+	table := func(title string, rows []valueCount) {
+		if len(rows) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "%s:\n", title)
+		for _, r := range rows {
+			fmt.Fprintf(w, "  %-24s %8d  %5.1f%%\n", r.Value, r.Count, 100*float64(r.Count)/float64(rep.Messages))
+		}
+	}
+
+	var sizes []valueCount
+	for _, b := range rep.SizeHistogram {
+		if b.Count > 0 {
+			sizes = append(sizes, valueCount{Value: b.Label, Count: b.Count})
+		}
+	}
+	table("payload sizes", sizes)
+	table("content types", rep.ContentTypes)
+	table("top keys", rep.TopKeys)
+	for _, p := range slices.Sorted(maps.Keys(rep.Properties)) {
+		table("property "+p, rep.Properties[p])
+	}
+
+	if len(rep.Timeline) > 0 {
+		fmt.Fprintln(w, "rate:")
+		for _, s := range rep.Timeline {
+			fmt.Fprintf(w, "  +%-7s %8d  %8.1f msg/s\n", strconv.FormatFloat(s.OffsetSeconds, 'f', -1, 64)+"s", s.Count, s.Rate)
+		}
+	}
+	return nil
+}
+
+// writeStreamReportCSV writes the report as section,value,count rows so it
+// can be loaded into a spreadsheet or filtered with standard tools.
+func writeStreamReportCSV(w io.Writer, rep streamReport) error {
+	cw := csv.NewWriter(w)
+	row := func(section, value string, count any) {
+		cw.Write([]string{section, value, fmt.Sprint(count)}) //nolint:errcheck
+	}
+	row("section", "value", "count")
+	row("summary", "messages", rep.Messages)
+	row("summary", "bytes", rep.Bytes)
+	row("summary", "windowSeconds", strconv.FormatFloat(rep.WindowSeconds, 'f', 3, 64))
+	row("summary", "rate", strconv.FormatFloat(rep.Rate, 'f', 3, 64))
+	for _, b := range rep.SizeHistogram {
+		row("size", b.Label, b.Count)
+	}
+	for _, r := range rep.ContentTypes {
+		row("contentType", r.Value, r.Count)
+	}
+	for _, r := range rep.TopKeys {
+		row("key", r.Value, r.Count)
+	}
+	for _, p := range slices.Sorted(maps.Keys(rep.Properties)) {
+		for _, r := range rep.Properties[p] {
+			row("property:"+p, r.Value, r.Count)
+		}
+	}
+	for _, s := range rep.Timeline {
+		row("timeline", strconv.FormatFloat(s.OffsetSeconds, 'f', -1, 64), s.Count)
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+func newStatsTestQueue() *browseQueue {
+	msg := func(size int, key, ct, region string) *backends.Message {
+		m := &backends.Message{Data: bytes.Repeat([]byte("x"), size), Key: key, ContentType: ct}
+		if region != "" {
+			m.Properties = map[string]any{"region": region}
+		}
+		return m
+	}
+	return &browseQueue{queues: map[string][]*backends.Message{
+		"events": {
+			msg(10, "user-1", "application/json", "eu"),
+			msg(100, "user-1", "application/json", "eu"),
+			msg(2000, "user-2", "text/plain", "us"),
+			msg(5000, "", "", ""),
+		},
+	}}
+}
+
+func runStatsStream(t *testing.T, q backends.QueueBackend, args ...string) (string, error) {
+	t.Helper()
+	cmd := NewStatsStreamCommand(func() (backends.QueueBackend, error) { return q, nil }, nil, nil)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestStatsStream_BrowseJSON(t *testing.T) {
+	out, err := runStatsStream(t, newStatsTestQueue(), "events", "--by", "region", "--top", "1", "-J")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rep streamReport
+	if err := json.Unmarshal([]byte(out), &rep); err != nil {
+		t.Fatalf("not JSON: %v (%q)", err, out)
+	}
+	if rep.Mode != "browse" || rep.Messages != 4 || rep.Bytes != 7110 {
+		t.Errorf("summary = %+v", rep)
+	}
+	if rep.Size.Min != 10 || rep.Size.Max != 5000 {
+		t.Errorf("size = %+v", rep.Size)
+	}
+	if len(rep.TopKeys) != 1 || rep.TopKeys[0] != (valueCount{"user-1", 2}) {
+		t.Errorf("top keys = %+v", rep.TopKeys)
+	}
+	if got := rep.Properties["region"]; len(got) != 1 || got[0] != (valueCount{"eu", 2}) {
+		t.Errorf("region counts = %+v", got)
+	}
+	if len(rep.ContentTypes) != 3 || rep.ContentTypes[0] != (valueCount{"application/json", 2}) {
+		t.Errorf("content types = %+v", rep.ContentTypes)
+	}
+	hist := map[string]int{}
+	for _, b := range rep.SizeHistogram {
+		hist[b.Label] = b.Count
+	}
+	if hist["<= 64 B"] != 1 || hist["<= 256 B"] != 1 || hist["<= 4.0 KB"] != 1 || hist["<= 16.0 KB"] != 1 {
+		t.Errorf("size histogram = %+v", rep.SizeHistogram)
+	}
+	if rep.Timeline != nil {
+		t.Errorf("browse mode should have no rate timeline: %+v", rep.Timeline)
+	}
+}
+
+func TestStatsStream_ConsumeTimelineCSV(t *testing.T) {
+	q := &chanQueue{ch: make(chan *backends.Message, 10)}
+	for range 3 {
+		q.ch <- &backends.Message{Data: []byte("abc"), Key: "k"}
+	}
+	out, err := runStatsStream(t, q, "work", "--consume", "--for", "300ms", "--interval", "100ms", "--csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"section,value,count\n", "summary,messages,3\n", "key,k,3\n", "timeline,0,3\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("CSV missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestStatsStream_CountStopsEarly(t *testing.T) {
+	q := &chanQueue{ch: make(chan *backends.Message, 10)}
+	for range 5 {
+		q.ch <- &backends.Message{Data: []byte("abc")}
+	}
+	start := time.Now()
+	out, err := runStatsStream(t, q, "work", "--consume", "-n", "2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "messages: 2 in") || time.Since(start) > 5*time.Second {
+		t.Errorf("-n 2 should stop after two messages:\n%s", out)
+	}
+	if len(q.ch) != 3 {
+		t.Errorf("%d messages left, want 3", len(q.ch))
+	}
+}
+
+func TestStatsStream_RequiresBrowseOrConsume(t *testing.T) {
+	_, err := runStatsStream(t, &mockQueueBackend{}, "work")
+	if err == nil || !strings.Contains(err.Error(), "--consume") {
+		t.Errorf("error = %v, want a hint to use --consume", err)
+	}
+}
+
+func TestTopCounts(t *testing.T) {
+	got := topCounts(map[string]int{"b": 2, "a": 2, "c": 5, "d": 1}, 3)
+	want := []valueCount{{"c", 5}, {"a", 2}, {"b", 2}}
+	if len(got) != len(want) {
+		t.Fatalf("topCounts = %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("topCounts[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -2,6 +2,9 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/makibytes/xmc/broker/backends"
@@ -12,12 +15,24 @@ import (
 // When resolver is non-nil, --exchange and --queue flags are registered
 // for exchange-routed brokers (e.g. RabbitMQ). Note: -q is already taken
 // by --quiet, so long-form only.
-func NewSubscribeCommand(backend backends.TopicBackend, resolver TargetResolver, consumeExtra func(*cobra.Command) map[string]string, exchRouting ...bool) *cobra.Command {
+//
+// Several topics, or a wildcard pattern, are consumed as one merged stream.
+// fanout opens the extra adapters needed when the broker has no native
+// multi-topic subscription; nil shares backend across the topics.
+func NewSubscribeCommand(backend backends.TopicBackend, fanout TopicAdapterFactory, resolver TargetResolver, consumeExtra func(*cobra.Command) map[string]string, exchRouting ...bool) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "subscribe <topic>",
-		Short: "Subscribe and receive a message from a topic",
+		Use:   "subscribe <topic> [<topic>...]",
+		Short: "Subscribe and receive messages from one or more topics",
+		Long: `Subscribe and receive messages from one or more topics.
+
+Several topics, or a wildcard pattern, are merged into one stream. Patterns
+use the broker's native wildcards where it has them (MQTT + and #, NATS * and
+>, RabbitMQ binding keys with * and #); Kafka, Pulsar and Redis take shell
+globs (*, ?, [...]). Brokers without multi-topic subscriptions get one
+subscription per topic. Each message is labelled with its source topic: the
+"Topic:" line, %t in --format, and "topic" in --json/--ndjson.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doSubscribe(cmd, args, backend, resolver, consumeExtra)
+			return doSubscribe(cmd, args, backend, fanout, resolver, consumeExtra)
 		},
 	}
 
@@ -37,7 +52,7 @@ func NewSubscribeCommand(backend backends.TopicBackend, resolver TargetResolver,
 
 	hasExchRouting := len(exchRouting) > 0 && exchRouting[0]
 	if hasExchRouting {
-		cmd.Use = "subscribe [--exchange <exchange> [--routing-key <key>] | --queue <queue>] [<to>]"
+		cmd.Use = "subscribe [--exchange <exchange> [--routing-key <key>] | --queue <queue>] [<to>...]"
 		cmd.Flags().String("exchange", "", "Exchange to subscribe to (default: amq.topic)")
 		cmd.Flags().String("routing-key", "", "Routing key for the exchange (omit for fanout/headers)")
 		// Long-form only: -q is --quiet on read commands. --queue-name is the
@@ -52,7 +67,7 @@ func NewSubscribeCommand(backend backends.TopicBackend, resolver TargetResolver,
 	return cmd
 }
 
-func doSubscribe(cmd *cobra.Command, args []string, backend backends.TopicBackend, resolver TargetResolver, extraFn func(*cobra.Command) map[string]string) error {
+func doSubscribe(cmd *cobra.Command, args []string, backend backends.TopicBackend, fanout TopicAdapterFactory, resolver TargetResolver, extraFn func(*cobra.Command) map[string]string) error {
 	groupID, _ := cmd.Flags().GetString("group")
 	timeout := float32(getDuration(cmd, "timeout").Seconds())
 	wait, _ := cmd.Flags().GetBool("wait")
@@ -78,7 +93,7 @@ func doSubscribe(cmd *cobra.Command, args []string, backend backends.TopicBacken
 		count = 0
 	}
 
-	topic, err := resolveConsumeTarget(cmd, args, resolver, true)
+	topics, err := resolveSubscribeTopics(cmd, args, resolver)
 	if err != nil {
 		return err
 	}
@@ -89,7 +104,7 @@ func doSubscribe(cmd *cobra.Command, args []string, backend backends.TopicBacken
 	}
 
 	opts := backends.SubscribeOptions{
-		Topic:       topic,
+		Topic:       topics[0],
 		GroupID:     groupID,
 		Timeout:     timeout,
 		Wait:        wait,
@@ -100,10 +115,17 @@ func doSubscribe(cmd *cobra.Command, args []string, backend backends.TopicBacken
 		Extra:       extra,
 	}
 
-	parentCtx := cmd.Context()
-	return runConsume(func(ctx context.Context) (*backends.Message, error) {
+	receive := func(ctx context.Context) (*backends.Message, error) {
 		return backend.Subscribe(ctx, opts)
-	}, consumeConfig{
+	}
+	if len(topics) > 1 || slices.ContainsFunc(args, isTopicPattern) {
+		r := newMultiTopicReceiver(backend, fanout, topics, opts)
+		defer r.close()
+		receive = r.receive
+	}
+
+	parentCtx := cmd.Context()
+	return runConsume(receive, consumeConfig{
 		count:      count,
 		jsonOutput: jsonOutput,
 		verbosity:  opts.Verbosity,
@@ -114,3 +136,36 @@ func doSubscribe(cmd *cobra.Command, args []string, backend backends.TopicBacken
 		metaOut:    cmd.ErrOrStderr(),
 	}, sf.Duration, sf.Stats, parentCtx)
 }
+
+// resolveSubscribeTopics resolves each topic argument to a broker address.
+// With --exchange every argument is a binding key on that exchange.
+func resolveSubscribeTopics(cmd *cobra.Command, args []string, resolver TargetResolver) ([]string, error) {
+	if len(args) <= 1 {
+		topic, err := resolveConsumeTarget(cmd, args, resolver, true)
+		if err != nil {
+			return nil, err
+		}
+		return []string{topic}, nil
+	}
+	if key, _ := cmd.Flags().GetString("routing-key"); key != "" {
+		return nil, fmt.Errorf("--routing-key takes a single binding key; pass several keys as arguments instead")
+	}
+
+	topics := make([]string, 0, len(args))
+	for _, arg := range args {
+		topic, err := resolveConsumeTarget(cmd, []string{arg}, resolver, true)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(topics, topic) {
+			topics = append(topics, topic)
+		}
+	}
+	return topics, nil
+}
+
+// isTopicPattern reports whether a topic argument uses wildcards: shell globs
+// or the native MQTT (+ #), NATS (* >) and AMQP binding (* #) wildcards.
+func isTopicPattern(topic string) bool {
+	return strings.ContainsAny(topic, "*?[+#>")
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	mrand "math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// messageTemplate renders the payload and -P property values of send/publish
+// --template as Go text/templates, once per message, so a counted send (-n)
+// produces distinct messages instead of byte-identical copies.
+//
+// Available helpers:
+//
+//	seq                  1-based number of the message within this run
+//	uuid                 random RFC 4122 version 4 UUID
+//	ulid                 ULID (time-ordered, 26 characters)
+//	now [layout]         current time: RFC 3339 by default, or a Go layout, "unix", "unixms"
+//	randInt min max      random integer in [min, max]
+//	randChoice a b ...   one of the arguments, at random
+//	fake.<field>         fake data: name, firstName, lastName, email, phone, city, country, company
+//	file path            contents of a file (read once, then cached)
+//
+// Every call to fake draws a new person, so use {{with fake}}...{{end}} to take
+// several consistent fields (e.g. a name and a matching email) from one draw.
+//
+// A property that was given as a number or boolean keeps that type when its
+// rendered value still parses as one; otherwise it is sent as the string.
+type messageTemplate struct {
+	body  *template.Template
+	props map[string]*template.Template
+	typed map[string]any // non-string property values, see retype
+
+	seq   int
+	files map[string]string
+}
+
+// newMessageTemplate parses the payload and every property value. Parse errors
+// are reported up front, before anything is sent.
+func newMessageTemplate(body []byte, props map[string]any) (*messageTemplate, error) {
+	t := &messageTemplate{props: make(map[string]*template.Template, len(props)), typed: map[string]any{}, files: map[string]string{}}
+	funcs := t.funcs()
+
+	var err error
+	if t.body, err = template.New("message").Funcs(funcs).Option("missingkey=error").Parse(string(body)); err != nil {
+		return nil, fmt.Errorf("invalid message template: %w", err)
+	}
+	for k, v := range props {
+		pt, err := template.New(k).Funcs(funcs).Option("missingkey=error").Parse(fmt.Sprint(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid template in property %q: %w", k, err)
+		}
+		t.props[k] = pt
+		if _, ok := v.(string); !ok {
+			t.typed[k] = v
+		}
+	}
+	return t, nil
+}
+
+// render evaluates the templates for the next message.
+func (t *messageTemplate) render() ([]byte, map[string]any, error) {
+	t.seq++
+	var buf bytes.Buffer
+	if err := t.body.Execute(&buf, nil); err != nil {
+		return nil, nil, fmt.Errorf("render message template: %w", err)
+	}
+	props := make(map[string]any, len(t.props))
+	for k, pt := range t.props {
+		var pb strings.Builder
+		if err := pt.Execute(&pb, nil); err != nil {
+			return nil, nil, fmt.Errorf("render property %q: %w", k, err)
+		}
+		props[k] = retype(pb.String(), t.typed[k])
+	}
+	return buf.Bytes(), props, nil
+}
+
+// retype converts a rendered property value back to the type of the value it
+// was rendered from, and leaves it a string when it no longer parses as one.
+func retype(s string, orig any) any {
+	var v any
+	var err error
+	switch orig.(type) {
+	case int:
+		v, err = strconv.Atoi(s)
+	case int32:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 32)
+		v = int32(n)
+	case int64:
+		v, err = strconv.ParseInt(s, 10, 64)
+	case float32:
+		var f float64
+		f, err = strconv.ParseFloat(s, 32)
+		v = float32(f)
+	case float64:
+		v, err = strconv.ParseFloat(s, 64)
+	case bool:
+		v, err = strconv.ParseBool(s)
+	default:
+		return s
+	}
+	if err != nil {
+		return s
+	}
+	return v
+}
+
+func (t *messageTemplate) funcs() template.FuncMap {
+	return template.FuncMap{
+		"seq":  func() int { return t.seq },
+		"uuid": newUUID,
+		"ulid": func() string { return newULID(time.Now()) },
+		"now": func(layout ...string) string {
+			now := time.Now()
+			if len(layout) == 0 {
+				return now.UTC().Format(time.RFC3339Nano)
+			}
+			switch layout[0] {
+			case "unix":
+				return strconv.FormatInt(now.Unix(), 10)
+			case "unixms":
+				return strconv.FormatInt(now.UnixMilli(), 10)
+			}
+			return now.Format(layout[0])
+		},
+		"randInt": func(lo, hi int) (int, error) {
+			if hi < lo {
+				return 0, fmt.Errorf("randInt: max %d is less than min %d", hi, lo)
+			}
+			return lo + mrand.IntN(hi-lo+1), nil
+		},
+		"randChoice": func(choices ...any) (any, error) {
+			if len(choices) == 0 {
+				return nil, fmt.Errorf("randChoice: needs at least one argument")
+			}
+			return choices[mrand.IntN(len(choices))], nil
+		},
+		"fake": fakePerson,
+		"file": func(path string) (string, error) {
+			if data, ok := t.files[path]; ok {
+				return data, nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			t.files[path] = string(data)
+			return string(data), nil
+		},
+	}
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:]) //nolint:errcheck
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// newULID returns a ULID: a 48-bit millisecond timestamp followed by 80 random
+// bits, Crockford base32 encoded, so ULIDs sort by creation time.
+func newULID(now time.Time) string {
+	const alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	var b [16]byte
+	ms := uint64(now.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+	rand.Read(b[6:]) //nolint:errcheck
+	n := new(big.Int).SetBytes(b[:])
+	out := make([]byte, 26)
+	base := big.NewInt(32)
+	mod := new(big.Int)
+	for i := 25; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = alphabet[mod.Int64()]
+	}
+	return string(out)
+}
+
+var (
+	fakeFirstNames = []string{"Alice", "Ben", "Chiara", "Dmitri", "Emma", "Farid", "Grace", "Hiro", "Ines", "Jonas", "Keiko", "Liam", "Maya", "Noah", "Olga", "Pedro", "Quinn", "Rosa", "Sven", "Tara"}
+	fakeLastNames  = []string{"Anders", "Becker", "Costa", "Dubois", "Evans", "Fischer", "Garcia", "Hansen", "Ito", "Jensen", "Kowalski", "Lopez", "Moreau", "Nakamura", "Okafor", "Petrov", "Rossi", "Schmidt", "Tanaka", "Weber"}
+	fakeDomains    = []string{"example.com", "example.org", "example.net", "mail.test"}
+	fakeCities     = []string{"Amsterdam", "Berlin", "Chicago", "Dublin", "Lisbon", "Madrid", "Osaka", "Oslo", "Paris", "Seoul", "Sydney", "Toronto", "Vienna", "Zurich"}
+	fakeCountries  = []string{"AT", "AU", "CA", "CH", "DE", "ES", "FR", "IE", "JP", "KR", "NL", "NO", "PT", "US"}
+	fakeCompanies  = []string{"Acme Corp", "Globex", "Initech", "Umbrella", "Hooli", "Stark Industries", "Wayne Enterprises", "Cyberdyne", "Soylent", "Tyrell"}
+)
+
+// fakePerson draws one random person. The result is a map so templates can
+// address fields directly ({{fake.email}}) or bind one draw with {{with fake}}.
+func fakePerson() map[string]string {
+	first := fakeFirstNames[mrand.IntN(len(fakeFirstNames))]
+	last := fakeLastNames[mrand.IntN(len(fakeLastNames))]
+	return map[string]string{
+		"firstName": first,
+		"lastName":  last,
+		"name":      first + " " + last,
+		"email":     strings.ToLower(first+"."+last) + "@" + fakeDomains[mrand.IntN(len(fakeDomains))],
+		"phone":     fmt.Sprintf("+1-555-%04d", mrand.IntN(10000)),
+		"city":      fakeCities[mrand.IntN(len(fakeCities))],
+		"country":   fakeCountries[mrand.IntN(len(fakeCountries))],
+		"company":   fakeCompanies[mrand.IntN(len(fakeCompanies))],
+	}
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/makibytes/xmc/broker/backends"
+)
+
+func TestMessageTemplate_SeqAndIDs(t *testing.T) {
+	tmpl, err := newMessageTemplate([]byte(`{{seq}} {{uuid}} {{ulid}}`), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	uuidRe := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulidRe := regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`)
+
+	seen := map[string]bool{}
+	for i := 1; i <= 3; i++ {
+		data, _, err := tmpl.render()
+		if err != nil {
+			t.Fatalf("render: %v", err)
+		}
+		fields := strings.Fields(string(data))
+		if fields[0] != strconv.Itoa(i) {
+			t.Errorf("seq = %s, want %d", fields[0], i)
+		}
+		if !uuidRe.MatchString(fields[1]) || !ulidRe.MatchString(fields[2]) {
+			t.Errorf("bad uuid/ulid: %q", data)
+		}
+		if seen[fields[1]] {
+			t.Errorf("duplicate uuid %s", fields[1])
+		}
+		seen[fields[1]] = true
+	}
+}
+
+func TestMessageTemplate_Helpers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snippet.txt")
+	if err := os.WriteFile(path, []byte("from-file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"n":{{randInt 5 5}},"c":"{{randChoice "x"}}","f":"{{file "` + path + `"}}",` +
+		`{{with fake}}"name":"{{.name}}","email":"{{.email}}"{{end}},"t":{{now "unix"}}}`
+	tmpl, err := newMessageTemplate([]byte(body), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _, err := tmpl.render()
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	var got struct {
+		N     int    `json:"n"`
+		C     string `json:"c"`
+		F     string `json:"f"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+		T     int64  `json:"t"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("rendered payload is not JSON: %v (%s)", err, data)
+	}
+	if got.N != 5 || got.C != "x" || got.F != "from-file" || got.T == 0 {
+		t.Errorf("unexpected render: %s", data)
+	}
+	first, last, _ := strings.Cut(got.Name, " ")
+	if got.Email == "" || !strings.HasPrefix(got.Email, strings.ToLower(first+"."+last)+"@") {
+		t.Errorf("email %q does not match name %q", got.Email, got.Name)
+	}
+}
+
+func TestMessageTemplate_Errors(t *testing.T) {
+	if _, err := newMessageTemplate([]byte(`{{seq`), nil); err == nil {
+		t.Error("unterminated action should fail to parse")
+	}
+	if _, err := newMessageTemplate([]byte(`ok`), map[string]any{"id": "{{nope}}"}); err == nil || !strings.Contains(err.Error(), `"id"`) {
+		t.Errorf("unknown function in property: err = %v", err)
+	}
+	tmpl, err := newMessageTemplate([]byte(`{{randInt 9 1}}`), nil)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if _, _, err := tmpl.render(); err == nil {
+		t.Error("randInt with max < min should fail")
+	}
+}
+
+func TestMessageTemplate_KeepsPropertyTypes(t *testing.T) {
+	tmpl, err := newMessageTemplate([]byte(`x`), map[string]any{
+		"n": 7, "big": int64(1) << 40, "ratio": 0.5, "ok": true, "label": "v{{seq}}", "num": "{{seq}}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, props, err := tmpl.render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"n": 7, "big": int64(1) << 40, "ratio": 0.5, "ok": true, "label": "v1", "num": "1"}
+	for k, w := range want {
+		if props[k] != w {
+			t.Errorf("%s = %#v, want %#v", k, props[k], w)
+		}
+	}
+
+	// A rendered value that no longer parses as the original type stays a string.
+	for _, orig := range []any{3, int64(3), 1.5, false} {
+		if got := retype("three", orig); got != "three" {
+			t.Errorf("retype(%q, %T) = %#v, want the string", "three", orig, got)
+		}
+	}
+}
+
+// recordingQueue remembers every message sent to it.
+type recordingQueue struct{ sent []backends.SendOptions }
+
+func (q *recordingQueue) Send(_ context.Context, opts backends.SendOptions) error {
+	q.sent = append(q.sent, opts)
+	return nil
+}
+
+func (q *recordingQueue) Receive(context.Context, backends.ReceiveOptions) (*backends.Message, error) {
+	return nil, backends.ErrNoMessageAvailable
+}
+
+func (q *recordingQueue) Close() error { return nil }
+
+func TestSendCommand_Template(t *testing.T) {
+	q := &recordingQueue{}
+	cmd := NewSendCommand(q, nil, nil)
+	cmd.SetArgs([]string{"q", "-n", "3", "--template", "-P", "idx=n{{seq}}", "msg-{{seq}}"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(q.sent) != 3 {
+		t.Fatalf("sent %d messages, want 3", len(q.sent))
+	}
+	for i, opts := range q.sent {
+		want := strconv.Itoa(i + 1)
+		if string(opts.Message) != "msg-"+want || opts.Properties["idx"] != "n"+want {
+			t.Errorf("message %d = %q %v", i, opts.Message, opts.Properties)
+		}
+	}
+}
+
+func TestSendCommand_WithoutTemplateIsLiteral(t *testing.T) {
+	mock := &mockQueueBackend{}
+	cmd := NewSendCommand(mock, nil, nil)
+	cmd.SetArgs([]string{"q", "{{seq}}"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(mock.lastSendOpts.Message) != "{{seq}}" {
+		t.Errorf("message = %q, want it unrendered", mock.lastSendOpts.Message)
+	}
+}
+
+func TestSendCommand_TemplateRejectsLines(t *testing.T) {
+	cmd := NewSendCommand(&mockQueueBackend{}, nil, nil)
+	cmd.SetArgs([]string{"q", "--template", "-l"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--template") {
+		t.Errorf("error = %v, want --template conflict", err)
+	}
+}
@@ -674,7 +674,7 @@ func registerListTopics(s *Server, d Deps) {
 func registerQueueStats(s *Server, d Deps) {
 	s.AddTool(&Tool{
 		Name:        "manage_queue_stats",
-		Description: "Show statistics for one queue (message count, consumers, lifetime enqueue/dequeue). Read-only.",
+		Description: "Show statistics for one queue (message count, consumers, and lifetime enqueue/dequeue where the broker keeps them). Read-only.",
 		InputSchema: object(map[string]any{
 			"queue": stringProp("Queue name to inspect."),
 		}, "queue"),
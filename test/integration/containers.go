@@ -23,6 +23,7 @@ type BrokerContainer struct {
 	Container     testcontainers.Container
 	URL           string
 	ManagementURL string // HTTP management URL (RabbitMQ only)
+	WebSocketURL  string // MQTT over WebSockets URL (Mosquitto only)
 }
 
 func (b *BrokerContainer) Terminate(ctx context.Context) {
@@ -135,16 +136,20 @@ func StartNATS(ctx context.Context) (*BrokerContainer, error) {
 func StartMosquitto(ctx context.Context) (*BrokerContainer, error) {
 	req := testcontainers.ContainerRequest{
 		Image:        "eclipse-mosquitto:latest",
-		ExposedPorts: []string{"1883/tcp"},
-		// Write a minimal config that allows anonymous connections with MQTT 5 support.
+		ExposedPorts: []string{"1883/tcp", "8080/tcp"},
+		// Write a minimal config that allows anonymous connections with MQTT 5
+		// support, on TCP and on WebSockets.
 		Files: []testcontainers.ContainerFile{
 			{
-				Reader:            strings.NewReader("listener 1883\nallow_anonymous true\n"),
+				Reader:            strings.NewReader("listener 1883\nlistener 8080\nprotocol websockets\nallow_anonymous true\n"),
 				ContainerFilePath: "/mosquitto/config/mosquitto.conf",
 				FileMode:          0o644,
 			},
 		},
-		WaitingFor: wait.ForListeningPort("1883/tcp").WithStartupTimeout(30 * time.Second),
+		WaitingFor: wait.ForAll(
+			wait.ForListeningPort("1883/tcp"),
+			wait.ForListeningPort("8080/tcp"),
+		).WithDeadline(30 * time.Second),
 	}
 	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
 		ContainerRequest: req,
@@ -164,10 +169,16 @@ func StartMosquitto(ctx context.Context) (*BrokerContainer, error) {
 		container.Terminate(ctx) //nolint:errcheck
 		return nil, err
 	}
+	wsPort, err := container.MappedPort(ctx, "8080")
+	if err != nil {
+		container.Terminate(ctx) //nolint:errcheck
+		return nil, err
+	}
 
 	return &BrokerContainer{
-		Container: container,
-		URL:       fmt.Sprintf("tcp://%s:%s", host, port.Port()),
+		Container:    container,
+		URL:          fmt.Sprintf("tcp://%s:%s", host, port.Port()),
+		WebSocketURL: fmt.Sprintf("ws://%s:%s", host, wsPort.Port()),
 	}, nil
 }
 